@@ -0,0 +1,80 @@
+// Command breached builds the bloom filter BREACHED_PASSWORDS_FILE points
+// at from a Have I Been Pwned SHA-1 password list ("<hash>:<count>" per
+// line), e.g.
+//
+//	go run ./cmd/breached -in pwned-passwords-sha1.txt -out breached.bf -min-count 10
+//
+// The full list is around a billion hashes; -min-count keeps only those
+// seen at least that often, which are the ones attackers try first.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/tsatsarisg/go-fit/internal/platform/breached"
+)
+
+func main() {
+	if err := run(); err != nil {
+		log.Printf("fatal: %v", err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	var (
+		in       = flag.String("in", "", "SHA-1 password list to read (required)")
+		out      = flag.String("out", "breached.bf", "filter file to write")
+		n        = flag.Uint64("n", 0, "expected number of hashes; 0 counts them first")
+		fpRate   = flag.Float64("fp", 1e-6, "false-positive rate to size the filter for")
+		minCount = flag.Int("min-count", 1, "skip hashes seen fewer times than this")
+	)
+	flag.Parse()
+	if *in == "" {
+		flag.Usage()
+		return fmt.Errorf("-in is required")
+	}
+	if *fpRate <= 0 || *fpRate >= 1 {
+		return fmt.Errorf("-fp must be between 0 and 1")
+	}
+
+	src, err := os.Open(*in)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	if *n == 0 {
+		if *n, err = breached.CountHashes(bufio.NewReaderSize(src, 1<<20), *minCount); err != nil {
+			return fmt.Errorf("count hashes: %w", err)
+		}
+		if _, err := src.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+	}
+
+	f := breached.New(*n, *fpRate)
+	added, err := f.AddHashes(bufio.NewReaderSize(src, 1<<20), *minCount)
+	if err != nil {
+		return fmt.Errorf("add hashes: %w", err)
+	}
+
+	dst, err := os.Create(*out)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteTo(dst); err != nil {
+		_ = dst.Close()
+		return fmt.Errorf("write filter: %w", err)
+	}
+	if err := dst.Close(); err != nil {
+		return err
+	}
+	log.Printf("wrote %s: %d hashes", *out, added)
+	return nil
+}
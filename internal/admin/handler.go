@@ -0,0 +1,277 @@
+package admin
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/tsatsarisg/go-fit/internal/auth"
+	"github.com/tsatsarisg/go-fit/internal/httpx"
+	"github.com/tsatsarisg/go-fit/internal/user"
+	"github.com/tsatsarisg/go-fit/internal/workout"
+)
+
+type Handler struct {
+	service *Service
+	logger  *slog.Logger
+}
+
+func NewHandler(service *Service, logger *slog.Logger) *Handler {
+	return &Handler{service: service, logger: logger}
+}
+
+var (
+	userErrors    = httpx.StoreErrorMapping{ResourceName: "User", NotFoundErr: user.ErrNotFound}
+	workoutErrors = httpx.StoreErrorMapping{ResourceName: "Workout", NotFoundErr: workout.ErrNotFound}
+)
+
+// actorOf is the admin making the request, for the audit trail. The routes
+// are guarded by RequirePermission, so the principal is never anonymous
+// here; the check only keeps a mis-wired route from recording actor 0.
+func actorOf(w http.ResponseWriter, r *http.Request) (Actor, bool) {
+	p := auth.GetPrincipal(r)
+	if p.IsAnonymous() {
+		httpx.WriteJson(w, http.StatusUnauthorized, httpx.Envelope{"error": "Unauthenticated"})
+		return Actor{}, false
+	}
+	return Actor{ID: p.ID, IP: httpx.ClientIP(r)}, true
+}
+
+func writeValidationError(w http.ResponseWriter, err error) {
+	httpx.WriteJson(w, http.StatusBadRequest, httpx.Envelope{"error": err.Error()})
+}
+
+// writeError maps the errors every admin action can return; m names the
+// resource for a 404.
+func (h *Handler) writeError(w http.ResponseWriter, r *http.Request, err error, m httpx.StoreErrorMapping, msg string) {
+	if errors.Is(err, ErrValidation) || errors.Is(err, user.ErrValidation) {
+		writeValidationError(w, err)
+		return
+	}
+	httpx.WriteStoreError(r.Context(), w, h.logger, err, m, msg)
+}
+
+// readUserID reads the {id} URL parameter as a user id.
+func readUserID(w http.ResponseWriter, r *http.Request) (user.UserID, bool) {
+	id, err := httpx.ReadIdParam(r, "id")
+	if err != nil {
+		writeValidationError(w, err)
+		return 0, false
+	}
+	return user.UserID(id), true
+}
+
+// HandleSearchUsers serves GET /admin/users?q=&role=&disabled=&limit=&cursor=.
+func (h *Handler) HandleSearchUsers(w http.ResponseWriter, r *http.Request) {
+	actor, ok := actorOf(w, r)
+	if !ok {
+		return
+	}
+	q, err := parseSearchQuery(r.URL.Query())
+	if err != nil {
+		writeValidationError(w, err)
+		return
+	}
+	page, err := h.service.SearchUsers(r.Context(), actor, q)
+	if err != nil {
+		h.writeError(w, r, err, userErrors, "Failed to search users")
+		return
+	}
+	httpx.WriteJson(w, http.StatusOK, httpx.Envelope{"users": page.Users, "next_cursor": page.NextCursor})
+}
+
+// parseSearchQuery maps ?q, role, disabled, limit and cursor onto a
+// user.SearchQuery. Range checks live in SearchQuery.Validate.
+func parseSearchQuery(v url.Values) (user.SearchQuery, error) {
+	q := user.SearchQuery{Query: v.Get("q"), Role: user.Role(v.Get("role"))}
+	var err error
+	if s := v.Get("disabled"); s != "" {
+		disabled, err := strconv.ParseBool(s)
+		if err != nil {
+			return q, errors.New("disabled must be true or false")
+		}
+		q.Disabled = &disabled
+	}
+	if s := v.Get("limit"); s != "" {
+		if q.Limit, err = strconv.Atoi(s); err != nil {
+			return q, errors.New("limit must be an integer")
+		}
+	}
+	if s := v.Get("cursor"); s != "" {
+		after, err := strconv.ParseInt(s, 10, 64)
+		if err != nil || after < 1 {
+			return q, errors.New("invalid cursor")
+		}
+		q.After = user.UserID(after)
+	}
+	return q, nil
+}
+
+// HandleGetUser serves GET /admin/users/{id}.
+func (h *Handler) HandleGetUser(w http.ResponseWriter, r *http.Request) {
+	actor, ok := actorOf(w, r)
+	if !ok {
+		return
+	}
+	id, ok := readUserID(w, r)
+	if !ok {
+		return
+	}
+	u, err := h.service.GetUser(r.Context(), actor, id)
+	if err != nil {
+		h.writeError(w, r, err, userErrors, "Failed to retrieve user")
+		return
+	}
+	httpx.WriteJson(w, http.StatusOK, httpx.Envelope{"user": u})
+}
+
+type setRoleRequest struct {
+	Role string `json:"role"`
+}
+
+// HandleSetRole serves PUT /admin/users/{id}/role.
+func (h *Handler) HandleSetRole(w http.ResponseWriter, r *http.Request) {
+	actor, ok := actorOf(w, r)
+	if !ok {
+		return
+	}
+	id, ok := readUserID(w, r)
+	if !ok {
+		return
+	}
+	var req setRoleRequest
+	if derr := httpx.DecodeJSONBody(w, r, &req); derr != nil {
+		h.logger.WarnContext(r.Context(), "decode set role", slog.Any("err", derr))
+		httpx.WriteDecodeError(w, derr)
+		return
+	}
+	u, err := h.service.SetRole(r.Context(), actor, id, user.Role(req.Role))
+	if err != nil {
+		h.writeError(w, r, err, userErrors, "Failed to set role")
+		return
+	}
+	httpx.WriteJson(w, http.StatusOK, httpx.Envelope{"user": u})
+}
+
+// HandleDisableUser serves POST /admin/users/{id}/disable.
+func (h *Handler) HandleDisableUser(w http.ResponseWriter, r *http.Request) {
+	actor, ok := actorOf(w, r)
+	if !ok {
+		return
+	}
+	id, ok := readUserID(w, r)
+	if !ok {
+		return
+	}
+	u, err := h.service.DisableUser(r.Context(), actor, id)
+	if err != nil {
+		h.writeError(w, r, err, userErrors, "Failed to disable user")
+		return
+	}
+	httpx.WriteJson(w, http.StatusOK, httpx.Envelope{"user": u})
+}
+
+// HandleEnableUser serves POST /admin/users/{id}/enable.
+func (h *Handler) HandleEnableUser(w http.ResponseWriter, r *http.Request) {
+	actor, ok := actorOf(w, r)
+	if !ok {
+		return
+	}
+	id, ok := readUserID(w, r)
+	if !ok {
+		return
+	}
+	u, err := h.service.EnableUser(r.Context(), actor, id)
+	if err != nil {
+		h.writeError(w, r, err, userErrors, "Failed to enable user")
+		return
+	}
+	httpx.WriteJson(w, http.StatusOK, httpx.Envelope{"user": u})
+}
+
+// HandleRevokeTokens serves POST /admin/users/{id}/revoke-tokens.
+func (h *Handler) HandleRevokeTokens(w http.ResponseWriter, r *http.Request) {
+	actor, ok := actorOf(w, r)
+	if !ok {
+		return
+	}
+	id, ok := readUserID(w, r)
+	if !ok {
+		return
+	}
+	if err := h.service.RevokeTokens(r.Context(), actor, id); err != nil {
+		h.writeError(w, r, err, userErrors, "Failed to revoke tokens")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandleGetWorkout serves GET /admin/workouts/{id}.
+func (h *Handler) HandleGetWorkout(w http.ResponseWriter, r *http.Request) {
+	actor, ok := actorOf(w, r)
+	if !ok {
+		return
+	}
+	id, err := httpx.ReadIdParam(r, "id")
+	if err != nil {
+		writeValidationError(w, err)
+		return
+	}
+	wo, err := h.service.GetWorkout(r.Context(), actor, workout.WorkoutID(id))
+	if err != nil {
+		h.writeError(w, r, err, workoutErrors, "Failed to retrieve workout")
+		return
+	}
+	httpx.WriteJson(w, http.StatusOK, httpx.Envelope{"workout": wo})
+}
+
+// HandleListAudit serves GET /admin/audit?actor=&target=&action=&limit=&cursor=.
+func (h *Handler) HandleListAudit(w http.ResponseWriter, r *http.Request) {
+	actor, ok := actorOf(w, r)
+	if !ok {
+		return
+	}
+	q, err := parseAuditQuery(r.URL.Query())
+	if err != nil {
+		writeValidationError(w, err)
+		return
+	}
+	page, err := h.service.ListAudit(r.Context(), actor, q)
+	if err != nil {
+		h.writeError(w, r, err, httpx.StoreErrorMapping{}, "Failed to list audit entries")
+		return
+	}
+	httpx.WriteJson(w, http.StatusOK, httpx.Envelope{"entries": page.Entries, "next_cursor": page.NextCursor})
+}
+
+// parseAuditQuery maps ?actor, target, action, limit and cursor onto an
+// AuditQuery.
+func parseAuditQuery(v url.Values) (AuditQuery, error) {
+	q := AuditQuery{Action: v.Get("action")}
+	for _, p := range []struct {
+		key string
+		dst *int64
+	}{
+		{"actor", (*int64)(&q.ActorID)},
+		{"target", (*int64)(&q.TargetUserID)},
+		{"cursor", (*int64)(&q.Before)},
+	} {
+		if s := v.Get(p.key); s != "" {
+			n, err := strconv.ParseInt(s, 10, 64)
+			if err != nil || n < 1 {
+				return q, errors.New(p.key + " must be a positive integer")
+			}
+			*p.dst = n
+		}
+	}
+	if s := v.Get("limit"); s != "" {
+		limit, err := strconv.Atoi(s)
+		if err != nil {
+			return q, errors.New("limit must be an integer")
+		}
+		q.Limit = limit
+	}
+	return q, nil
+}
@@ -0,0 +1,53 @@
+package admin
+
+import (
+	"time"
+
+	"github.com/tsatsarisg/go-fit/internal/user"
+)
+
+// AuditEntryID is the admin_audit_log.id column.
+type AuditEntryID int64
+
+// Audit actions, one per admin endpoint. Stored as text, so a new action
+// needs no migration.
+const (
+	ActionSearchUsers  = "users.search"
+	ActionViewUser     = "user.view"
+	ActionSetRole      = "user.set_role"
+	ActionDisableUser  = "user.disable"
+	ActionEnableUser   = "user.enable"
+	ActionRevokeTokens = "user.revoke_tokens"
+	ActionViewWorkout  = "workout.view"
+	ActionListAudit    = "audit.list"
+)
+
+// Audit outcomes. An action that changes an account is recorded as
+// OutcomeAttempted before it runs and updated after; one that stays
+// attempted may or may not have taken effect. Reads are recorded once they
+// succeeded.
+const (
+	OutcomeAttempted = "attempted"
+	OutcomeSucceeded = "succeeded"
+	OutcomeFailed    = "failed"
+)
+
+// AuditEntry is one admin action. TargetUserID is the account acted on,
+// nil for actions on no single account (a search); for a workout it is the
+// owner. Details holds the action's parameters.
+type AuditEntry struct {
+	ID           AuditEntryID   `json:"id"`
+	ActorID      user.UserID    `json:"actor_id"`
+	Action       string         `json:"action"`
+	TargetUserID *user.UserID   `json:"target_user_id,omitempty"`
+	Details      map[string]any `json:"details"`
+	IP           string         `json:"ip"`
+	Outcome      string         `json:"outcome"`
+	CreatedAt    time.Time      `json:"created_at"`
+}
+
+// Actor is the admin behind an action, as the audit trail records them.
+type Actor struct {
+	ID user.UserID
+	IP string
+}
@@ -0,0 +1,84 @@
+package admin
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+type PostgresStore struct {
+	db *sql.DB
+}
+
+func NewPostgresStore(db *sql.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+// RecordAction appends e to the trail, filling in its ID and CreatedAt.
+func (pg *PostgresStore) RecordAction(ctx context.Context, e *AuditEntry) error {
+	details, err := json.Marshal(e.Details)
+	if err != nil {
+		return err
+	}
+	query := `INSERT INTO admin_audit_log (actor_id, action, target_user_id, details, ip, outcome)
+	          VALUES ($1, $2, $3, $4, $5, $6)
+	          RETURNING id, created_at`
+	return pg.db.QueryRowContext(ctx, query, e.ActorID, e.Action, e.TargetUserID, details, e.IP, e.Outcome).Scan(&e.ID, &e.CreatedAt)
+}
+
+// SetOutcome records how the action of entry id turned out.
+func (pg *PostgresStore) SetOutcome(ctx context.Context, id AuditEntryID, outcome string) error {
+	_, err := pg.db.ExecContext(ctx, `UPDATE admin_audit_log SET outcome = $2 WHERE id = $1`, id, outcome)
+	return err
+}
+
+// ListActions pages through the trail newest first by id, which is also
+// insertion order. Every predicate is parameterized.
+func (pg *PostgresStore) ListActions(ctx context.Context, q AuditQuery) ([]*AuditEntry, error) {
+	var (
+		args  []any
+		conds = []string{"TRUE"}
+	)
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+	if q.ActorID != 0 {
+		conds = append(conds, "actor_id = "+arg(q.ActorID))
+	}
+	if q.TargetUserID != 0 {
+		conds = append(conds, "target_user_id = "+arg(q.TargetUserID))
+	}
+	if q.Action != "" {
+		conds = append(conds, "action = "+arg(q.Action))
+	}
+	if q.Before != 0 {
+		conds = append(conds, "id < "+arg(q.Before))
+	}
+	query := `SELECT id, actor_id, action, target_user_id, details, ip, outcome, created_at
+	          FROM admin_audit_log
+	          WHERE ` + strings.Join(conds, " AND ") + `
+	          ORDER BY id DESC
+	          LIMIT ` + arg(q.Limit+1)
+
+	rows, err := pg.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var entries []*AuditEntry
+	for rows.Next() {
+		e := &AuditEntry{}
+		var details []byte
+		if err := rows.Scan(&e.ID, &e.ActorID, &e.Action, &e.TargetUserID, &details, &e.IP, &e.Outcome, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(details, &e.Details); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
@@ -0,0 +1,277 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+
+	"github.com/tsatsarisg/go-fit/internal/user"
+	"github.com/tsatsarisg/go-fit/internal/workout"
+)
+
+// Store is the admin context's persistence port: the audit trail. The
+// accounts and workouts it acts on belong to other contexts and are
+// reached through their services.
+type Store interface {
+	RecordAction(ctx context.Context, e *AuditEntry) error
+	SetOutcome(ctx context.Context, id AuditEntryID, outcome string) error
+	// ListActions returns up to q.Limit+1 entries, newest first, before
+	// q.Before.
+	ListActions(ctx context.Context, q AuditQuery) ([]*AuditEntry, error)
+}
+
+// Users is the slice of user.Service the admin API drives.
+type Users interface {
+	Get(ctx context.Context, id user.UserID) (*user.User, error)
+	Search(ctx context.Context, q user.SearchQuery) (*user.UserPage, error)
+	SetRole(ctx context.Context, id user.UserID, role user.Role) (*user.User, error)
+	Disable(ctx context.Context, id user.UserID) (*user.User, error)
+	Enable(ctx context.Context, id user.UserID) (*user.User, error)
+}
+
+// Tokens is the slice of auth.Service that signs accounts out. admin can
+// import auth, but a narrow port keeps what it may do to tokens explicit.
+type Tokens interface {
+	Logout(ctx context.Context, id user.UserID) error
+	RevokeAllTokens(ctx context.Context, id user.UserID) error
+}
+
+// Workouts reads any workout, past the visibility rules.
+type Workouts interface {
+	GetAny(ctx context.Context, id workout.WorkoutID) (*workout.Workout, error)
+}
+
+// ErrValidation wraps bad input, including an admin acting on their own
+// account in a way that could lock them out → 400.
+var ErrValidation = errors.New("admin validation failed")
+
+// Service runs admin actions and records each in the audit trail.
+// Authorization is the router's job (auth.Middleware.RequirePermission
+// with an admin permission); by the time a method runs, the actor is an
+// admin.
+//
+// An action that changes an account fails closed: its entry is written as
+// attempted before it runs, the action doesn't run if that write fails, and
+// the entry is marked succeeded or failed afterwards. Nothing can change
+// without a trace. A read is recorded after it succeeds, and its result is
+// withheld if the record can't be written.
+type Service struct {
+	store    Store
+	users    Users
+	tokens   Tokens
+	workouts Workouts
+}
+
+func NewService(store Store, users Users, tokens Tokens, workouts Workouts) *Service {
+	return &Service{store: store, users: users, tokens: tokens, workouts: workouts}
+}
+
+// record writes an entry with outcome.
+func (s *Service) record(ctx context.Context, actor Actor, action string, target *user.UserID, details map[string]any, outcome string) (*AuditEntry, error) {
+	if details == nil {
+		details = map[string]any{}
+	}
+	e := &AuditEntry{ActorID: actor.ID, Action: action, TargetUserID: target, Details: details, IP: actor.IP, Outcome: outcome}
+	if err := s.store.RecordAction(ctx, e); err != nil {
+		return nil, fmt.Errorf("record %s: %w", action, err)
+	}
+	return e, nil
+}
+
+// recordRead records a read that succeeded.
+func (s *Service) recordRead(ctx context.Context, actor Actor, action string, target *user.UserID, details map[string]any) error {
+	_, err := s.record(ctx, actor, action, target, details, OutcomeSucceeded)
+	return err
+}
+
+// audited runs change after recording it as attempted, then records its
+// outcome. change doesn't run when the first write fails. An outcome that
+// can't be recorded leaves the entry attempted and fails the call, though
+// the change happened.
+func (s *Service) audited(ctx context.Context, actor Actor, action string, target *user.UserID, details map[string]any, change func() error) error {
+	e, err := s.record(ctx, actor, action, target, details, OutcomeAttempted)
+	if err != nil {
+		return err
+	}
+	outcome := OutcomeSucceeded
+	changeErr := change()
+	if changeErr != nil {
+		outcome = OutcomeFailed
+	}
+	if err := s.store.SetOutcome(ctx, e.ID, outcome); err != nil && changeErr == nil {
+		return fmt.Errorf("record outcome of %s: %w", action, err)
+	}
+	return changeErr
+}
+
+// SearchUsers lists accounts matching q. Errors are user.Service.Search's.
+func (s *Service) SearchUsers(ctx context.Context, actor Actor, q user.SearchQuery) (*user.UserPage, error) {
+	page, err := s.users.Search(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	details := map[string]any{"query": q.Query, "role": q.Role, "results": len(page.Users)}
+	if q.Disabled != nil {
+		details["disabled"] = *q.Disabled
+	}
+	if q.After != 0 {
+		details["after"] = q.After
+	}
+	return page, s.recordRead(ctx, actor, ActionSearchUsers, nil, details)
+}
+
+// GetUser returns any account, deleted and disabled ones included.
+func (s *Service) GetUser(ctx context.Context, actor Actor, id user.UserID) (*user.User, error) {
+	u, err := s.users.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return u, s.recordRead(ctx, actor, ActionViewUser, &id, nil)
+}
+
+// SetRole gives the account role. An admin can't change their own, so the
+// last admin can't demote themselves by mistake. A demotion signs the
+// account out everywhere: a JWT carries the role it was issued with, and
+// must not keep the old permissions until it expires. A promotion takes
+// effect at the next token refresh.
+func (s *Service) SetRole(ctx context.Context, actor Actor, id user.UserID, role user.Role) (*user.User, error) {
+	if id == actor.ID {
+		return nil, fmt.Errorf("%w: you can't change your own role", ErrValidation)
+	}
+	before, err := s.users.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	var u *user.User
+	err = s.audited(ctx, actor, ActionSetRole, &id, map[string]any{"from": before.Role, "to": role}, func() error {
+		if u, err = s.users.SetRole(ctx, id, role); err != nil {
+			return err
+		}
+		if slices.Index(user.Roles, role) < slices.Index(user.Roles, before.Role) {
+			return s.tokens.Logout(ctx, id)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// DisableUser stops the account from signing in and revokes every token it
+// holds. Its data stays, and EnableUser undoes it.
+func (s *Service) DisableUser(ctx context.Context, actor Actor, id user.UserID) (*user.User, error) {
+	if id == actor.ID {
+		return nil, fmt.Errorf("%w: you can't disable your own account", ErrValidation)
+	}
+	var u *user.User
+	err := s.audited(ctx, actor, ActionDisableUser, &id, nil, func() error {
+		var err error
+		if u, err = s.users.Disable(ctx, id); err != nil {
+			return err
+		}
+		return s.tokens.RevokeAllTokens(ctx, id)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// EnableUser lets a disabled account sign in again. Its revoked tokens
+// stay revoked.
+func (s *Service) EnableUser(ctx context.Context, actor Actor, id user.UserID) (*user.User, error) {
+	var u *user.User
+	err := s.audited(ctx, actor, ActionEnableUser, &id, nil, func() error {
+		var err error
+		u, err = s.users.Enable(ctx, id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// RevokeTokens signs the account out everywhere and voids every token it
+// holds, personal access tokens included, without disabling it.
+func (s *Service) RevokeTokens(ctx context.Context, actor Actor, id user.UserID) error {
+	if _, err := s.users.Get(ctx, id); err != nil {
+		return err
+	}
+	return s.audited(ctx, actor, ActionRevokeTokens, &id, nil, func() error {
+		return s.tokens.RevokeAllTokens(ctx, id)
+	})
+}
+
+// GetWorkout returns any workout, whatever its visibility.
+func (s *Service) GetWorkout(ctx context.Context, actor Actor, id workout.WorkoutID) (*workout.Workout, error) {
+	w, err := s.workouts.GetAny(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return w, s.recordRead(ctx, actor, ActionViewWorkout, &w.UserID, map[string]any{"workout_id": w.ID})
+}
+
+// Page size bounds for ListAudit.
+const (
+	defaultAuditLimit = 50
+	maxAuditLimit     = 200
+)
+
+// AuditQuery filters the audit trail; zero fields match everything.
+// Before is the cursor: the id of the last entry of the previous page.
+type AuditQuery struct {
+	ActorID      user.UserID
+	TargetUserID user.UserID
+	Action       string
+	Limit        int
+	Before       AuditEntryID
+}
+
+func (q *AuditQuery) Validate() error {
+	if q.Limit == 0 {
+		q.Limit = defaultAuditLimit
+	}
+	if q.Limit < 1 || q.Limit > maxAuditLimit {
+		return fmt.Errorf("limit must be between 1 and %d", maxAuditLimit)
+	}
+	return nil
+}
+
+// AuditPage is one page of ListAudit results. NextCursor is empty on the
+// last page.
+type AuditPage struct {
+	Entries    []*AuditEntry `json:"entries"`
+	NextCursor string        `json:"next_cursor,omitempty"`
+}
+
+// ListAudit reads the audit trail, newest first. Reading it is an admin
+// action too, and is recorded like the others.
+func (s *Service) ListAudit(ctx context.Context, actor Actor, q AuditQuery) (*AuditPage, error) {
+	if err := q.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrValidation, err)
+	}
+	entries, err := s.store.ListActions(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	page := &AuditPage{Entries: entries}
+	if len(entries) > q.Limit {
+		page.Entries = entries[:q.Limit]
+		page.NextCursor = strconv.FormatInt(int64(page.Entries[q.Limit-1].ID), 10)
+	}
+	if page.Entries == nil {
+		page.Entries = []*AuditEntry{}
+	}
+	details := map[string]any{"action": q.Action}
+	if q.ActorID != 0 {
+		details["actor_id"] = q.ActorID
+	}
+	if q.TargetUserID != 0 {
+		details["target_user_id"] = q.TargetUserID
+	}
+	return page, s.recordRead(ctx, actor, ActionListAudit, nil, details)
+}
@@ -0,0 +1,160 @@
+package admin
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/tsatsarisg/go-fit/internal/auth"
+	"github.com/tsatsarisg/go-fit/internal/exercise"
+	"github.com/tsatsarisg/go-fit/internal/platform/postgres/pgtest"
+	"github.com/tsatsarisg/go-fit/internal/user"
+	"github.com/tsatsarisg/go-fit/internal/workout"
+)
+
+func setupTestDB(t *testing.T) *sql.DB {
+	return pgtest.Open(t, "admin", "users", "login_failures", "admin_audit_log")
+}
+
+func TestAdminActions(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	ctx := context.Background()
+	userSvc := user.NewService(user.NewPostgresStore(db), user.NewBcryptHasher(bcrypt.MinCost), user.PasswordPolicy{}, time.Hour)
+	tokenStore := auth.NewPostgresStore(db)
+	access := auth.NewOpaqueAccessTokens(tokenStore)
+	authSvc := auth.NewService(tokenStore, userSvc, nil, nil, access, nil)
+	workoutSvc := workout.NewService(workout.NewPostgresStore(db), exercise.NewService(exercise.NewPostgresStore(db)))
+	svc := NewService(NewPostgresStore(db), userSvc, authSvc, workoutSvc)
+
+	register := func(name string) *user.User {
+		u, err := userSvc.Register(ctx, user.RegisterCommand{Username: name, Email: name + "@example.com", Password: "correct horse battery"})
+		require.NoError(t, err)
+		return u
+	}
+	root, alice := register("root"), register("alice")
+	assert.Equal(t, user.RoleUser, alice.Role)
+	_, err := userSvc.SetRole(ctx, root.ID, user.RoleAdmin)
+	require.NoError(t, err)
+	actor := Actor{ID: root.ID, IP: "192.0.2.1"}
+
+	login := func() (*auth.LoginResult, error) {
+		return authSvc.Login(ctx, auth.LoginCommand{Username: "alice", Password: "correct horse battery"})
+	}
+	resolves := func(token string) bool {
+		p, err := access.Resolve(ctx, token)
+		require.NoError(t, err)
+		return p != nil
+	}
+
+	page, err := svc.SearchUsers(ctx, actor, user.SearchQuery{Query: "ALI"})
+	require.NoError(t, err)
+	require.Len(t, page.Users, 1)
+	assert.Equal(t, alice.ID, page.Users[0].ID)
+
+	t.Run("disable signs out and refuses login", func(t *testing.T) {
+		session, err := login()
+		require.NoError(t, err)
+		token := session.Session.Access.Plaintext
+		assert.True(t, resolves(token))
+
+		disabled, err := svc.DisableUser(ctx, actor, alice.ID)
+		require.NoError(t, err)
+		assert.True(t, disabled.Disabled())
+		assert.False(t, resolves(token))
+		_, err = login()
+		assert.ErrorIs(t, err, auth.ErrAccountDisabled)
+
+		_, err = svc.EnableUser(ctx, actor, alice.ID)
+		require.NoError(t, err)
+		_, err = login()
+		assert.NoError(t, err)
+		assert.False(t, resolves(token), "enabling doesn't bring tokens back")
+
+		_, err = svc.DisableUser(ctx, actor, root.ID)
+		assert.ErrorIs(t, err, ErrValidation)
+	})
+
+	t.Run("role changes", func(t *testing.T) {
+		session, err := login()
+		require.NoError(t, err)
+		token := session.Session.Access.Plaintext
+
+		coach, err := svc.SetRole(ctx, actor, alice.ID, user.RoleCoach)
+		require.NoError(t, err)
+		assert.Equal(t, user.RoleCoach, coach.Role)
+		assert.True(t, resolves(token), "a promotion keeps the session")
+
+		_, err = svc.SetRole(ctx, actor, alice.ID, user.RoleUser)
+		require.NoError(t, err)
+		assert.False(t, resolves(token), "a demotion ends it")
+
+		_, err = svc.SetRole(ctx, actor, alice.ID, "owner")
+		assert.ErrorIs(t, err, user.ErrValidation)
+		_, err = svc.SetRole(ctx, actor, root.ID, user.RoleUser)
+		assert.ErrorIs(t, err, ErrValidation)
+	})
+
+	t.Run("audit trail", func(t *testing.T) {
+		trail, err := svc.ListAudit(ctx, actor, AuditQuery{TargetUserID: alice.ID, Limit: 2})
+		require.NoError(t, err)
+		require.Len(t, trail.Entries, 2)
+		assert.NotEmpty(t, trail.NextCursor)
+		failed, demoted := trail.Entries[0], trail.Entries[1]
+		assert.Equal(t, ActionSetRole, failed.Action)
+		assert.Equal(t, OutcomeFailed, failed.Outcome)
+		assert.Equal(t, map[string]any{"from": "user", "to": "owner"}, failed.Details)
+		assert.Equal(t, ActionSetRole, demoted.Action)
+		assert.Equal(t, OutcomeSucceeded, demoted.Outcome)
+		assert.Equal(t, root.ID, demoted.ActorID)
+		assert.Equal(t, "192.0.2.1", demoted.IP)
+		assert.Equal(t, map[string]any{"from": "coach", "to": "user"}, demoted.Details)
+
+		all, err := svc.ListAudit(ctx, actor, AuditQuery{ActorID: root.ID})
+		require.NoError(t, err)
+		var actions []string
+		for _, e := range all.Entries {
+			actions = append(actions, e.Action)
+		}
+		assert.Equal(t, []string{
+			ActionListAudit, ActionSetRole, ActionSetRole, ActionSetRole, ActionEnableUser, ActionDisableUser, ActionSearchUsers,
+		}, actions, "actions refused before they start aren't recorded")
+	})
+
+	t.Run("no audit, no action", func(t *testing.T) {
+		session, err := login()
+		require.NoError(t, err)
+		blind := NewService(failingAudit{NewPostgresStore(db)}, userSvc, authSvc, workoutSvc)
+
+		_, err = blind.DisableUser(ctx, actor, alice.ID)
+		assert.Error(t, err)
+		_, err = blind.SetRole(ctx, actor, alice.ID, user.RoleAdmin)
+		assert.Error(t, err)
+		assert.Error(t, blind.RevokeTokens(ctx, actor, alice.ID))
+
+		u, err := userSvc.Get(ctx, alice.ID)
+		require.NoError(t, err)
+		assert.False(t, u.Disabled())
+		assert.Equal(t, user.RoleUser, u.Role)
+		assert.True(t, resolves(session.Session.Access.Plaintext))
+
+		_, err = blind.GetUser(ctx, actor, alice.ID)
+		assert.Error(t, err, "a read isn't answered without its record")
+	})
+}
+
+// failingAudit is an audit store whose writes fail.
+type failingAudit struct {
+	Store
+}
+
+func (failingAudit) RecordAction(context.Context, *AuditEntry) error {
+	return errors.New("audit log unavailable")
+}
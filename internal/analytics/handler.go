@@ -0,0 +1,107 @@
+package analytics
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/tsatsarisg/go-fit/internal/auth"
+	"github.com/tsatsarisg/go-fit/internal/httpx"
+)
+
+type Handler struct {
+	service *Service
+	logger  *slog.Logger
+}
+
+func NewHandler(service *Service, logger *slog.Logger) *Handler {
+	return &Handler{service: service, logger: logger}
+}
+
+var errorMapping = httpx.StoreErrorMapping{ResourceName: "Stats"}
+
+// readQuery builds the Query shared by every /me/stats endpoint from
+// ?bucket=&tz=&from=&to=. It writes the error response itself and returns
+// ok=false when the request can't proceed.
+func (h *Handler) readQuery(w http.ResponseWriter, r *http.Request) (Query, bool) {
+	principal := auth.GetPrincipal(r)
+	if principal.IsAnonymous() {
+		httpx.WriteJson(w, http.StatusUnauthorized, httpx.Envelope{"error": "Unauthenticated"})
+		return Query{}, false
+	}
+
+	v := r.URL.Query()
+	q, err := NewQuery(principal.ID, v.Get("bucket"), v.Get("tz"), v.Get("from"), v.Get("to"), time.Now())
+	if err != nil {
+		httpx.WriteJson(w, http.StatusBadRequest, httpx.Envelope{"error": err.Error()})
+		return Query{}, false
+	}
+	return q, true
+}
+
+// writeSeries renders a series with the parameters that produced it, so a
+// client charting defaults knows what range and zone it got.
+func (h *Handler) writeSeries(w http.ResponseWriter, r *http.Request, q Query, series any, err error) {
+	if err != nil {
+		if errors.Is(err, ErrValidation) {
+			httpx.WriteJson(w, http.StatusBadRequest, httpx.Envelope{"error": err.Error()})
+			return
+		}
+		httpx.WriteStoreError(r.Context(), w, h.logger, err, errorMapping, "Failed to compute stats")
+		return
+	}
+	httpx.WriteJson(w, http.StatusOK, httpx.Envelope{
+		"bucket":   q.Bucket,
+		"timezone": q.Location.String(),
+		"from":     q.From.Format(dateLayout),
+		"to":       q.To.AddDate(0, 0, -1).Format(dateLayout),
+		"series":   series,
+	})
+}
+
+func (h *Handler) HandleVolume(w http.ResponseWriter, r *http.Request) {
+	q, ok := h.readQuery(w, r)
+	if !ok {
+		return
+	}
+	series, err := h.service.Volume(r.Context(), q)
+	h.writeSeries(w, r, q, series, err)
+}
+
+func (h *Handler) HandleMuscles(w http.ResponseWriter, r *http.Request) {
+	q, ok := h.readQuery(w, r)
+	if !ok {
+		return
+	}
+	series, err := h.service.Muscles(r.Context(), q)
+	h.writeSeries(w, r, q, series, err)
+}
+
+func (h *Handler) HandleTraining(w http.ResponseWriter, r *http.Request) {
+	q, ok := h.readQuery(w, r)
+	if !ok {
+		return
+	}
+	series, err := h.service.Training(r.Context(), q)
+	h.writeSeries(w, r, q, series, err)
+}
+
+// HandleOneRepMax serves GET /me/stats/one-rep-max/{exercise}, where
+// {exercise} is a catalog exercise id or a URL-escaped name.
+func (h *Handler) HandleOneRepMax(w http.ResponseWriter, r *http.Request) {
+	q, ok := h.readQuery(w, r)
+	if !ok {
+		return
+	}
+	ref, err := url.PathUnescape(chi.URLParam(r, "exercise"))
+	if err != nil {
+		httpx.WriteJson(w, http.StatusBadRequest, httpx.Envelope{"error": "invalid exercise"})
+		return
+	}
+	series, err := h.service.OneRepMax(r.Context(), q, ref)
+	h.writeSeries(w, r, q, series, err)
+}
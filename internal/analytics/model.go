@@ -0,0 +1,179 @@
+package analytics
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/tsatsarisg/go-fit/internal/user"
+)
+
+// Bucket is the width of one point on a time series.
+type Bucket string
+
+const (
+	BucketDay   Bucket = "day"
+	BucketWeek  Bucket = "week"
+	BucketMonth Bucket = "month"
+)
+
+func (b Bucket) Valid() bool {
+	switch b {
+	case BucketDay, BucketWeek, BucketMonth:
+		return true
+	}
+	return false
+}
+
+// Range limits. Day buckets are capped tighter so a single response stays a
+// chart, not a data dump.
+const (
+	defaultRangeDays = 90
+	maxRangeDays     = 3 * 366
+	maxDayRangeDays  = 366
+)
+
+const dateLayout = time.DateOnly
+
+// Query selects the caller's workouts with created_at in [From, To) and
+// groups them into Bucket-wide periods in Location. From and To are local
+// midnights in Location; periods start on local midnight, weeks on Monday.
+type Query struct {
+	UserID   user.UserID
+	Bucket   Bucket
+	Location *time.Location
+	From     time.Time
+	To       time.Time
+}
+
+// NewQuery builds a Query from the request's raw parameters. Empty values
+// take defaults: week buckets, UTC, and the 90 days up to and including
+// today in tz.
+func NewQuery(userID user.UserID, bucket, tz, from, to string, now time.Time) (Query, error) {
+	q := Query{UserID: userID, Bucket: BucketWeek, Location: time.UTC}
+	if bucket != "" {
+		q.Bucket = Bucket(bucket)
+		if !q.Bucket.Valid() {
+			return q, fmt.Errorf("bucket must be one of %q, %q or %q", BucketDay, BucketWeek, BucketMonth)
+		}
+	}
+	if tz != "" {
+		loc, err := time.LoadLocation(tz)
+		if err != nil {
+			return q, fmt.Errorf("tz: unknown time zone %q", tz)
+		}
+		q.Location = loc
+	}
+
+	today := startOfDay(now.In(q.Location))
+	q.To = today.AddDate(0, 0, 1)
+	if to != "" {
+		d, err := time.ParseInLocation(dateLayout, to, q.Location)
+		if err != nil {
+			return q, errors.New("to must be a date (YYYY-MM-DD)")
+		}
+		q.To = d.AddDate(0, 0, 1)
+	}
+	q.From = q.To.AddDate(0, 0, -defaultRangeDays)
+	if from != "" {
+		d, err := time.ParseInLocation(dateLayout, from, q.Location)
+		if err != nil {
+			return q, errors.New("from must be a date (YYYY-MM-DD)")
+		}
+		q.From = d
+	}
+	return q, q.Validate()
+}
+
+func (q *Query) Validate() error {
+	if !q.Bucket.Valid() {
+		return fmt.Errorf("bucket must be one of %q, %q or %q", BucketDay, BucketWeek, BucketMonth)
+	}
+	if !q.From.Before(q.To) {
+		return errors.New("from must not be after to")
+	}
+	days := int(q.To.Sub(q.From).Hours()/24 + 0.5)
+	if days > maxRangeDays {
+		return fmt.Errorf("range must not exceed %d days", maxRangeDays)
+	}
+	if q.Bucket == BucketDay && days > maxDayRangeDays {
+		return fmt.Errorf("range must not exceed %d days with day buckets", maxDayRangeDays)
+	}
+	return nil
+}
+
+// periodStarts lists the start date of every bucket the range touches, in
+// order, so sparse SQL results can be padded into a continuous series.
+func (q *Query) periodStarts() []string {
+	var out []string
+	for t := q.truncate(q.From); t.Before(q.To); t = q.next(t) {
+		out = append(out, t.Format(dateLayout))
+	}
+	return out
+}
+
+// truncate mirrors Postgres date_trunc for the query's bucket: weeks start
+// on Monday, months on the 1st.
+func (q *Query) truncate(t time.Time) time.Time {
+	t = startOfDay(t)
+	switch q.Bucket {
+	case BucketWeek:
+		offset := (int(t.Weekday()) + 6) % 7
+		return t.AddDate(0, 0, -offset)
+	case BucketMonth:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+	}
+	return t
+}
+
+func (q *Query) next(t time.Time) time.Time {
+	switch q.Bucket {
+	case BucketWeek:
+		return t.AddDate(0, 0, 7)
+	case BucketMonth:
+		return t.AddDate(0, 1, 0)
+	}
+	return t.AddDate(0, 0, 1)
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// VolumePoint is training volume for one period. Tonnage is the sum of
+// reps × weight over completed, non-warmup sets.
+type VolumePoint struct {
+	PeriodStart string  `json:"period_start"`
+	Tonnage     float64 `json:"tonnage"`
+	Sets        int     `json:"sets"`
+	Reps        int     `json:"reps"`
+}
+
+// MusclePoint counts the completed, non-warmup sets that hit Muscle in one
+// period, as a primary or as a secondary mover. Only entries linked to the
+// exercise catalog carry muscle data.
+type MusclePoint struct {
+	PeriodStart   string `json:"period_start"`
+	Muscle        string `json:"muscle"`
+	Sets          int    `json:"sets"`
+	SecondarySets int    `json:"secondary_sets"`
+}
+
+// TrainingPoint is how often and how long the user trained in one period.
+type TrainingPoint struct {
+	PeriodStart     string `json:"period_start"`
+	Workouts        int    `json:"workouts"`
+	ActiveDays      int    `json:"active_days"`
+	DurationMinutes int    `json:"duration_minutes"`
+	CaloriesBurned  int    `json:"calories_burned"`
+}
+
+// OneRepMaxPoint is the best estimated 1RM for one exercise in one period,
+// and the set it came from. Periods without a qualifying set are omitted.
+type OneRepMaxPoint struct {
+	PeriodStart  string  `json:"period_start"`
+	Estimated1RM float64 `json:"estimated_1rm"`
+	Weight       float64 `json:"weight"`
+	Reps         int     `json:"reps"`
+	WorkoutID    int64   `json:"workout_id"`
+}
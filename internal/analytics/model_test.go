@@ -0,0 +1,48 @@
+package analytics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewQuery(t *testing.T) {
+	now := time.Date(2026, 4, 22, 23, 30, 0, 0, time.UTC)
+
+	t.Run("defaults to 90 days of weeks in UTC", func(t *testing.T) {
+		q, err := NewQuery(1, "", "", "", "", now)
+		assert.NoError(t, err)
+		assert.Equal(t, BucketWeek, q.Bucket)
+		assert.Equal(t, time.Date(2026, 4, 23, 0, 0, 0, 0, time.UTC), q.To)
+		assert.Equal(t, time.Date(2026, 1, 23, 0, 0, 0, 0, time.UTC), q.From)
+	})
+
+	t.Run("today follows the requested time zone", func(t *testing.T) {
+		q, err := NewQuery(1, "day", "Europe/Athens", "", "", now)
+		assert.NoError(t, err)
+		assert.Equal(t, "2026-04-24", q.To.Format(dateLayout))
+	})
+
+	t.Run("rejects bad input", func(t *testing.T) {
+		for _, tc := range [][4]string{
+			{"year", "", "", ""},
+			{"", "Mars/Olympus", "", ""},
+			{"", "", "2026-05-01", "2026-04-01"},
+			{"day", "", "2024-01-01", "2026-01-01"},
+		} {
+			_, err := NewQuery(1, tc[0], tc[1], tc[2], tc[3], now)
+			assert.Error(t, err, tc)
+		}
+	})
+}
+
+func TestPeriodStarts(t *testing.T) {
+	q, err := NewQuery(1, "week", "", "2026-04-01", "2026-04-14", time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"2026-03-30", "2026-04-06", "2026-04-13"}, q.periodStarts())
+
+	q, err = NewQuery(1, "month", "", "2026-01-31", "2026-03-01", time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"2026-01-01", "2026-02-01", "2026-03-01"}, q.periodStarts())
+}
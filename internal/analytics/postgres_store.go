@@ -0,0 +1,163 @@
+package analytics
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/tsatsarisg/go-fit/internal/exercise"
+)
+
+type PostgresStore struct {
+	db *sql.DB
+}
+
+func NewPostgresStore(db *sql.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+// Every query shares the same first five parameters, built by baseArgs:
+// $1 user id, $2 bucket, $3 time zone, $4 range start, $5 range end.
+// periodExpr turns a workout's created_at into its local period start, the
+// same boundaries Query.truncate computes in Go.
+const (
+	periodExpr   = `to_char(date_trunc($2, w.created_at AT TIME ZONE $3), 'YYYY-MM-DD')`
+	workoutRange = `w.user_id = $1 AND w.created_at >= $4 AND w.created_at < $5`
+	countedSet   = `s.completed AND s.set_type <> 'warmup'`
+)
+
+func baseArgs(q Query) []any {
+	return []any{q.UserID, string(q.Bucket), q.Location.String(), q.From, q.To}
+}
+
+func (pg *PostgresStore) Volume(ctx context.Context, q Query) ([]VolumePoint, error) {
+	query := `SELECT ` + periodExpr + ` AS period,
+					 COALESCE(SUM(s.reps * COALESCE(s.weight, 0)), 0),
+					 COUNT(*),
+					 COALESCE(SUM(s.reps), 0)
+			  FROM workouts w
+			  JOIN workout_entries e ON e.workout_id = w.id
+			  JOIN workout_sets s ON s.workout_entry_id = e.id
+			  WHERE ` + workoutRange + ` AND ` + countedSet + `
+			  GROUP BY period
+			  ORDER BY period`
+
+	rows, err := pg.db.QueryContext(ctx, query, baseArgs(q)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	points := []VolumePoint{}
+	for rows.Next() {
+		var p VolumePoint
+		if err := rows.Scan(&p.PeriodStart, &p.Tonnage, &p.Sets, &p.Reps); err != nil {
+			return nil, err
+		}
+		points = append(points, p)
+	}
+	return points, rows.Err()
+}
+
+func (pg *PostgresStore) Muscles(ctx context.Context, q Query) ([]MusclePoint, error) {
+	query := `SELECT ` + periodExpr + ` AS period,
+					 m.muscle,
+					 COUNT(*) FILTER (WHERE m.is_primary),
+					 COUNT(*) FILTER (WHERE NOT m.is_primary)
+			  FROM workouts w
+			  JOIN workout_entries e ON e.workout_id = w.id
+			  JOIN workout_sets s ON s.workout_entry_id = e.id
+			  JOIN exercises x ON x.id = e.exercise_id
+			  CROSS JOIN LATERAL (
+			    SELECT unnest(x.primary_muscles) AS muscle, TRUE AS is_primary
+			    UNION ALL
+			    SELECT unnest(x.secondary_muscles), FALSE
+			  ) m
+			  WHERE ` + workoutRange + ` AND ` + countedSet + `
+			  GROUP BY period, m.muscle
+			  ORDER BY period, m.muscle`
+
+	rows, err := pg.db.QueryContext(ctx, query, baseArgs(q)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	points := []MusclePoint{}
+	for rows.Next() {
+		var p MusclePoint
+		if err := rows.Scan(&p.PeriodStart, &p.Muscle, &p.Sets, &p.SecondarySets); err != nil {
+			return nil, err
+		}
+		points = append(points, p)
+	}
+	return points, rows.Err()
+}
+
+func (pg *PostgresStore) Training(ctx context.Context, q Query) ([]TrainingPoint, error) {
+	query := `SELECT ` + periodExpr + ` AS period,
+					 COUNT(*),
+					 COUNT(DISTINCT (w.created_at AT TIME ZONE $3)::date),
+					 COALESCE(SUM(w.duration_minutes), 0),
+					 COALESCE(SUM(w.calories_burned), 0)
+			  FROM workouts w
+			  WHERE ` + workoutRange + `
+			  GROUP BY period
+			  ORDER BY period`
+
+	rows, err := pg.db.QueryContext(ctx, query, baseArgs(q)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	points := []TrainingPoint{}
+	for rows.Next() {
+		var p TrainingPoint
+		if err := rows.Scan(&p.PeriodStart, &p.Workouts, &p.ActiveDays, &p.DurationMinutes, &p.CaloriesBurned); err != nil {
+			return nil, err
+		}
+		points = append(points, p)
+	}
+	return points, rows.Err()
+}
+
+// OneRepMax uses the same estimate as personal records: the weight itself
+// for a single, Brzycki up to 10 reps, Epley for 11-12, nothing above.
+func (pg *PostgresStore) OneRepMax(ctx context.Context, q Query, exerciseID *exercise.ExerciseID, name string) ([]OneRepMaxPoint, error) {
+	query := `SELECT DISTINCT ON (period) period, e1rm, weight, reps, workout_id
+			  FROM (
+			    SELECT ` + periodExpr + ` AS period,
+			           ROUND(CASE
+			             WHEN s.reps = 1 THEN s.weight
+			             WHEN s.reps <= 10 THEN s.weight * 36 / (37 - s.reps)
+			             ELSE s.weight * (1 + s.reps / 30.0)
+			           END, 2) AS e1rm,
+			           s.weight, s.reps, w.id AS workout_id
+			    FROM workouts w
+			    JOIN workout_entries e ON e.workout_id = w.id
+			    JOIN workout_sets s ON s.workout_entry_id = e.id
+			    WHERE ` + workoutRange + ` AND ` + countedSet + `
+			      AND s.reps BETWEEN 1 AND 12 AND s.weight > 0
+			      AND (e.exercise_id = $6::bigint
+			           OR ($7::text <> '' AND e.exercise_id IS NULL
+			               AND regexp_replace(lower(btrim(e.exercise_name)), '\s+', ' ', 'g') = $7::text))
+			  ) sets
+			  ORDER BY period, e1rm DESC, workout_id`
+
+	args := append(baseArgs(q), exerciseID, name)
+	rows, err := pg.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	points := []OneRepMaxPoint{}
+	for rows.Next() {
+		var p OneRepMaxPoint
+		if err := rows.Scan(&p.PeriodStart, &p.Estimated1RM, &p.Weight, &p.Reps, &p.WorkoutID); err != nil {
+			return nil, err
+		}
+		points = append(points, p)
+	}
+	return points, rows.Err()
+}
@@ -0,0 +1,131 @@
+package analytics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/tsatsarisg/go-fit/internal/exercise"
+	"github.com/tsatsarisg/go-fit/internal/user"
+)
+
+// Store runs the aggregate queries. It reads the workout tables directly:
+// analytics is a read model over them, and one GROUP BY beats loading every
+// workout through workout.Service.
+type Store interface {
+	Volume(ctx context.Context, q Query) ([]VolumePoint, error)
+	Muscles(ctx context.Context, q Query) ([]MusclePoint, error)
+	Training(ctx context.Context, q Query) ([]TrainingPoint, error)
+	// OneRepMax matches entries linked to exerciseID (when non-nil) or
+	// named name (normalized, when non-empty).
+	OneRepMax(ctx context.Context, q Query, exerciseID *exercise.ExerciseID, name string) ([]OneRepMaxPoint, error)
+}
+
+// Exercises is the narrow slice of exercise.Service used to resolve the
+// {exercise} path segment.
+type Exercises interface {
+	Resolve(ctx context.Context, userID user.UserID, names []string) (map[string]*exercise.Exercise, error)
+}
+
+// Domain-level sentinels:
+//   - ErrValidation: bad bucket, time zone or range → 400
+var ErrValidation = errors.New("validation failed")
+
+func wrapValidation(err error) error {
+	if err == nil {
+		return nil
+	}
+	return fmt.Errorf("%w: %v", ErrValidation, err)
+}
+
+type Service struct {
+	store     Store
+	exercises Exercises
+}
+
+func NewService(store Store, exercises Exercises) *Service {
+	return &Service{store: store, exercises: exercises}
+}
+
+// Volume returns one point per period in the range, zero-filled.
+func (s *Service) Volume(ctx context.Context, q Query) ([]VolumePoint, error) {
+	if err := q.Validate(); err != nil {
+		return nil, wrapValidation(err)
+	}
+	points, err := s.store.Volume(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	return fill(q, points, func(p VolumePoint) string { return p.PeriodStart }, func(start string) VolumePoint {
+		return VolumePoint{PeriodStart: start}
+	}), nil
+}
+
+// Muscles returns only the (period, muscle) pairs that were trained.
+func (s *Service) Muscles(ctx context.Context, q Query) ([]MusclePoint, error) {
+	if err := q.Validate(); err != nil {
+		return nil, wrapValidation(err)
+	}
+	return s.store.Muscles(ctx, q)
+}
+
+// Training returns one point per period in the range, zero-filled.
+func (s *Service) Training(ctx context.Context, q Query) ([]TrainingPoint, error) {
+	if err := q.Validate(); err != nil {
+		return nil, wrapValidation(err)
+	}
+	points, err := s.store.Training(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	return fill(q, points, func(p TrainingPoint) string { return p.PeriodStart }, func(start string) TrainingPoint {
+		return TrainingPoint{PeriodStart: start}
+	}), nil
+}
+
+// OneRepMax returns the estimated-1RM trend for one exercise. ref is a
+// catalog exercise id or a name; a name that resolves to the catalog also
+// matches entries linked to that exercise.
+func (s *Service) OneRepMax(ctx context.Context, q Query, ref string) ([]OneRepMaxPoint, error) {
+	if err := q.Validate(); err != nil {
+		return nil, wrapValidation(err)
+	}
+	if id, err := strconv.ParseInt(ref, 10, 64); err == nil {
+		exID := exercise.ExerciseID(id)
+		return s.store.OneRepMax(ctx, q, &exID, "")
+	}
+
+	name := exercise.NormalizeName(ref)
+	if name == "" {
+		return nil, wrapValidation(errors.New("exercise must not be empty"))
+	}
+	resolved, err := s.exercises.Resolve(ctx, q.UserID, []string{name})
+	if err != nil {
+		return nil, err
+	}
+	var exID *exercise.ExerciseID
+	if ex, ok := resolved[name]; ok {
+		exID = &ex.ID
+	}
+	return s.store.OneRepMax(ctx, q, exID, name)
+}
+
+// fill pads a sparse, period-ordered series so every period in the range
+// has a point.
+func fill[T any](q Query, points []T, key func(T) string, zero func(string) T) []T {
+	byStart := make(map[string]T, len(points))
+	for _, p := range points {
+		byStart[key(p)] = p
+	}
+	starts := q.periodStarts()
+	out := make([]T, 0, len(starts))
+	for _, start := range starts {
+		if p, ok := byStart[start]; ok {
+			out = append(out, p)
+		} else {
+			out = append(out, zero(start))
+		}
+	}
+	return out
+}
@@ -12,12 +12,22 @@ import (
 
 	"github.com/go-chi/chi/v5"
 	chimw "github.com/go-chi/chi/v5/middleware"
-	"golang.org/x/crypto/bcrypt"
 
+	"github.com/tsatsarisg/go-fit/internal/admin"
+	"github.com/tsatsarisg/go-fit/internal/analytics"
 	"github.com/tsatsarisg/go-fit/internal/auth"
 	"github.com/tsatsarisg/go-fit/internal/config"
+	"github.com/tsatsarisg/go-fit/internal/exercise"
+	"github.com/tsatsarisg/go-fit/internal/export"
 	"github.com/tsatsarisg/go-fit/internal/httpx"
+	"github.com/tsatsarisg/go-fit/internal/importer"
+	"github.com/tsatsarisg/go-fit/internal/platform/breached"
+	"github.com/tsatsarisg/go-fit/internal/platform/jobs"
+	"github.com/tsatsarisg/go-fit/internal/platform/jwt"
+	"github.com/tsatsarisg/go-fit/internal/platform/mail"
 	"github.com/tsatsarisg/go-fit/internal/platform/postgres"
+	"github.com/tsatsarisg/go-fit/internal/platform/sealer"
+	"github.com/tsatsarisg/go-fit/internal/program"
 	"github.com/tsatsarisg/go-fit/internal/user"
 	"github.com/tsatsarisg/go-fit/internal/workout"
 	"github.com/tsatsarisg/go-fit/migrations"
@@ -28,10 +38,17 @@ import (
 // The old god-struct exposing every handler field is gone (A4): wiring lives
 // here, and nothing outside this package can bypass it.
 type Application struct {
-	cfg    *config.Config
-	logger *slog.Logger
-	db     *sql.DB
-	server *http.Server
+	cfg     *config.Config
+	logger  *slog.Logger
+	db      *sql.DB
+	server  *http.Server
+	jobs    *jobs.Queue
+	userSvc *user.Service
+	authSvc *auth.Service
+	// revocations is only set when access tokens are JWTs.
+	revocations *auth.RevocationList
+	// rateLimiter is only set when rate limits are kept in Postgres.
+	rateLimiter *httpx.PostgresRateLimiter
 }
 
 // New wires up the application: opens the DB, runs migrations, constructs
@@ -62,37 +79,211 @@ func New(ctx context.Context, cfg *config.Config) (*Application, error) {
 	workoutStore := workout.NewPostgresStore(pgDB)
 	userStore := user.NewPostgresStore(pgDB)
 	tokenStore := auth.NewPostgresStore(pgDB)
+	programStore := program.NewPostgresStore(pgDB)
+	exerciseStore := exercise.NewPostgresStore(pgDB)
+	analyticsStore := analytics.NewPostgresStore(pgDB)
+	auditStore := admin.NewPostgresStore(pgDB)
+
+	// Work that follows a response: mail, and the password-reset lookup.
+	// Bounded, since unauthenticated endpoints submit to it.
+	background := jobs.New(logger, backgroundWorkers, backgroundQueueSize)
+	mailer := mail.NewAsync(newMailer(cfg.Mail, logger), background)
+
+	// Session access tokens are opaque unless AUTH_TOKEN_FORMAT=jwt. A JWT
+	// instance must load the revocation list before it serves anything.
+	access := auth.NewOpaqueAccessTokens(tokenStore)
+	var (
+		jwtKeys     *jwt.KeySet
+		revocations *auth.RevocationList
+	)
+	if cfg.Auth.TokenFormat == config.TokenFormatJWT {
+		jwtKeys, err = jwt.NewKeySet(cfg.Auth.JWTKeys...)
+		if err != nil {
+			_ = pgDB.Close()
+			return nil, fmt.Errorf("invalid JWT keys: %w", err)
+		}
+		revocations = auth.NewRevocationList(tokenStore)
+		if err := revocations.Sync(openCtx); err != nil {
+			_ = pgDB.Close()
+			return nil, fmt.Errorf("failed to load session revocations: %w", err)
+		}
+		access = auth.NewJWTAccessTokens(jwtKeys, cfg.Auth.JWTIssuer, revocations)
+	}
+
+	// Two-factor secrets are sealed with TOTP_ENCRYPTION_KEY; without one,
+	// 2FA can't be enrolled.
+	var totpSecrets *sealer.Sealer
+	if cfg.Auth.TOTPKey != nil {
+		totpSecrets, err = sealer.New(cfg.Auth.TOTPKey)
+		if err != nil {
+			_ = pgDB.Close()
+			return nil, fmt.Errorf("invalid TOTP encryption key: %w", err)
+		}
+	}
+
+	// New passwords are screened against BREACHED_PASSWORDS_FILE, if set.
+	var policy user.PasswordPolicy
+	if cfg.BreachedPasswordsFile != "" {
+		corpus, err := breached.Load(cfg.BreachedPasswordsFile)
+		if err != nil {
+			_ = pgDB.Close()
+			return nil, fmt.Errorf("failed to load breached passwords: %w", err)
+		}
+		policy.Breached = corpus
+		logger.InfoContext(ctx, "breached passwords loaded", "file", cfg.BreachedPasswordsFile)
+	}
 
 	// Services
-	hasher := user.NewBcryptHasher(bcrypt.DefaultCost)
-	userSvc := user.NewService(userStore, hasher)
-	workoutSvc := workout.NewService(workoutStore)
-	authSvc := auth.NewService(tokenStore, userSvc)
+	hasher := newHasher(cfg.PasswordHash)
+	userSvc := user.NewService(userStore, hasher, policy, cfg.DeletionGrace)
+	exerciseSvc := exercise.NewService(exerciseStore)
+	workoutSvc := workout.NewService(workoutStore, exerciseSvc)
+	authSvc := auth.NewService(tokenStore, userSvc, mailer, background, access, totpSecrets)
+	programSvc := program.NewService(programStore, workoutSvc, workoutSvc)
+	analyticsSvc := analytics.NewService(analyticsStore, exerciseSvc)
+	importSvc := importer.NewService(workoutSvc)
+	exportSvc := export.NewService(workoutSvc, userSvc)
+	adminSvc := admin.NewService(auditStore, userSvc, authSvc, workoutSvc)
 
 	// Handlers
 	workoutH := workout.NewHandler(workoutSvc, logger)
-	userH := user.NewHandler(userSvc, logger)
+	userH := user.NewHandler(userSvc, logger, auth.CurrentUserID, authSvc.SendActivation)
 	tokenH := auth.NewHandler(authSvc, logger)
+	programH := program.NewHandler(programSvc, logger)
+	exerciseH := exercise.NewHandler(exerciseSvc, logger)
+	analyticsH := analytics.NewHandler(analyticsSvc, logger)
+	importH := importer.NewHandler(importSvc, logger)
+	exportH := export.NewHandler(exportSvc, logger)
+	adminH := admin.NewHandler(adminSvc, logger)
 
 	// Middleware
-	authMW := auth.NewMiddleware(tokenStore)
+	authMW := auth.NewMiddleware(tokenStore, access)
+
+	// RATE_LIMIT_BACKEND=postgres shares the buckets between replicas; the
+	// in-memory default counts per replica.
+	var (
+		rateLimit     *httpx.RateLimit
+		pgRateLimiter *httpx.PostgresRateLimiter
+	)
+	switch cfg.RateLimit.Backend {
+	case config.RateLimitBackendMemory:
+		rateLimit = httpx.NewRateLimit(httpx.NewMemoryRateLimiter(), auth.RateLimitKey, logger)
+	case config.RateLimitBackendPostgres:
+		pgRateLimiter = httpx.NewPostgresRateLimiter(pgDB)
+		rateLimit = httpx.NewRateLimit(pgRateLimiter, auth.RateLimitKey, logger)
+	}
 
 	r := chi.NewRouter()
 	r.Use(chimw.RequestID)
+	r.Use(httpx.TrustProxies(cfg.TrustedProxies))
 	r.Use(httpx.RequestLogger(logger))
 	r.Use(authMW.Authenticate)
 
 	r.Get("/health", healthCheck)
-	r.Post("/users", userH.HandleRegisterUser)
-	r.Post("/tokens/authentication", tokenH.HandleCreateToken)
-	r.Post("/tokens/authentication/logout", authMW.RequireAuthenticatedUser(tokenH.HandleLogout))
+	if jwtKeys != nil {
+		r.Get("/.well-known/jwks.json", auth.JWKSHandler(jwtKeys))
+	}
+
+	// Rate limits are per route group: a tight budget for the endpoints
+	// that create accounts and tokens, which attackers hammer, and a
+	// generous one for the rest of the API, reads more than writes.
+	authLimit, apiLimit := noLimit, noLimit
+	if rateLimit != nil {
+		authLimit = rateLimit.Limit(rateLimitPolicy("auth", cfg.RateLimit.Auth))
+		apiLimit = rateLimit.LimitByMethod(rateLimitPolicy("read", cfg.RateLimit.Read), rateLimitPolicy("write", cfg.RateLimit.Write))
+	}
+
+	r.Group(func(r chi.Router) {
+		r.Use(authLimit)
+		r.Post("/users", userH.HandleRegisterUser)
+		r.Post("/users/restore", tokenH.HandleRestoreAccount)
+		r.Post("/tokens/authentication", tokenH.HandleCreateToken)
+		r.Post("/tokens/authentication/2fa", tokenH.HandleCompleteTwoFactor)
+		r.Post("/tokens/refresh", tokenH.HandleRefresh)
+		r.Post("/tokens/authentication/logout", authMW.RequireAuthenticatedUser(tokenH.HandleLogout))
+		r.Post("/tokens/authentication/logout/current", authMW.RequireAuthenticatedUser(tokenH.HandleLogoutCurrent))
+		r.Post("/tokens/password-reset", tokenH.HandleRequestPasswordReset)
+		r.Put("/users/password", tokenH.HandleResetPassword)
+		r.Put("/users/activated", tokenH.HandleActivate)
+		r.Post("/users/me/activation", authMW.RequireAuthenticatedUser(userH.HandleResendActivation))
+		// Personal access tokens are managed with a session token only, so a
+		// leaked one can't mint more of itself.
+		r.Post("/tokens/personal", authMW.RequireAuthenticatedUser(tokenH.HandleCreatePersonalAccessToken))
+		r.Get("/tokens/personal", authMW.RequireAuthenticatedUser(tokenH.HandleListPersonalAccessTokens))
+		r.Delete("/tokens/personal/{id}", authMW.RequireAuthenticatedUser(tokenH.HandleRevokePersonalAccessToken))
+	})
+
+	r.Group(func(r chi.Router) {
+		r.Use(apiLimit)
+		r.Get("/users/me", authMW.RequirePermission(auth.PermissionProfileRead, userH.HandleGetMe))
+		r.Patch("/users/me", authMW.RequirePermission(auth.PermissionProfileWrite, userH.HandleUpdateMe))
+		r.Delete("/users/me", authMW.RequireAuthenticatedUser(tokenH.HandleDeleteAccount))
+		r.Put("/users/me/password", authMW.RequireAuthenticatedUser(tokenH.HandleChangePassword))
+		r.Put("/users/{id}/follow", authMW.RequirePermission(auth.PermissionProfileWrite, userH.HandleFollow))
+		r.Delete("/users/{id}/follow", authMW.RequirePermission(auth.PermissionProfileWrite, userH.HandleUnfollow))
+		r.Get("/me/sessions", authMW.RequireAuthenticatedUser(tokenH.HandleListSessions))
+		r.Delete("/me/sessions/{id}", authMW.RequireAuthenticatedUser(tokenH.HandleRevokeSession))
+		r.Post("/me/2fa", authMW.RequireAuthenticatedUser(tokenH.HandleEnrollTwoFactor))
+		r.Post("/me/2fa/confirm", authMW.RequireAuthenticatedUser(tokenH.HandleConfirmTwoFactor))
+		r.Delete("/me/2fa", authMW.RequireAuthenticatedUser(tokenH.HandleDisableTwoFactor))
 
-	r.Get("/workouts/{id}", authMW.RequireAuthenticatedUser(workoutH.HandleGetWorkoutByID))
-	r.Post("/workouts", authMW.RequireAuthenticatedUser(workoutH.HandleCreateWorkout))
-	// PATCH — body is a partial-merge patch (nil fields = untouched), not
-	// a full replacement, so PATCH is the correct verb per RFC 5789.
-	r.Patch("/workouts/{id}", authMW.RequireAuthenticatedUser(workoutH.HandleUpdateWorkout))
-	r.Delete("/workouts/{id}", authMW.RequireAuthenticatedUser(workoutH.HandleDeleteWorkout))
+		r.Get("/workouts", authMW.RequirePermission(auth.PermissionWorkoutsRead, workoutH.HandleListWorkouts))
+		r.Get("/workouts/{id}", authMW.RequirePermission(auth.PermissionWorkoutsRead, workoutH.HandleGetWorkoutByID))
+		r.Post("/workouts", authMW.RequirePermission(auth.PermissionWorkoutsWrite, workoutH.HandleCreateWorkout))
+		// PATCH — body is a partial-merge patch (nil fields = untouched), not
+		// a full replacement, so PATCH is the correct verb per RFC 5789.
+		r.Patch("/workouts/{id}", authMW.RequirePermission(auth.PermissionWorkoutsWrite, workoutH.HandleUpdateWorkout))
+		r.Delete("/workouts/{id}", authMW.RequirePermission(auth.PermissionWorkoutsWrite, workoutH.HandleDeleteWorkout))
+		// Share links publish a workout to anyone holding the URL, so only
+		// accounts with a confirmed email may mint them.
+		r.Post("/workouts/{id}/shares", authMW.RequirePermission(auth.PermissionWorkoutsWrite, authMW.RequireActivatedUser(workoutH.HandleCreateShare)))
+		r.Get("/workouts/{id}/shares", authMW.RequirePermission(auth.PermissionWorkoutsRead, workoutH.HandleListShares))
+		r.Delete("/workouts/{id}/shares/{shareID}", authMW.RequirePermission(auth.PermissionWorkoutsWrite, workoutH.HandleRevokeShare))
+		r.Get("/templates", authMW.RequirePermission(auth.PermissionWorkoutsRead, workoutH.HandleListTemplates))
+		r.Post("/templates", authMW.RequirePermission(auth.PermissionWorkoutsWrite, workoutH.HandleCreateTemplate))
+		r.Get("/templates/{id}", authMW.RequirePermission(auth.PermissionWorkoutsRead, workoutH.HandleGetTemplate))
+		r.Patch("/templates/{id}", authMW.RequirePermission(auth.PermissionWorkoutsWrite, workoutH.HandleUpdateTemplate))
+		r.Delete("/templates/{id}", authMW.RequirePermission(auth.PermissionWorkoutsWrite, workoutH.HandleDeleteTemplate))
+		r.Post("/templates/{id}/start", authMW.RequirePermission(auth.PermissionWorkoutsWrite, workoutH.HandleStartTemplate))
+		r.Post("/imports", authMW.RequirePermission(auth.PermissionWorkoutsWrite, importH.HandleImport))
+
+		r.Get("/exercises", authMW.RequirePermission(auth.PermissionWorkoutsRead, exerciseH.HandleListExercises))
+		r.Post("/exercises", authMW.RequirePermission(auth.PermissionWorkoutsWrite, exerciseH.HandleCreateExercise))
+		r.Get("/exercises/{id}", authMW.RequirePermission(auth.PermissionWorkoutsRead, exerciseH.HandleGetExercise))
+		r.Delete("/exercises/{id}", authMW.RequirePermission(auth.PermissionWorkoutsWrite, exerciseH.HandleDeleteExercise))
+
+		r.Get("/programs", authMW.RequirePermission(auth.PermissionWorkoutsRead, programH.HandleListPrograms))
+		r.Post("/programs", authMW.RequirePermission(auth.PermissionProgramsWrite, programH.HandleCreateProgram))
+		r.Get("/programs/{id}", authMW.RequirePermission(auth.PermissionWorkoutsRead, programH.HandleGetProgram))
+		r.Delete("/programs/{id}", authMW.RequirePermission(auth.PermissionProgramsWrite, programH.HandleDeleteProgram))
+		r.Post("/programs/{id}/enrollments", authMW.RequirePermission(auth.PermissionWorkoutsWrite, programH.HandleEnroll))
+		r.Get("/me/records", authMW.RequirePermission(auth.PermissionWorkoutsRead, workoutH.HandleListRecords))
+		r.Get("/me/records/{exercise}", authMW.RequirePermission(auth.PermissionWorkoutsRead, workoutH.HandleRecordHistory))
+		r.Get("/me/stats/volume", authMW.RequirePermission(auth.PermissionWorkoutsRead, analyticsH.HandleVolume))
+		r.Get("/me/stats/muscles", authMW.RequirePermission(auth.PermissionWorkoutsRead, analyticsH.HandleMuscles))
+		r.Get("/me/stats/training", authMW.RequirePermission(auth.PermissionWorkoutsRead, analyticsH.HandleTraining))
+		r.Get("/me/stats/one-rep-max/{exercise}", authMW.RequirePermission(auth.PermissionWorkoutsRead, analyticsH.HandleOneRepMax))
+		r.Get("/me/export", authMW.RequirePermission(auth.PermissionWorkoutsRead, authMW.RequirePermission(auth.PermissionProfileRead, exportH.HandleExport)))
+		r.Get("/me/enrollments", authMW.RequirePermission(auth.PermissionWorkoutsRead, programH.HandleListEnrollments))
+		r.Delete("/me/enrollments/{id}", authMW.RequirePermission(auth.PermissionWorkoutsWrite, programH.HandleUnenroll))
+		r.Post("/me/enrollments/{id}/start", authMW.RequirePermission(auth.PermissionWorkoutsWrite, programH.HandleStartSession))
+		r.Get("/me/schedule", authMW.RequirePermission(auth.PermissionWorkoutsRead, programH.HandleSchedule))
+
+		// Public: possession of the share token is the authorization.
+		r.Get("/shared/workouts/{token}", workoutH.HandleGetSharedWorkout)
+
+		// Admin API. Admin permissions come with the admin role and can't be
+		// granted to a personal access token, so these take an admin's
+		// session token. Every call is recorded in the audit trail.
+		r.Get("/admin/users", authMW.RequirePermission(auth.PermissionAdminUsers, adminH.HandleSearchUsers))
+		r.Get("/admin/users/{id}", authMW.RequirePermission(auth.PermissionAdminUsers, adminH.HandleGetUser))
+		r.Put("/admin/users/{id}/role", authMW.RequirePermission(auth.PermissionAdminUsers, adminH.HandleSetRole))
+		r.Post("/admin/users/{id}/disable", authMW.RequirePermission(auth.PermissionAdminUsers, adminH.HandleDisableUser))
+		r.Post("/admin/users/{id}/enable", authMW.RequirePermission(auth.PermissionAdminUsers, adminH.HandleEnableUser))
+		r.Post("/admin/users/{id}/revoke-tokens", authMW.RequirePermission(auth.PermissionAdminUsers, adminH.HandleRevokeTokens))
+		r.Get("/admin/workouts/{id}", authMW.RequirePermission(auth.PermissionAdminWorkouts, adminH.HandleGetWorkout))
+		r.Get("/admin/audit", authMW.RequirePermission(auth.PermissionAdminAudit, adminH.HandleListAudit))
+	})
 
 	server := &http.Server{
 		Addr:         fmt.Sprintf(":%d", cfg.Port),
@@ -103,13 +294,47 @@ func New(ctx context.Context, cfg *config.Config) (*Application, error) {
 	}
 
 	return &Application{
-		cfg:    cfg,
-		logger: logger,
-		db:     pgDB,
-		server: server,
+		cfg:     cfg,
+		logger:  logger,
+		db:      pgDB,
+		server:  server,
+		jobs:    background,
+		userSvc: userSvc,
+		authSvc: authSvc,
+
+		revocations: revocations,
+		rateLimiter: pgRateLimiter,
 	}, nil
 }
 
+// newHasher picks the password hasher named by PASSWORD_HASH. Hashes made
+// by the other one keep working and are replaced on the next login.
+func newHasher(cfg config.PasswordHashConfig) user.Hasher {
+	if cfg.Algorithm == config.PasswordHashBcrypt {
+		return user.NewBcryptHasher(cfg.BcryptCost)
+	}
+	return user.NewArgon2idHasher(cfg.Argon2Memory, cfg.Argon2Iterations, cfg.Argon2Parallelism)
+}
+
+// noLimit is the middleware of a route group when rate limiting is off.
+func noLimit(next http.Handler) http.Handler { return next }
+
+// rateLimitPolicy names a configured limit for httpx.
+func rateLimitPolicy(name string, l config.RateLimit) httpx.RateLimitPolicy {
+	return httpx.RateLimitPolicy{Name: name, Limit: l.Limit, Period: l.Period}
+}
+
+// newMailer picks the mail adapter named by MAIL_DRIVER.
+func newMailer(cfg config.MailConfig, logger *slog.Logger) mail.Sender {
+	switch cfg.Driver {
+	case config.MailDriverSMTP:
+		return mail.NewSMTPMailer(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.From)
+	case config.MailDriverFile:
+		return mail.NewFileMailer(cfg.File, cfg.From)
+	}
+	return mail.NewLogMailer(logger)
+}
+
 // Run starts the HTTP server and blocks until either the server fails or
 // ctx is cancelled (signal received). On cancellation, performs a bounded
 // graceful shutdown; if that fails, forcibly closes the server.
@@ -119,6 +344,11 @@ func (a *Application) Run(ctx context.Context) error {
 		slog.String("env", a.cfg.Env),
 	)
 
+	go a.runPeriodically(ctx, purgeInterval, a.purge)
+	if a.revocations != nil {
+		go a.runPeriodically(ctx, revocationSyncInterval, a.syncRevocations)
+	}
+
 	serverErrCh := make(chan error, 1)
 	go func() {
 		if err := a.server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
@@ -156,9 +386,76 @@ func (a *Application) Run(ctx context.Context) error {
 	}
 }
 
-// Close releases long-lived resources (currently just the DB pool). Safe to
-// call after Run; safe to defer in main.
+// purgeInterval is how often purge looks for accounts past their deletion
+// grace period. Purging a day late is harmless; the grace period is
+// measured in weeks.
+const purgeInterval = time.Hour
+
+// Background job pool. A few workers keep a slow mail relay from holding
+// up the rest; the queue absorbs a burst of sign-ups, and beyond it jobs
+// are dropped rather than let an attacker pile them up.
+const (
+	backgroundWorkers   = 4
+	backgroundQueueSize = 1000
+)
+
+// revocationSyncInterval bounds how long a revoked JWT keeps working on an
+// instance other than the one that revoked it.
+const revocationSyncInterval = 5 * time.Second
+
+// runPeriodically runs job once at startup and then every interval, until
+// ctx is cancelled. Every replica runs the jobs: each is idempotent.
+func (a *Application) runPeriodically(ctx context.Context, interval time.Duration, job func(context.Context)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		job(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// purge erases accounts whose deletion grace period has passed, and session
+// revocations, login failures and rate-limit buckets too old to matter.
+func (a *Application) purge(ctx context.Context) {
+	n, err := a.userSvc.Purge(ctx)
+	if err != nil && ctx.Err() == nil {
+		a.logger.ErrorContext(ctx, "purge deleted accounts failed", slog.Any("err", err))
+	} else if n > 0 {
+		a.logger.InfoContext(ctx, "purged deleted accounts", slog.Int64("count", n))
+	}
+
+	if _, err := a.authSvc.PruneSessionRevocations(ctx); err != nil && ctx.Err() == nil {
+		a.logger.ErrorContext(ctx, "prune session revocations failed", slog.Any("err", err))
+	}
+	if _, err := a.authSvc.PruneLoginFailures(ctx); err != nil && ctx.Err() == nil {
+		a.logger.ErrorContext(ctx, "prune login failures failed", slog.Any("err", err))
+	}
+	if a.rateLimiter != nil {
+		if _, err := a.rateLimiter.Prune(ctx); err != nil && ctx.Err() == nil {
+			a.logger.ErrorContext(ctx, "prune rate limits failed", slog.Any("err", err))
+		}
+	}
+}
+
+// syncRevocations refreshes the JWT revocation list. After a minute of
+// failures the list is considered stale and JWTs are refused; see
+// auth.RevocationList.
+func (a *Application) syncRevocations(ctx context.Context) {
+	if err := a.revocations.Sync(ctx); err != nil && ctx.Err() == nil {
+		a.logger.ErrorContext(ctx, "sync session revocations failed", slog.Any("err", err))
+	}
+}
+
+// Close releases long-lived resources: it lets queued background jobs
+// finish, then closes the DB pool. Safe to call after Run; safe to defer in
+// main.
 func (a *Application) Close() error {
+	a.jobs.Close()
 	a.logger.Info("closing database")
 	return a.db.Close()
 }
@@ -0,0 +1,212 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tsatsarisg/go-fit/internal/httpx"
+	"github.com/tsatsarisg/go-fit/internal/platform/jwt"
+	"github.com/tsatsarisg/go-fit/internal/user"
+)
+
+// AccessTokens is the strategy behind session access tokens, chosen by
+// config.AuthConfig.TokenFormat. Refresh tokens, personal access tokens and
+// the one-time mailed tokens are always opaque; only the short-lived bearer
+// token a session hands out varies.
+//
+// Resolve follows Store.ResolvePrincipal: (nil, nil) for a token that is
+// unknown, expired or revoked. SessionsRevoked is called after sessions were
+// deleted, so a strategy that can't see the deletion catches up.
+type AccessTokens interface {
+	Issue(ctx context.Context, p *Principal, ttl time.Duration) (*Token, error)
+	Resolve(ctx context.Context, plaintext string) (*Principal, error)
+	SessionsRevoked(ctx context.Context) error
+}
+
+// opaqueAccessTokens stores each access token in its session's family, so
+// resolving one is a lookup and deleting the family revokes it at once.
+type opaqueAccessTokens struct {
+	store Store
+}
+
+func NewOpaqueAccessTokens(store Store) AccessTokens {
+	return &opaqueAccessTokens{store: store}
+}
+
+func (o *opaqueAccessTokens) Issue(ctx context.Context, p *Principal, ttl time.Duration) (*Token, error) {
+	return o.store.IssueAccessToken(ctx, p.ID, p.SessionID, ttl)
+}
+
+func (o *opaqueAccessTokens) Resolve(ctx context.Context, plaintext string) (*Principal, error) {
+	return o.store.ResolvePrincipal(ctx, ScopeAuth, plaintext)
+}
+
+func (o *opaqueAccessTokens) SessionsRevoked(context.Context) error {
+	return nil
+}
+
+// accessClaims is the payload of a JWT access token. Scope lists, space
+// separated as in RFC 8693, the permissions a session has, for services
+// that verify the token against the JWKS; this one doesn't read it back,
+// it derives them from Role.
+type accessClaims struct {
+	jwt.Claims
+	Username  string    `json:"username"`
+	Activated bool      `json:"activated"`
+	Role      user.Role `json:"role"`
+	Scope     string    `json:"scope"`
+	SessionID SessionID `json:"sid"`
+}
+
+// jwtAccessTokens signs access tokens instead of storing them, so the
+// middleware verifies them without a database round trip. Revocation goes
+// through a RevocationList keyed by session.
+type jwtAccessTokens struct {
+	keys        *jwt.KeySet
+	issuer      string
+	revocations *RevocationList
+}
+
+func NewJWTAccessTokens(keys *jwt.KeySet, issuer string, revocations *RevocationList) AccessTokens {
+	return &jwtAccessTokens{keys: keys, issuer: issuer, revocations: revocations}
+}
+
+// sessionScope is the scope claim of a session token: a session may do
+// anything its user's role grants.
+func sessionScope(role user.Role) string {
+	perms := RolePermissions(role)
+	s := make([]string, len(perms))
+	for i, p := range perms {
+		s[i] = string(p)
+	}
+	return strings.Join(s, " ")
+}
+
+func (j *jwtAccessTokens) Issue(_ context.Context, p *Principal, ttl time.Duration) (*Token, error) {
+	now := time.Now()
+	expiry := now.Add(ttl)
+	signed, err := j.keys.Sign(accessClaims{
+		Claims: jwt.Claims{
+			Issuer:    j.issuer,
+			Subject:   strconv.FormatInt(int64(p.ID), 10),
+			IssuedAt:  now.Unix(),
+			ExpiresAt: expiry.Unix(),
+		},
+		Username:  p.Username,
+		Activated: p.Activated,
+		Role:      p.Role,
+		Scope:     sessionScope(p.Role),
+		SessionID: p.SessionID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Token{Plaintext: signed, UserID: p.ID, Expiry: expiry, Scope: ScopeAuth, SessionID: p.SessionID}, nil
+}
+
+func (j *jwtAccessTokens) Resolve(_ context.Context, plaintext string) (*Principal, error) {
+	var claims accessClaims
+	if err := j.keys.Verify(plaintext, &claims, time.Now()); err != nil {
+		return nil, nil
+	}
+	id, err := strconv.ParseInt(claims.Subject, 10, 64)
+	if err != nil || claims.Issuer != j.issuer || claims.SessionID == 0 {
+		return nil, nil
+	}
+	revoked, err := j.revocations.Revoked(claims.SessionID)
+	if err != nil {
+		return nil, err
+	}
+	if revoked {
+		return nil, nil
+	}
+	return &Principal{
+		ID:        user.UserID(id),
+		Username:  claims.Username,
+		Activated: claims.Activated,
+		Role:      claims.Role,
+		Scope:     ScopeAuth,
+		SessionID: claims.SessionID,
+		stateless: true,
+	}, nil
+}
+
+// SessionsRevoked syncs the list right away, so the instance that handled a
+// logout rejects the session's tokens from the next request on. Other
+// instances pick it up on their next Sync.
+func (j *jwtAccessTokens) SessionsRevoked(ctx context.Context) error {
+	return j.revocations.Sync(ctx)
+}
+
+// JWKSHandler serves the public keys of keys, the JWKS that JWT access
+// tokens are verified against.
+func JWKSHandler(keys *jwt.KeySet) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Short enough that a rotated-in key is picked up well before the
+		// tokens it signs are common.
+		w.Header().Set("Cache-Control", "public, max-age=300")
+		httpx.WriteJson(w, http.StatusOK, httpx.Envelope{"keys": keys.JWKS()})
+	}
+}
+
+// revocationMaxStaleness bounds how out of date the list may get before
+// Revoked refuses to answer. If the database is unreachable for longer than
+// that, JWTs are rejected rather than trusted past a logout.
+const revocationMaxStaleness = time.Minute
+
+// errRevocationsStale is Revoked's answer once the list is too old to trust.
+var errRevocationsStale = errors.New("session revocation list is stale")
+
+// revocationRetention is how long a revocation matters: any older one is
+// covered by the expiry of the tokens it revoked. The margin absorbs clock
+// skew between the database and the instances.
+const revocationRetention = accessTokenTTL + 5*time.Minute
+
+// RevocationList is an in-memory copy of the sessions revoked within
+// revocationRetention. Each instance keeps one and refreshes it with Sync.
+type RevocationList struct {
+	store Store
+
+	mu       sync.RWMutex
+	revoked  map[SessionID]struct{}
+	syncedAt time.Time
+}
+
+func NewRevocationList(store Store) *RevocationList {
+	return &RevocationList{store: store, revoked: map[SessionID]struct{}{}}
+}
+
+// Sync reloads the list from the store.
+func (l *RevocationList) Sync(ctx context.Context) error {
+	now := time.Now()
+	ids, err := l.store.ListSessionRevocations(ctx, now.Add(-revocationRetention))
+	if err != nil {
+		return err
+	}
+	revoked := make(map[SessionID]struct{}, len(ids))
+	for _, id := range ids {
+		revoked[id] = struct{}{}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.revoked = revoked
+	l.syncedAt = now
+	return nil
+}
+
+// Revoked reports whether session was revoked as of the last Sync.
+func (l *RevocationList) Revoked(session SessionID) (bool, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if time.Since(l.syncedAt) > revocationMaxStaleness {
+		return false, errRevocationsStale
+	}
+	_, ok := l.revoked[session]
+	return ok, nil
+}
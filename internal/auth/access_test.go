@@ -0,0 +1,82 @@
+package auth
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tsatsarisg/go-fit/internal/platform/jwt"
+	"github.com/tsatsarisg/go-fit/internal/user"
+)
+
+// revocationStore serves revoked as the session revocation table and
+// counts TouchToken calls, which a JWT must never cause.
+type revocationStore struct {
+	Store
+	revoked []SessionID
+	touched int
+}
+
+func (s *revocationStore) ListSessionRevocations(context.Context, time.Time) ([]SessionID, error) {
+	return s.revoked, nil
+}
+
+func (s *revocationStore) TouchToken(context.Context, []byte, time.Time) error {
+	s.touched++
+	return nil
+}
+
+func TestJWTAccessTokens(t *testing.T) {
+	ctx := context.Background()
+	key, err := jwt.ParseKey("k1", jwt.AlgEdDSA, base64.StdEncoding.EncodeToString([]byte(strings.Repeat("k", 32))))
+	require.NoError(t, err)
+	keys, err := jwt.NewKeySet(key)
+	require.NoError(t, err)
+
+	store := &revocationStore{}
+	revocations := NewRevocationList(store)
+	access := NewJWTAccessTokens(keys, "go-fit", revocations)
+
+	p, err := access.Resolve(ctx, "anything")
+	assert.NoError(t, err)
+	assert.Nil(t, p, "an unparsable token is anonymous, not an error")
+
+	token, err := access.Issue(ctx, &Principal{ID: 7, Username: "alice", Activated: true, Role: user.RoleAdmin, SessionID: 3}, time.Minute)
+	require.NoError(t, err)
+
+	_, err = access.Resolve(ctx, token.Plaintext)
+	assert.ErrorIs(t, err, errRevocationsStale, "never synced: refuse rather than trust")
+
+	require.NoError(t, revocations.Sync(ctx))
+	var seen *Principal
+	handler := NewMiddleware(store, access).Authenticate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = GetPrincipal(r)
+	}))
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Authorization", "Bearer "+token.Plaintext)
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+	require.NotNil(t, seen)
+	assert.Equal(t, "alice", seen.Username)
+	assert.Equal(t, SessionID(3), seen.SessionID)
+	assert.True(t, seen.Activated)
+	assert.Equal(t, user.RoleAdmin, seen.Role)
+	assert.Zero(t, store.touched, "a JWT has no row to touch")
+
+	other := NewJWTAccessTokens(keys, "someone-else", revocations)
+	p, err = other.Resolve(ctx, token.Plaintext)
+	assert.NoError(t, err)
+	assert.Nil(t, p, "issuer must match")
+
+	store.revoked = []SessionID{3}
+	require.NoError(t, access.SessionsRevoked(ctx))
+	p, err = access.Resolve(ctx, token.Plaintext)
+	assert.NoError(t, err)
+	assert.Nil(t, p, "revoked session")
+}
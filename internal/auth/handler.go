@@ -4,8 +4,10 @@ import (
 	"errors"
 	"log/slog"
 	"net/http"
+	"time"
 
 	"github.com/tsatsarisg/go-fit/internal/httpx"
+	"github.com/tsatsarisg/go-fit/internal/user"
 )
 
 type Handler struct {
@@ -22,6 +24,35 @@ func NewHandler(service *Service, logger *slog.Logger) *Handler {
 	return &Handler{service: service, logger: logger}
 }
 
+// clientOf is what a session records about the client making r.
+func clientOf(r *http.Request) Client {
+	return Client{UserAgent: r.UserAgent(), IP: httpx.ClientIP(r)}
+}
+
+// sessionEnvelope is the body of every response that starts or extends a
+// session. "token" and "expiry" keep their pre-refresh-token meaning: the
+// bearer token and when it stops working.
+func sessionEnvelope(tokens *SessionTokens) httpx.Envelope {
+	return httpx.Envelope{
+		"token":                tokens.Access.Plaintext,
+		"expiry":               tokens.Access.Expiry,
+		"refresh_token":        tokens.Refresh.Plaintext,
+		"refresh_token_expiry": tokens.Refresh.Expiry,
+	}
+}
+
+// writeLoginThrottled answers a throttled login with 429 and Retry-After;
+// it reports false for any other error.
+func writeLoginThrottled(w http.ResponseWriter, err error) bool {
+	var throttled *LoginThrottledError
+	if !errors.As(err, &throttled) {
+		return false
+	}
+	httpx.SetRetryAfter(w, throttled.RetryAfter)
+	httpx.WriteJson(w, http.StatusTooManyRequests, httpx.Envelope{"error": "too many failed login attempts; try again later"})
+	return true
+}
+
 func (h *Handler) HandleCreateToken(w http.ResponseWriter, r *http.Request) {
 	var req createTokenRequest
 	if derr := httpx.DecodeJSONBody(w, r, &req); derr != nil {
@@ -30,28 +61,115 @@ func (h *Handler) HandleCreateToken(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	token, err := h.service.Login(r.Context(), LoginCommand{
+	result, err := h.service.Login(r.Context(), LoginCommand{
 		Username: req.Username,
 		Password: req.Password,
+		Client:   clientOf(r),
 	})
 	if err != nil {
+		if writeLoginThrottled(w, err) {
+			return
+		}
 		if errors.Is(err, ErrInvalidCredentials) {
 			httpx.WriteJson(w, http.StatusUnauthorized, httpx.Envelope{"error": "invalid credentials"})
 			return
 		}
+		if errors.Is(err, ErrAccountPendingDeletion) {
+			httpx.WriteJson(w, http.StatusForbidden, httpx.Envelope{"error": "account is scheduled for deletion; POST /users/restore to cancel"})
+			return
+		}
+		if errors.Is(err, ErrAccountDisabled) {
+			httpx.WriteJson(w, http.StatusForbidden, httpx.Envelope{"error": err.Error()})
+			return
+		}
 		h.logger.ErrorContext(r.Context(), "login failed", slog.Any("err", err))
 		httpx.WriteJson(w, http.StatusInternalServerError, httpx.Envelope{"error": "internal error"})
 		return
 	}
 
+	if result.TwoFactorToken != nil {
+		httpx.WriteJson(w, http.StatusOK, httpx.Envelope{
+			"two_factor_required":     true,
+			"two_factor_token":        result.TwoFactorToken.Plaintext,
+			"two_factor_token_expiry": result.TwoFactorToken.Expiry,
+		})
+		return
+	}
+
 	// 200 OK — token creation is RPC-style authentication, not a REST
 	// resource creation (no addressable URI for the token).
-	httpx.WriteJson(w, http.StatusOK, httpx.Envelope{"token": token.Plaintext, "expiry": token.Expiry})
+	httpx.WriteJson(w, http.StatusOK, sessionEnvelope(result.Session))
+}
+
+// HandleCompleteTwoFactor serves POST /tokens/authentication/2fa: redeems
+// the token a 2FA login returned, with a code, for a session.
+func (h *Handler) HandleCompleteTwoFactor(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Token string `json:"two_factor_token"`
+		Code  string `json:"code"`
+	}
+	if derr := httpx.DecodeJSONBody(w, r, &req); derr != nil {
+		h.logger.WarnContext(r.Context(), "decode two-factor login", slog.Any("err", derr))
+		httpx.WriteDecodeError(w, derr)
+		return
+	}
+
+	tokens, err := h.service.CompleteTwoFactorLogin(r.Context(), TwoFactorLoginCommand{
+		Token:  req.Token,
+		Code:   req.Code,
+		Client: clientOf(r),
+	})
+	if err != nil {
+		if writeLoginThrottled(w, err) {
+			return
+		}
+		switch {
+		case errors.Is(err, ErrInvalidToken):
+			httpx.WriteJson(w, http.StatusUnauthorized, httpx.Envelope{"error": "invalid or expired two-factor token; log in again"})
+		case errors.Is(err, ErrInvalidTwoFactorCode):
+			httpx.WriteJson(w, http.StatusUnauthorized, httpx.Envelope{"error": err.Error()})
+		default:
+			h.logger.ErrorContext(r.Context(), "two-factor login failed", slog.Any("err", err))
+			httpx.WriteJson(w, http.StatusInternalServerError, httpx.Envelope{"error": "internal error"})
+		}
+		return
+	}
+
+	httpx.WriteJson(w, http.StatusOK, sessionEnvelope(tokens))
 }
 
-// HandleLogout revokes every authentication-scoped token for the current
-// principal. RequireAuthenticatedUser guards the route so an anonymous
-// caller never reaches here, but we double-check defensively.
+// HandleRefresh serves POST /tokens/refresh: trades a refresh token for the
+// next access and refresh token of its session.
+func (h *Handler) HandleRefresh(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	if derr := httpx.DecodeJSONBody(w, r, &req); derr != nil {
+		h.logger.WarnContext(r.Context(), "decode refresh token", slog.Any("err", derr))
+		httpx.WriteDecodeError(w, derr)
+		return
+	}
+
+	tokens, err := h.service.Refresh(r.Context(), req.RefreshToken, clientOf(r))
+	if err != nil {
+		if errors.Is(err, ErrRefreshTokenReused) {
+			h.logger.WarnContext(r.Context(), "refresh token reused; session revoked", slog.String("ip", httpx.ClientIP(r)))
+		}
+		if errors.Is(err, ErrInvalidToken) {
+			httpx.WriteJson(w, http.StatusUnauthorized, httpx.Envelope{"error": "invalid or expired refresh token"})
+			return
+		}
+		h.logger.ErrorContext(r.Context(), "refresh failed", slog.Any("err", err))
+		httpx.WriteJson(w, http.StatusInternalServerError, httpx.Envelope{"error": "internal error"})
+		return
+	}
+
+	httpx.WriteJson(w, http.StatusOK, sessionEnvelope(tokens))
+}
+
+// HandleLogout ends every session of the current principal.
+// RequireAuthenticatedUser guards the route so an anonymous caller never
+// reaches here, but we double-check defensively.
 func (h *Handler) HandleLogout(w http.ResponseWriter, r *http.Request) {
 	p := GetPrincipal(r)
 	if p.IsAnonymous() {
@@ -67,3 +185,448 @@ func (h *Handler) HandleLogout(w http.ResponseWriter, r *http.Request) {
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// HandleLogoutCurrent ends only the session the request was made with,
+// leaving the caller's other devices signed in.
+func (h *Handler) HandleLogoutCurrent(w http.ResponseWriter, r *http.Request) {
+	p := GetPrincipal(r)
+	if p.IsAnonymous() {
+		httpx.WriteJson(w, http.StatusUnauthorized, httpx.Envelope{"error": "Unauthenticated"})
+		return
+	}
+
+	if err := h.service.LogoutSession(r.Context(), p); err != nil {
+		h.logger.ErrorContext(r.Context(), "logout session failed", slog.Any("err", err))
+		httpx.WriteJson(w, http.StatusInternalServerError, httpx.Envelope{"error": "internal error"})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+var sessionErrorMapping = httpx.StoreErrorMapping{
+	ResourceName: "Session",
+	NotFoundErr:  ErrTokenNotFound,
+}
+
+func (h *Handler) HandleListSessions(w http.ResponseWriter, r *http.Request) {
+	p := GetPrincipal(r)
+	if p.IsAnonymous() {
+		httpx.WriteJson(w, http.StatusUnauthorized, httpx.Envelope{"error": "Unauthenticated"})
+		return
+	}
+
+	sessions, err := h.service.ListSessions(r.Context(), p)
+	if err != nil {
+		httpx.WriteStoreError(r.Context(), w, h.logger, err, sessionErrorMapping, "Failed to list sessions")
+		return
+	}
+
+	httpx.WriteJson(w, http.StatusOK, httpx.Envelope{"sessions": sessions})
+}
+
+func (h *Handler) HandleRevokeSession(w http.ResponseWriter, r *http.Request) {
+	id, err := httpx.ReadIdParam(r, "id")
+	if err != nil {
+		h.logger.WarnContext(r.Context(), "read id param", slog.Any("err", err))
+		httpx.WriteJson(w, http.StatusBadRequest, httpx.Envelope{"error": err.Error()})
+		return
+	}
+
+	p := GetPrincipal(r)
+	if p.IsAnonymous() {
+		httpx.WriteJson(w, http.StatusUnauthorized, httpx.Envelope{"error": "Unauthenticated"})
+		return
+	}
+
+	if err := h.service.RevokeSession(r.Context(), SessionID(id), p.ID); err != nil {
+		httpx.WriteStoreError(r.Context(), w, h.logger, err, sessionErrorMapping, "Failed to revoke session")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandleChangePassword serves PUT /users/me/password. It lives in auth, not
+// user, because it revokes the caller's other tokens.
+func (h *Handler) HandleChangePassword(w http.ResponseWriter, r *http.Request) {
+	p := GetPrincipal(r)
+	if p.IsAnonymous() {
+		httpx.WriteJson(w, http.StatusUnauthorized, httpx.Envelope{"error": "Unauthenticated"})
+		return
+	}
+
+	var req struct {
+		CurrentPassword string `json:"current_password"`
+		NewPassword     string `json:"new_password"`
+	}
+	if derr := httpx.DecodeJSONBody(w, r, &req); derr != nil {
+		h.logger.WarnContext(r.Context(), "decode change password", slog.Any("err", derr))
+		httpx.WriteDecodeError(w, derr)
+		return
+	}
+
+	err := h.service.ChangePassword(r.Context(), ChangePasswordCommand{
+		Principal:       p,
+		CurrentPassword: req.CurrentPassword,
+		NewPassword:     req.NewPassword,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, user.ErrWrongPassword):
+			httpx.WriteJson(w, http.StatusForbidden, httpx.Envelope{"error": err.Error()})
+		case errors.Is(err, user.ErrValidation):
+			httpx.WriteJson(w, http.StatusBadRequest, httpx.Envelope{"error": err.Error()})
+		default:
+			h.logger.ErrorContext(r.Context(), "change password failed", slog.Any("err", err))
+			httpx.WriteJson(w, http.StatusInternalServerError, httpx.Envelope{"error": "internal error"})
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandleRequestPasswordReset always answers 202 with the same body, whether
+// or not the email belongs to an account.
+func (h *Handler) HandleRequestPasswordReset(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Email string `json:"email"`
+	}
+	if derr := httpx.DecodeJSONBody(w, r, &req); derr != nil {
+		h.logger.WarnContext(r.Context(), "decode password reset request", slog.Any("err", derr))
+		httpx.WriteDecodeError(w, derr)
+		return
+	}
+
+	if err := h.service.RequestPasswordReset(r.Context(), req.Email); err != nil {
+		h.logger.ErrorContext(r.Context(), "password reset request failed", slog.Any("err", err))
+		httpx.WriteJson(w, http.StatusInternalServerError, httpx.Envelope{"error": "internal error"})
+		return
+	}
+
+	httpx.WriteJson(w, http.StatusAccepted, httpx.Envelope{"message": "If an account uses that email, a password reset token has been sent to it."})
+}
+
+// HandleResetPassword serves PUT /users/password: token + new password.
+func (h *Handler) HandleResetPassword(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Token    string `json:"token"`
+		Password string `json:"password"`
+	}
+	if derr := httpx.DecodeJSONBody(w, r, &req); derr != nil {
+		h.logger.WarnContext(r.Context(), "decode reset password", slog.Any("err", derr))
+		httpx.WriteDecodeError(w, derr)
+		return
+	}
+
+	if err := h.service.ResetPassword(r.Context(), req.Token, req.Password); err != nil {
+		switch {
+		case errors.Is(err, ErrInvalidToken):
+			httpx.WriteJson(w, http.StatusBadRequest, httpx.Envelope{"error": "invalid or expired password reset token"})
+		case errors.Is(err, user.ErrValidation):
+			httpx.WriteJson(w, http.StatusBadRequest, httpx.Envelope{"error": err.Error()})
+		default:
+			h.logger.ErrorContext(r.Context(), "reset password failed", slog.Any("err", err))
+			httpx.WriteJson(w, http.StatusInternalServerError, httpx.Envelope{"error": "internal error"})
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandleActivate serves PUT /users/activated: redeems an activation token.
+func (h *Handler) HandleActivate(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Token string `json:"token"`
+	}
+	if derr := httpx.DecodeJSONBody(w, r, &req); derr != nil {
+		h.logger.WarnContext(r.Context(), "decode activate user", slog.Any("err", derr))
+		httpx.WriteDecodeError(w, derr)
+		return
+	}
+
+	u, err := h.service.Activate(r.Context(), req.Token)
+	if err != nil {
+		if errors.Is(err, ErrInvalidToken) {
+			httpx.WriteJson(w, http.StatusBadRequest, httpx.Envelope{"error": "invalid or expired activation token"})
+			return
+		}
+		h.logger.ErrorContext(r.Context(), "activate user failed", slog.Any("err", err))
+		httpx.WriteJson(w, http.StatusInternalServerError, httpx.Envelope{"error": "internal error"})
+		return
+	}
+
+	httpx.WriteJson(w, http.StatusOK, httpx.Envelope{"user": u})
+}
+
+// HandleDeleteAccount serves DELETE /users/me. The account is only
+// scheduled for deletion; the response says until when it can be restored.
+func (h *Handler) HandleDeleteAccount(w http.ResponseWriter, r *http.Request) {
+	p := GetPrincipal(r)
+	if p.IsAnonymous() {
+		httpx.WriteJson(w, http.StatusUnauthorized, httpx.Envelope{"error": "Unauthenticated"})
+		return
+	}
+
+	var req struct {
+		Password string `json:"password"`
+	}
+	if derr := httpx.DecodeJSONBody(w, r, &req); derr != nil {
+		h.logger.WarnContext(r.Context(), "decode delete account", slog.Any("err", derr))
+		httpx.WriteDecodeError(w, derr)
+		return
+	}
+
+	deletion, err := h.service.DeleteAccount(r.Context(), DeleteAccountCommand{Principal: p, Password: req.Password})
+	if err != nil {
+		if errors.Is(err, user.ErrWrongPassword) {
+			httpx.WriteJson(w, http.StatusForbidden, httpx.Envelope{"error": "password is incorrect"})
+			return
+		}
+		h.logger.ErrorContext(r.Context(), "delete account failed", slog.Any("err", err))
+		httpx.WriteJson(w, http.StatusInternalServerError, httpx.Envelope{"error": "internal error"})
+		return
+	}
+
+	httpx.WriteJson(w, http.StatusAccepted, httpx.Envelope{"deletion": deletion})
+}
+
+// HandleRestoreAccount serves POST /users/restore: cancels a pending
+// deletion and logs in, taking the same body as POST /tokens/authentication
+// plus the two-factor code if the account has 2FA on.
+func (h *Handler) HandleRestoreAccount(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		createTokenRequest
+		Code string `json:"code"`
+	}
+	if derr := httpx.DecodeJSONBody(w, r, &req); derr != nil {
+		h.logger.WarnContext(r.Context(), "decode restore account", slog.Any("err", derr))
+		httpx.WriteDecodeError(w, derr)
+		return
+	}
+
+	tokens, err := h.service.RestoreAccount(r.Context(), RestoreAccountCommand{
+		LoginCommand: LoginCommand{
+			Username: req.Username,
+			Password: req.Password,
+			Client:   clientOf(r),
+		},
+		Code: req.Code,
+	})
+	if err != nil {
+		if writeLoginThrottled(w, err) {
+			return
+		}
+		if errors.Is(err, ErrInvalidCredentials) {
+			httpx.WriteJson(w, http.StatusUnauthorized, httpx.Envelope{"error": "invalid credentials"})
+			return
+		}
+		if errors.Is(err, ErrTwoFactorRequired) || errors.Is(err, ErrInvalidTwoFactorCode) {
+			httpx.WriteJson(w, http.StatusUnauthorized, httpx.Envelope{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, ErrAccountDisabled) {
+			httpx.WriteJson(w, http.StatusForbidden, httpx.Envelope{"error": err.Error()})
+			return
+		}
+		h.logger.ErrorContext(r.Context(), "restore account failed", slog.Any("err", err))
+		httpx.WriteJson(w, http.StatusInternalServerError, httpx.Envelope{"error": "internal error"})
+		return
+	}
+
+	httpx.WriteJson(w, http.StatusOK, sessionEnvelope(tokens))
+}
+
+var personalAccessTokenErrorMapping = httpx.StoreErrorMapping{
+	ResourceName: "Token",
+	NotFoundErr:  ErrTokenNotFound,
+}
+
+type createPersonalAccessTokenRequest struct {
+	Name           string   `json:"name"`
+	Permissions    []string `json:"permissions"`
+	ExpiresInHours *int     `json:"expires_in_hours"`
+}
+
+// HandleCreatePersonalAccessToken serves POST /tokens/personal. The
+// response is the only time the token's plaintext is shown.
+func (h *Handler) HandleCreatePersonalAccessToken(w http.ResponseWriter, r *http.Request) {
+	p := GetPrincipal(r)
+	if p.IsAnonymous() {
+		httpx.WriteJson(w, http.StatusUnauthorized, httpx.Envelope{"error": "Unauthenticated"})
+		return
+	}
+
+	var req createPersonalAccessTokenRequest
+	if derr := httpx.DecodeJSONBody(w, r, &req); derr != nil {
+		h.logger.WarnContext(r.Context(), "decode create personal access token", slog.Any("err", derr))
+		httpx.WriteDecodeError(w, derr)
+		return
+	}
+
+	cmd := CreatePersonalAccessTokenCommand{UserID: p.ID, Name: req.Name, Permissions: req.Permissions}
+	if req.ExpiresInHours != nil {
+		ttl := time.Duration(*req.ExpiresInHours) * time.Hour
+		cmd.TTL = &ttl
+	}
+
+	token, err := h.service.CreatePersonalAccessToken(r.Context(), cmd)
+	if err != nil {
+		if errors.Is(err, ErrValidation) {
+			httpx.WriteJson(w, http.StatusBadRequest, httpx.Envelope{"error": err.Error()})
+			return
+		}
+		httpx.WriteStoreError(r.Context(), w, h.logger, err, personalAccessTokenErrorMapping, "Failed to create token")
+		return
+	}
+
+	httpx.WriteJson(w, http.StatusCreated, httpx.Envelope{"token": token})
+}
+
+func (h *Handler) HandleListPersonalAccessTokens(w http.ResponseWriter, r *http.Request) {
+	p := GetPrincipal(r)
+	if p.IsAnonymous() {
+		httpx.WriteJson(w, http.StatusUnauthorized, httpx.Envelope{"error": "Unauthenticated"})
+		return
+	}
+
+	tokens, err := h.service.ListPersonalAccessTokens(r.Context(), p.ID)
+	if err != nil {
+		httpx.WriteStoreError(r.Context(), w, h.logger, err, personalAccessTokenErrorMapping, "Failed to list tokens")
+		return
+	}
+
+	httpx.WriteJson(w, http.StatusOK, httpx.Envelope{"tokens": tokens})
+}
+
+func (h *Handler) HandleRevokePersonalAccessToken(w http.ResponseWriter, r *http.Request) {
+	id, err := httpx.ReadIdParam(r, "id")
+	if err != nil {
+		h.logger.WarnContext(r.Context(), "read id param", slog.Any("err", err))
+		httpx.WriteJson(w, http.StatusBadRequest, httpx.Envelope{"error": err.Error()})
+		return
+	}
+
+	p := GetPrincipal(r)
+	if p.IsAnonymous() {
+		httpx.WriteJson(w, http.StatusUnauthorized, httpx.Envelope{"error": "Unauthenticated"})
+		return
+	}
+
+	if err := h.service.RevokePersonalAccessToken(r.Context(), PersonalAccessTokenID(id), p.ID); err != nil {
+		httpx.WriteStoreError(r.Context(), w, h.logger, err, personalAccessTokenErrorMapping, "Failed to revoke token")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeTwoFactorError maps the errors the two-factor management endpoints
+// share; it reports false for anything else.
+func writeTwoFactorError(w http.ResponseWriter, err error) bool {
+	switch {
+	case errors.Is(err, ErrTwoFactorUnavailable):
+		httpx.WriteJson(w, http.StatusServiceUnavailable, httpx.Envelope{"error": err.Error()})
+	case errors.Is(err, ErrTwoFactorEnabled), errors.Is(err, ErrTwoFactorNotEnabled):
+		httpx.WriteJson(w, http.StatusConflict, httpx.Envelope{"error": err.Error()})
+	case errors.Is(err, ErrInvalidTwoFactorCode):
+		httpx.WriteJson(w, http.StatusBadRequest, httpx.Envelope{"error": err.Error()})
+	default:
+		return false
+	}
+	return true
+}
+
+// HandleEnrollTwoFactor serves POST /me/2fa: returns a new TOTP secret to
+// set up in an authenticator app. 2FA is on once it is confirmed.
+func (h *Handler) HandleEnrollTwoFactor(w http.ResponseWriter, r *http.Request) {
+	p := GetPrincipal(r)
+	if p.IsAnonymous() {
+		httpx.WriteJson(w, http.StatusUnauthorized, httpx.Envelope{"error": "Unauthenticated"})
+		return
+	}
+
+	enrollment, err := h.service.EnrollTwoFactor(r.Context(), p)
+	if err != nil {
+		if writeTwoFactorError(w, err) {
+			return
+		}
+		h.logger.ErrorContext(r.Context(), "enroll two-factor failed", slog.Any("err", err))
+		httpx.WriteJson(w, http.StatusInternalServerError, httpx.Envelope{"error": "internal error"})
+		return
+	}
+
+	httpx.WriteJson(w, http.StatusOK, httpx.Envelope{"two_factor": enrollment})
+}
+
+// HandleConfirmTwoFactor serves POST /me/2fa/confirm: turns 2FA on with a
+// first code and returns the recovery codes, once.
+func (h *Handler) HandleConfirmTwoFactor(w http.ResponseWriter, r *http.Request) {
+	p := GetPrincipal(r)
+	if p.IsAnonymous() {
+		httpx.WriteJson(w, http.StatusUnauthorized, httpx.Envelope{"error": "Unauthenticated"})
+		return
+	}
+
+	var req struct {
+		Code string `json:"code"`
+	}
+	if derr := httpx.DecodeJSONBody(w, r, &req); derr != nil {
+		h.logger.WarnContext(r.Context(), "decode confirm two-factor", slog.Any("err", derr))
+		httpx.WriteDecodeError(w, derr)
+		return
+	}
+
+	codes, err := h.service.ConfirmTwoFactor(r.Context(), p, req.Code)
+	if err != nil {
+		if writeTwoFactorError(w, err) {
+			return
+		}
+		h.logger.ErrorContext(r.Context(), "confirm two-factor failed", slog.Any("err", err))
+		httpx.WriteJson(w, http.StatusInternalServerError, httpx.Envelope{"error": "internal error"})
+		return
+	}
+
+	httpx.WriteJson(w, http.StatusOK, httpx.Envelope{"recovery_codes": codes})
+}
+
+// HandleDisableTwoFactor serves DELETE /me/2fa.
+func (h *Handler) HandleDisableTwoFactor(w http.ResponseWriter, r *http.Request) {
+	p := GetPrincipal(r)
+	if p.IsAnonymous() {
+		httpx.WriteJson(w, http.StatusUnauthorized, httpx.Envelope{"error": "Unauthenticated"})
+		return
+	}
+
+	var req struct {
+		Password string `json:"password"`
+		Code     string `json:"code"`
+	}
+	if derr := httpx.DecodeJSONBody(w, r, &req); derr != nil {
+		h.logger.WarnContext(r.Context(), "decode disable two-factor", slog.Any("err", derr))
+		httpx.WriteDecodeError(w, derr)
+		return
+	}
+
+	err := h.service.DisableTwoFactor(r.Context(), DisableTwoFactorCommand{Principal: p, Password: req.Password, Code: req.Code})
+	if err != nil {
+		if errors.Is(err, user.ErrWrongPassword) {
+			httpx.WriteJson(w, http.StatusForbidden, httpx.Envelope{"error": "password is incorrect"})
+			return
+		}
+		if errors.Is(err, ErrInvalidTwoFactorCode) {
+			httpx.WriteJson(w, http.StatusForbidden, httpx.Envelope{"error": err.Error()})
+			return
+		}
+		if writeTwoFactorError(w, err) {
+			return
+		}
+		h.logger.ErrorContext(r.Context(), "disable two-factor failed", slog.Any("err", err))
+		httpx.WriteJson(w, http.StatusInternalServerError, httpx.Envelope{"error": "internal error"})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
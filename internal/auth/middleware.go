@@ -2,10 +2,13 @@ package auth
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/tsatsarisg/go-fit/internal/httpx"
+	"github.com/tsatsarisg/go-fit/internal/user"
 )
 
 // ScopeAuth is the token scope used by the bearer-token authentication flow.
@@ -14,12 +17,20 @@ import (
 // in auth).
 const ScopeAuth = "authentication"
 
+// lastUsedGranularity bounds how often a busy token writes its
+// last_used_at: once a minute is precise enough to read, and keeps a client
+// hammering the API from turning every read into a write.
+const lastUsedGranularity = time.Minute
+
+// Middleware resolves personal access tokens through Store and session
+// access tokens through Access, whichever format those are in.
 type Middleware struct {
-	Store Store
+	Store  Store
+	Access AccessTokens
 }
 
-func NewMiddleware(store Store) *Middleware {
-	return &Middleware{Store: store}
+func NewMiddleware(store Store, access AccessTokens) *Middleware {
+	return &Middleware{Store: store, Access: access}
 }
 
 type contextKey string
@@ -45,9 +56,33 @@ func GetPrincipal(r *http.Request) *Principal {
 	return p
 }
 
+// CurrentUserID adapts GetPrincipal to the user.CurrentUserFunc shape, so
+// packages that auth depends on can still learn who is calling without
+// importing auth.
+func CurrentUserID(r *http.Request) (user.UserID, bool) {
+	p := GetPrincipal(r)
+	if p.IsAnonymous() {
+		return 0, false
+	}
+	return p.ID, true
+}
+
+// RateLimitKey is the httpx.RateLimitKeyFunc for the API: a principal's
+// requests share one bucket whatever token or address they come from, and
+// anonymous ones are bucketed by IP.
+func RateLimitKey(r *http.Request) string {
+	p := GetPrincipal(r)
+	if p.IsAnonymous() {
+		return httpx.RateLimitByIP(r)
+	}
+	return fmt.Sprintf("user:%d", p.ID)
+}
+
 // Authenticate resolves the bearer token (if present) to a Principal and
-// stashes it on the request. Missing / empty header ⇒ AnonymousPrincipal so
-// public routes still work. Malformed header ⇒ 401 immediately.
+// stashes it on the request, recording the token's last use at most once
+// per lastUsedGranularity. A stateless JWT has no row to record it on.
+// Missing / empty header ⇒ AnonymousPrincipal so public routes still work.
+// Malformed header ⇒ 401 immediately.
 func (mw *Middleware) Authenticate(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Add("Vary", "Authorization")
@@ -65,7 +100,13 @@ func (mw *Middleware) Authenticate(next http.Handler) http.Handler {
 			return
 		}
 
-		principal, err := mw.Store.ResolvePrincipal(r.Context(), ScopeAuth, parts[1])
+		var principal *Principal
+		var err error
+		if strings.HasPrefix(parts[1], PersonalAccessTokenPrefix) {
+			principal, err = mw.Store.ResolvePersonalAccessToken(r.Context(), parts[1])
+		} else {
+			principal, err = mw.Access.Resolve(r.Context(), parts[1])
+		}
 		if err != nil {
 			// Infrastructure failure — don't leak details to the client.
 			httpx.WriteJson(w, http.StatusInternalServerError, httpx.Envelope{"error": "internal error"})
@@ -75,18 +116,79 @@ func (mw *Middleware) Authenticate(next http.Handler) http.Handler {
 			httpx.WriteJson(w, http.StatusUnauthorized, httpx.Envelope{"error": "Invalid token"})
 			return
 		}
+		if now := time.Now(); !principal.stateless && (principal.lastUsedAt == nil || now.Sub(*principal.lastUsedAt) >= lastUsedGranularity) {
+			if err := mw.Store.TouchToken(r.Context(), principal.TokenHash, now); err != nil {
+				httpx.WriteJson(w, http.StatusInternalServerError, httpx.Envelope{"error": "internal error"})
+				return
+			}
+		}
 
 		r = SetPrincipal(r, principal)
 		next.ServeHTTP(w, r)
 	})
 }
 
+// writeUnauthenticated is the 401 every guard gives an anonymous caller.
+func writeUnauthenticated(w http.ResponseWriter) {
+	httpx.WriteJson(w, http.StatusUnauthorized, httpx.Envelope{"error": "You must be authenticated to access this resource"})
+}
+
 // RequireAuthenticatedUser is a route-level guard for endpoints that demand a
 // real principal. Public routes can skip it and read GetPrincipal directly.
+//
+// It admits session tokens only: a personal access token gets 403 here, so
+// account management (passwords, deletion, the tokens themselves) can't be
+// reached with one. Routes a personal access token may call use
+// RequirePermission instead.
 func (mw *Middleware) RequireAuthenticatedUser(next http.HandlerFunc) http.HandlerFunc {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if GetPrincipal(r).IsAnonymous() {
-			httpx.WriteJson(w, http.StatusUnauthorized, httpx.Envelope{"error": "You must be authenticated to access this resource"})
+		p := GetPrincipal(r)
+		if p.IsAnonymous() {
+			writeUnauthenticated(w)
+			return
+		}
+		if p.IsPersonalAccess() {
+			httpx.WriteJson(w, http.StatusForbidden, httpx.Envelope{"error": "This resource can't be used with a personal access token"})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RequirePermission admits a session token whose user's role grants perm,
+// and a personal access token that was also granted it.
+func (mw *Middleware) RequirePermission(perm Permission, next http.HandlerFunc) http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p := GetPrincipal(r)
+		if p.IsAnonymous() {
+			writeUnauthenticated(w)
+			return
+		}
+		if !p.RoleCan(perm) {
+			httpx.WriteJson(w, http.StatusForbidden, httpx.Envelope{"error": "Your account lacks the " + string(perm) + " permission"})
+			return
+		}
+		if !p.Can(perm) {
+			httpx.WriteJson(w, http.StatusForbidden, httpx.Envelope{"error": "This token lacks the " + string(perm) + " permission"})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RequireActivatedUser demands a confirmed email, for routes an unverified
+// account shouldn't reach. It answers 403 rather than 401: the credentials
+// are fine, the account isn't ready. It says nothing about which tokens may
+// call, so wrap it in RequireAuthenticatedUser or RequirePermission.
+func (mw *Middleware) RequireActivatedUser(next http.HandlerFunc) http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p := GetPrincipal(r)
+		if p.IsAnonymous() {
+			writeUnauthenticated(w)
+			return
+		}
+		if !p.Activated {
+			httpx.WriteJson(w, http.StatusForbidden, httpx.Envelope{"error": "Your account must be activated to access this resource"})
 			return
 		}
 		next.ServeHTTP(w, r)
@@ -0,0 +1,109 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/tsatsarisg/go-fit/internal/user"
+)
+
+func TestRouteGuards(t *testing.T) {
+	session := &Principal{ID: 1, Activated: true, Scope: ScopeAuth}
+	pat := &Principal{ID: 1, Activated: true, Scope: ScopePersonalAccess, Permissions: []Permission{PermissionWorkoutsRead}}
+	unactivatedPAT := &Principal{ID: 1, Scope: ScopePersonalAccess, Permissions: []Permission{PermissionWorkoutsWrite}}
+	admin := &Principal{ID: 2, Activated: true, Role: user.RoleAdmin, Scope: ScopeAuth}
+	adminPAT := &Principal{ID: 2, Activated: true, Role: user.RoleAdmin, Scope: ScopePersonalAccess, Permissions: []Permission{PermissionWorkoutsRead}}
+	coach := &Principal{ID: 3, Activated: true, Role: user.RoleCoach, Scope: ScopeAuth}
+
+	mw := NewMiddleware(nil, nil)
+	ok := func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusNoContent) }
+
+	tests := []struct {
+		name      string
+		guard     http.HandlerFunc
+		principal *Principal
+		want      int
+	}{
+		{"session on account route", mw.RequireAuthenticatedUser(ok), session, http.StatusNoContent},
+		{"pat on account route", mw.RequireAuthenticatedUser(ok), pat, http.StatusForbidden},
+		{"anonymous on account route", mw.RequireAuthenticatedUser(ok), AnonymousPrincipal, http.StatusUnauthorized},
+		{"session on write route", mw.RequirePermission(PermissionWorkoutsWrite, ok), session, http.StatusNoContent},
+		{"pat with permission", mw.RequirePermission(PermissionWorkoutsRead, ok), pat, http.StatusNoContent},
+		{"pat without permission", mw.RequirePermission(PermissionWorkoutsWrite, ok), pat, http.StatusForbidden},
+		{"anonymous on permission route", mw.RequirePermission(PermissionWorkoutsRead, ok), AnonymousPrincipal, http.StatusUnauthorized},
+		{"unactivated pat", mw.RequirePermission(PermissionWorkoutsWrite, mw.RequireActivatedUser(ok)), unactivatedPAT, http.StatusForbidden},
+		{"admin on admin route", mw.RequirePermission(PermissionAdminUsers, ok), admin, http.StatusNoContent},
+		{"admin pat on admin route", mw.RequirePermission(PermissionAdminUsers, ok), adminPAT, http.StatusForbidden},
+		{"admin pat on granted route", mw.RequirePermission(PermissionWorkoutsRead, ok), adminPAT, http.StatusNoContent},
+		{"user on admin route", mw.RequirePermission(PermissionAdminWorkouts, ok), session, http.StatusForbidden},
+		{"coach on admin route", mw.RequirePermission(PermissionAdminAudit, ok), coach, http.StatusForbidden},
+		{"coach on write route", mw.RequirePermission(PermissionWorkoutsWrite, ok), coach, http.StatusNoContent},
+		{"user on programs route", mw.RequirePermission(PermissionProgramsWrite, ok), session, http.StatusForbidden},
+		{"coach on programs route", mw.RequirePermission(PermissionProgramsWrite, ok), coach, http.StatusNoContent},
+		{"admin on programs route", mw.RequirePermission(PermissionProgramsWrite, ok), admin, http.StatusNoContent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			tt.guard(rec, SetPrincipal(httptest.NewRequest(http.MethodGet, "/", nil), tt.principal))
+			assert.Equal(t, tt.want, rec.Code)
+		})
+	}
+}
+
+func TestParsePermissions(t *testing.T) {
+	perms, err := parsePermissions([]string{"workouts:write", "profile:read", "workouts:write"})
+	assert.NoError(t, err)
+	assert.Equal(t, []Permission{PermissionProfileRead, PermissionWorkoutsWrite}, perms)
+
+	_, err = parsePermissions([]string{"workouts:delete"})
+	assert.Error(t, err)
+	_, err = parsePermissions(nil)
+	assert.Error(t, err)
+}
+
+// touchStore resolves every bearer token to principal and counts the
+// last-used writes Authenticate makes.
+type touchStore struct {
+	Store
+	principal Principal
+	touched   int
+}
+
+func (s *touchStore) ResolvePrincipal(_ context.Context, scope, _ string) (*Principal, error) {
+	p := s.principal
+	p.Scope = scope
+	return &p, nil
+}
+
+func (s *touchStore) TouchToken(_ context.Context, _ []byte, at time.Time) error {
+	s.touched++
+	s.principal.lastUsedAt = &at
+	return nil
+}
+
+func TestAuthenticateThrottlesLastUsed(t *testing.T) {
+	store := &touchStore{principal: Principal{ID: 1}}
+	handler := NewMiddleware(store, NewOpaqueAccessTokens(store)).Authenticate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, ScopeAuth, GetPrincipal(r).Scope)
+	}))
+	call := func() {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Authorization", "Bearer SESSIONTOKEN")
+		handler.ServeHTTP(httptest.NewRecorder(), r)
+	}
+
+	call()
+	call()
+	assert.Equal(t, 1, store.touched, "a second request within the minute must not write")
+
+	stale := time.Now().Add(-2 * lastUsedGranularity)
+	store.principal.lastUsedAt = &stale
+	call()
+	assert.Equal(t, 2, store.touched)
+}
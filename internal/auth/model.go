@@ -7,9 +7,87 @@ import (
 )
 
 type Token struct {
-	Plaintext string       `json:"token"`
-	Hash      []byte       `json:"-"`
-	UserID    user.UserID  `json:"-"`
-	Expiry    time.Time    `json:"expiry"`
-	Scope     string       `json:"-"`
+	Plaintext string      `json:"token"`
+	Hash      []byte      `json:"-"`
+	UserID    user.UserID `json:"-"`
+	Expiry    time.Time   `json:"expiry"`
+	Scope     string      `json:"-"`
+	// SessionID is the token family of an access or refresh token.
+	SessionID SessionID `json:"-"`
+}
+
+// SessionTokens is what login and refresh hand out: a short-lived access
+// token (ScopeAuth) and the refresh token (ScopeRefresh) that renews it.
+type SessionTokens struct {
+	Access  *Token
+	Refresh *Token
+}
+
+// Client describes who signed in, as recorded on their session.
+type Client struct {
+	UserAgent string
+	IP        string
+}
+
+// PersonalAccessTokenID wraps tokens.id. Personal access tokens are listed
+// and revoked by id; the token itself is only ever shown once.
+type PersonalAccessTokenID int64
+
+// PersonalAccessToken is a long-lived, named bearer token limited to
+// Permissions. Token is populated only on the creation response. A nil
+// Expiry means the token lives until revoked.
+type PersonalAccessToken struct {
+	ID          PersonalAccessTokenID `json:"id"`
+	Name        string                `json:"name"`
+	Permissions []Permission          `json:"permissions"`
+	Token       string                `json:"token,omitempty"`
+	Expiry      *time.Time            `json:"expiry"`
+	LastUsedAt  *time.Time            `json:"last_used_at"`
+	CreatedAt   time.Time             `json:"created_at"`
+}
+
+// SessionID wraps token_families.id.
+type SessionID int64
+
+// Session is one signed-in device: a token family, the access and refresh
+// tokens descended from one login, plus what was recorded about the client
+// that last used it. Current marks the session the listing request itself
+// was made with.
+type Session struct {
+	ID         SessionID  `json:"id"`
+	UserAgent  string     `json:"user_agent"`
+	IP         string     `json:"ip"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastSeenAt *time.Time `json:"last_seen_at"`
+	Expiry     time.Time  `json:"expiry"`
+	Current    bool       `json:"current"`
+}
+
+// LoginResult is the outcome of a correct password. Session is set unless
+// the account has two-factor authentication on; then TwoFactorToken is,
+// and the session only starts once it is redeemed with a code.
+type LoginResult struct {
+	Session        *SessionTokens
+	TwoFactorToken *Token
+}
+
+// TwoFactor is a user's TOTP enrollment. Secret is sealed; ConfirmedAt is
+// nil until the first code has been entered, and only then is the second
+// factor required. LastUsedStep is the TOTP step of the last code
+// accepted.
+type TwoFactor struct {
+	Secret       []byte
+	ConfirmedAt  *time.Time
+	LastUsedStep *int64
+}
+
+func (tf *TwoFactor) Enabled() bool {
+	return tf.ConfirmedAt != nil
+}
+
+// TwoFactorEnrollment is shown once, when enrolling: the secret to type
+// into an authenticator app, or the otpauth:// URI to scan as a QR code.
+type TwoFactorEnrollment struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
 }
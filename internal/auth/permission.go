@@ -0,0 +1,90 @@
+package auth
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/tsatsarisg/go-fit/internal/user"
+)
+
+// Permission is one thing a principal may do. A session token from POST
+// /tokens/authentication holds every permission its user's role grants; a
+// personal access token holds the ones it was created with, as far as the
+// role still grants them.
+type Permission string
+
+const (
+	PermissionWorkoutsRead  Permission = "workouts:read"
+	PermissionWorkoutsWrite Permission = "workouts:write"
+	PermissionProfileRead   Permission = "profile:read"
+	PermissionProfileWrite  Permission = "profile:write"
+	// PermissionProgramsWrite publishes and deletes programs, which every
+	// account can read and enroll in, so it comes with RoleCoach and
+	// RoleAdmin only.
+	PermissionProgramsWrite Permission = "programs:write"
+)
+
+// Admin permissions come with RoleAdmin only. They are not in Permissions,
+// so no personal access token can be granted one: the admin API takes a
+// session token.
+const (
+	PermissionAdminUsers    Permission = "admin:users"
+	PermissionAdminWorkouts Permission = "admin:workouts"
+	PermissionAdminAudit    Permission = "admin:audit"
+)
+
+// Permissions lists every permission a personal access token can be
+// granted, in the order they are documented.
+var Permissions = []Permission{
+	PermissionWorkoutsRead,
+	PermissionWorkoutsWrite,
+	PermissionProfileRead,
+	PermissionProfileWrite,
+	PermissionProgramsWrite,
+}
+
+// userPermissions is what every account may do with its own data.
+var userPermissions = []Permission{
+	PermissionWorkoutsRead,
+	PermissionWorkoutsWrite,
+	PermissionProfileRead,
+	PermissionProfileWrite,
+}
+
+// coachPermissions add publishing programs to userPermissions.
+var coachPermissions = append(slices.Clone(userPermissions), PermissionProgramsWrite)
+
+// rolePermissions is what each role grants.
+var rolePermissions = map[user.Role][]Permission{
+	user.RoleUser:  userPermissions,
+	user.RoleCoach: coachPermissions,
+	user.RoleAdmin: append(slices.Clone(coachPermissions), PermissionAdminUsers, PermissionAdminWorkouts, PermissionAdminAudit),
+}
+
+// RolePermissions returns the permissions role grants. An unknown or empty
+// role grants what RoleUser does, so a principal built without one is
+// never more privileged than the default.
+func RolePermissions(role user.Role) []Permission {
+	if perms, ok := rolePermissions[role]; ok {
+		return perms
+	}
+	return rolePermissions[user.RoleUser]
+}
+
+// parsePermissions validates names against Permissions and returns them
+// deduplicated and sorted, which is how they are stored.
+func parsePermissions(names []string) ([]Permission, error) {
+	if len(names) == 0 {
+		return nil, fmt.Errorf("at least one permission is required")
+	}
+	perms := make([]Permission, 0, len(names))
+	for _, name := range names {
+		p := Permission(name)
+		if !slices.Contains(Permissions, p) {
+			return nil, fmt.Errorf("unknown permission %q", name)
+		}
+		perms = append(perms, p)
+	}
+	slices.Sort(perms)
+	return slices.Compact(perms), nil
+}
@@ -3,9 +3,11 @@ package auth
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"time"
 
+	"github.com/tsatsarisg/go-fit/internal/platform/postgres"
 	"github.com/tsatsarisg/go-fit/internal/user"
 )
 
@@ -43,6 +45,284 @@ func (pts *PostgresStore) Insert(ctx context.Context, token *Token) error {
 	return err
 }
 
+// IssueActivation issues an activation token bound to email, the address
+// it is about to be mailed to.
+func (pts *PostgresStore) IssueActivation(ctx context.Context, userID user.UserID, email string, ttl time.Duration) (*Token, error) {
+	token, err := GenerateToken(userID, ttl, ScopeActivation)
+	if err != nil {
+		return nil, err
+	}
+	query := `
+		INSERT INTO tokens (hash, user_id, expiry, scope, email)
+		VALUES ($1, $2, $3, $4, $5)`
+
+	if _, err := pts.db.ExecContext(ctx, query, token.Hash, token.UserID, token.Expiry, token.Scope, email); err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+// ConsumeActivation redeems an activation token like ConsumeToken, but only
+// while its account still has the address the token was mailed to and is
+// neither disabled nor pending deletion. Anything else is ErrInvalidToken.
+func (pts *PostgresStore) ConsumeActivation(ctx context.Context, plaintext string) (user.UserID, error) {
+	query := `
+		DELETE FROM tokens t
+		USING users u
+		WHERE t.hash = $1 AND t.scope = $2 AND t.expiry > now()
+		  AND u.id = t.user_id AND u.email = t.email
+		  AND u.deletion_requested_at IS NULL AND u.disabled_at IS NULL
+		RETURNING t.user_id`
+
+	var userID user.UserID
+	err := pts.db.QueryRowContext(ctx, query, HashPlaintext(plaintext), ScopeActivation).Scan(&userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrInvalidToken
+	}
+	return userID, err
+}
+
+// insertSessionToken mints a token of scope in family and persists it,
+// recording parentID (0 for none) as the token it was rotated from.
+func insertSessionToken(ctx context.Context, db execer, userID user.UserID, family SessionID, parentID int64, ttl time.Duration, scope string) (*Token, error) {
+	token, err := GenerateToken(userID, ttl, scope)
+	if err != nil {
+		return nil, err
+	}
+	token.SessionID = family
+	query := `
+		INSERT INTO tokens (hash, user_id, expiry, scope, family_id, parent_id)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, 0))`
+
+	if _, err := db.ExecContext(ctx, query, token.Hash, token.UserID, token.Expiry, token.Scope, family, parentID); err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+// execer is what insertSessionToken needs of a *sql.DB or *sql.Tx.
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// StartSession opens a token family for a new sign-in from client and
+// issues its refresh token, in one transaction. The access token is the
+// AccessTokens strategy's to issue.
+func (pts *PostgresStore) StartSession(ctx context.Context, userID user.UserID, client Client, refreshTTL time.Duration) (*Token, error) {
+	tx, err := pts.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var family SessionID
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO token_families (user_id, user_agent, ip)
+		VALUES ($1, NULLIF($2, ''), NULLIF($3, ''))
+		RETURNING id`, userID, client.UserAgent, client.IP).Scan(&family)
+	if err != nil {
+		return nil, err
+	}
+
+	refresh, err := insertSessionToken(ctx, tx, userID, family, 0, refreshTTL, ScopeRefresh)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return refresh, nil
+}
+
+// IssueAccessToken adds an opaque ScopeAuth token to session.
+func (pts *PostgresStore) IssueAccessToken(ctx context.Context, userID user.UserID, session SessionID, ttl time.Duration) (*Token, error) {
+	return insertSessionToken(ctx, pts.db, userID, session, 0, ttl, ScopeAuth)
+}
+
+// RotateRefreshToken spends the refresh token plaintext and issues the next
+// one in its family. A token that was already spent means two parties hold
+// it, so the whole family is revoked and ErrRefreshTokenReused returned.
+// Unknown and expired tokens, and those of an account pending deletion or
+// disabled, are ErrInvalidToken.
+//
+// The row lock makes concurrent rotations of one token serialize: the
+// second sees the first's rotated_at and is treated as reuse.
+func (pts *PostgresStore) RotateRefreshToken(ctx context.Context, plaintext string, client Client, refreshTTL time.Duration) (*Token, error) {
+	tx, err := pts.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var (
+		id        int64
+		userID    user.UserID
+		family    SessionID
+		rotatedAt *time.Time
+	)
+	now := time.Now()
+	err = tx.QueryRowContext(ctx, `
+		SELECT t.id, t.user_id, t.family_id, t.rotated_at
+		FROM tokens t
+		INNER JOIN users u ON u.id = t.user_id
+		WHERE t.scope = $1 AND t.hash = $2 AND t.expiry > $3
+		  AND u.deletion_requested_at IS NULL AND u.disabled_at IS NULL
+		FOR UPDATE OF t`, ScopeRefresh, HashPlaintext(plaintext), now).Scan(&id, &userID, &family, &rotatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrInvalidToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if rotatedAt != nil {
+		if _, err := tx.ExecContext(ctx, revokeSessionsQuery+`WHERE id = $1`+recordRevocationsQuery, family); err != nil {
+			return nil, err
+		}
+		if err := tx.Commit(); err != nil {
+			return nil, err
+		}
+		return nil, ErrRefreshTokenReused
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE tokens SET rotated_at = $1 WHERE id = $2`, now, id); err != nil {
+		return nil, err
+	}
+	_, err = tx.ExecContext(ctx, `
+		UPDATE token_families
+		SET user_agent = NULLIF($1, ''), ip = NULLIF($2, ''), last_seen_at = $3
+		WHERE id = $4`, client.UserAgent, client.IP, now, family)
+	if err != nil {
+		return nil, err
+	}
+
+	refresh, err := insertSessionToken(ctx, tx, userID, family, id, refreshTTL, ScopeRefresh)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return refresh, nil
+}
+
+// TouchToken records that the token whose hash is hash was used at at, and
+// that its session, if it has one, was seen.
+func (pts *PostgresStore) TouchToken(ctx context.Context, hash []byte, at time.Time) error {
+	query := `
+		WITH t AS (
+			UPDATE tokens SET last_used_at = $1 WHERE hash = $2 RETURNING family_id
+		)
+		UPDATE token_families SET last_seen_at = $1
+		WHERE id = (SELECT family_id FROM t)`
+
+	_, err := pts.db.ExecContext(ctx, query, at, hash)
+	return err
+}
+
+// ListSessions returns the user's token families that still hold a usable
+// token, most recently started first, marking current. Expiry is when the
+// last of those tokens runs out.
+func (pts *PostgresStore) ListSessions(ctx context.Context, userID user.UserID, current SessionID) ([]*Session, error) {
+	query := `
+		SELECT f.id, COALESCE(f.user_agent, ''), COALESCE(f.ip, ''), f.created_at, f.last_seen_at, MAX(t.expiry), f.id = $2
+		FROM token_families f
+		INNER JOIN tokens t ON t.family_id = f.id
+		WHERE f.user_id = $1 AND t.expiry > $3 AND t.rotated_at IS NULL
+		GROUP BY f.id
+		ORDER BY f.created_at DESC, f.id DESC`
+
+	rows, err := pts.db.QueryContext(ctx, query, userID, current, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []*Session{}
+	for rows.Next() {
+		s := &Session{}
+		if err := rows.Scan(&s.ID, &s.UserAgent, &s.IP, &s.CreatedAt, &s.LastSeenAt, &s.Expiry, &s.Current); err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, s)
+	}
+	return sessions, rows.Err()
+}
+
+// revokeSessionsQuery and recordRevocationsQuery go either side of a WHERE
+// clause on token_families: every family deleted is recorded in
+// session_revocations, so stateless access tokens of the session can be
+// rejected too (see RevocationList).
+const (
+	revokeSessionsQuery = `
+		WITH gone AS (
+			DELETE FROM token_families `
+	recordRevocationsQuery = `
+			RETURNING id, user_id
+		)
+		INSERT INTO session_revocations (session_id, user_id)
+		SELECT id, user_id FROM gone`
+)
+
+// DeleteSession revokes one of the user's token families, access and
+// refresh tokens alike. Someone else's session is ErrTokenNotFound, like a
+// missing one.
+func (pts *PostgresStore) DeleteSession(ctx context.Context, id SessionID, userID user.UserID) error {
+	res, err := pts.db.ExecContext(ctx, revokeSessionsQuery+`WHERE id = $1 AND user_id = $2`+recordRevocationsQuery, id, userID)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrTokenNotFound
+	}
+	return nil
+}
+
+// DeleteSessionsExcept revokes every token family of the user but keep.
+func (pts *PostgresStore) DeleteSessionsExcept(ctx context.Context, userID user.UserID, keep SessionID) error {
+	_, err := pts.db.ExecContext(ctx, revokeSessionsQuery+`WHERE user_id = $1 AND id <> $2`+recordRevocationsQuery, userID, keep)
+	return err
+}
+
+// DeleteAllSessions revokes every token family of the user.
+func (pts *PostgresStore) DeleteAllSessions(ctx context.Context, userID user.UserID) error {
+	_, err := pts.db.ExecContext(ctx, revokeSessionsQuery+`WHERE user_id = $1`+recordRevocationsQuery, userID)
+	return err
+}
+
+// ListSessionRevocations returns the sessions revoked after since.
+func (pts *PostgresStore) ListSessionRevocations(ctx context.Context, since time.Time) ([]SessionID, error) {
+	rows, err := pts.db.QueryContext(ctx, `SELECT session_id FROM session_revocations WHERE revoked_at > $1`, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []SessionID{}
+	for rows.Next() {
+		var id SessionID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// PruneSessionRevocations forgets revocations made before cutoff and
+// reports how many went.
+func (pts *PostgresStore) PruneSessionRevocations(ctx context.Context, cutoff time.Time) (int64, error) {
+	res, err := pts.db.ExecContext(ctx, `DELETE FROM session_revocations WHERE revoked_at < $1`, cutoff)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
 func (pts *PostgresStore) DeleteAllForUser(ctx context.Context, scope string, userID user.UserID) error {
 	query := `
 		DELETE FROM tokens
@@ -52,24 +332,323 @@ func (pts *PostgresStore) DeleteAllForUser(ctx context.Context, scope string, us
 	return err
 }
 
+func (pts *PostgresStore) DeleteEveryScopeForUser(ctx context.Context, userID user.UserID) error {
+	if err := pts.DeleteAllSessions(ctx, userID); err != nil {
+		return err
+	}
+	_, err := pts.db.ExecContext(ctx, `DELETE FROM tokens WHERE user_id = $1`, userID)
+	return err
+}
+
+// ConsumeToken deletes the unexpired token of that scope and reports whose
+// it was, in one statement, so two requests racing with the same one-time
+// token can't both redeem it. An unknown, expired or already used token is
+// ErrInvalidToken.
+func (pts *PostgresStore) ConsumeToken(ctx context.Context, scope, plaintext string) (user.UserID, error) {
+	query := `
+		DELETE FROM tokens
+		WHERE hash = $1 AND scope = $2 AND expiry > now()
+		RETURNING user_id`
+
+	var userID user.UserID
+	err := pts.db.QueryRowContext(ctx, query, HashPlaintext(plaintext), scope).Scan(&userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrInvalidToken
+	}
+	return userID, err
+}
+
 // ResolvePrincipal hashes the plaintext and looks up the matching non-expired
-// token, returning a minimal Principal (ID + Username). Returns (nil, nil)
-// when the token is unknown or expired — callers treat that as "anonymous"
-// rather than as an error so routine unauthenticated traffic doesn't log-spam.
+// token, returning a minimal Principal (ID, Username, Activated, Role).
+// Returns (nil, nil) when the token is unknown or expired, or its user is
+// disabled or pending deletion — callers treat that as "anonymous" rather
+// than as an error so routine unauthenticated traffic doesn't log-spam.
 func (pts *PostgresStore) ResolvePrincipal(ctx context.Context, scope, plaintext string) (*Principal, error) {
 	tokenHash := HashPlaintext(plaintext)
-	query := `SELECT u.id, u.username
+	query := `SELECT u.id, u.username, u.activated, u.role, t.last_used_at, COALESCE(t.family_id, 0)
+	          FROM users u
+	          INNER JOIN tokens t ON u.id = t.user_id
+	          WHERE t.scope = $1 AND t.hash = $2 AND t.expiry > $3
+	            AND u.deletion_requested_at IS NULL AND u.disabled_at IS NULL`
+
+	p := &Principal{TokenHash: tokenHash, Scope: scope}
+	err := pts.db.QueryRowContext(ctx, query, scope, tokenHash, time.Now()).Scan(&p.ID, &p.Username, &p.Activated, &p.Role, &p.lastUsedAt, &p.SessionID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// InsertPersonalAccessToken persists t under ScopePersonalAccess, filling
+// in its ID and CreatedAt.
+func (pts *PostgresStore) InsertPersonalAccessToken(ctx context.Context, t *PersonalAccessToken, hash []byte, userID user.UserID) error {
+	query := `
+		INSERT INTO tokens (hash, user_id, expiry, scope, name, permissions)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at`
+
+	perms := make([]string, len(t.Permissions))
+	for i, p := range t.Permissions {
+		perms[i] = string(p)
+	}
+	err := pts.db.QueryRowContext(ctx, query, hash, userID, t.Expiry, ScopePersonalAccess, t.Name, perms).Scan(&t.ID, &t.CreatedAt)
+	return postgres.ClassifyError(err)
+}
+
+// ListPersonalAccessTokens returns the user's personal access tokens,
+// expired ones included so they can be seen and cleaned up, newest first.
+func (pts *PostgresStore) ListPersonalAccessTokens(ctx context.Context, userID user.UserID) ([]*PersonalAccessToken, error) {
+	query := `
+		SELECT id, name, to_json(permissions), expiry, last_used_at, created_at
+		FROM tokens
+		WHERE scope = $1 AND user_id = $2
+		ORDER BY created_at DESC, id DESC`
+
+	rows, err := pts.db.QueryContext(ctx, query, ScopePersonalAccess, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tokens := []*PersonalAccessToken{}
+	for rows.Next() {
+		t := &PersonalAccessToken{}
+		var perms []byte
+		if err := rows.Scan(&t.ID, &t.Name, &perms, &t.Expiry, &t.LastUsedAt, &t.CreatedAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(perms, &t.Permissions); err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, t)
+	}
+	return tokens, rows.Err()
+}
+
+// DeletePersonalAccessToken revokes one of the user's personal access
+// tokens. Someone else's token is ErrTokenNotFound, like a missing one.
+func (pts *PostgresStore) DeletePersonalAccessToken(ctx context.Context, id PersonalAccessTokenID, userID user.UserID) error {
+	query := `
+		DELETE FROM tokens
+		WHERE id = $1 AND scope = $2 AND user_id = $3`
+
+	res, err := pts.db.ExecContext(ctx, query, id, ScopePersonalAccess, userID)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrTokenNotFound
+	}
+	return nil
+}
+
+// ResolvePersonalAccessToken is ResolvePrincipal for ScopePersonalAccess:
+// it also loads the token's permissions. Returns (nil, nil) for an unknown,
+// expired or revoked token.
+func (pts *PostgresStore) ResolvePersonalAccessToken(ctx context.Context, plaintext string) (*Principal, error) {
+	tokenHash := HashPlaintext(plaintext)
+	query := `SELECT u.id, u.username, u.activated, u.role, to_json(t.permissions), t.last_used_at
 	          FROM users u
 	          INNER JOIN tokens t ON u.id = t.user_id
-	          WHERE t.scope = $1 AND t.hash = $2 AND t.expiry > $3`
+	          WHERE t.scope = $1 AND t.hash = $2 AND (t.expiry IS NULL OR t.expiry > $3)
+	            AND u.deletion_requested_at IS NULL AND u.disabled_at IS NULL`
 
-	p := &Principal{}
-	err := pts.db.QueryRowContext(ctx, query, scope, tokenHash, time.Now()).Scan(&p.ID, &p.Username)
+	p := &Principal{TokenHash: tokenHash, Scope: ScopePersonalAccess}
+	var perms []byte
+	err := pts.db.QueryRowContext(ctx, query, ScopePersonalAccess, tokenHash, time.Now()).Scan(&p.ID, &p.Username, &p.Activated, &p.Role, &perms, &p.lastUsedAt)
 	if errors.Is(err, sql.ErrNoRows) {
 		return nil, nil
 	}
 	if err != nil {
 		return nil, err
 	}
+	if err := json.Unmarshal(perms, &p.Permissions); err != nil {
+		return nil, err
+	}
 	return p, nil
 }
+
+// SaveTwoFactorSecret starts, or restarts, the user's two-factor
+// enrollment with sealed as its secret. A confirmed enrollment is left
+// alone: ErrTwoFactorEnabled.
+func (pts *PostgresStore) SaveTwoFactorSecret(ctx context.Context, userID user.UserID, sealed []byte) error {
+	query := `
+		INSERT INTO two_factor (user_id, secret)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE
+		SET secret = EXCLUDED.secret, last_used_step = NULL, created_at = NOW()
+		WHERE two_factor.confirmed_at IS NULL`
+
+	res, err := pts.db.ExecContext(ctx, query, userID, sealed)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrTwoFactorEnabled
+	}
+	return nil
+}
+
+// GetTwoFactor returns the user's enrollment, confirmed or not, or
+// ErrTwoFactorNotEnabled when there is none.
+func (pts *PostgresStore) GetTwoFactor(ctx context.Context, userID user.UserID) (*TwoFactor, error) {
+	tf := &TwoFactor{}
+	err := pts.db.QueryRowContext(ctx, `
+		SELECT secret, confirmed_at, last_used_step
+		FROM two_factor
+		WHERE user_id = $1`, userID).Scan(&tf.Secret, &tf.ConfirmedAt, &tf.LastUsedStep)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrTwoFactorNotEnabled
+	}
+	if err != nil {
+		return nil, err
+	}
+	return tf, nil
+}
+
+// ConfirmTwoFactor turns the user's pending enrollment on, recording step
+// as used and storing the recovery code hashes, in one transaction.
+// Returns ErrTwoFactorNotEnabled when there is no pending enrollment.
+func (pts *PostgresStore) ConfirmTwoFactor(ctx context.Context, userID user.UserID, step int64, recoveryHashes [][]byte) error {
+	tx, err := pts.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `
+		UPDATE two_factor SET confirmed_at = NOW(), last_used_step = $2
+		WHERE user_id = $1 AND confirmed_at IS NULL`, userID, step)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrTwoFactorNotEnabled
+	}
+
+	for _, hash := range recoveryHashes {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO two_factor_recovery_codes (user_id, hash) VALUES ($1, $2)`, userID, hash); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// UseTwoFactorStep records step as the user's last accepted TOTP step. It
+// reports false, changing nothing, when a step at or after it was already
+// used: the code is a replay.
+func (pts *PostgresStore) UseTwoFactorStep(ctx context.Context, userID user.UserID, step int64) (bool, error) {
+	res, err := pts.db.ExecContext(ctx, `
+		UPDATE two_factor SET last_used_step = $2
+		WHERE user_id = $1 AND confirmed_at IS NOT NULL
+		  AND (last_used_step IS NULL OR last_used_step < $2)`, userID, step)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+// UseRecoveryCode spends the user's unused recovery code with hash,
+// reporting whether there was one.
+func (pts *PostgresStore) UseRecoveryCode(ctx context.Context, userID user.UserID, hash []byte) (bool, error) {
+	res, err := pts.db.ExecContext(ctx, `
+		UPDATE two_factor_recovery_codes SET used_at = NOW()
+		WHERE user_id = $1 AND hash = $2 AND used_at IS NULL`, userID, hash)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// DeleteTwoFactor removes the user's enrollment and recovery codes.
+func (pts *PostgresStore) DeleteTwoFactor(ctx context.Context, userID user.UserID) error {
+	_, err := pts.db.ExecContext(ctx, `DELETE FROM two_factor WHERE user_id = $1`, userID)
+	return err
+}
+
+// FailTwoFactorAttempt counts a wrong code against the 2fa-pending token
+// with hash, and revokes the token once max have been made.
+func (pts *PostgresStore) FailTwoFactorAttempt(ctx context.Context, hash []byte, max int) error {
+	query := `
+		WITH t AS (
+			UPDATE tokens SET failed_attempts = failed_attempts + 1
+			WHERE scope = $1 AND hash = $2
+			RETURNING id, failed_attempts
+		)
+		DELETE FROM tokens WHERE id IN (SELECT id FROM t WHERE failed_attempts >= $3)`
+
+	_, err := pts.db.ExecContext(ctx, query, ScopeTwoFactorPending, hash, max)
+	return err
+}
+
+// LoginLockedUntil returns the latest lock among keys that is still in
+// force at now, or the zero time when none is.
+func (pts *PostgresStore) LoginLockedUntil(ctx context.Context, keys []string, now time.Time) (time.Time, error) {
+	var until sql.NullTime
+	err := pts.db.QueryRowContext(ctx, `
+		SELECT MAX(locked_until)
+		FROM login_failures
+		WHERE key = ANY($1) AND locked_until > $2`, keys, now).Scan(&until)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return until.Time, nil
+}
+
+// RecordLoginFailure counts a failed login against key at at and returns
+// the count, which starts over when the previous failure was before
+// resetBefore.
+func (pts *PostgresStore) RecordLoginFailure(ctx context.Context, key string, at, resetBefore time.Time) (int, error) {
+	query := `
+		INSERT INTO login_failures (key, failures, last_failure_at)
+		VALUES ($1, 1, $2)
+		ON CONFLICT (key) DO UPDATE
+		SET failures = CASE WHEN login_failures.last_failure_at < $3 THEN 1 ELSE login_failures.failures + 1 END,
+		    last_failure_at = EXCLUDED.last_failure_at
+		RETURNING failures`
+
+	var failures int
+	err := pts.db.QueryRowContext(ctx, query, key, at, resetBefore).Scan(&failures)
+	return failures, err
+}
+
+// LockLogin refuses logins for key until until.
+func (pts *PostgresStore) LockLogin(ctx context.Context, key string, until time.Time) error {
+	_, err := pts.db.ExecContext(ctx, `UPDATE login_failures SET locked_until = $2 WHERE key = $1`, key, until)
+	return err
+}
+
+// ClearLoginFailures forgets key's failures.
+func (pts *PostgresStore) ClearLoginFailures(ctx context.Context, key string) error {
+	_, err := pts.db.ExecContext(ctx, `DELETE FROM login_failures WHERE key = $1`, key)
+	return err
+}
+
+// PruneLoginFailures forgets keys whose last failure and lock, if any,
+// both ended before cutoff, and reports how many went.
+func (pts *PostgresStore) PruneLoginFailures(ctx context.Context, cutoff time.Time) (int64, error) {
+	res, err := pts.db.ExecContext(ctx, `
+		DELETE FROM login_failures
+		WHERE last_failure_at < $1 AND (locked_until IS NULL OR locked_until < $1)`, cutoff)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -1,14 +1,42 @@
 package auth
 
-import "github.com/tsatsarisg/go-fit/internal/user"
+import (
+	"slices"
+	"time"
+
+	"github.com/tsatsarisg/go-fit/internal/user"
+)
 
 // Principal is the identity that authenticated code branches act on. It is
 // intentionally a minimal projection of the user (ID + Username) so transport
 // and feature packages never carry the full *user.User aggregate through
 // request context — that was the A3 leak in the original layout.
+//
+// Activated mirrors users.activated, for routes wrapped in
+// Middleware.RequireActivatedUser. Role is the user's role, which bounds
+// what Can allows. TokenHash identifies the token the request
+// authenticated with, so an operation that revokes a user's tokens can
+// spare the caller's own. Scope is that token's scope; Permissions is
+// only set for ScopePersonalAccess, see Can. SessionID is the token family
+// of a session token, zero for any other.
+//
+// A principal resolved from a JWT is stateless: nothing was read from the
+// database, so Activated and Role are as of the token's issue and there is
+// no token row to record the use on.
 type Principal struct {
-	ID       user.UserID
-	Username string
+	ID          user.UserID
+	Username    string
+	Activated   bool
+	Role        user.Role
+	TokenHash   []byte
+	Scope       string
+	Permissions []Permission
+	SessionID   SessionID
+
+	// lastUsedAt is the token's last_used_at as resolved, so Authenticate
+	// can tell whether it is due for a refresh.
+	lastUsedAt *time.Time
+	stateless  bool
 }
 
 // AnonymousPrincipal represents a request with no (or an invalid) bearer
@@ -22,3 +50,32 @@ var AnonymousPrincipal = &Principal{}
 func (p *Principal) IsAnonymous() bool {
 	return p == AnonymousPrincipal
 }
+
+// IsPersonalAccess reports whether p authenticated with a personal access
+// token rather than a session token.
+func (p *Principal) IsPersonalAccess() bool {
+	return p.Scope == ScopePersonalAccess
+}
+
+// Can reports whether p may exercise perm. A session token may do anything
+// its user's role grants; a personal access token only what it was
+// granted, and only while the role still grants it.
+func (p *Principal) Can(perm Permission) bool {
+	if !p.RoleCan(perm) {
+		return false
+	}
+	if !p.IsPersonalAccess() {
+		return true
+	}
+	return slices.Contains(p.Permissions, perm)
+}
+
+// RoleCan reports whether p's role grants perm, whatever the token. Can
+// is the check to authorize with; RoleCan tells a 403 for the account
+// from one for the token.
+func (p *Principal) RoleCan(perm Permission) bool {
+	if p.IsAnonymous() {
+		return false
+	}
+	return slices.Contains(RolePermissions(p.Role), perm)
+}
@@ -3,8 +3,12 @@ package auth
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strings"
 	"time"
 
+	"github.com/tsatsarisg/go-fit/internal/platform/mail"
+	"github.com/tsatsarisg/go-fit/internal/platform/sealer"
 	"github.com/tsatsarisg/go-fit/internal/user"
 )
 
@@ -17,7 +21,52 @@ type Store interface {
 	Insert(ctx context.Context, token *Token) error
 	Issue(ctx context.Context, userID user.UserID, ttl time.Duration, scope string) (*Token, error)
 	DeleteAllForUser(ctx context.Context, scope string, userID user.UserID) error
+	// DeleteEveryScopeForUser revokes all of the user's tokens, whatever
+	// they were issued for.
+	DeleteEveryScopeForUser(ctx context.Context, userID user.UserID) error
 	ResolvePrincipal(ctx context.Context, scope, plaintext string) (*Principal, error)
+	// ConsumeToken redeems a one-time token: it is deleted as it is read.
+	ConsumeToken(ctx context.Context, scope, plaintext string) (user.UserID, error)
+	// Activation tokens remember the address they were mailed to, and
+	// only redeem while the account still has it.
+	IssueActivation(ctx context.Context, userID user.UserID, email string, ttl time.Duration) (*Token, error)
+	ConsumeActivation(ctx context.Context, plaintext string) (user.UserID, error)
+	TouchToken(ctx context.Context, hash []byte, at time.Time) error
+
+	// Sessions are token families: StartSession opens one at login with
+	// its refresh token and RotateRefreshToken extends it. Deleting a
+	// session revokes every token in it and records the revocation for
+	// the tokens that aren't stored (see RevocationList).
+	StartSession(ctx context.Context, userID user.UserID, client Client, refreshTTL time.Duration) (*Token, error)
+	RotateRefreshToken(ctx context.Context, plaintext string, client Client, refreshTTL time.Duration) (*Token, error)
+	IssueAccessToken(ctx context.Context, userID user.UserID, session SessionID, ttl time.Duration) (*Token, error)
+	ListSessions(ctx context.Context, userID user.UserID, current SessionID) ([]*Session, error)
+	DeleteSession(ctx context.Context, id SessionID, userID user.UserID) error
+	DeleteSessionsExcept(ctx context.Context, userID user.UserID, keep SessionID) error
+	DeleteAllSessions(ctx context.Context, userID user.UserID) error
+	ListSessionRevocations(ctx context.Context, since time.Time) ([]SessionID, error)
+	PruneSessionRevocations(ctx context.Context, cutoff time.Time) (int64, error)
+
+	InsertPersonalAccessToken(ctx context.Context, t *PersonalAccessToken, hash []byte, userID user.UserID) error
+	ListPersonalAccessTokens(ctx context.Context, userID user.UserID) ([]*PersonalAccessToken, error)
+	DeletePersonalAccessToken(ctx context.Context, id PersonalAccessTokenID, userID user.UserID) error
+	ResolvePersonalAccessToken(ctx context.Context, plaintext string) (*Principal, error)
+
+	SaveTwoFactorSecret(ctx context.Context, userID user.UserID, sealed []byte) error
+	GetTwoFactor(ctx context.Context, userID user.UserID) (*TwoFactor, error)
+	ConfirmTwoFactor(ctx context.Context, userID user.UserID, step int64, recoveryHashes [][]byte) error
+	UseTwoFactorStep(ctx context.Context, userID user.UserID, step int64) (bool, error)
+	UseRecoveryCode(ctx context.Context, userID user.UserID, hash []byte) (bool, error)
+	DeleteTwoFactor(ctx context.Context, userID user.UserID) error
+	FailTwoFactorAttempt(ctx context.Context, hash []byte, max int) error
+
+	// Login failures are counted per key (see loginKeys); a key that has
+	// failed too often is locked until a given time.
+	LoginLockedUntil(ctx context.Context, keys []string, now time.Time) (time.Time, error)
+	RecordLoginFailure(ctx context.Context, key string, at, resetBefore time.Time) (int, error)
+	LockLogin(ctx context.Context, key string, until time.Time) error
+	ClearLoginFailures(ctx context.Context, key string) error
+	PruneLoginFailures(ctx context.Context, cutoff time.Time) (int64, error)
 }
 
 // ErrInvalidCredentials is the single-body sentinel returned to the handler
@@ -26,53 +75,590 @@ type Store interface {
 // stays intact.
 var ErrInvalidCredentials = errors.New("invalid credentials")
 
-// tokenTTL is the default lifetime of a newly-issued authentication token.
-// Pulled up into a service-local constant so the handler doesn't hardcode it.
-const tokenTTL = 24 * time.Hour
+// ErrInvalidToken covers a one-time token (password reset) that is unknown,
+// expired or already used; the three are deliberately indistinguishable.
+var ErrInvalidToken = errors.New("invalid or expired token")
+
+// ErrAccountPendingDeletion is returned by Login, after the password
+// checked out, for an account in its deletion grace period. It is safe to
+// be specific here: only the owner gets this far.
+var ErrAccountPendingDeletion = errors.New("account is scheduled for deletion")
+
+// ErrAccountDisabled is returned by Login and RestoreAccount, after the
+// password checked out, for an account an admin has disabled.
+var ErrAccountDisabled = errors.New("account is disabled")
+
+// ErrRefreshTokenReused is a refresh token presented after it was already
+// rotated. Its family has been revoked by the time the caller sees this.
+// Callers should answer it like ErrInvalidToken.
+var ErrRefreshTokenReused = fmt.Errorf("%w: refresh token reused", ErrInvalidToken)
+
+// ErrTokenNotFound is a session or personal access token id that doesn't
+// exist or belongs to someone else → 404.
+var ErrTokenNotFound = errors.New("token not found")
+
+// ErrValidation wraps input-shape failures of the auth commands → 400.
+var ErrValidation = errors.New("auth validation failed")
+
+// Mailer delivers transactional email. Satisfied by the adapters in
+// platform/mail; production wiring wraps them in mail.Async.
+type Mailer interface {
+	Send(ctx context.Context, msg mail.Message) error
+}
+
+// Jobs runs work after the response has been written. Satisfied by
+// *jobs.Queue; Submit reports false when the job was dropped.
+type Jobs interface {
+	Submit(ctx context.Context, name string, fn func(context.Context) error) bool
+}
+
+// ScopeRefresh labels refresh tokens. The middleware only accepts ScopeAuth
+// and personal access tokens, so a refresh token can't be used as a bearer
+// token; it is only good for POST /tokens/refresh.
+const ScopeRefresh = "refresh"
+
+// Access tokens are short-lived: a leaked one is useful for minutes, and a
+// JWT outlives its session's revocation by at most that long on an instance
+// that hasn't synced. The refresh token that renews it lives for a month of
+// inactivity. Pulled up into service-local values so the handler doesn't
+// hardcode them.
+const (
+	accessTokenTTL  = 15 * time.Minute
+	refreshTokenTTL = 30 * 24 * time.Hour
+)
 
 // Service is the auth bounded context's application service. Owns login
-// (verify-then-issue) and logout (revoke-all-for-user).
+// (verify-then-issue), logout (revoke-all-for-user) and the password flows
+// that revoke tokens. access issues the session access tokens, in whichever
+// format is configured. jobs runs the work a response mustn't wait on.
+// secrets seals two-factor secrets; when it is nil, two-factor
+// authentication can't be enrolled.
 type Service struct {
 	tokenStore Store
 	userSvc    *user.Service
+	mailer     Mailer
+	jobs       Jobs
+	access     AccessTokens
+	secrets    *sealer.Sealer
+}
+
+func NewService(tokenStore Store, userSvc *user.Service, mailer Mailer, jobs Jobs, access AccessTokens, secrets *sealer.Sealer) *Service {
+	return &Service{tokenStore: tokenStore, userSvc: userSvc, mailer: mailer, jobs: jobs, access: access, secrets: secrets}
 }
 
-func NewService(tokenStore Store, userSvc *user.Service) *Service {
-	return &Service{tokenStore: tokenStore, userSvc: userSvc}
+// startSession opens a session for u and issues its first token pair.
+func (s *Service) startSession(ctx context.Context, u *user.User, client Client) (*SessionTokens, error) {
+	refresh, err := s.tokenStore.StartSession(ctx, u.ID, client.sanitize(), refreshTokenTTL)
+	if err != nil {
+		return nil, err
+	}
+	return s.issueAccess(ctx, u, refresh)
 }
 
+// issueAccess pairs refresh with a fresh access token for its session.
+func (s *Service) issueAccess(ctx context.Context, u *user.User, refresh *Token) (*SessionTokens, error) {
+	access, err := s.access.Issue(ctx, &Principal{
+		ID:        u.ID,
+		Username:  u.Username,
+		Activated: u.Activated,
+		Role:      u.Role,
+		Scope:     ScopeAuth,
+		SessionID: refresh.SessionID,
+	}, accessTokenTTL)
+	if err != nil {
+		return nil, err
+	}
+	return &SessionTokens{Access: access, Refresh: refresh}, nil
+}
+
+// sessionsRevoked tells the access-token strategy that sessions were
+// deleted; see AccessTokens.
+func (s *Service) sessionsRevoked(ctx context.Context) error {
+	return s.access.SessionsRevoked(ctx)
+}
+
+// PruneSessionRevocations forgets revocations too old to matter. Meant to
+// run periodically alongside user.Service.Purge.
+func (s *Service) PruneSessionRevocations(ctx context.Context) (int64, error) {
+	return s.tokenStore.PruneSessionRevocations(ctx, time.Now().Add(-revocationRetention))
+}
+
+// LoginCommand carries the credentials plus the client they came from,
+// which is recorded on the session.
 type LoginCommand struct {
 	Username string
 	Password string
+	Client   Client
 }
 
-// Login verifies credentials and issues a fresh token. Returns
-// ErrInvalidCredentials for both "no such user" and "wrong password" so the
-// handler can map to 401 without leaking which branch matched.
+// maxUserAgentLen caps what is stored of a User-Agent header.
+const maxUserAgentLen = 512
+
+// sanitize trims c to what the store accepts.
+func (c Client) sanitize() Client {
+	if len(c.UserAgent) > maxUserAgentLen {
+		c.UserAgent = c.UserAgent[:maxUserAgentLen]
+	}
+	// Postgres TEXT rejects invalid UTF-8, which a header may well carry.
+	c.UserAgent = strings.ToValidUTF8(c.UserAgent, "")
+	return c
+}
+
+// Login verifies credentials and starts a session, or, when the account
+// has two-factor authentication on, issues the 2fa-pending token that
+// CompleteTwoFactorLogin redeems for one. Returns ErrInvalidCredentials for
+// both "no such user" and "wrong password" so the handler can map to 401
+// without leaking which branch matched.
 //
 // The ErrNotFound branch intentionally falls through to VerifyPassword with
-// u == nil — VerifyPassword runs bcrypt against a package-level dummy hash
-// in that case, so the response timing for "missing user" matches the
-// "wrong password" path. Skipping bcrypt on ErrNotFound would reintroduce
-// the enumeration side-channel C5 closed.
-func (s *Service) Login(ctx context.Context, cmd LoginCommand) (*Token, error) {
+// u == nil — VerifyPassword hashes against a dummy hash made by the same
+// Hasher in that case, so the response timing for "missing user" matches
+// the "wrong password" path. Skipping the hash on ErrNotFound would
+// reintroduce the enumeration side-channel C5 closed.
+//
+// Failures are counted per username and per client IP; past a few, the
+// login is refused with a *LoginThrottledError before anything else runs
+// (see throttle.go). Both branches above count and lock alike, so the
+// throttle keeps them indistinguishable too.
+func (s *Service) Login(ctx context.Context, cmd LoginCommand) (*LoginResult, error) {
+	keys := loginKeys(cmd.Username, cmd.Client)
+	if err := s.checkLoginThrottle(ctx, keys); err != nil {
+		return nil, err
+	}
+
 	u, err := s.userSvc.FindByUsername(ctx, cmd.Username)
 	if err != nil && !errors.Is(err, user.ErrNotFound) {
 		return nil, err
 	}
 
-	ok, err := user.VerifyPassword(u, cmd.Password)
+	ok, err := s.userSvc.VerifyPassword(ctx, u, cmd.Password)
 	if err != nil {
 		return nil, err
 	}
 	if !ok {
+		if err := s.recordLoginFailure(ctx, keys); err != nil {
+			return nil, err
+		}
 		return nil, ErrInvalidCredentials
 	}
+	tf, err := s.enabledTwoFactor(ctx, u.ID)
+	if err != nil {
+		return nil, err
+	}
+	// With two-factor on, the password alone isn't a successful login:
+	// CompleteTwoFactorLogin clears the failures once the code is right.
+	if tf == nil {
+		if err := s.clearLoginFailures(ctx, keys); err != nil {
+			return nil, err
+		}
+	}
+	if u.Disabled() {
+		return nil, ErrAccountDisabled
+	}
+	if u.PendingDeletion() {
+		return nil, ErrAccountPendingDeletion
+	}
+
+	if tf != nil {
+		token, err := s.tokenStore.Issue(ctx, u.ID, twoFactorPendingTTL, ScopeTwoFactorPending)
+		if err != nil {
+			return nil, err
+		}
+		return &LoginResult{TwoFactorToken: token}, nil
+	}
+
+	session, err := s.startSession(ctx, u, cmd.Client)
+	if err != nil {
+		return nil, err
+	}
+	return &LoginResult{Session: session}, nil
+}
 
-	return s.tokenStore.Issue(ctx, u.ID, tokenTTL, ScopeAuth)
+// Refresh spends a refresh token and returns the session's next access and
+// refresh token. Presenting a spent refresh token revokes the whole session
+// (ErrRefreshTokenReused): either the client or an attacker is replaying
+// it, and there's no telling which.
+//
+// The user is read afresh so the new access token carries current claims.
+func (s *Service) Refresh(ctx context.Context, plaintext string, client Client) (*SessionTokens, error) {
+	if plaintext == "" {
+		return nil, ErrInvalidToken
+	}
+	refresh, err := s.tokenStore.RotateRefreshToken(ctx, plaintext, client.sanitize(), refreshTokenTTL)
+	if errors.Is(err, ErrRefreshTokenReused) {
+		if serr := s.sessionsRevoked(ctx); serr != nil {
+			return nil, serr
+		}
+		return nil, err
+	}
+	if err != nil {
+		return nil, err
+	}
+	u, err := s.userSvc.Get(ctx, refresh.UserID)
+	if err != nil {
+		return nil, err
+	}
+	return s.issueAccess(ctx, u, refresh)
 }
 
-// Logout revokes every auth-scoped token for the given principal.
+// Logout ends every session of the given principal, refresh tokens
+// included.
 func (s *Service) Logout(ctx context.Context, principalID user.UserID) error {
-	return s.tokenStore.DeleteAllForUser(ctx, ScopeAuth, principalID)
+	if err := s.tokenStore.DeleteAllSessions(ctx, principalID); err != nil {
+		return err
+	}
+	return s.sessionsRevoked(ctx)
+}
+
+// RevokeAllTokens signs the user out everywhere and voids every token they
+// hold: sessions, personal access tokens and any pending reset,
+// activation or two-factor login. It is the admin API's force sign-out.
+func (s *Service) RevokeAllTokens(ctx context.Context, userID user.UserID) error {
+	if err := s.tokenStore.DeleteEveryScopeForUser(ctx, userID); err != nil {
+		return err
+	}
+	return s.sessionsRevoked(ctx)
+}
+
+// LogoutSession ends only the session p authenticated with, signing out
+// this one device.
+func (s *Service) LogoutSession(ctx context.Context, p *Principal) error {
+	return s.RevokeSession(ctx, p.SessionID, p.ID)
+}
+
+// ListSessions returns p's signed-in devices, marking the one p is using.
+func (s *Service) ListSessions(ctx context.Context, p *Principal) ([]*Session, error) {
+	return s.tokenStore.ListSessions(ctx, p.ID, p.SessionID)
+}
+
+// RevokeSession signs one of the user's devices out.
+func (s *Service) RevokeSession(ctx context.Context, id SessionID, userID user.UserID) error {
+	if err := s.tokenStore.DeleteSession(ctx, id, userID); err != nil {
+		return err
+	}
+	return s.sessionsRevoked(ctx)
+}
+
+type ChangePasswordCommand struct {
+	Principal       *Principal
+	CurrentPassword string
+	NewPassword     string
+}
+
+// ChangePassword sets a new password and then signs the user out everywhere
+// else: every other session is revoked, while the one that made the call
+// keeps working. Errors are user.Service.ChangePassword's.
+func (s *Service) ChangePassword(ctx context.Context, cmd ChangePasswordCommand) error {
+	if err := s.userSvc.ChangePassword(ctx, cmd.Principal.ID, cmd.CurrentPassword, cmd.NewPassword); err != nil {
+		return err
+	}
+	if err := s.tokenStore.DeleteSessionsExcept(ctx, cmd.Principal.ID, cmd.Principal.SessionID); err != nil {
+		return err
+	}
+	return s.sessionsRevoked(ctx)
+}
+
+// ScopePasswordReset labels the one-time tokens mailed by
+// RequestPasswordReset. The middleware only accepts ScopeAuth, so a reset
+// token can't be used as a bearer token.
+const ScopePasswordReset = "password-reset"
+
+// passwordResetTTL is short: the token sits in a mailbox.
+const passwordResetTTL = 30 * time.Minute
+
+// RequestPasswordReset mails a reset token to the account with that email,
+// if there is one. The lookup, the token and the mail all happen in a
+// background job and the call returns nil at once either way, so neither
+// the answer nor its timing tells the caller whether the address is
+// registered: the same protection Login gives usernames.
+func (s *Service) RequestPasswordReset(ctx context.Context, email string) error {
+	s.jobs.Submit(ctx, "password reset", func(ctx context.Context) error {
+		return s.sendPasswordReset(ctx, email)
+	})
+	return nil
+}
+
+// sendPasswordReset is the body of RequestPasswordReset's job.
+func (s *Service) sendPasswordReset(ctx context.Context, email string) error {
+	u, err := s.userSvc.FindByEmail(ctx, email)
+	if errors.Is(err, user.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	token, err := s.tokenStore.Issue(ctx, u.ID, passwordResetTTL, ScopePasswordReset)
+	if err != nil {
+		return err
+	}
+	return s.mailer.Send(ctx, mail.Message{
+		To:      u.Email.String(),
+		Subject: "Reset your go-fit password",
+		Body: fmt.Sprintf(`Hi %s,
+
+Someone asked to reset the password of your go-fit account. If it was you,
+use this token within %d minutes:
+
+    %s
+
+Send it with your new password to PUT /users/password. If it wasn't you,
+ignore this email; your password stays as it is.
+`, u.Username, int(passwordResetTTL.Minutes()), token.Plaintext),
+	})
+}
+
+// ResetPassword redeems a reset token and sets a new password. On success
+// every reset token, session, personal access token and pending two-factor
+// login of the user is revoked, since a reset usually means the old
+// password can't be trusted. Two-factor authentication stays on: a reset
+// proves the mailbox, not the second factor. A password that fails
+// validation leaves the token usable for another try; otherwise the token
+// is consumed before the password changes, so of two concurrent requests
+// with it only one gets through.
+func (s *Service) ResetPassword(ctx context.Context, plaintext, newPassword string) error {
+	p, err := s.tokenStore.ResolvePrincipal(ctx, ScopePasswordReset, plaintext)
+	if err != nil {
+		return err
+	}
+	if p == nil {
+		return ErrInvalidToken
+	}
+	if err := s.userSvc.ValidatePassword(ctx, p.ID, newPassword); err != nil {
+		return err
+	}
+
+	userID, err := s.tokenStore.ConsumeToken(ctx, ScopePasswordReset, plaintext)
+	if err != nil {
+		return err
+	}
+	if err := s.userSvc.SetPassword(ctx, userID, newPassword); err != nil {
+		return err
+	}
+	for _, scope := range []string{ScopePasswordReset, ScopePersonalAccess, ScopeTwoFactorPending} {
+		if err := s.tokenStore.DeleteAllForUser(ctx, scope, userID); err != nil {
+			return err
+		}
+	}
+	if err := s.tokenStore.DeleteAllSessions(ctx, userID); err != nil {
+		return err
+	}
+	return s.sessionsRevoked(ctx)
+}
+
+// ScopeActivation labels the tokens that confirm an account's email.
+const ScopeActivation = "activation"
+
+// activationTTL gives people a few days to get to their inbox.
+const activationTTL = 3 * 24 * time.Hour
+
+// SendActivation mails u a fresh activation token, first revoking any
+// earlier one. The token is bound to the address it is sent to, so it
+// stops working if the email changes before it is redeemed.
+func (s *Service) SendActivation(ctx context.Context, u *user.User) error {
+	if err := s.tokenStore.DeleteAllForUser(ctx, ScopeActivation, u.ID); err != nil {
+		return err
+	}
+	token, err := s.tokenStore.IssueActivation(ctx, u.ID, u.Email.String(), activationTTL)
+	if err != nil {
+		return err
+	}
+	return s.mailer.Send(ctx, mail.Message{
+		To:      u.Email.String(),
+		Subject: "Activate your go-fit account",
+		Body: fmt.Sprintf(`Hi %s,
+
+Confirm this email address for your go-fit account with this token
+within %d days:
+
+    %s
+
+Send it to PUT /users/activated. If you didn't sign up, ignore this email.
+`, u.Username, int(activationTTL.Hours()/24), token.Plaintext),
+	})
+}
+
+// Activate redeems an activation token and marks the account activated.
+// A token mailed to an address the account no longer has is
+// ErrInvalidToken, like an unknown or expired one.
+func (s *Service) Activate(ctx context.Context, plaintext string) (*user.User, error) {
+	userID, err := s.tokenStore.ConsumeActivation(ctx, plaintext)
+	if err != nil {
+		return nil, err
+	}
+
+	u, err := s.userSvc.Activate(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.tokenStore.DeleteAllForUser(ctx, ScopeActivation, userID); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+type DeleteAccountCommand struct {
+	Principal *Principal
+	Password  string
+}
+
+// AccountDeletion says when a deletion was requested and when it becomes
+// final.
+type AccountDeletion struct {
+	RequestedAt time.Time `json:"deletion_requested_at"`
+	PurgeAfter  time.Time `json:"purge_after"`
+}
+
+// DeleteAccount schedules the caller's account for deletion and revokes
+// every token it has, signing it out everywhere. The account can be
+// restored with RestoreAccount until the purge job erases it.
+func (s *Service) DeleteAccount(ctx context.Context, cmd DeleteAccountCommand) (*AccountDeletion, error) {
+	u, err := s.userSvc.RequestDeletion(ctx, cmd.Principal.ID, cmd.Password)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.RevokeAllTokens(ctx, u.ID); err != nil {
+		return nil, err
+	}
+	return &AccountDeletion{RequestedAt: *u.DeletionRequestedAt, PurgeAfter: s.userSvc.PurgeAfter(u)}, nil
+}
+
+// RestoreAccountCommand is LoginCommand plus the two-factor code an account
+// with 2FA on must give. Restoring can't go through a 2fa-pending token:
+// an account pending deletion holds no tokens.
+type RestoreAccountCommand struct {
+	LoginCommand
+	Code string
+}
+
+// RestoreAccount is Login for an account in its deletion grace period: the
+// same credential check, and the second factor if it is on, then the
+// deletion is cancelled and a session started. On an account that isn't
+// pending deletion it is simply Login. It shares Login's throttle, and a
+// wrong two-factor code counts as a failure: there is no pending token
+// here to cap the guesses.
+func (s *Service) RestoreAccount(ctx context.Context, cmd RestoreAccountCommand) (*SessionTokens, error) {
+	keys := loginKeys(cmd.Username, cmd.Client)
+	if err := s.checkLoginThrottle(ctx, keys); err != nil {
+		return nil, err
+	}
+
+	u, err := s.userSvc.FindByUsername(ctx, cmd.Username)
+	if err != nil && !errors.Is(err, user.ErrNotFound) {
+		return nil, err
+	}
+
+	ok, err := s.userSvc.VerifyPassword(ctx, u, cmd.Password)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		if err := s.recordLoginFailure(ctx, keys); err != nil {
+			return nil, err
+		}
+		return nil, ErrInvalidCredentials
+	}
+	tf, err := s.enabledTwoFactor(ctx, u.ID)
+	if err != nil {
+		return nil, err
+	}
+	if tf != nil {
+		if cmd.Code == "" {
+			return nil, ErrTwoFactorRequired
+		}
+		if err := s.checkTwoFactorCode(ctx, u.ID, tf, cmd.Code); err != nil {
+			if errors.Is(err, ErrInvalidTwoFactorCode) {
+				if ferr := s.recordLoginFailure(ctx, keys); ferr != nil {
+					return nil, ferr
+				}
+			}
+			return nil, err
+		}
+	}
+	if err := s.clearLoginFailures(ctx, keys); err != nil {
+		return nil, err
+	}
+	if u.Disabled() {
+		return nil, ErrAccountDisabled
+	}
+	if u.PendingDeletion() {
+		if err := s.userSvc.CancelDeletion(ctx, u.ID); err != nil {
+			return nil, err
+		}
+		u.DeletionRequestedAt = nil
+	}
+
+	return s.startSession(ctx, u, cmd.Client)
+}
+
+// ScopePersonalAccess labels long-lived tokens created by the user for
+// scripts and integrations. Unlike ScopeAuth they are limited to the
+// Permissions they were created with.
+const ScopePersonalAccess = "personal-access"
+
+// PersonalAccessTokenPrefix starts every personal access token, so the
+// middleware knows which kind of token it was handed and secret scanners
+// can recognise a leaked one.
+const PersonalAccessTokenPrefix = "gofit_pat_"
+
+// maxPersonalAccessTTL bounds the expiry a personal access token can be
+// given. Tokens without one live until revoked.
+const maxPersonalAccessTTL = 365 * 24 * time.Hour
+
+// maxTokenNameLen keeps names to something a listing can show.
+const maxTokenNameLen = 100
+
+// CreatePersonalAccessTokenCommand asks for a new personal access token. A
+// nil TTL creates a token that lives until revoked.
+type CreatePersonalAccessTokenCommand struct {
+	UserID      user.UserID
+	Name        string
+	Permissions []string
+	TTL         *time.Duration
+}
+
+// CreatePersonalAccessToken mints a token with GenerateToken, like every
+// other token, and prefixes it with PersonalAccessTokenPrefix. The
+// plaintext is returned on the result exactly once.
+func (s *Service) CreatePersonalAccessToken(ctx context.Context, cmd CreatePersonalAccessTokenCommand) (*PersonalAccessToken, error) {
+	if cmd.Name == "" || len(cmd.Name) > maxTokenNameLen {
+		return nil, fmt.Errorf("%w: name must be 1 to %d bytes long", ErrValidation, maxTokenNameLen)
+	}
+	perms, err := parsePermissions(cmd.Permissions)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrValidation, err)
+	}
+	var ttl time.Duration
+	if cmd.TTL != nil {
+		ttl = *cmd.TTL
+		if ttl <= 0 || ttl > maxPersonalAccessTTL {
+			return nil, fmt.Errorf("%w: expiry must be between now and %d days out", ErrValidation, int(maxPersonalAccessTTL.Hours()/24))
+		}
+	}
+
+	token, err := GenerateToken(cmd.UserID, ttl, ScopePersonalAccess)
+	if err != nil {
+		return nil, fmt.Errorf("generate personal access token: %w", err)
+	}
+
+	pat := &PersonalAccessToken{
+		Name:        cmd.Name,
+		Permissions: perms,
+		Token:       PersonalAccessTokenPrefix + token.Plaintext,
+	}
+	if cmd.TTL != nil {
+		pat.Expiry = &token.Expiry
+	}
+	if err := s.tokenStore.InsertPersonalAccessToken(ctx, pat, HashPlaintext(pat.Token), cmd.UserID); err != nil {
+		return nil, err
+	}
+	return pat, nil
+}
+
+func (s *Service) ListPersonalAccessTokens(ctx context.Context, userID user.UserID) ([]*PersonalAccessToken, error) {
+	return s.tokenStore.ListPersonalAccessTokens(ctx, userID)
+}
+
+func (s *Service) RevokePersonalAccessToken(ctx context.Context, id PersonalAccessTokenID, userID user.UserID) error {
+	return s.tokenStore.DeletePersonalAccessToken(ctx, id, userID)
 }
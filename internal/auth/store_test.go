@@ -0,0 +1,438 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/tsatsarisg/go-fit/internal/platform/mail"
+	"github.com/tsatsarisg/go-fit/internal/platform/postgres/pgtest"
+	"github.com/tsatsarisg/go-fit/internal/platform/sealer"
+	"github.com/tsatsarisg/go-fit/internal/platform/totp"
+	"github.com/tsatsarisg/go-fit/internal/user"
+)
+
+func setupTestDB(t *testing.T) *sql.DB {
+	return pgtest.Open(t, "auth", "users", "login_failures")
+}
+
+func TestRefreshTokenRotation(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	ctx := context.Background()
+	userSvc := user.NewService(user.NewPostgresStore(db), user.NewBcryptHasher(bcrypt.MinCost), user.PasswordPolicy{}, time.Hour)
+	store := NewPostgresStore(db)
+	svc := NewService(store, userSvc, nil, nil, NewOpaqueAccessTokens(store), nil)
+
+	_, err := userSvc.Register(ctx, user.RegisterCommand{Username: "alice", Email: "alice@example.com", Password: "correct horse battery"})
+	require.NoError(t, err)
+	login := LoginCommand{Username: "alice", Password: "correct horse battery", Client: Client{UserAgent: "laptop", IP: "192.0.2.1"}}
+
+	first, err := svc.Login(ctx, login)
+	require.NoError(t, err)
+	second, err := svc.Login(ctx, login)
+	require.NoError(t, err)
+
+	rotated, err := svc.Refresh(ctx, first.Session.Refresh.Plaintext, Client{UserAgent: "laptop", IP: "192.0.2.2"})
+	require.NoError(t, err)
+	assert.NotEqual(t, first.Session.Refresh.Plaintext, rotated.Refresh.Plaintext)
+
+	p, err := store.ResolvePrincipal(ctx, ScopeAuth, rotated.Access.Plaintext)
+	require.NoError(t, err)
+	require.NotNil(t, p)
+	sessions, err := svc.ListSessions(ctx, p)
+	require.NoError(t, err)
+	require.Len(t, sessions, 2)
+	assert.True(t, sessions[1].Current, "the rotated pair stays in the first login's session")
+	assert.Equal(t, "192.0.2.2", sessions[1].IP)
+
+	// Replaying the spent token revokes the whole family: the rotated pair
+	// stops working too, while the other login is untouched.
+	_, err = svc.Refresh(ctx, first.Session.Refresh.Plaintext, login.Client)
+	assert.ErrorIs(t, err, ErrRefreshTokenReused)
+	_, err = svc.Refresh(ctx, rotated.Refresh.Plaintext, login.Client)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+	p, err = store.ResolvePrincipal(ctx, ScopeAuth, rotated.Access.Plaintext)
+	assert.NoError(t, err)
+	assert.Nil(t, p)
+
+	_, err = svc.Refresh(ctx, second.Session.Refresh.Plaintext, login.Client)
+	assert.NoError(t, err)
+
+	// A refresh token is not a bearer token.
+	p, err = store.ResolvePrincipal(ctx, ScopeAuth, second.Session.Refresh.Plaintext)
+	assert.NoError(t, err)
+	assert.Nil(t, p)
+}
+
+func TestDeleteSessionsExcept(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	ctx := context.Background()
+	userSvc := user.NewService(user.NewPostgresStore(db), user.NewBcryptHasher(bcrypt.MinCost), user.PasswordPolicy{}, time.Hour)
+	store := NewPostgresStore(db)
+	svc := NewService(store, userSvc, nil, nil, NewOpaqueAccessTokens(store), nil)
+
+	alice, err := userSvc.Register(ctx, user.RegisterCommand{Username: "alice", Email: "alice@example.com", Password: "correct horse battery"})
+	require.NoError(t, err)
+	_, err = userSvc.Register(ctx, user.RegisterCommand{Username: "bob", Email: "bob@example.com", Password: "correct horse battery"})
+	require.NoError(t, err)
+
+	current, err := svc.Login(ctx, LoginCommand{Username: "alice", Password: "correct horse battery"})
+	require.NoError(t, err)
+	other, err := svc.Login(ctx, LoginCommand{Username: "alice", Password: "correct horse battery"})
+	require.NoError(t, err)
+	bob, err := svc.Login(ctx, LoginCommand{Username: "bob", Password: "correct horse battery"})
+	require.NoError(t, err)
+	pat, err := svc.CreatePersonalAccessToken(ctx, CreatePersonalAccessTokenCommand{UserID: alice.ID, Name: "cli", Permissions: []string{string(PermissionWorkoutsRead)}})
+	require.NoError(t, err)
+
+	p, err := store.ResolvePrincipal(ctx, ScopeAuth, current.Session.Access.Plaintext)
+	require.NoError(t, err)
+	require.NotNil(t, p)
+	require.NoError(t, store.DeleteSessionsExcept(ctx, alice.ID, p.SessionID))
+
+	// The caller's session keeps working, access and refresh alike.
+	p, err = store.ResolvePrincipal(ctx, ScopeAuth, current.Session.Access.Plaintext)
+	assert.NoError(t, err)
+	assert.NotNil(t, p)
+	_, err = svc.Refresh(ctx, current.Session.Refresh.Plaintext, Client{})
+	assert.NoError(t, err)
+
+	// The other session is revoked.
+	p, err = store.ResolvePrincipal(ctx, ScopeAuth, other.Session.Access.Plaintext)
+	assert.NoError(t, err)
+	assert.Nil(t, p)
+	_, err = svc.Refresh(ctx, other.Session.Refresh.Plaintext, Client{})
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	// Personal access tokens and other users' sessions are not sessions of
+	// the caller and survive.
+	p, err = store.ResolvePersonalAccessToken(ctx, pat.Token)
+	assert.NoError(t, err)
+	assert.NotNil(t, p)
+	p, err = store.ResolvePrincipal(ctx, ScopeAuth, bob.Session.Access.Plaintext)
+	assert.NoError(t, err)
+	assert.NotNil(t, p)
+}
+
+func TestTwoFactorLogin(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	ctx := context.Background()
+	userSvc := user.NewService(user.NewPostgresStore(db), user.NewBcryptHasher(bcrypt.MinCost), user.PasswordPolicy{}, time.Hour)
+	store := NewPostgresStore(db)
+	secrets, err := sealer.New(bytes.Repeat([]byte{7}, sealer.KeySize))
+	require.NoError(t, err)
+	svc := NewService(store, userSvc, nil, nil, NewOpaqueAccessTokens(store), secrets)
+
+	u, err := userSvc.Register(ctx, user.RegisterCommand{Username: "alice", Email: "alice@example.com", Password: "correct horse battery"})
+	require.NoError(t, err)
+	p := &Principal{ID: u.ID, Username: u.Username, Scope: ScopeAuth}
+	login := LoginCommand{Username: "alice", Password: "correct horse battery"}
+
+	enrollment, err := svc.EnrollTwoFactor(ctx, p)
+	require.NoError(t, err)
+	secret, err := recoveryEncoding.DecodeString(enrollment.Secret)
+	require.NoError(t, err)
+
+	// Until confirmed, login is unchanged.
+	result, err := svc.Login(ctx, login)
+	require.NoError(t, err)
+	require.NotNil(t, result.Session)
+
+	step := totp.Step(time.Now())
+	_, err = svc.ConfirmTwoFactor(ctx, p, "000000")
+	assert.ErrorIs(t, err, ErrInvalidTwoFactorCode)
+	codes, err := svc.ConfirmTwoFactor(ctx, p, totp.Code(secret, step))
+	require.NoError(t, err)
+	require.Len(t, codes, recoveryCodeCount)
+
+	result, err = svc.Login(ctx, login)
+	require.NoError(t, err)
+	require.Nil(t, result.Session)
+	require.NotNil(t, result.TwoFactorToken)
+	pending := result.TwoFactorToken.Plaintext
+
+	_, err = svc.CompleteTwoFactorLogin(ctx, TwoFactorLoginCommand{Token: pending, Code: totp.Code(secret, step)})
+	assert.ErrorIs(t, err, ErrInvalidTwoFactorCode, "the confirming code can't be replayed")
+
+	tokens, err := svc.CompleteTwoFactorLogin(ctx, TwoFactorLoginCommand{Token: pending, Code: strings.ToLower(codes[0])})
+	require.NoError(t, err)
+	assert.NotEmpty(t, tokens.Access.Plaintext)
+	_, err = svc.CompleteTwoFactorLogin(ctx, TwoFactorLoginCommand{Token: pending, Code: totp.Code(secret, step+1)})
+	assert.ErrorIs(t, err, ErrInvalidToken, "the pending token is spent")
+
+	// A recovery code works once, and enough wrong codes burn the token.
+	result, err = svc.Login(ctx, login)
+	require.NoError(t, err)
+	pending = result.TwoFactorToken.Plaintext
+	for range maxTwoFactorAttempts {
+		_, err = svc.CompleteTwoFactorLogin(ctx, TwoFactorLoginCommand{Token: pending, Code: codes[0]})
+		assert.ErrorIs(t, err, ErrInvalidTwoFactorCode)
+	}
+	_, err = svc.CompleteTwoFactorLogin(ctx, TwoFactorLoginCommand{Token: pending, Code: codes[1]})
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	err = svc.DisableTwoFactor(ctx, DisableTwoFactorCommand{Principal: p, Password: "correct horse battery", Code: codes[1]})
+	require.NoError(t, err)
+	result, err = svc.Login(ctx, login)
+	require.NoError(t, err)
+	assert.NotNil(t, result.Session)
+}
+
+func TestTwoFactorLoginThrottle(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	ctx := context.Background()
+	userSvc := user.NewService(user.NewPostgresStore(db), user.NewBcryptHasher(bcrypt.MinCost), user.PasswordPolicy{}, time.Hour)
+	store := NewPostgresStore(db)
+	secrets, err := sealer.New(bytes.Repeat([]byte{7}, sealer.KeySize))
+	require.NoError(t, err)
+	svc := NewService(store, userSvc, nil, nil, NewOpaqueAccessTokens(store), secrets)
+
+	u, err := userSvc.Register(ctx, user.RegisterCommand{Username: "alice", Email: "alice@example.com", Password: "correct horse battery"})
+	require.NoError(t, err)
+	p := &Principal{ID: u.ID, Username: u.Username, Scope: ScopeAuth}
+	enrollment, err := svc.EnrollTwoFactor(ctx, p)
+	require.NoError(t, err)
+	secret, err := recoveryEncoding.DecodeString(enrollment.Secret)
+	require.NoError(t, err)
+	step := totp.Step(time.Now())
+	codes, err := svc.ConfirmTwoFactor(ctx, p, totp.Code(secret, step))
+	require.NoError(t, err)
+
+	login := LoginCommand{Username: "alice", Password: "correct horse battery"}
+	wrong := totp.Code(secret, step-10)
+
+	// The right password doesn't reset the count: burning one pending token
+	// and logging in again leaves the wrong codes counted, and the next one
+	// locks the login.
+	var pending string
+	for range 2 {
+		result, err := svc.Login(ctx, login)
+		require.NoError(t, err)
+		require.NotNil(t, result.TwoFactorToken)
+		pending = result.TwoFactorToken.Plaintext
+		for range maxTwoFactorAttempts {
+			_, err = svc.CompleteTwoFactorLogin(ctx, TwoFactorLoginCommand{Token: pending, Code: wrong})
+			if errors.Is(err, ErrLoginThrottled) {
+				break
+			}
+			require.ErrorIs(t, err, ErrInvalidTwoFactorCode)
+		}
+	}
+	var throttled *LoginThrottledError
+	_, err = svc.Login(ctx, login)
+	require.ErrorAs(t, err, &throttled)
+	assert.Positive(t, throttled.RetryAfter)
+
+	// Even a right code waits out the lock.
+	_, err = svc.CompleteTwoFactorLogin(ctx, TwoFactorLoginCommand{Token: pending, Code: codes[0]})
+	assert.ErrorIs(t, err, ErrLoginThrottled)
+}
+
+func TestLoginThrottle(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	ctx := context.Background()
+	userSvc := user.NewService(user.NewPostgresStore(db), user.NewBcryptHasher(bcrypt.MinCost), user.PasswordPolicy{}, time.Hour)
+	store := NewPostgresStore(db)
+	svc := NewService(store, userSvc, nil, nil, NewOpaqueAccessTokens(store), nil)
+
+	_, err := userSvc.Register(ctx, user.RegisterCommand{Username: "alice", Email: "alice@example.com", Password: "correct horse battery"})
+	require.NoError(t, err)
+	client := Client{IP: "203.0.113.7"}
+	wrong := LoginCommand{Username: "alice", Password: "wrong", Client: client}
+
+	for range usernameBackoff.free {
+		_, err := svc.Login(ctx, wrong)
+		require.ErrorIs(t, err, ErrInvalidCredentials)
+	}
+	_, err = svc.Login(ctx, wrong)
+	require.ErrorIs(t, err, ErrInvalidCredentials, "the failure that locks is still answered normally")
+
+	_, err = svc.Login(ctx, LoginCommand{Username: "alice", Password: "correct horse battery", Client: client})
+	var throttled *LoginThrottledError
+	require.ErrorAs(t, err, &throttled, "locked even with the right password")
+	assert.ErrorIs(t, err, ErrLoginThrottled)
+	assert.InDelta(t, usernameBackoff.base.Seconds(), throttled.RetryAfter.Seconds(), 5)
+
+	// An unknown username locks the same way.
+	for range usernameBackoff.free + 1 {
+		_, err := svc.Login(ctx, LoginCommand{Username: "nobody", Password: "wrong"})
+		require.ErrorIs(t, err, ErrInvalidCredentials)
+	}
+	_, err = svc.Login(ctx, LoginCommand{Username: "nobody", Password: "wrong"})
+	assert.ErrorIs(t, err, ErrLoginThrottled)
+
+	// Once the lock is over, a successful login forgets the failures.
+	_, err = db.ExecContext(ctx, `UPDATE login_failures SET locked_until = NULL`)
+	require.NoError(t, err)
+	_, err = svc.Login(ctx, LoginCommand{Username: "alice", Password: "correct horse battery", Client: client})
+	require.NoError(t, err)
+	_, err = svc.Login(ctx, wrong)
+	require.ErrorIs(t, err, ErrInvalidCredentials, "the count started over")
+}
+
+// inlineJobs runs each job before Submit returns.
+type inlineJobs struct{ t *testing.T }
+
+func (j inlineJobs) Submit(ctx context.Context, name string, fn func(context.Context) error) bool {
+	j.t.Helper()
+	require.NoError(j.t, fn(ctx), name)
+	return true
+}
+
+// outbox keeps what it is asked to send.
+type outbox []mail.Message
+
+func (o *outbox) Send(_ context.Context, msg mail.Message) error {
+	*o = append(*o, msg)
+	return nil
+}
+
+// lastToken pulls the token out of the last mail.
+func (o *outbox) lastToken(t *testing.T) string {
+	t.Helper()
+	require.NotEmpty(t, *o)
+	for _, line := range strings.Split((*o)[len(*o)-1].Body, "\n") {
+		if strings.HasPrefix(line, "    ") {
+			return strings.TrimSpace(line)
+		}
+	}
+	t.Fatal("no token in the mail")
+	return ""
+}
+
+func TestPasswordReset(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	ctx := context.Background()
+	userSvc := user.NewService(user.NewPostgresStore(db), user.NewBcryptHasher(bcrypt.MinCost), user.PasswordPolicy{}, time.Hour)
+	store := NewPostgresStore(db)
+	var sent outbox
+	svc := NewService(store, userSvc, &sent, inlineJobs{t}, NewOpaqueAccessTokens(store), nil)
+
+	u, err := userSvc.Register(ctx, user.RegisterCommand{Username: "alice", Email: "alice@example.com", Password: "correct horse battery"})
+	require.NoError(t, err)
+	login, err := svc.Login(ctx, LoginCommand{Username: "alice", Password: "correct horse battery"})
+	require.NoError(t, err)
+	pat, err := svc.CreatePersonalAccessToken(ctx, CreatePersonalAccessTokenCommand{UserID: u.ID, Name: "cli", Permissions: []string{string(PermissionWorkoutsRead)}})
+	require.NoError(t, err)
+
+	require.NoError(t, svc.RequestPasswordReset(ctx, "nobody@example.com"))
+	assert.Empty(t, sent, "an unknown address gets no mail")
+
+	require.NoError(t, svc.RequestPasswordReset(ctx, "alice@example.com"))
+	require.Len(t, sent, 1)
+	assert.Equal(t, "alice@example.com", sent[0].To)
+	token := sent.lastToken(t)
+
+	assert.ErrorIs(t, svc.ResetPassword(ctx, "not-a-token", "a brand new password"), ErrInvalidToken)
+	assert.ErrorIs(t, svc.ResetPassword(ctx, token, "short"), user.ErrValidation)
+
+	require.NoError(t, svc.ResetPassword(ctx, token, "a brand new password"), "a failed validation leaves the token usable")
+	assert.ErrorIs(t, svc.ResetPassword(ctx, token, "another new password"), ErrInvalidToken, "the token is single use")
+
+	// Every way in that predates the reset is gone.
+	p, err := store.ResolvePrincipal(ctx, ScopeAuth, login.Session.Access.Plaintext)
+	assert.NoError(t, err)
+	assert.Nil(t, p)
+	_, err = svc.Refresh(ctx, login.Session.Refresh.Plaintext, Client{})
+	assert.ErrorIs(t, err, ErrInvalidToken)
+	p, err = store.ResolvePersonalAccessToken(ctx, pat.Token)
+	assert.NoError(t, err)
+	assert.Nil(t, p)
+
+	_, err = svc.Login(ctx, LoginCommand{Username: "alice", Password: "correct horse battery"})
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+	_, err = svc.Login(ctx, LoginCommand{Username: "alice", Password: "a brand new password"})
+	assert.NoError(t, err)
+
+	// An expired token is refused like an unknown one.
+	require.NoError(t, svc.RequestPasswordReset(ctx, "alice@example.com"))
+	expired := sent.lastToken(t)
+	_, err = db.ExecContext(ctx, `UPDATE tokens SET expiry = now() - interval '1 minute' WHERE scope = $1`, ScopePasswordReset)
+	require.NoError(t, err)
+	assert.ErrorIs(t, svc.ResetPassword(ctx, expired, "yet another password"), ErrInvalidToken)
+}
+
+func TestActivation(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	ctx := context.Background()
+	userSvc := user.NewService(user.NewPostgresStore(db), user.NewBcryptHasher(bcrypt.MinCost), user.PasswordPolicy{}, time.Hour)
+	store := NewPostgresStore(db)
+	var sent outbox
+	svc := NewService(store, userSvc, &sent, inlineJobs{t}, NewOpaqueAccessTokens(store), nil)
+
+	register := func(name string) *user.User {
+		u, err := userSvc.Register(ctx, user.RegisterCommand{Username: name, Email: name + "@example.com", Password: "correct horse battery"})
+		require.NoError(t, err)
+		require.False(t, u.Activated)
+		return u
+	}
+
+	t.Run("redeem", func(t *testing.T) {
+		u := register("alice")
+		require.NoError(t, svc.SendActivation(ctx, u))
+		assert.Equal(t, "alice@example.com", sent[len(sent)-1].To)
+		stale := sent.lastToken(t)
+		require.NoError(t, svc.SendActivation(ctx, u), "a resend")
+		token := sent.lastToken(t)
+
+		_, err := svc.Activate(ctx, stale)
+		assert.ErrorIs(t, err, ErrInvalidToken, "a resend revokes the earlier token")
+		activated, err := svc.Activate(ctx, token)
+		require.NoError(t, err)
+		assert.True(t, activated.Activated)
+		_, err = svc.Activate(ctx, token)
+		assert.ErrorIs(t, err, ErrInvalidToken, "the token is single use")
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		u := register("bob")
+		require.NoError(t, svc.SendActivation(ctx, u))
+		_, err := db.ExecContext(ctx, `UPDATE tokens SET expiry = now() - interval '1 minute' WHERE user_id = $1 AND scope = $2`, u.ID, ScopeActivation)
+		require.NoError(t, err)
+		_, err = svc.Activate(ctx, sent.lastToken(t))
+		assert.ErrorIs(t, err, ErrInvalidToken)
+	})
+
+	t.Run("email change", func(t *testing.T) {
+		u := register("carol")
+		require.NoError(t, svc.SendActivation(ctx, u))
+		old := sent.lastToken(t)
+
+		// Change the address without going through the handler, which
+		// would revoke the token by sending a new one: the binding alone
+		// must refuse it.
+		email := "carol@example.org"
+		u, err := userSvc.UpdateProfile(ctx, u.ID, user.UserPatch{Email: &email})
+		require.NoError(t, err)
+		_, err = svc.Activate(ctx, old)
+		assert.ErrorIs(t, err, ErrInvalidToken, "sent to the previous address")
+
+		require.NoError(t, svc.SendActivation(ctx, u))
+		assert.Equal(t, email, sent[len(sent)-1].To)
+		activated, err := svc.Activate(ctx, sent.lastToken(t))
+		require.NoError(t, err)
+		assert.True(t, activated.Activated)
+	})
+}
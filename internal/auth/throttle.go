@@ -0,0 +1,125 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrLoginThrottled is a login refused without checking the password,
+// because the username or the client's IP failed too often lately. It
+// comes wrapped in a *LoginThrottledError saying when to try again → 429.
+var ErrLoginThrottled = errors.New("too many failed login attempts")
+
+// LoginThrottledError carries how long a throttled login must wait.
+type LoginThrottledError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LoginThrottledError) Error() string {
+	return fmt.Sprintf("%v; retry in %v", ErrLoginThrottled, e.RetryAfter)
+}
+
+func (e *LoginThrottledError) Unwrap() error { return ErrLoginThrottled }
+
+// loginBackoff is the lockout schedule of one kind of key: the first free
+// failures cost nothing, then each one locks the key for base, doubling
+// per failure up to max. A failure more than loginFailureWindow after the
+// previous one starts the count over.
+type loginBackoff struct {
+	free int
+	base time.Duration
+	max  time.Duration
+}
+
+// lockout is how long the failures-th failure locks the key for.
+func (b loginBackoff) lockout(failures int) time.Duration {
+	if failures <= b.free {
+		return 0
+	}
+	exp := failures - b.free - 1
+	if exp >= 32 || b.base > b.max>>exp {
+		return b.max
+	}
+	return b.base << exp
+}
+
+// A username gets a handful of tries, then backs off from 30 seconds to a
+// quarter of an hour, which keeps online guessing at a few attempts an hour
+// while a locked-out owner waits minutes, not days. An IP gets more, since
+// a NAT or an office can put many users behind one.
+var (
+	usernameBackoff = loginBackoff{free: 5, base: 30 * time.Second, max: 15 * time.Minute}
+	ipBackoff       = loginBackoff{free: 20, base: 30 * time.Second, max: 15 * time.Minute}
+)
+
+// loginFailureWindow is how long failures are remembered without another.
+const loginFailureWindow = time.Hour
+
+type loginKey struct {
+	key     string
+	backoff loginBackoff
+}
+
+// loginKeys are what a login attempt is counted against. The username is
+// the one submitted, not the account's id, so an unknown username locks
+// exactly like a real one and the lockout tells nothing about which exist.
+func loginKeys(username string, client Client) []loginKey {
+	keys := []loginKey{{key: "username:" + strings.ToLower(username), backoff: usernameBackoff}}
+	if client.IP != "" {
+		keys = append(keys, loginKey{key: "ip:" + client.IP, backoff: ipBackoff})
+	}
+	return keys
+}
+
+// checkLoginThrottle returns a *LoginThrottledError while any of keys is
+// locked. It runs before the user lookup and hashing, so a locked login
+// costs one query, and costs the same whether or not the user exists.
+func (s *Service) checkLoginThrottle(ctx context.Context, keys []loginKey) error {
+	names := make([]string, len(keys))
+	for i, k := range keys {
+		names[i] = k.key
+	}
+	now := time.Now()
+	until, err := s.tokenStore.LoginLockedUntil(ctx, names, now)
+	if err != nil {
+		return err
+	}
+	if until.IsZero() {
+		return nil
+	}
+	return &LoginThrottledError{RetryAfter: until.Sub(now)}
+}
+
+// recordLoginFailure counts a failed login against every key, locking
+// those that have run out of free attempts.
+func (s *Service) recordLoginFailure(ctx context.Context, keys []loginKey) error {
+	now := time.Now()
+	for _, k := range keys {
+		failures, err := s.tokenStore.RecordLoginFailure(ctx, k.key, now, now.Add(-loginFailureWindow))
+		if err != nil {
+			return err
+		}
+		if d := k.backoff.lockout(failures); d > 0 {
+			if err := s.tokenStore.LockLogin(ctx, k.key, now.Add(d)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// clearLoginFailures forgets the username's failures after a successful
+// login. The IP's stay: logging in to an account of one's own mustn't buy
+// more guesses at someone else's.
+func (s *Service) clearLoginFailures(ctx context.Context, keys []loginKey) error {
+	return s.tokenStore.ClearLoginFailures(ctx, keys[0].key)
+}
+
+// PruneLoginFailures forgets failures that no longer count and whose lock
+// is over. Meant to run periodically alongside user.Service.Purge.
+func (s *Service) PruneLoginFailures(ctx context.Context) (int64, error) {
+	return s.tokenStore.PruneLoginFailures(ctx, time.Now().Add(-loginFailureWindow))
+}
@@ -0,0 +1,29 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoginBackoff(t *testing.T) {
+	b := loginBackoff{free: 3, base: 30 * time.Second, max: 15 * time.Minute}
+	assert.Zero(t, b.lockout(1))
+	assert.Zero(t, b.lockout(2))
+	assert.Zero(t, b.lockout(3), "the last free failure")
+	assert.Equal(t, 30*time.Second, b.lockout(4), "the first that locks")
+	assert.Equal(t, time.Minute, b.lockout(5))
+	assert.Equal(t, 8*time.Minute, b.lockout(8))
+	assert.Equal(t, 15*time.Minute, b.lockout(9), "capped")
+	assert.Equal(t, 15*time.Minute, b.lockout(1000), "no overflow")
+}
+
+func TestLoginKeys(t *testing.T) {
+	keys := loginKeys("Alice", Client{IP: "203.0.113.7"})
+	assert.Equal(t, []loginKey{
+		{key: "username:alice", backoff: usernameBackoff},
+		{key: "ip:203.0.113.7", backoff: ipBackoff},
+	}, keys)
+	assert.Len(t, loginKeys("alice", Client{}), 1)
+}
@@ -0,0 +1,298 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/tsatsarisg/go-fit/internal/platform/totp"
+	"github.com/tsatsarisg/go-fit/internal/user"
+)
+
+// ScopeTwoFactorPending labels the token Login hands out, instead of a
+// session, to an account with two-factor authentication on. It proves the
+// password was right and is only good for POST /tokens/authentication/2fa.
+const ScopeTwoFactorPending = "2fa-pending"
+
+// twoFactorPendingTTL is long enough to find the phone, not much longer.
+const twoFactorPendingTTL = 5 * time.Minute
+
+// maxTwoFactorAttempts wrong codes revoke a 2fa-pending token, so guessing
+// the six digits means guessing the password again every few tries. Each
+// wrong code is also a failed login of the username and the client's IP,
+// so logging in again doesn't buy fresh tries past the login lockout.
+const maxTwoFactorAttempts = 5
+
+// twoFactorIssuer names the account in authenticator apps.
+const twoFactorIssuer = "go-fit"
+
+// recoveryCodeCount codes are handed out when 2FA is confirmed. Each is
+// 80 random bits, enough that an unsalted SHA-256 of it is safe to store,
+// like a token's.
+const (
+	recoveryCodeCount = 10
+	recoveryCodeBytes = 10
+)
+
+// Two-factor sentinels.
+//   - ErrTwoFactorUnavailable: no TOTP_ENCRYPTION_KEY configured → 503.
+//   - ErrTwoFactorEnabled / ErrTwoFactorNotEnabled: the enrollment isn't
+//     in the state the operation needs → 409.
+//   - ErrTwoFactorRequired: RestoreAccount was called without a code for
+//     an account that needs one → 401.
+//   - ErrInvalidTwoFactorCode: wrong, expired, replayed or spent code.
+var (
+	ErrTwoFactorUnavailable = errors.New("two-factor authentication is not configured on this server")
+	ErrTwoFactorEnabled     = errors.New("two-factor authentication is already enabled")
+	ErrTwoFactorNotEnabled  = errors.New("two-factor authentication is not enabled")
+	ErrTwoFactorRequired    = errors.New("two-factor code required")
+	ErrInvalidTwoFactorCode = errors.New("invalid two-factor code")
+)
+
+// secretAD binds a sealed secret to its owner, so a row copied onto
+// another user doesn't open.
+func secretAD(userID user.UserID) []byte {
+	return fmt.Appendf(nil, "two-factor:%d", userID)
+}
+
+// enabledTwoFactor returns the user's confirmed enrollment, or nil when
+// two-factor authentication is off.
+func (s *Service) enabledTwoFactor(ctx context.Context, userID user.UserID) (*TwoFactor, error) {
+	tf, err := s.tokenStore.GetTwoFactor(ctx, userID)
+	if errors.Is(err, ErrTwoFactorNotEnabled) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !tf.Enabled() {
+		return nil, nil
+	}
+	return tf, nil
+}
+
+func (s *Service) openSecret(userID user.UserID, tf *TwoFactor) ([]byte, error) {
+	if s.secrets == nil {
+		return nil, ErrTwoFactorUnavailable
+	}
+	secret, err := s.secrets.Open(tf.Secret, secretAD(userID))
+	if err != nil {
+		return nil, fmt.Errorf("open two-factor secret: %w", err)
+	}
+	return secret, nil
+}
+
+// checkTwoFactorCode accepts a current TOTP code that hasn't been used yet,
+// or an unused recovery code, spending it. Recovery codes are checked
+// without opening the secret, so they still work if the sealing key is
+// lost.
+func (s *Service) checkTwoFactorCode(ctx context.Context, userID user.UserID, tf *TwoFactor, code string) error {
+	code = strings.TrimSpace(code)
+	if len(code) != totp.Digits {
+		used, err := s.tokenStore.UseRecoveryCode(ctx, userID, HashPlaintext(normalizeRecoveryCode(code)))
+		if err != nil {
+			return err
+		}
+		if !used {
+			return ErrInvalidTwoFactorCode
+		}
+		return nil
+	}
+
+	secret, err := s.openSecret(userID, tf)
+	if err != nil {
+		return err
+	}
+	step, ok := totp.Verify(secret, code, time.Now())
+	if !ok {
+		return ErrInvalidTwoFactorCode
+	}
+	used, err := s.tokenStore.UseTwoFactorStep(ctx, userID, step)
+	if err != nil {
+		return err
+	}
+	if !used {
+		return ErrInvalidTwoFactorCode
+	}
+	return nil
+}
+
+var recoveryEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// newRecoveryCode returns a code grouped for reading, like
+// ABCD-EFGH-IJKL-MNOP.
+func newRecoveryCode() (string, error) {
+	b := make([]byte, recoveryCodeBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	raw := recoveryEncoding.EncodeToString(b)
+	groups := make([]string, 0, len(raw)/4)
+	for i := 0; i < len(raw); i += 4 {
+		groups = append(groups, raw[i:i+4])
+	}
+	return strings.Join(groups, "-"), nil
+}
+
+// normalizeRecoveryCode is what gets hashed: the code without grouping,
+// upper-cased, so however it is typed back it matches.
+func normalizeRecoveryCode(code string) string {
+	return strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(code))
+}
+
+// EnrollTwoFactor starts enrollment with a fresh secret, replacing any
+// earlier enrollment that was never confirmed. Nothing changes for login
+// until ConfirmTwoFactor.
+func (s *Service) EnrollTwoFactor(ctx context.Context, p *Principal) (*TwoFactorEnrollment, error) {
+	if s.secrets == nil {
+		return nil, ErrTwoFactorUnavailable
+	}
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, fmt.Errorf("generate two-factor secret: %w", err)
+	}
+	sealed, err := s.secrets.Seal(secret, secretAD(p.ID))
+	if err != nil {
+		return nil, fmt.Errorf("seal two-factor secret: %w", err)
+	}
+	if err := s.tokenStore.SaveTwoFactorSecret(ctx, p.ID, sealed); err != nil {
+		return nil, err
+	}
+	return &TwoFactorEnrollment{
+		Secret:          totp.EncodeSecret(secret),
+		ProvisioningURI: totp.ProvisioningURI(twoFactorIssuer, p.Username, secret),
+	}, nil
+}
+
+// ConfirmTwoFactor turns two-factor authentication on with the first code
+// from the authenticator app, which proves the secret was set up right,
+// and returns the recovery codes. They are stored hashed: this is the only
+// time they can be shown.
+func (s *Service) ConfirmTwoFactor(ctx context.Context, p *Principal, code string) ([]string, error) {
+	tf, err := s.tokenStore.GetTwoFactor(ctx, p.ID)
+	if err != nil {
+		return nil, err
+	}
+	if tf.Enabled() {
+		return nil, ErrTwoFactorEnabled
+	}
+	secret, err := s.openSecret(p.ID, tf)
+	if err != nil {
+		return nil, err
+	}
+	step, ok := totp.Verify(secret, strings.TrimSpace(code), time.Now())
+	if !ok {
+		return nil, ErrInvalidTwoFactorCode
+	}
+
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([][]byte, recoveryCodeCount)
+	for i := range codes {
+		if codes[i], err = newRecoveryCode(); err != nil {
+			return nil, fmt.Errorf("generate recovery code: %w", err)
+		}
+		hashes[i] = HashPlaintext(normalizeRecoveryCode(codes[i]))
+	}
+	if err := s.tokenStore.ConfirmTwoFactor(ctx, p.ID, step, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+type DisableTwoFactorCommand struct {
+	Principal *Principal
+	Password  string
+	Code      string
+}
+
+// DisableTwoFactor turns two-factor authentication off, or abandons an
+// enrollment that was never confirmed. It asks for the password, and for a
+// code when 2FA is on, so a session left open on a shared computer isn't
+// enough to strip the second factor.
+func (s *Service) DisableTwoFactor(ctx context.Context, cmd DisableTwoFactorCommand) error {
+	u, err := s.userSvc.Get(ctx, cmd.Principal.ID)
+	if err != nil {
+		return err
+	}
+	ok, err := s.userSvc.VerifyPassword(ctx, u, cmd.Password)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return user.ErrWrongPassword
+	}
+
+	tf, err := s.tokenStore.GetTwoFactor(ctx, u.ID)
+	if err != nil {
+		return err
+	}
+	if tf.Enabled() {
+		if err := s.checkTwoFactorCode(ctx, u.ID, tf, cmd.Code); err != nil {
+			return err
+		}
+	}
+	return s.tokenStore.DeleteTwoFactor(ctx, u.ID)
+}
+
+// TwoFactorLoginCommand redeems the token Login returned with a TOTP or
+// recovery code.
+type TwoFactorLoginCommand struct {
+	Token  string
+	Code   string
+	Client Client
+}
+
+// CompleteTwoFactorLogin finishes a login that Login left pending and
+// starts the session. A wrong code is ErrInvalidTwoFactorCode and counts
+// against the pending token and as a failed login; an unknown, expired or
+// exhausted token is ErrInvalidToken. While the login is locked, a code is
+// refused with a *LoginThrottledError without being checked. Only a right
+// code clears the username's failures, which Login left alone.
+func (s *Service) CompleteTwoFactorLogin(ctx context.Context, cmd TwoFactorLoginCommand) (*SessionTokens, error) {
+	p, err := s.tokenStore.ResolvePrincipal(ctx, ScopeTwoFactorPending, cmd.Token)
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		return nil, ErrInvalidToken
+	}
+	tf, err := s.enabledTwoFactor(ctx, p.ID)
+	if err != nil {
+		return nil, err
+	}
+	if tf == nil {
+		// Turned off since the password was checked; log in again.
+		return nil, ErrInvalidToken
+	}
+	u, err := s.userSvc.Get(ctx, p.ID)
+	if err != nil {
+		return nil, err
+	}
+	keys := loginKeys(u.Username, cmd.Client)
+	if err := s.checkLoginThrottle(ctx, keys); err != nil {
+		return nil, err
+	}
+
+	if err := s.checkTwoFactorCode(ctx, p.ID, tf, cmd.Code); err != nil {
+		if errors.Is(err, ErrInvalidTwoFactorCode) {
+			if ferr := s.tokenStore.FailTwoFactorAttempt(ctx, p.TokenHash, maxTwoFactorAttempts); ferr != nil {
+				return nil, ferr
+			}
+			if ferr := s.recordLoginFailure(ctx, keys); ferr != nil {
+				return nil, ferr
+			}
+		}
+		return nil, err
+	}
+	if err := s.clearLoginFailures(ctx, keys); err != nil {
+		return nil, err
+	}
+	if err := s.tokenStore.DeleteAllForUser(ctx, ScopeTwoFactorPending, p.ID); err != nil {
+		return nil, err
+	}
+	return s.startSession(ctx, u, cmd.Client)
+}
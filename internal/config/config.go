@@ -1,13 +1,19 @@
 package config
 
 import (
+	"encoding/base64"
 	"errors"
 	"fmt"
+	"net/netip"
 	"os"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
+	"golang.org/x/crypto/bcrypt"
+	"github.com/tsatsarisg/go-fit/internal/platform/jwt"
+	"github.com/tsatsarisg/go-fit/internal/platform/sealer"
 )
 
 const (
@@ -20,6 +26,101 @@ type Config struct {
 	DatabaseURL string
 	Port        int
 	Env         string
+	Mail        MailConfig
+	// DeletionGrace is how long a deleted account stays restorable before
+	// the purge job erases it.
+	DeletionGrace time.Duration
+	Auth          AuthConfig
+	RateLimit     RateLimitConfig
+	PasswordHash  PasswordHashConfig
+	// BreachedPasswordsFile is the breached-password corpus new passwords
+	// are screened against; empty turns the screening off.
+	BreachedPasswordsFile string
+	// TrustedProxies are the proxies whose X-Forwarded-For is believed when
+	// working out a client's IP; empty means the peer address is used.
+	TrustedProxies []netip.Prefix
+}
+
+// Access-token formats. TokenFormatOpaque is the default: random tokens
+// looked up in the database on every request. TokenFormatJWT issues signed
+// tokens the middleware verifies without a lookup.
+const (
+	TokenFormatOpaque = "opaque"
+	TokenFormatJWT    = "jwt"
+)
+
+// AuthConfig selects the access-token format. JWTKeys and JWTIssuer are only
+// used with TokenFormatJWT; the first key signs, all of them verify.
+//
+// TOTPKey encrypts two-factor secrets at rest. Without it, two-factor
+// authentication can't be enrolled.
+type AuthConfig struct {
+	TokenFormat string
+	JWTKeys     []jwt.Key
+	JWTIssuer   string
+	TOTPKey     []byte
+}
+
+// Rate-limit backends. RateLimitBackendMemory counts per instance and is
+// the default; RateLimitBackendPostgres shares the counts between
+// instances; RateLimitBackendOff disables rate limiting.
+const (
+	RateLimitBackendMemory   = "memory"
+	RateLimitBackendPostgres = "postgres"
+	RateLimitBackendOff      = "off"
+)
+
+// RateLimit allows Limit requests at once, refilled at Limit per Period.
+type RateLimit struct {
+	Limit  int
+	Period time.Duration
+}
+
+// RateLimitConfig holds the rate-limit backend and the policy of each route
+// group: Auth for the account and token endpoints, Read and Write for the
+// rest of the API by method.
+type RateLimitConfig struct {
+	Backend string
+	Auth    RateLimit
+	Read    RateLimit
+	Write   RateLimit
+}
+
+// Password hash algorithms. New passwords are hashed with the configured
+// one; hashes made by the other, or with weaker parameters, are replaced on
+// the next login.
+const (
+	PasswordHashArgon2id = "argon2id"
+	PasswordHashBcrypt   = "bcrypt"
+)
+
+// PasswordHashConfig picks the password hash algorithm and its parameters.
+// Argon2Memory is in KiB.
+type PasswordHashConfig struct {
+	Algorithm         string
+	BcryptCost        int
+	Argon2Memory      uint32
+	Argon2Iterations  uint32
+	Argon2Parallelism uint8
+}
+
+// Mail drivers. MailDriverLog only logs messages and is the default, so a
+// fresh checkout runs without an SMTP relay.
+const (
+	MailDriverLog  = "log"
+	MailDriverFile = "file"
+	MailDriverSMTP = "smtp"
+)
+
+// MailConfig selects and configures the outgoing-mail adapter.
+type MailConfig struct {
+	Driver       string
+	From         string
+	File         string
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
 }
 
 // Load reads configuration from the environment. It attempts to load a local
@@ -47,13 +148,212 @@ func Load() (*Config, error) {
 		return nil, err
 	}
 
+	mail, err := loadMailConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	grace, err := time.ParseDuration(getEnv("ACCOUNT_DELETION_GRACE", "720h"))
+	if err != nil || grace < 0 {
+		return nil, fmt.Errorf("invalid ACCOUNT_DELETION_GRACE: must be a non-negative duration like 720h")
+	}
+
+	auth, err := loadAuthConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	rateLimit, err := loadRateLimitConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	passwordHash, err := loadPasswordHashConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	trustedProxies, err := parseTrustedProxies(os.Getenv("TRUSTED_PROXIES"))
+	if err != nil {
+		return nil, err
+	}
+
 	return &Config{
 		DatabaseURL: dsn,
 		Port:        port,
 		Env:         env,
+		Mail:        mail,
+
+		DeletionGrace: grace,
+		Auth:          auth,
+		RateLimit:     rateLimit,
+		PasswordHash:  passwordHash,
+
+		BreachedPasswordsFile: os.Getenv("BREACHED_PASSWORDS_FILE"),
+		TrustedProxies:        trustedProxies,
 	}, nil
 }
 
+// parseTrustedProxies reads TRUSTED_PROXIES as a comma-separated list of
+// CIDRs or single addresses, e.g. "10.0.0.0/8,192.0.2.1".
+func parseTrustedProxies(raw string) ([]netip.Prefix, error) {
+	var prefixes []netip.Prefix
+	for _, entry := range strings.Split(raw, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if addr, err := netip.ParseAddr(entry); err == nil {
+			prefixes = append(prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid TRUSTED_PROXIES entry %q: must be a CIDR or an IP address", entry)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
+
+// loadAuthConfig reads JWT_KEYS as a comma-separated list of kid:alg:base64
+// entries, e.g. "2026-10:EdDSA:<seed>,2026-04:EdDSA:<seed>".
+func loadAuthConfig() (AuthConfig, error) {
+	c := AuthConfig{
+		TokenFormat: getEnv("AUTH_TOKEN_FORMAT", TokenFormatOpaque),
+		JWTIssuer:   getEnv("JWT_ISSUER", "go-fit"),
+	}
+	if raw := os.Getenv("TOTP_ENCRYPTION_KEY"); raw != "" {
+		key, err := base64.StdEncoding.DecodeString(raw)
+		if err != nil || len(key) != sealer.KeySize {
+			return AuthConfig{}, fmt.Errorf("invalid TOTP_ENCRYPTION_KEY: must be %d bytes, base64-encoded", sealer.KeySize)
+		}
+		c.TOTPKey = key
+	}
+
+	switch c.TokenFormat {
+	case TokenFormatOpaque:
+		return c, nil
+	case TokenFormatJWT:
+	default:
+		return AuthConfig{}, fmt.Errorf("invalid AUTH_TOKEN_FORMAT %q: must be %s or %s", c.TokenFormat, TokenFormatOpaque, TokenFormatJWT)
+	}
+
+	raw := os.Getenv("JWT_KEYS")
+	if raw == "" {
+		return AuthConfig{}, errors.New("JWT_KEYS is required when AUTH_TOKEN_FORMAT=jwt")
+	}
+	for _, entry := range strings.Split(raw, ",") {
+		parts := strings.SplitN(strings.TrimSpace(entry), ":", 3)
+		if len(parts) != 3 {
+			return AuthConfig{}, errors.New("invalid JWT_KEYS: each entry must be kid:alg:base64")
+		}
+		key, err := jwt.ParseKey(parts[0], parts[1], parts[2])
+		if err != nil {
+			return AuthConfig{}, fmt.Errorf("invalid JWT_KEYS: %w", err)
+		}
+		c.JWTKeys = append(c.JWTKeys, key)
+	}
+	// Catch duplicate kids at startup rather than when the key set is built.
+	if _, err := jwt.NewKeySet(c.JWTKeys...); err != nil {
+		return AuthConfig{}, fmt.Errorf("invalid JWT_KEYS: %w", err)
+	}
+	return c, nil
+}
+
+// loadRateLimitConfig reads the RATE_LIMIT_* policies as limit/period, e.g.
+// "20/1m" for twenty requests a minute.
+func loadRateLimitConfig() (RateLimitConfig, error) {
+	c := RateLimitConfig{Backend: getEnv("RATE_LIMIT_BACKEND", RateLimitBackendMemory)}
+	switch c.Backend {
+	case RateLimitBackendMemory, RateLimitBackendPostgres, RateLimitBackendOff:
+	default:
+		return RateLimitConfig{}, fmt.Errorf("invalid RATE_LIMIT_BACKEND %q: must be one of %s, %s or %s", c.Backend, RateLimitBackendMemory, RateLimitBackendPostgres, RateLimitBackendOff)
+	}
+
+	for _, p := range []struct {
+		name     string
+		fallback string
+		dst      *RateLimit
+	}{
+		{"RATE_LIMIT_AUTH", "20/1m", &c.Auth},
+		{"RATE_LIMIT_READ", "600/1m", &c.Read},
+		{"RATE_LIMIT_WRITE", "120/1m", &c.Write},
+	} {
+		limit, period, ok := strings.Cut(getEnv(p.name, p.fallback), "/")
+		n, err := strconv.Atoi(limit)
+		if !ok || err != nil || n < 1 {
+			return RateLimitConfig{}, fmt.Errorf("invalid %s: must be limit/period, like %s", p.name, p.fallback)
+		}
+		d, err := time.ParseDuration(period)
+		if err != nil || d <= 0 {
+			return RateLimitConfig{}, fmt.Errorf("invalid %s: must be limit/period, like %s", p.name, p.fallback)
+		}
+		*p.dst = RateLimit{Limit: n, Period: d}
+	}
+	return c, nil
+}
+
+// loadPasswordHashConfig defaults to argon2id with OWASP's minimum
+// parameters (19 MiB, two passes, one lane).
+func loadPasswordHashConfig() (PasswordHashConfig, error) {
+	c := PasswordHashConfig{Algorithm: getEnv("PASSWORD_HASH", PasswordHashArgon2id)}
+	switch c.Algorithm {
+	case PasswordHashArgon2id, PasswordHashBcrypt:
+	default:
+		return PasswordHashConfig{}, fmt.Errorf("invalid PASSWORD_HASH %q: must be %s or %s", c.Algorithm, PasswordHashArgon2id, PasswordHashBcrypt)
+	}
+
+	cost, err := strconv.Atoi(getEnv("BCRYPT_COST", strconv.Itoa(bcrypt.DefaultCost)))
+	if err != nil || cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		return PasswordHashConfig{}, fmt.Errorf("invalid BCRYPT_COST: must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+	}
+	c.BcryptCost = cost
+
+	memory, err := strconv.ParseUint(getEnv("ARGON2_MEMORY", "19456"), 10, 32)
+	if err != nil || memory < 8*1024 {
+		return PasswordHashConfig{}, errors.New("invalid ARGON2_MEMORY: must be at least 8192 (KiB)")
+	}
+	iterations, err := strconv.ParseUint(getEnv("ARGON2_ITERATIONS", "2"), 10, 32)
+	if err != nil || iterations < 1 {
+		return PasswordHashConfig{}, errors.New("invalid ARGON2_ITERATIONS: must be a positive integer")
+	}
+	parallelism, err := strconv.ParseUint(getEnv("ARGON2_PARALLELISM", "1"), 10, 8)
+	if err != nil || parallelism < 1 {
+		return PasswordHashConfig{}, errors.New("invalid ARGON2_PARALLELISM: must be between 1 and 255")
+	}
+	c.Argon2Memory = uint32(memory)
+	c.Argon2Iterations = uint32(iterations)
+	c.Argon2Parallelism = uint8(parallelism)
+	return c, nil
+}
+
+func loadMailConfig() (MailConfig, error) {
+	smtpPort, err := strconv.Atoi(getEnv("SMTP_PORT", "587"))
+	if err != nil {
+		return MailConfig{}, fmt.Errorf("invalid SMTP_PORT: %w", err)
+	}
+	c := MailConfig{
+		Driver:       getEnv("MAIL_DRIVER", MailDriverLog),
+		From:         getEnv("MAIL_FROM", "go-fit <no-reply@localhost>"),
+		File:         getEnv("MAIL_FILE", "mail.log"),
+		SMTPHost:     os.Getenv("SMTP_HOST"),
+		SMTPPort:     smtpPort,
+		SMTPUsername: os.Getenv("SMTP_USERNAME"),
+		SMTPPassword: os.Getenv("SMTP_PASSWORD"),
+	}
+	switch c.Driver {
+	case MailDriverLog, MailDriverFile:
+	case MailDriverSMTP:
+		if c.SMTPHost == "" {
+			return MailConfig{}, errors.New("SMTP_HOST is required when MAIL_DRIVER=smtp")
+		}
+	default:
+		return MailConfig{}, fmt.Errorf("invalid MAIL_DRIVER %q: must be one of %s, %s or %s", c.Driver, MailDriverLog, MailDriverFile, MailDriverSMTP)
+	}
+	return c, nil
+}
+
 // IsProduction reports whether the config is targeting a production environment.
 func (c *Config) IsProduction() bool {
 	return c.Env == EnvProduction
@@ -0,0 +1,135 @@
+package exercise
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/tsatsarisg/go-fit/internal/auth"
+	"github.com/tsatsarisg/go-fit/internal/httpx"
+)
+
+type Handler struct {
+	service *Service
+	logger  *slog.Logger
+}
+
+func NewHandler(service *Service, logger *slog.Logger) *Handler {
+	return &Handler{service: service, logger: logger}
+}
+
+var errorMapping = httpx.StoreErrorMapping{
+	ResourceName: "Exercise",
+	NotFoundErr:  ErrNotFound,
+	ForbiddenErr: ErrForbidden,
+}
+
+type createExerciseRequest struct {
+	Name             string      `json:"name"`
+	Aliases          []string    `json:"aliases"`
+	PrimaryMuscles   []string    `json:"primary_muscles"`
+	SecondaryMuscles []string    `json:"secondary_muscles"`
+	Equipment        string      `json:"equipment"`
+	MovementPattern  string      `json:"movement_pattern"`
+	Measurement      Measurement `json:"measurement"`
+}
+
+func (h *Handler) HandleCreateExercise(w http.ResponseWriter, r *http.Request) {
+	principal := auth.GetPrincipal(r)
+	if principal.IsAnonymous() {
+		httpx.WriteJson(w, http.StatusUnauthorized, httpx.Envelope{"error": "Unauthenticated"})
+		return
+	}
+
+	var req createExerciseRequest
+	if derr := httpx.DecodeJSONBody(w, r, &req); derr != nil {
+		h.logger.WarnContext(r.Context(), "decode create exercise", slog.Any("err", derr))
+		httpx.WriteDecodeError(w, derr)
+		return
+	}
+
+	created, err := h.service.Create(r.Context(), CreateExerciseCommand{
+		UserID:           principal.ID,
+		Name:             req.Name,
+		Aliases:          req.Aliases,
+		PrimaryMuscles:   req.PrimaryMuscles,
+		SecondaryMuscles: req.SecondaryMuscles,
+		Equipment:        req.Equipment,
+		MovementPattern:  req.MovementPattern,
+		Measurement:      req.Measurement,
+	})
+	if err != nil {
+		if errors.Is(err, ErrValidation) {
+			httpx.WriteJson(w, http.StatusBadRequest, httpx.Envelope{"error": err.Error()})
+			return
+		}
+		httpx.WriteStoreError(r.Context(), w, h.logger, err, errorMapping, "Failed to create exercise")
+		return
+	}
+	httpx.WriteJson(w, http.StatusCreated, httpx.Envelope{"exercise": created})
+}
+
+// HandleListExercises serves GET /exercises?q=&muscle=&equipment=: the
+// global catalog plus the caller's custom exercises.
+func (h *Handler) HandleListExercises(w http.ResponseWriter, r *http.Request) {
+	principal := auth.GetPrincipal(r)
+	if principal.IsAnonymous() {
+		httpx.WriteJson(w, http.StatusUnauthorized, httpx.Envelope{"error": "Unauthenticated"})
+		return
+	}
+
+	values := r.URL.Query()
+	exercises, err := h.service.List(r.Context(), principal.ID, ListFilter{
+		Query:     values.Get("q"),
+		Muscle:    values.Get("muscle"),
+		Equipment: values.Get("equipment"),
+	})
+	if err != nil {
+		httpx.WriteStoreError(r.Context(), w, h.logger, err, errorMapping, "Failed to list exercises")
+		return
+	}
+	httpx.WriteJson(w, http.StatusOK, httpx.Envelope{"exercises": exercises})
+}
+
+func (h *Handler) HandleGetExercise(w http.ResponseWriter, r *http.Request) {
+	exerciseID, err := httpx.ReadIdParam(r, "id")
+	if err != nil {
+		h.logger.WarnContext(r.Context(), "read id param", slog.Any("err", err))
+		httpx.WriteJson(w, http.StatusBadRequest, httpx.Envelope{"error": err.Error()})
+		return
+	}
+
+	principal := auth.GetPrincipal(r)
+	if principal.IsAnonymous() {
+		httpx.WriteJson(w, http.StatusUnauthorized, httpx.Envelope{"error": "Unauthenticated"})
+		return
+	}
+
+	exercise, err := h.service.Get(r.Context(), ExerciseID(exerciseID), principal.ID)
+	if err != nil {
+		httpx.WriteStoreError(r.Context(), w, h.logger, err, errorMapping, "Failed to retrieve exercise")
+		return
+	}
+	httpx.WriteJson(w, http.StatusOK, httpx.Envelope{"exercise": exercise})
+}
+
+func (h *Handler) HandleDeleteExercise(w http.ResponseWriter, r *http.Request) {
+	exerciseID, err := httpx.ReadIdParam(r, "id")
+	if err != nil {
+		h.logger.WarnContext(r.Context(), "read id param", slog.Any("err", err))
+		httpx.WriteJson(w, http.StatusBadRequest, httpx.Envelope{"error": err.Error()})
+		return
+	}
+
+	principal := auth.GetPrincipal(r)
+	if principal.IsAnonymous() {
+		httpx.WriteJson(w, http.StatusUnauthorized, httpx.Envelope{"error": "Unauthenticated"})
+		return
+	}
+
+	if err := h.service.Delete(r.Context(), ExerciseID(exerciseID), principal.ID); err != nil {
+		httpx.WriteStoreError(r.Context(), w, h.logger, err, errorMapping, "Failed to delete exercise")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
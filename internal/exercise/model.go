@@ -0,0 +1,132 @@
+package exercise
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/tsatsarisg/go-fit/internal/user"
+)
+
+// ExerciseID wraps exercises.id, typed like every other id in the codebase.
+type ExerciseID int64
+
+// Measurement says how sets of an exercise are counted: in reps or in
+// seconds. It mirrors the reps XOR duration_seconds split on entries.
+type Measurement string
+
+const (
+	MeasurementReps Measurement = "reps"
+	MeasurementTime Measurement = "time"
+)
+
+// Muscles, Equipment and MovementPatterns are the closed vocabularies the
+// catalog is tagged with. Keeping them closed is what makes per-muscle
+// analytics possible; free text would reintroduce the naming problem the
+// catalog exists to solve.
+var (
+	Muscles = []string{
+		"chest", "lats", "upper_back", "traps", "lower_back", "front_delts", "side_delts", "rear_delts",
+		"biceps", "triceps", "forearms", "abs", "obliques", "glutes", "quads", "hamstrings",
+		"adductors", "abductors", "calves", "cardio",
+	}
+	Equipment = []string{
+		"barbell", "dumbbell", "kettlebell", "machine", "cable", "bodyweight", "band", "smith_machine", "ez_bar", "other",
+	}
+	MovementPatterns = []string{
+		"horizontal_push", "vertical_push", "horizontal_pull", "vertical_pull", "squat", "hinge", "lunge",
+		"carry", "core", "isolation", "cardio",
+	}
+)
+
+// Exercise is a catalog entry. UserID is nil for the seeded global catalog
+// and set for a user's custom exercise, which only that user can see.
+type Exercise struct {
+	ID               ExerciseID   `json:"id"`
+	UserID           *user.UserID `json:"user_id"`
+	Name             string       `json:"name"`
+	Aliases          []string     `json:"aliases"`
+	PrimaryMuscles   []string     `json:"primary_muscles"`
+	SecondaryMuscles []string     `json:"secondary_muscles"`
+	Equipment        string       `json:"equipment"`
+	MovementPattern  string       `json:"movement_pattern"`
+	Measurement      Measurement  `json:"measurement"`
+	CreatedAt        time.Time    `json:"created_at"`
+}
+
+// Custom reports whether the exercise belongs to a user rather than the
+// global catalog.
+func (e *Exercise) Custom() bool {
+	return e.UserID != nil
+}
+
+func (e *Exercise) Validate() error {
+	if NormalizeName(e.Name) == "" {
+		return errors.New("name must not be empty")
+	}
+	if len(e.PrimaryMuscles) == 0 {
+		return errors.New("primary_muscles must not be empty")
+	}
+	if err := checkMuscles("primary_muscles", e.PrimaryMuscles); err != nil {
+		return err
+	}
+	if err := checkMuscles("secondary_muscles", e.SecondaryMuscles); err != nil {
+		return err
+	}
+	if !slices.Contains(Equipment, e.Equipment) {
+		return fmt.Errorf("equipment must be one of %s", strings.Join(Equipment, ", "))
+	}
+	if !slices.Contains(MovementPatterns, e.MovementPattern) {
+		return fmt.Errorf("movement_pattern must be one of %s", strings.Join(MovementPatterns, ", "))
+	}
+	if e.Measurement != MeasurementReps && e.Measurement != MeasurementTime {
+		return fmt.Errorf("measurement must be %q or %q", MeasurementReps, MeasurementTime)
+	}
+	for i, a := range e.Aliases {
+		if NormalizeName(a) == "" {
+			return fmt.Errorf("aliases[%d] must not be empty", i)
+		}
+	}
+	return nil
+}
+
+func checkMuscles(field string, values []string) error {
+	for i, v := range values {
+		if !slices.Contains(Muscles, v) {
+			return fmt.Errorf("%s[%d]: %q is not a known muscle", field, i, v)
+		}
+	}
+	return nil
+}
+
+// NormalizeName is the key names are matched on: lower-cased, with runs of
+// whitespace collapsed, so "Bench  Press" and "bench press" are one exercise.
+func NormalizeName(name string) string {
+	return strings.Join(strings.Fields(strings.ToLower(name)), " ")
+}
+
+// normalize canonicalises the stored form: a trimmed display name and
+// normalized, deduplicated aliases.
+func (e *Exercise) normalize() {
+	e.Name = strings.Join(strings.Fields(e.Name), " ")
+	aliases := make([]string, 0, len(e.Aliases))
+	for _, a := range e.Aliases {
+		n := NormalizeName(a)
+		if n != NormalizeName(e.Name) && !slices.Contains(aliases, n) {
+			aliases = append(aliases, n)
+		}
+	}
+	e.Aliases = aliases
+	if e.SecondaryMuscles == nil {
+		e.SecondaryMuscles = []string{}
+	}
+}
+
+// ListFilter narrows GET /exercises. Zero values mean "don't filter".
+type ListFilter struct {
+	Query     string
+	Muscle    string
+	Equipment string
+}
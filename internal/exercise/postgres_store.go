@@ -0,0 +1,172 @@
+package exercise
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/tsatsarisg/go-fit/internal/platform/postgres"
+	"github.com/tsatsarisg/go-fit/internal/user"
+)
+
+type PostgresStore struct {
+	db *sql.DB
+}
+
+func NewPostgresStore(db *sql.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+// exerciseColumns projects an exercises row (aliased e). The TEXT[] columns
+// are read as JSON so they scan through database/sql without a pgtype map.
+const exerciseColumns = `e.id, e.user_id, e.name, to_json(e.aliases), to_json(e.primary_muscles), to_json(e.secondary_muscles), e.equipment, e.movement_pattern, e.measurement, e.created_at`
+
+// visibleTo is the WHERE fragment every read shares: the global catalog plus
+// the caller's own exercises. $1 is always the caller's id.
+const visibleTo = `(e.user_id IS NULL OR e.user_id = $1)`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanExercise(row rowScanner) (*Exercise, error) {
+	e := &Exercise{}
+	var aliases, primary, secondary []byte
+	err := row.Scan(&e.ID, &e.UserID, &e.Name, &aliases, &primary, &secondary, &e.Equipment, &e.MovementPattern, &e.Measurement, &e.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	for _, col := range []struct {
+		raw []byte
+		dst *[]string
+	}{{aliases, &e.Aliases}, {primary, &e.PrimaryMuscles}, {secondary, &e.SecondaryMuscles}} {
+		if err := json.Unmarshal(col.raw, col.dst); err != nil {
+			return nil, err
+		}
+	}
+	return e, nil
+}
+
+func (pg *PostgresStore) queryExercises(ctx context.Context, query string, args ...any) ([]*Exercise, error) {
+	rows, err := pg.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	exercises := []*Exercise{}
+	for rows.Next() {
+		e, err := scanExercise(rows)
+		if err != nil {
+			return nil, err
+		}
+		exercises = append(exercises, e)
+	}
+	return exercises, rows.Err()
+}
+
+func (pg *PostgresStore) CreateExercise(ctx context.Context, exercise *Exercise) (*Exercise, error) {
+	query := `INSERT INTO exercises (user_id, name, aliases, primary_muscles, secondary_muscles, equipment, movement_pattern, measurement)
+			  VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			  RETURNING id, created_at`
+
+	err := pg.db.QueryRowContext(ctx, query, exercise.UserID, exercise.Name, exercise.Aliases, exercise.PrimaryMuscles, exercise.SecondaryMuscles, exercise.Equipment, exercise.MovementPattern, string(exercise.Measurement)).Scan(&exercise.ID, &exercise.CreatedAt)
+	if err != nil {
+		return nil, postgres.ClassifyError(err)
+	}
+	return exercise, nil
+}
+
+func (pg *PostgresStore) GetExercise(ctx context.Context, id ExerciseID, userID user.UserID) (*Exercise, error) {
+	query := `SELECT ` + exerciseColumns + `
+			  FROM exercises e
+			  WHERE ` + visibleTo + ` AND e.id = $2`
+
+	e, err := scanExercise(pg.db.QueryRowContext(ctx, query, userID, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+func (pg *PostgresStore) ListExercises(ctx context.Context, userID user.UserID, filter ListFilter) ([]*Exercise, error) {
+	where := []string{visibleTo}
+	args := []any{userID}
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if q := NormalizeName(filter.Query); q != "" {
+		p := arg("%" + likeEscaper.Replace(q) + "%")
+		where = append(where, fmt.Sprintf(`(lower(e.name) LIKE %s OR EXISTS (SELECT 1 FROM unnest(e.aliases) AS a WHERE a LIKE %s))`, p, p))
+	}
+	if filter.Muscle != "" {
+		p := arg(filter.Muscle)
+		where = append(where, fmt.Sprintf(`(%s = ANY(e.primary_muscles) OR %s = ANY(e.secondary_muscles))`, p, p))
+	}
+	if filter.Equipment != "" {
+		where = append(where, `e.equipment = `+arg(filter.Equipment))
+	}
+
+	query := `SELECT ` + exerciseColumns + `
+			  FROM exercises e
+			  WHERE ` + strings.Join(where, " AND ") + `
+			  ORDER BY lower(e.name), e.id`
+
+	return pg.queryExercises(ctx, query, args...)
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+func (pg *PostgresStore) DeleteExercise(ctx context.Context, id ExerciseID, userID user.UserID) error {
+	var ownerID *user.UserID
+	err := pg.db.QueryRowContext(ctx, `SELECT e.user_id FROM exercises e WHERE `+visibleTo+` AND e.id = $2`, userID, id).Scan(&ownerID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if ownerID == nil {
+		return ErrForbidden
+	}
+
+	res, err := pg.db.ExecContext(ctx, `DELETE FROM exercises WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (pg *PostgresStore) GetExercisesByID(ctx context.Context, userID user.UserID, ids []ExerciseID) ([]*Exercise, error) {
+	raw := make([]int64, len(ids))
+	for i, id := range ids {
+		raw[i] = int64(id)
+	}
+	query := `SELECT ` + exerciseColumns + `
+			  FROM exercises e
+			  WHERE ` + visibleTo + ` AND e.id = ANY($2)`
+	return pg.queryExercises(ctx, query, userID, raw)
+}
+
+func (pg *PostgresStore) FindByNames(ctx context.Context, userID user.UserID, names []string) ([]*Exercise, error) {
+	query := `SELECT ` + exerciseColumns + `
+			  FROM exercises e
+			  WHERE ` + visibleTo + `
+			    AND (lower(e.name) = ANY($2) OR e.aliases && $2)`
+	return pg.queryExercises(ctx, query, userID, names)
+}
@@ -0,0 +1,163 @@
+package exercise
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/tsatsarisg/go-fit/internal/user"
+)
+
+// Store is the exercise catalog's persistence port. Every read is scoped to
+// what userID can see: the global catalog plus the user's own exercises.
+type Store interface {
+	CreateExercise(ctx context.Context, exercise *Exercise) (*Exercise, error)
+	GetExercise(ctx context.Context, id ExerciseID, userID user.UserID) (*Exercise, error)
+	ListExercises(ctx context.Context, userID user.UserID, filter ListFilter) ([]*Exercise, error)
+	// DeleteExercise only removes custom exercises: ErrNotFound when the row
+	// isn't visible to userID, ErrForbidden for a global catalog entry.
+	DeleteExercise(ctx context.Context, id ExerciseID, userID user.UserID) error
+	GetExercisesByID(ctx context.Context, userID user.UserID, ids []ExerciseID) ([]*Exercise, error)
+	// FindByNames returns every visible exercise whose normalized name or
+	// alias is in names.
+	FindByNames(ctx context.Context, userID user.UserID, names []string) ([]*Exercise, error)
+}
+
+// Domain-level sentinels:
+//   - ErrNotFound:   exercise doesn't exist or isn't visible to you → 404
+//   - ErrForbidden:  exercise is part of the global catalog          → 403
+//   - ErrValidation: invariant violated                              → 400
+var (
+	ErrNotFound   = errors.New("exercise not found")
+	ErrForbidden  = errors.New("forbidden")
+	ErrValidation = errors.New("validation failed")
+)
+
+func wrapValidation(err error) error {
+	if err == nil {
+		return nil
+	}
+	return fmt.Errorf("%w: %v", ErrValidation, err)
+}
+
+type Service struct {
+	store Store
+}
+
+func NewService(store Store) *Service {
+	return &Service{store: store}
+}
+
+// CreateExerciseCommand adds a custom exercise owned by UserID.
+type CreateExerciseCommand struct {
+	UserID           user.UserID
+	Name             string
+	Aliases          []string
+	PrimaryMuscles   []string
+	SecondaryMuscles []string
+	Equipment        string
+	MovementPattern  string
+	Measurement      Measurement
+}
+
+func (s *Service) Create(ctx context.Context, cmd CreateExerciseCommand) (*Exercise, error) {
+	e := &Exercise{
+		UserID:           &cmd.UserID,
+		Name:             cmd.Name,
+		Aliases:          cmd.Aliases,
+		PrimaryMuscles:   cmd.PrimaryMuscles,
+		SecondaryMuscles: cmd.SecondaryMuscles,
+		Equipment:        cmd.Equipment,
+		MovementPattern:  cmd.MovementPattern,
+		Measurement:      cmd.Measurement,
+	}
+	if err := e.Validate(); err != nil {
+		return nil, wrapValidation(err)
+	}
+	e.normalize()
+	return s.store.CreateExercise(ctx, e)
+}
+
+func (s *Service) Get(ctx context.Context, id ExerciseID, userID user.UserID) (*Exercise, error) {
+	return s.store.GetExercise(ctx, id, userID)
+}
+
+func (s *Service) List(ctx context.Context, userID user.UserID, filter ListFilter) ([]*Exercise, error) {
+	return s.store.ListExercises(ctx, userID, filter)
+}
+
+func (s *Service) Delete(ctx context.Context, id ExerciseID, userID user.UserID) error {
+	return s.store.DeleteExercise(ctx, id, userID)
+}
+
+// ByID returns the exercises in ids that userID can see. Missing or
+// invisible ids are simply absent from the map.
+func (s *Service) ByID(ctx context.Context, userID user.UserID, ids []ExerciseID) (map[ExerciseID]*Exercise, error) {
+	out := make(map[ExerciseID]*Exercise, len(ids))
+	if len(ids) == 0 {
+		return out, nil
+	}
+	exercises, err := s.store.GetExercisesByID(ctx, userID, ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range exercises {
+		out[e.ID] = e
+	}
+	return out, nil
+}
+
+// Resolve maps free-text exercise names to catalog entries, keyed by
+// NormalizeName of the input. A name matches an exercise's name or one of
+// its aliases; when both a custom and a global exercise match, the user's
+// own wins, and an exact name beats an alias. Unmatched names are absent.
+func (s *Service) Resolve(ctx context.Context, userID user.UserID, names []string) (map[string]*Exercise, error) {
+	out := make(map[string]*Exercise, len(names))
+	keys := make([]string, 0, len(names))
+	for _, n := range names {
+		if k := NormalizeName(n); k != "" {
+			keys = append(keys, k)
+		}
+	}
+	if len(keys) == 0 {
+		return out, nil
+	}
+
+	candidates, err := s.store.FindByNames(ctx, userID, keys)
+	if err != nil {
+		return nil, err
+	}
+	rank := func(e *Exercise, key string) int {
+		r := 0
+		if e.Custom() {
+			r += 2
+		}
+		if NormalizeName(e.Name) == key {
+			r++
+		}
+		return r
+	}
+	for _, key := range keys {
+		for _, e := range candidates {
+			if !e.matches(key) {
+				continue
+			}
+			if cur, ok := out[key]; !ok || rank(e, key) > rank(cur, key) {
+				out[key] = e
+			}
+		}
+	}
+	return out, nil
+}
+
+func (e *Exercise) matches(key string) bool {
+	if NormalizeName(e.Name) == key {
+		return true
+	}
+	for _, a := range e.Aliases {
+		if a == key {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,102 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/tsatsarisg/go-fit/internal/importer"
+	"github.com/tsatsarisg/go-fit/internal/workout"
+)
+
+// csvEncoder writes the importer's generic format, one row per logged set,
+// so POST /imports reads an export back unchanged. CSV has no room for the
+// profile; the schema version travels in the response header only.
+type csvEncoder struct {
+	w *csv.Writer
+}
+
+func newCSVEncoder(w io.Writer) *csvEncoder {
+	return &csvEncoder{w: csv.NewWriter(w)}
+}
+
+func (e *csvEncoder) begin(*Export) error {
+	return e.w.Write(importer.GenericColumns)
+}
+
+func (e *csvEncoder) workout(w *workout.Workout) error {
+	base := map[string]string{
+		// Full precision, so a re-import matches this workout as a duplicate.
+		"date":             w.CreatedAt.UTC().Format(time.RFC3339Nano),
+		"title":            w.Title,
+		"description":      w.Description,
+		"duration_minutes": strconv.Itoa(w.DurationMinutes),
+		"calories_burned":  strconv.Itoa(w.CaloriesBurned),
+	}
+	if len(w.Entries) == 0 {
+		return e.write(base, nil)
+	}
+	for _, entry := range w.Entries {
+		for _, set := range entrySets(entry) {
+			row := map[string]string{
+				"exercise":         entry.ExerciseName,
+				"notes":            entry.Notes,
+				"set_type":         string(set.Type),
+				"reps":             formatInt(set.Reps),
+				"duration_seconds": formatInt(set.DurationSeconds),
+				"weight":           formatFloat(set.Weight),
+				"rpe":              formatFloat(set.RPE),
+				"completed":        strconv.FormatBool(set.Completed),
+			}
+			if err := e.write(base, row); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (e *csvEncoder) end() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+func (e *csvEncoder) write(base, row map[string]string) error {
+	record := make([]string, len(importer.GenericColumns))
+	for i, col := range importer.GenericColumns {
+		if v, ok := row[col]; ok {
+			record[i] = v
+		} else {
+			record[i] = base[col]
+		}
+	}
+	return e.w.Write(record)
+}
+
+// entrySets returns the entry's logged sets, or expands its flat fields the
+// way workout.Service does for an entry saved without any.
+func entrySets(e workout.WorkoutEntry) []workout.WorkoutSet {
+	if len(e.LoggedSets) > 0 {
+		return e.LoggedSets
+	}
+	sets := make([]workout.WorkoutSet, max(e.Sets, 1))
+	for i := range sets {
+		sets[i] = workout.WorkoutSet{Type: workout.SetTypeWorking, Reps: e.Reps, DurationSeconds: e.DurationSeconds, Weight: e.Weight, Completed: true}
+	}
+	return sets
+}
+
+func formatInt(v *int) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.Itoa(*v)
+}
+
+func formatFloat(v *float64) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*v, 'f', -1, 64)
+}
@@ -0,0 +1,90 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tsatsarisg/go-fit/internal/importer"
+	"github.com/tsatsarisg/go-fit/internal/user"
+	"github.com/tsatsarisg/go-fit/internal/workout"
+)
+
+type fakeWorkouts []*workout.Workout
+
+func (f fakeWorkouts) Export(_ context.Context, _ user.UserID, fn func(*workout.Workout) error) error {
+	for _, w := range f {
+		if err := fn(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type capture struct {
+	got []*workout.Workout
+}
+
+func (c *capture) Import(_ context.Context, _ user.UserID, ws []*workout.Workout) (*workout.ImportResult, error) {
+	c.got = ws
+	return &workout.ImportResult{Created: ws}, nil
+}
+
+func ptr[T any](v T) *T { return &v }
+
+// TestCSVRoundTrip checks that a CSV export imports back as the same
+// workouts, entries and sets.
+func TestCSVRoundTrip(t *testing.T) {
+	start := time.Date(2026, 4, 21, 18, 30, 0, 123456000, time.UTC)
+	exported := []*workout.Workout{
+		{
+			Title:           "Push, heavy",
+			Description:     "felt \"strong\"",
+			DurationMinutes: 65,
+			CaloriesBurned:  400,
+			Visibility:      workout.VisibilityPrivate,
+			CreatedAt:       start,
+			Entries: []workout.WorkoutEntry{
+				{ExerciseName: "Bench Press", Notes: "pause reps", LoggedSets: []workout.WorkoutSet{
+					{Type: workout.SetTypeWarmup, Reps: ptr(10), Weight: ptr(40.0), Completed: true},
+					{Type: workout.SetTypeWorking, Reps: ptr(5), Weight: ptr(82.5), RPE: ptr(8.5), Completed: true},
+					{Type: workout.SetTypeFailure, Reps: ptr(3), Weight: ptr(82.5), Completed: false},
+				}},
+				{ExerciseName: "Plank", OrderIndex: 1, LoggedSets: []workout.WorkoutSet{
+					{Type: workout.SetTypeWorking, DurationSeconds: ptr(60), Completed: true},
+				}},
+			},
+		},
+		{Title: "Rest day walk", Visibility: workout.VisibilityPrivate, CreatedAt: start.AddDate(0, 0, 1), Entries: []workout.WorkoutEntry{}},
+	}
+
+	ex := &Export{Format: FormatCSV, Profile: &user.User{ID: 1}, ExportedAt: start, workouts: fakeWorkouts(exported)}
+	var buf bytes.Buffer
+	require.NoError(t, ex.Write(context.Background(), &buf))
+
+	imported := &capture{}
+	report, err := importer.NewService(imported).Import(context.Background(), importer.ImportCommand{UserID: 1, File: &buf})
+	require.NoError(t, err)
+	assert.Equal(t, "generic", report.Format)
+	assert.Empty(t, report.Errors)
+
+	require.Len(t, imported.got, len(exported))
+	for i, want := range exported {
+		got := imported.got[i]
+		assert.Equal(t, want.Title, got.Title)
+		assert.Equal(t, want.Description, got.Description)
+		assert.Equal(t, want.DurationMinutes, got.DurationMinutes)
+		assert.Equal(t, want.CaloriesBurned, got.CaloriesBurned)
+		assert.True(t, want.CreatedAt.Equal(got.CreatedAt))
+		require.Len(t, got.Entries, len(want.Entries))
+		for j, entry := range want.Entries {
+			assert.Equal(t, entry.ExerciseName, got.Entries[j].ExerciseName)
+			assert.Equal(t, entry.Notes, got.Entries[j].Notes)
+			assert.Equal(t, entry.LoggedSets, got.Entries[j].LoggedSets)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package export
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/tsatsarisg/go-fit/internal/workout"
+)
+
+type Format string
+
+const (
+	FormatCSV    Format = "csv"
+	FormatJSON   Format = "json"
+	FormatNDJSON Format = "ndjson"
+)
+
+// ParseFormat maps ?format= to a Format; empty means JSON, the only one
+// that carries everything in a single document.
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(s); f {
+	case "":
+		return FormatJSON, nil
+	case FormatCSV, FormatJSON, FormatNDJSON:
+		return f, nil
+	}
+	return "", fmt.Errorf("format must be one of %q, %q or %q", FormatCSV, FormatJSON, FormatNDJSON)
+}
+
+func (f Format) ContentType() string {
+	switch f {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatNDJSON:
+		return "application/x-ndjson"
+	}
+	return "application/json"
+}
+
+func (f Format) extension() string { return string(f) }
+
+// encoder writes one export format. begin and end bracket the stream;
+// workout is called once per workout in between.
+type encoder interface {
+	begin(e *Export) error
+	workout(w *workout.Workout) error
+	end() error
+}
+
+func (f Format) newEncoder(w io.Writer) encoder {
+	switch f {
+	case FormatCSV:
+		return newCSVEncoder(w)
+	case FormatNDJSON:
+		return &ndjsonEncoder{w: w}
+	}
+	return &jsonEncoder{w: w}
+}
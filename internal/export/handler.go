@@ -0,0 +1,62 @@
+package export
+
+import (
+	"errors"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/tsatsarisg/go-fit/internal/auth"
+	"github.com/tsatsarisg/go-fit/internal/httpx"
+	"github.com/tsatsarisg/go-fit/internal/user"
+)
+
+// SchemaVersionHeader carries SchemaVersion on every export response, so
+// a client can tell layouts apart without opening the file (CSV has no
+// other place for it).
+const SchemaVersionHeader = "X-Export-Schema-Version"
+
+type Handler struct {
+	service *Service
+	logger  *slog.Logger
+}
+
+func NewHandler(service *Service, logger *slog.Logger) *Handler {
+	return &Handler{service: service, logger: logger}
+}
+
+var errorMapping = httpx.StoreErrorMapping{ResourceName: "User", NotFoundErr: user.ErrNotFound}
+
+// HandleExport streams GET /me/export?format=csv|json|ndjson as a download.
+// Once the body has started the status can't change, so a failure mid-way
+// aborts the connection: the client sees a broken transfer, never a short
+// file that looks complete.
+func (h *Handler) HandleExport(w http.ResponseWriter, r *http.Request) {
+	principal := auth.GetPrincipal(r)
+	if principal.IsAnonymous() {
+		httpx.WriteJson(w, http.StatusUnauthorized, httpx.Envelope{"error": "Unauthenticated"})
+		return
+	}
+
+	ex, err := h.service.Open(r.Context(), principal.ID, r.URL.Query().Get("format"))
+	if err != nil {
+		if errors.Is(err, ErrValidation) {
+			httpx.WriteJson(w, http.StatusBadRequest, httpx.Envelope{"error": err.Error()})
+			return
+		}
+		httpx.WriteStoreError(r.Context(), w, h.logger, err, errorMapping, "Failed to export data")
+		return
+	}
+
+	w.Header().Set("Content-Type", ex.Format.ContentType())
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": ex.Filename()}))
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set(SchemaVersionHeader, strconv.Itoa(SchemaVersion))
+	w.WriteHeader(http.StatusOK)
+
+	if err := ex.Write(r.Context(), w); err != nil {
+		h.logger.ErrorContext(r.Context(), "export aborted", slog.Any("err", err), slog.Any("user_id", principal.ID))
+		panic(http.ErrAbortHandler)
+	}
+}
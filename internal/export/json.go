@@ -0,0 +1,85 @@
+package export
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/tsatsarisg/go-fit/internal/user"
+	"github.com/tsatsarisg/go-fit/internal/workout"
+)
+
+// header is the metadata both JSON formats open with.
+type header struct {
+	SchemaVersion int        `json:"schema_version"`
+	ExportedAt    time.Time  `json:"exported_at"`
+	Profile       *user.User `json:"profile"`
+}
+
+// jsonEncoder writes a single document,
+//
+//	{"schema_version":1,"exported_at":"…","profile":{…},"workouts":[{…},…]}
+//
+// marshalling one workout at a time rather than building the array.
+type jsonEncoder struct {
+	w     io.Writer
+	wrote bool
+}
+
+func (e *jsonEncoder) begin(ex *Export) error {
+	b, err := json.Marshal(header{SchemaVersion: SchemaVersion, ExportedAt: ex.ExportedAt, Profile: ex.Profile})
+	if err != nil {
+		return err
+	}
+	// Reopen the header object to append the workouts array.
+	b = append(b[:len(b)-1], `,"workouts":[`...)
+	_, err = e.w.Write(b)
+	return err
+}
+
+func (e *jsonEncoder) workout(w *workout.Workout) error {
+	b, err := json.Marshal(w)
+	if err != nil {
+		return err
+	}
+	if e.wrote {
+		b = append([]byte{','}, b...)
+	}
+	e.wrote = true
+	_, err = e.w.Write(b)
+	return err
+}
+
+func (e *jsonEncoder) end() error {
+	_, err := io.WriteString(e.w, "]}\n")
+	return err
+}
+
+// ndjsonEncoder writes one JSON value per line: the header first, tagged
+// "type":"export", then one "type":"workout" line per workout.
+type ndjsonEncoder struct {
+	w io.Writer
+}
+
+type ndjsonHeader struct {
+	Type string `json:"type"`
+	header
+}
+
+type ndjsonWorkout struct {
+	Type    string           `json:"type"`
+	Workout *workout.Workout `json:"workout"`
+}
+
+func (e *ndjsonEncoder) begin(ex *Export) error {
+	return json.NewEncoder(e.w).Encode(ndjsonHeader{
+		Type:   "export",
+		header: header{SchemaVersion: SchemaVersion, ExportedAt: ex.ExportedAt, Profile: ex.Profile},
+	})
+}
+
+func (e *ndjsonEncoder) workout(w *workout.Workout) error {
+	return json.NewEncoder(e.w).Encode(ndjsonWorkout{Type: "workout", Workout: w})
+}
+
+func (e *ndjsonEncoder) end() error { return nil }
@@ -0,0 +1,80 @@
+package export
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/tsatsarisg/go-fit/internal/user"
+	"github.com/tsatsarisg/go-fit/internal/workout"
+)
+
+// SchemaVersion identifies the layout of an export. Bump it whenever a
+// field is renamed or removed, or a CSV column changes meaning; additive
+// JSON fields don't need a bump.
+const SchemaVersion = 1
+
+// Workouts is the slice of workout.Service an export needs.
+type Workouts interface {
+	Export(ctx context.Context, userID user.UserID, fn func(*workout.Workout) error) error
+}
+
+// Profiles is the slice of user.Service an export needs.
+type Profiles interface {
+	Get(ctx context.Context, id user.UserID) (*user.User, error)
+}
+
+var ErrValidation = errors.New("validation failed")
+
+type Service struct {
+	workouts Workouts
+	profiles Profiles
+}
+
+func NewService(workouts Workouts, profiles Profiles) *Service {
+	return &Service{workouts: workouts, profiles: profiles}
+}
+
+// Export is an export that is ready to stream. Everything that can fail
+// with a clean HTTP error (bad format, unknown user) has already happened
+// by the time one exists, so the handler can commit to headers before
+// calling Write.
+type Export struct {
+	Format     Format
+	Profile    *user.User
+	ExportedAt time.Time
+
+	workouts Workouts
+}
+
+// Open validates the format and loads the profile.
+func (s *Service) Open(ctx context.Context, userID user.UserID, format string) (*Export, error) {
+	f, err := ParseFormat(format)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrValidation, err)
+	}
+	profile, err := s.profiles.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return &Export{Format: f, Profile: profile, ExportedAt: time.Now().UTC(), workouts: s.workouts}, nil
+}
+
+// Filename is the suggested download name, e.g. go-fit-export-2026-04-21.csv.
+func (e *Export) Filename() string {
+	return fmt.Sprintf("go-fit-export-%s.%s", e.ExportedAt.Format(time.DateOnly), e.Format.extension())
+}
+
+// Write streams the export to w, one workout at a time.
+func (e *Export) Write(ctx context.Context, w io.Writer) error {
+	enc := e.Format.newEncoder(w)
+	if err := enc.begin(e); err != nil {
+		return err
+	}
+	if err := e.workouts.Export(ctx, e.Profile.ID, enc.workout); err != nil {
+		return err
+	}
+	return enc.end()
+}
@@ -0,0 +1,89 @@
+package httpx
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+type contextKey string
+
+const clientIPContextKey = contextKey("httpx.client_ip")
+
+// TrustProxies makes ClientIP look through the given proxies. A request
+// whose peer is in trusted has its X-Forwarded-For walked from the right,
+// skipping trusted hops; the first address that isn't trusted is the
+// client. Everything to the left of it was written by the client and is
+// ignored. Requests from anywhere else keep their peer address, so a
+// client can't pick its own IP by sending the header. With no trusted
+// proxies the header is never read.
+func TrustProxies(trusted []netip.Prefix) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if len(trusted) == 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if ip, ok := forwardedClient(r, trusted); ok {
+				r = r.WithContext(context.WithValue(r.Context(), clientIPContextKey, ip))
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// forwardedClient is the client address of a request that came through
+// trusted proxies. ok is false when the peer isn't one of them. A hop
+// that doesn't parse ends the walk at the last proxy before it.
+func forwardedClient(r *http.Request, trusted []netip.Prefix) (string, bool) {
+	peer, err := netip.ParseAddr(peerHost(r))
+	if err != nil || !containsAddr(trusted, peer.Unmap()) {
+		return "", false
+	}
+
+	var hops []string
+	for _, v := range r.Header.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(v, ",")...)
+	}
+	client := peer.Unmap()
+	for i := len(hops) - 1; i >= 0; i-- {
+		addr, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			break
+		}
+		client = addr.Unmap()
+		if !containsAddr(trusted, client) {
+			break
+		}
+	}
+	return client.String(), true
+}
+
+func containsAddr(prefixes []netip.Prefix, addr netip.Addr) bool {
+	for _, p := range prefixes {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientIP is the address of the client that sent r, without the port: the
+// peer, or, behind a proxy trusted by TrustProxies, the address it
+// forwarded.
+func ClientIP(r *http.Request) string {
+	if ip, ok := r.Context().Value(clientIPContextKey).(string); ok {
+		return ip
+	}
+	return peerHost(r)
+}
+
+// peerHost is r.RemoteAddr without the port.
+func peerHost(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
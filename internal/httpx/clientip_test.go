@@ -0,0 +1,46 @@
+package httpx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientIP(t *testing.T) {
+	trusted := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8"), netip.MustParsePrefix("192.0.2.1/32")}
+
+	for _, tc := range []struct {
+		name    string
+		trusted []netip.Prefix
+		peer    string
+		xff     []string
+		want    string
+	}{
+		{"no proxies configured", nil, "10.0.0.1:5000", []string{"203.0.113.7"}, "10.0.0.1"},
+		{"untrusted peer", trusted, "198.51.100.9:5000", []string{"203.0.113.7"}, "198.51.100.9"},
+		{"one proxy", trusted, "10.0.0.1:5000", []string{"203.0.113.7"}, "203.0.113.7"},
+		{"chain of proxies", trusted, "10.0.0.1:5000", []string{"203.0.113.7, 192.0.2.1", "10.1.1.1"}, "203.0.113.7"},
+		{"spoofed left hops", trusted, "10.0.0.1:5000", []string{"1.2.3.4, 203.0.113.7"}, "203.0.113.7"},
+		{"no header", trusted, "10.0.0.1:5000", nil, "10.0.0.1"},
+		{"garbage hop", trusted, "10.0.0.1:5000", []string{"203.0.113.7, nonsense"}, "10.0.0.1"},
+		{"ipv4-mapped", trusted, "[::ffff:10.0.0.1]:5000", []string{"::ffff:203.0.113.7"}, "203.0.113.7"},
+		{"ipv6 client", trusted, "10.0.0.1:5000", []string{"2001:db8::1"}, "2001:db8::1"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var got string
+			h := TrustProxies(tc.trusted)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = ClientIP(r)
+			}))
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tc.peer
+			for _, v := range tc.xff {
+				req.Header.Add("X-Forwarded-For", v)
+			}
+			h.ServeHTTP(httptest.NewRecorder(), req)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
@@ -0,0 +1,187 @@
+package httpx
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RateLimitPolicy is a token bucket: Limit requests can be made at once,
+// and the bucket refills at Limit per Period. Name keeps the buckets of
+// different policies apart, so a caller has one bucket per policy.
+type RateLimitPolicy struct {
+	Name   string
+	Limit  int
+	Period time.Duration
+}
+
+// RateLimitDecision is the outcome of one request against a bucket. Reset
+// is how long until the bucket is full again; RetryAfter, when the request
+// was refused, how long until one more would be allowed.
+type RateLimitDecision struct {
+	Allowed    bool
+	Remaining  int
+	Reset      time.Duration
+	RetryAfter time.Duration
+}
+
+// RateLimiter is where the buckets live. MemoryRateLimiter keeps them in
+// the process; PostgresRateLimiter shares them between instances.
+//
+// Both store a bucket as its theoretical arrival time (tat, from GCRA):
+// the time at which it will be full again. That is a single timestamp per
+// bucket, which the Postgres backend can update in one statement.
+type RateLimiter interface {
+	Allow(ctx context.Context, key string, p RateLimitPolicy) (RateLimitDecision, error)
+}
+
+// emission is how long one request's token takes to come back.
+func (p RateLimitPolicy) emission() time.Duration {
+	return p.Period / time.Duration(p.Limit)
+}
+
+// take spends a token from the bucket whose tat is tat (zero for a new
+// bucket). It returns the bucket's new tat, unchanged if the request is
+// refused.
+func (p RateLimitPolicy) take(tat, now time.Time) (time.Time, RateLimitDecision) {
+	if tat.Before(now) {
+		tat = now
+	}
+	next := tat.Add(p.emission())
+	if next.Sub(now) > p.Period {
+		return tat, p.refused(tat, now)
+	}
+	return next, p.allowed(next, now)
+}
+
+// allowed is the decision for a request that moved the bucket's tat to tat.
+func (p RateLimitPolicy) allowed(tat, now time.Time) RateLimitDecision {
+	return RateLimitDecision{
+		Allowed:   true,
+		Remaining: int((p.Period - tat.Sub(now)) / p.emission()),
+		Reset:     tat.Sub(now),
+	}
+}
+
+// refused is the decision for a request turned away by a bucket at tat.
+func (p RateLimitPolicy) refused(tat, now time.Time) RateLimitDecision {
+	return RateLimitDecision{
+		Reset:      tat.Sub(now),
+		RetryAfter: tat.Add(p.emission()).Sub(now) - p.Period,
+	}
+}
+
+// RateLimitKeyFunc names whose bucket a request is taken from.
+type RateLimitKeyFunc func(r *http.Request) string
+
+// RateLimitByIP keys every caller by ClientIP. The app keys authenticated
+// callers by user instead, see auth.RateLimitKey.
+func RateLimitByIP(r *http.Request) string {
+	return "ip:" + ClientIP(r)
+}
+
+// RateLimit builds rate-limiting middleware over one limiter. Policies are
+// picked per route group with Limit and LimitByMethod.
+type RateLimit struct {
+	limiter RateLimiter
+	key     RateLimitKeyFunc
+	logger  *slog.Logger
+}
+
+func NewRateLimit(limiter RateLimiter, key RateLimitKeyFunc, logger *slog.Logger) *RateLimit {
+	return &RateLimit{limiter: limiter, key: key, logger: logger}
+}
+
+// Limit applies p to every request.
+func (rl *RateLimit) Limit(p RateLimitPolicy) func(http.Handler) http.Handler {
+	return rl.LimitByMethod(p, p)
+}
+
+// LimitByMethod applies reads to GET and HEAD requests and writes to the
+// rest.
+//
+// Every response carries RateLimit-Limit, RateLimit-Remaining,
+// RateLimit-Reset (seconds until the bucket is full) and RateLimit-Policy.
+// A refused request gets 429 with Retry-After. If the limiter fails, the
+// request is let through and the error logged: an outage of the limiter's
+// store shouldn't take the API down with it.
+func (rl *RateLimit) LimitByMethod(reads, writes RateLimitPolicy) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			p := writes
+			if r.Method == http.MethodGet || r.Method == http.MethodHead {
+				p = reads
+			}
+
+			d, err := rl.limiter.Allow(r.Context(), p.Name+":"+rl.key(r), p)
+			if err != nil {
+				rl.logger.ErrorContext(r.Context(), "rate limit check failed", slog.Any("err", err))
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			h := w.Header()
+			h.Set("RateLimit-Limit", strconv.Itoa(p.Limit))
+			h.Set("RateLimit-Remaining", strconv.Itoa(d.Remaining))
+			h.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(d.Reset)))
+			h.Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d", p.Limit, ceilSeconds(p.Period)))
+			if !d.Allowed {
+				SetRetryAfter(w, d.RetryAfter)
+				WriteJson(w, http.StatusTooManyRequests, Envelope{"error": "rate limit exceeded"})
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// SetRetryAfter sets the Retry-After header to d, rounded up to whole
+// seconds so the client doesn't come back a moment too early.
+func SetRetryAfter(w http.ResponseWriter, d time.Duration) {
+	w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(d)))
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+// memorySweepInterval is how often MemoryRateLimiter drops full buckets.
+const memorySweepInterval = time.Minute
+
+// MemoryRateLimiter keeps buckets in the process. Each instance counts on
+// its own, so behind a load balancer the effective limit is multiplied by
+// the number of instances; use PostgresRateLimiter there.
+type MemoryRateLimiter struct {
+	mu        sync.Mutex
+	tats      map[string]time.Time
+	lastSweep time.Time
+}
+
+func NewMemoryRateLimiter() *MemoryRateLimiter {
+	return &MemoryRateLimiter{tats: make(map[string]time.Time), lastSweep: time.Now()}
+}
+
+func (m *MemoryRateLimiter) Allow(_ context.Context, key string, p RateLimitPolicy) (RateLimitDecision, error) {
+	now := time.Now()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if now.Sub(m.lastSweep) > memorySweepInterval {
+		for k, tat := range m.tats {
+			if tat.Before(now) {
+				delete(m.tats, k)
+			}
+		}
+		m.lastSweep = now
+	}
+
+	tat, d := p.take(m.tats[key], now)
+	m.tats[key] = tat
+	return d, nil
+}
@@ -0,0 +1,62 @@
+package httpx
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// PostgresRateLimiter keeps buckets in the rate_limits table, so every
+// instance draws from the same ones. Time is the database's, so instances
+// with drifting clocks still agree.
+type PostgresRateLimiter struct {
+	db *sql.DB
+}
+
+func NewPostgresRateLimiter(db *sql.DB) *PostgresRateLimiter {
+	return &PostgresRateLimiter{db: db}
+}
+
+// Allow takes a token in a single upsert, which only moves tat when the
+// request fits in the bucket. When it doesn't, nothing is returned and the
+// bucket is read back to say how long to wait.
+func (l *PostgresRateLimiter) Allow(ctx context.Context, key string, p RateLimitPolicy) (RateLimitDecision, error) {
+	query := `
+		INSERT INTO rate_limits AS b (key, tat)
+		VALUES ($1, now() + $2::bigint * INTERVAL '1 microsecond')
+		ON CONFLICT (key) DO UPDATE
+		SET tat = GREATEST(b.tat, now()) + $2::bigint * INTERVAL '1 microsecond'
+		WHERE GREATEST(b.tat, now()) + $2::bigint * INTERVAL '1 microsecond' <= now() + $3::bigint * INTERVAL '1 microsecond'
+		RETURNING tat, now()`
+
+	var tat, now time.Time
+	err := l.db.QueryRowContext(ctx, query, key, p.emission().Microseconds(), p.Period.Microseconds()).Scan(&tat, &now)
+	if err == nil {
+		return p.allowed(tat, now), nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return RateLimitDecision{}, err
+	}
+
+	// A refused bucket's tat is in the future, and Prune leaves those
+	// alone, so the row is still there.
+	err = l.db.QueryRowContext(ctx, `SELECT tat, now() FROM rate_limits WHERE key = $1`, key).Scan(&tat, &now)
+	if err != nil {
+		return RateLimitDecision{}, err
+	}
+	return p.refused(tat, now), nil
+}
+
+// rateLimitPruneMargin keeps Prune clear of buckets Allow is reading back.
+const rateLimitPruneMargin = time.Minute
+
+// Prune deletes buckets that have been full for a while: they hold no
+// state a fresh row wouldn't. Meant to run periodically.
+func (l *PostgresRateLimiter) Prune(ctx context.Context) (int64, error) {
+	res, err := l.db.ExecContext(ctx, `DELETE FROM rate_limits WHERE tat < now() - $1::bigint * INTERVAL '1 microsecond'`, rateLimitPruneMargin.Microseconds())
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
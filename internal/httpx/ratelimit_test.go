@@ -0,0 +1,113 @@
+package httpx
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimitPolicyTake(t *testing.T) {
+	p := RateLimitPolicy{Name: "test", Limit: 3, Period: time.Minute}
+	now := time.Unix(1_800_000_000, 0)
+
+	var tat time.Time
+	for want := 2; want >= 0; want-- {
+		var d RateLimitDecision
+		tat, d = p.take(tat, now)
+		require.True(t, d.Allowed)
+		assert.Equal(t, want, d.Remaining)
+	}
+
+	_, d := p.take(tat, now)
+	assert.False(t, d.Allowed, "the burst is spent")
+	assert.Equal(t, 20*time.Second, d.RetryAfter, "one token comes back every Period/Limit")
+	assert.Equal(t, time.Minute, d.Reset)
+
+	_, d = p.take(tat, now.Add(20*time.Second))
+	assert.True(t, d.Allowed)
+	assert.Equal(t, 0, d.Remaining)
+
+	_, d = p.take(tat, now.Add(time.Hour))
+	assert.True(t, d.Allowed)
+	assert.Equal(t, 2, d.Remaining, "an idle bucket is full, not fuller")
+}
+
+type failingLimiter struct{}
+
+func (failingLimiter) Allow(context.Context, string, RateLimitPolicy) (RateLimitDecision, error) {
+	return RateLimitDecision{}, errors.New("db down")
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusNoContent) })
+	reads := RateLimitPolicy{Name: "read", Limit: 5, Period: time.Minute}
+	writes := RateLimitPolicy{Name: "write", Limit: 1, Period: time.Minute}
+	h := NewRateLimit(NewMemoryRateLimiter(), RateLimitByIP, logger).LimitByMethod(reads, writes)(ok)
+
+	serve := func(method, addr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/", nil)
+		req.RemoteAddr = addr
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := serve(http.MethodPost, "192.0.2.1:1234")
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, "1", rec.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "0", rec.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "60", rec.Header().Get("RateLimit-Reset"))
+	assert.Equal(t, "1;w=60", rec.Header().Get("RateLimit-Policy"))
+
+	rec = serve(http.MethodPost, "192.0.2.1:5678")
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code, "keyed by IP, not port")
+	assert.Equal(t, "60", rec.Header().Get("Retry-After"))
+
+	rec = serve(http.MethodGet, "192.0.2.1:1234")
+	assert.Equal(t, http.StatusNoContent, rec.Code, "reads have their own bucket")
+	assert.Equal(t, "4", rec.Header().Get("RateLimit-Remaining"))
+
+	rec = serve(http.MethodPost, "192.0.2.2:1234")
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+
+	h = NewRateLimit(failingLimiter{}, RateLimitByIP, logger).Limit(writes)(ok)
+	rec = serve(http.MethodPost, "192.0.2.1:1234")
+	assert.Equal(t, http.StatusNoContent, rec.Code, "fails open")
+	assert.Empty(t, rec.Header().Get("RateLimit-Limit"))
+}
+
+// TestRateLimitBehindProxy keys clients behind a trusted proxy by the
+// address it forwarded, and ignores the header from anyone else.
+func TestRateLimitBehindProxy(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusNoContent) })
+	limit := NewRateLimit(NewMemoryRateLimiter(), RateLimitByIP, logger).Limit(RateLimitPolicy{Name: "auth", Limit: 1, Period: time.Minute})
+	h := TrustProxies([]netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")})(limit(ok))
+
+	serve := func(peer, forwarded string) int {
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		req.RemoteAddr = peer
+		req.Header.Set("X-Forwarded-For", forwarded)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusNoContent, serve("10.0.0.1:1234", "203.0.113.7"))
+	assert.Equal(t, http.StatusNoContent, serve("10.0.0.1:1234", "203.0.113.8"), "another client behind the same proxy")
+	assert.Equal(t, http.StatusTooManyRequests, serve("10.0.0.2:1234", "203.0.113.7"), "the same client through another proxy")
+	assert.Equal(t, http.StatusTooManyRequests, serve("10.0.0.1:1234", "198.51.100.1, 203.0.113.7"), "a spoofed hop doesn't buy a new bucket")
+
+	assert.Equal(t, http.StatusNoContent, serve("192.0.2.1:1234", "203.0.113.9"))
+	assert.Equal(t, http.StatusTooManyRequests, serve("192.0.2.1:1234", "203.0.113.10"), "an untrusted peer is keyed by its own address")
+}
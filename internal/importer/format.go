@@ -0,0 +1,97 @@
+package importer
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/tsatsarisg/go-fit/internal/workout"
+)
+
+// Row is one CSV line: a single set, plus the workout it belongs to. Every
+// Format reduces its own columns to this shape, and grouping rows into
+// workouts is shared.
+type Row struct {
+	Line            int
+	Start           time.Time
+	Title           string
+	Description     string
+	DurationMinutes int
+	CaloriesBurned  int
+	Exercise        string
+	Notes           string
+	Set             workout.WorkoutSet
+}
+
+// Format is a pluggable CSV dialect. Detect is asked about the header when
+// the client doesn't name a format; Parse converts one record, returning
+// skip=true for lines that carry no set (Strong's rest-timer rows, say).
+type Format interface {
+	Name() string
+	Detect(header []string) bool
+	Parse(rec Record, loc *time.Location) (row Row, skip bool, err error)
+}
+
+// formats is the registry, in detection order. Generic goes last: its
+// columns are the most likely to overlap with something else.
+var formats = []Format{strongFormat{}, hevyFormat{}, genericFormat{}}
+
+// FormatNames lists the accepted values of the format form field.
+func FormatNames() []string {
+	names := make([]string, len(formats))
+	for i, f := range formats {
+		names[i] = f.Name()
+	}
+	return names
+}
+
+func lookupFormat(name string, header []string) (Format, error) {
+	for _, f := range formats {
+		if name == "" && f.Detect(header) || name != "" && f.Name() == name {
+			return f, nil
+		}
+	}
+	if name != "" {
+		return nil, fmt.Errorf("format must be one of %s", strings.Join(FormatNames(), ", "))
+	}
+	return nil, errors.New("unrecognised CSV header; pass format explicitly")
+}
+
+// Record is a CSV line addressed by header name. Lookups are
+// case-insensitive and a missing column reads as "".
+type Record struct {
+	columns map[string]int
+	fields  []string
+}
+
+func newColumns(header []string) map[string]int {
+	columns := make(map[string]int, len(header))
+	for i, h := range header {
+		columns[normalizeHeader(h)] = i
+	}
+	return columns
+}
+
+func normalizeHeader(h string) string {
+	return strings.ToLower(strings.TrimSpace(strings.TrimPrefix(h, "\ufeff")))
+}
+
+func (r Record) Get(name string) string {
+	i, ok := r.columns[name]
+	if !ok || i >= len(r.fields) {
+		return ""
+	}
+	return strings.TrimSpace(r.fields[i])
+}
+
+// hasColumns reports whether header contains every one of names.
+func hasColumns(header []string, names ...string) bool {
+	columns := newColumns(header)
+	for _, n := range names {
+		if _, ok := columns[n]; !ok {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,88 @@
+package importer
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/tsatsarisg/go-fit/internal/workout"
+)
+
+// GenericColumns is the documented column mapping of the generic format,
+// in the order the exporter writes them. Only date, title and exercise are
+// required; the rest may be omitted or left empty.
+var GenericColumns = []string{
+	"date", "title", "description", "duration_minutes", "calories_burned",
+	"exercise", "notes", "set_type", "reps", "duration_seconds", "weight", "rpe", "completed",
+}
+
+// genericFormat is go-fit's own CSV: one row per set, rows of a workout
+// sharing date and title. It is also what GET /me/export?format=csv writes,
+// so an export imports back unchanged.
+type genericFormat struct{}
+
+func (genericFormat) Name() string { return "generic" }
+
+func (genericFormat) Detect(header []string) bool {
+	return hasColumns(header, "date", "title", "exercise")
+}
+
+// genericTimeLayouts are tried in order; zone-less values are read in the
+// import's time zone.
+var genericTimeLayouts = []string{time.RFC3339Nano, time.DateTime, "2006-01-02T15:04:05", "2006-01-02 15:04", time.DateOnly}
+
+func (genericFormat) Parse(rec Record, loc *time.Location) (Row, bool, error) {
+	start, err := parseTime("date", rec.Get("date"), loc, genericTimeLayouts...)
+	if err != nil {
+		return Row{}, false, err
+	}
+	duration, err := parseInt("duration_minutes", rec.Get("duration_minutes"))
+	if err != nil {
+		return Row{}, false, err
+	}
+	calories, err := parseInt("calories_burned", rec.Get("calories_burned"))
+	if err != nil {
+		return Row{}, false, err
+	}
+	reps, err := parseOptionalInt("reps", rec.Get("reps"))
+	if err != nil {
+		return Row{}, false, err
+	}
+	seconds, err := parseOptionalInt("duration_seconds", rec.Get("duration_seconds"))
+	if err != nil {
+		return Row{}, false, err
+	}
+	weight, err := parseOptionalFloat("weight", rec.Get("weight"))
+	if err != nil {
+		return Row{}, false, err
+	}
+	rpe, err := parseOptionalFloat("rpe", rec.Get("rpe"))
+	if err != nil {
+		return Row{}, false, err
+	}
+	completed := true
+	if c := rec.Get("completed"); c != "" {
+		completed, err = strconv.ParseBool(c)
+		if err != nil {
+			return Row{}, false, fmt.Errorf("completed: %q is not true or false", c)
+		}
+	}
+
+	return Row{
+		Start:           start,
+		Title:           rec.Get("title"),
+		Description:     rec.Get("description"),
+		DurationMinutes: duration,
+		CaloriesBurned:  calories,
+		Exercise:        rec.Get("exercise"),
+		Notes:           rec.Get("notes"),
+		Set: workout.WorkoutSet{
+			Type:            workout.SetType(rec.Get("set_type")),
+			Reps:            reps,
+			DurationSeconds: seconds,
+			Weight:          weight,
+			RPE:             rpe,
+			Completed:       completed,
+		},
+	}, false, nil
+}
@@ -0,0 +1,122 @@
+package importer
+
+import (
+	"errors"
+	"time"
+
+	"github.com/tsatsarisg/go-fit/internal/workout"
+)
+
+// grouper folds per-set rows into workouts: rows with the same start time
+// and title form one workout, and consecutive rows of the same exercise
+// within it form one entry. None of the supported formats carries a workout
+// id, so the start time is compared in full, to the microsecond Postgres
+// keeps, and the stored workout gets that same time so a re-import finds it
+// as a duplicate. Source lines are kept alongside each set so validation
+// errors can point back at the file.
+type grouper struct {
+	byKey    map[groupKey]*groupedWorkout
+	workouts []*groupedWorkout
+}
+
+type groupKey struct {
+	start int64
+	title string
+}
+
+type groupedWorkout struct {
+	workout *workout.Workout
+	lines   [][]int // lines[i][j] is the source line of Entries[i].LoggedSets[j]
+	first   int
+}
+
+func newGrouper() *grouper {
+	return &grouper{byKey: make(map[groupKey]*groupedWorkout)}
+}
+
+func (g *grouper) add(row Row) {
+	start := row.Start.Truncate(time.Microsecond)
+	key := groupKey{start.UnixMicro(), row.Title}
+	gw, ok := g.byKey[key]
+	if !ok {
+		gw = &groupedWorkout{
+			workout: &workout.Workout{
+				Title:           row.Title,
+				Description:     row.Description,
+				DurationMinutes: row.DurationMinutes,
+				CaloriesBurned:  row.CaloriesBurned,
+				Visibility:      workout.VisibilityPrivate,
+				CreatedAt:       start,
+			},
+			first: row.Line,
+		}
+		g.byKey[key] = gw
+		g.workouts = append(g.workouts, gw)
+	}
+
+	// A row with no exercise and no set is the workout itself: that is how
+	// the exporter writes a workout that has no entries.
+	if row.Exercise == "" && row.Set.Reps == nil && row.Set.DurationSeconds == nil && row.Set.Weight == nil {
+		return
+	}
+
+	w := gw.workout
+	last := len(w.Entries) - 1
+	if last < 0 || w.Entries[last].ExerciseName != row.Exercise {
+		w.Entries = append(w.Entries, workout.WorkoutEntry{
+			ExerciseName: row.Exercise,
+			Notes:        row.Notes,
+			OrderIndex:   len(w.Entries),
+		})
+		gw.lines = append(gw.lines, nil)
+		last++
+	}
+	entry := &w.Entries[last]
+	if entry.Notes == "" {
+		entry.Notes = row.Notes
+	}
+	entry.LoggedSets = append(entry.LoggedSets, row.Set)
+	gw.lines[last] = append(gw.lines[last], row.Line)
+}
+
+// validate runs Workout.Validate, dropping the offending set or entry and
+// recording a RowError each time, until the workout is valid. It returns
+// nil when the workout itself is invalid or nothing is left of it.
+func (gw *groupedWorkout) validate(errs *[]RowError) *workout.Workout {
+	w := gw.workout
+	hadEntries := len(w.Entries) > 0
+	for {
+		err := w.Validate()
+		if err == nil {
+			break
+		}
+		var entryErr *workout.EntryError
+		if !errors.As(err, &entryErr) {
+			*errs = append(*errs, RowError{Row: gw.first, Error: err.Error()})
+			return nil
+		}
+
+		i, j := entryErr.Entry, entryErr.Set
+		entry := &w.Entries[i]
+		if j >= 0 && len(entry.LoggedSets) > 1 {
+			*errs = append(*errs, RowError{Row: gw.lines[i][j], Error: err.Error()})
+			entry.LoggedSets = append(entry.LoggedSets[:j], entry.LoggedSets[j+1:]...)
+			gw.lines[i] = append(gw.lines[i][:j], gw.lines[i][j+1:]...)
+			continue
+		}
+		line := gw.lines[i][0]
+		if j >= 0 {
+			line = gw.lines[i][j]
+		}
+		*errs = append(*errs, RowError{Row: line, Error: err.Error()})
+		w.Entries = append(w.Entries[:i], w.Entries[i+1:]...)
+		gw.lines = append(gw.lines[:i], gw.lines[i+1:]...)
+		for k := range w.Entries {
+			w.Entries[k].OrderIndex = k
+		}
+	}
+	if hadEntries && len(w.Entries) == 0 {
+		return nil
+	}
+	return w
+}
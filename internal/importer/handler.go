@@ -0,0 +1,77 @@
+package importer
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/tsatsarisg/go-fit/internal/auth"
+	"github.com/tsatsarisg/go-fit/internal/httpx"
+	"github.com/tsatsarisg/go-fit/internal/workout"
+)
+
+// maxUploadBytes caps the multipart body; a multi-year Strong export is a
+// few MB.
+const maxUploadBytes = 10 << 20
+
+type Handler struct {
+	service *Service
+	logger  *slog.Logger
+}
+
+func NewHandler(service *Service, logger *slog.Logger) *Handler {
+	return &Handler{service: service, logger: logger}
+}
+
+var errorMapping = httpx.StoreErrorMapping{ResourceName: "Import"}
+
+// HandleImport accepts multipart/form-data with the CSV in "file" and
+// optional "format" and "tz" fields.
+func (h *Handler) HandleImport(w http.ResponseWriter, r *http.Request) {
+	principal := auth.GetPrincipal(r)
+	if principal.IsAnonymous() {
+		httpx.WriteJson(w, http.StatusUnauthorized, httpx.Envelope{"error": "Unauthenticated"})
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxUploadBytes)
+	if err := r.ParseMultipartForm(maxUploadBytes); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			httpx.WriteJson(w, http.StatusRequestEntityTooLarge, httpx.Envelope{"error": fmt.Sprintf("upload must not be larger than %d bytes", maxUploadBytes)})
+			return
+		}
+		httpx.WriteJson(w, http.StatusBadRequest, httpx.Envelope{"error": "request must be multipart/form-data with a CSV in the \"file\" field"})
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		httpx.WriteJson(w, http.StatusBadRequest, httpx.Envelope{"error": "file: a CSV upload is required"})
+		return
+	}
+	defer file.Close()
+
+	report, err := h.service.Import(r.Context(), ImportCommand{
+		UserID:   principal.ID,
+		Format:   r.FormValue("format"),
+		TimeZone: r.FormValue("tz"),
+		File:     file,
+	})
+	if err != nil {
+		if errors.Is(err, ErrValidation) || errors.Is(err, workout.ErrValidation) {
+			httpx.WriteJson(w, http.StatusBadRequest, httpx.Envelope{"error": err.Error()})
+			return
+		}
+		httpx.WriteStoreError(r.Context(), w, h.logger, err, errorMapping, "Failed to import workouts")
+		return
+	}
+
+	status := http.StatusOK
+	if len(report.Created) > 0 {
+		status = http.StatusCreated
+	}
+	httpx.WriteJson(w, status, httpx.Envelope{"import": report})
+}
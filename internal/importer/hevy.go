@@ -0,0 +1,86 @@
+package importer
+
+import (
+	"time"
+
+	"github.com/tsatsarisg/go-fit/internal/workout"
+)
+
+// hevyFormat reads Hevy's workout export: one row per set, timestamps like
+// "26 Apr 2024, 18:30", weights already in kilograms.
+type hevyFormat struct{}
+
+func (hevyFormat) Name() string { return "hevy" }
+
+func (hevyFormat) Detect(header []string) bool {
+	return hasColumns(header, "title", "start_time", "exercise_title", "set_type")
+}
+
+const hevyTimeLayout = "2 Jan 2006, 15:04"
+
+func (hevyFormat) Parse(rec Record, loc *time.Location) (Row, bool, error) {
+	start, err := parseTime("start_time", rec.Get("start_time"), loc, hevyTimeLayout)
+	if err != nil {
+		return Row{}, false, err
+	}
+	var duration int
+	if end := rec.Get("end_time"); end != "" {
+		endTime, err := parseTime("end_time", end, loc, hevyTimeLayout)
+		if err != nil {
+			return Row{}, false, err
+		}
+		if endTime.After(start) {
+			duration = int(endTime.Sub(start).Minutes())
+		}
+	}
+	reps, err := parseOptionalInt("reps", rec.Get("reps"))
+	if err != nil {
+		return Row{}, false, err
+	}
+	seconds, err := parseOptionalInt("duration_seconds", rec.Get("duration_seconds"))
+	if err != nil {
+		return Row{}, false, err
+	}
+	weight, err := parseOptionalFloat("weight_kg", rec.Get("weight_kg"))
+	if err != nil {
+		return Row{}, false, err
+	}
+	rpe, err := parseOptionalFloat("rpe", rec.Get("rpe"))
+	if err != nil {
+		return Row{}, false, err
+	}
+
+	set := workout.WorkoutSet{
+		Type:      hevySetType(rec.Get("set_type")),
+		Weight:    positiveFloat(weight),
+		RPE:       rpe,
+		Completed: true,
+	}
+	if reps == nil && positiveInt(seconds) != nil {
+		set.DurationSeconds = seconds
+	} else {
+		set.Reps = reps
+	}
+
+	return Row{
+		Start:           start,
+		Title:           rec.Get("title"),
+		Description:     rec.Get("description"),
+		DurationMinutes: duration,
+		Exercise:        rec.Get("exercise_title"),
+		Notes:           rec.Get("exercise_notes"),
+		Set:             set,
+	}, false, nil
+}
+
+func hevySetType(t string) workout.SetType {
+	switch t {
+	case "warmup":
+		return workout.SetTypeWarmup
+	case "dropset":
+		return workout.SetTypeDrop
+	case "failure":
+		return workout.SetTypeFailure
+	}
+	return workout.SetTypeWorking
+}
@@ -0,0 +1,98 @@
+package importer
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Field parsers shared by the formats. Empty input is "absent" (nil), not
+// zero, so a format can tell "no reps column value" from "0 reps".
+
+func parseOptionalInt(field, s string) (*int, error) {
+	if s == "" {
+		return nil, nil
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil || f != math.Trunc(f) {
+		return nil, fmt.Errorf("%s: %q is not a whole number", field, s)
+	}
+	n := int(f)
+	return &n, nil
+}
+
+func parseOptionalFloat(field, s string) (*float64, error) {
+	if s == "" {
+		return nil, nil
+	}
+	f, err := strconv.ParseFloat(strings.ReplaceAll(s, ",", "."), 64)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %q is not a number", field, s)
+	}
+	return &f, nil
+}
+
+func parseInt(field, s string) (int, error) {
+	n, err := parseOptionalInt(field, s)
+	if err != nil || n == nil {
+		return 0, err
+	}
+	return *n, nil
+}
+
+// positive drops zero values: exporters write 0 for "not applicable".
+func positiveInt(n *int) *int {
+	if n == nil || *n == 0 {
+		return nil
+	}
+	return n
+}
+
+func positiveFloat(f *float64) *float64 {
+	if f == nil || *f == 0 {
+		return nil
+	}
+	return f
+}
+
+func parseTime(field, s string, loc *time.Location, layouts ...string) (time.Time, error) {
+	for _, layout := range layouts {
+		if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%s: %q is not a recognised date", field, s)
+}
+
+var durationPart = regexp.MustCompile(`(\d+)\s*([hms])`)
+
+// parseClockDuration reads Strong's "1h 5m" / "45m" / "30s" durations as
+// whole minutes.
+func parseClockDuration(s string) (int, error) {
+	if s == "" {
+		return 0, nil
+	}
+	if n, err := strconv.Atoi(s); err == nil {
+		return n / 60, nil
+	}
+	parts := durationPart.FindAllStringSubmatch(s, -1)
+	if len(parts) == 0 {
+		return 0, fmt.Errorf("duration: %q is not a recognised duration", s)
+	}
+	var d time.Duration
+	for _, p := range parts {
+		n, _ := strconv.Atoi(p[1])
+		switch p[2] {
+		case "h":
+			d += time.Duration(n) * time.Hour
+		case "m":
+			d += time.Duration(n) * time.Minute
+		case "s":
+			d += time.Duration(n) * time.Second
+		}
+	}
+	return int(d.Minutes()), nil
+}
@@ -0,0 +1,172 @@
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/tsatsarisg/go-fit/internal/user"
+	"github.com/tsatsarisg/go-fit/internal/workout"
+)
+
+// Workouts is the narrow slice of workout.Service an import needs: one call
+// that validates and saves the whole batch in a single transaction.
+type Workouts interface {
+	Import(ctx context.Context, userID user.UserID, workouts []*workout.Workout) (*workout.ImportResult, error)
+}
+
+// ErrValidation covers problems with the upload as a whole (unknown format
+// or time zone, unreadable CSV, too many rows) → 400. Problems with single
+// rows are reported in Report.Errors instead.
+var ErrValidation = errors.New("validation failed")
+
+func wrapValidation(err error) error {
+	if err == nil {
+		return nil
+	}
+	return fmt.Errorf("%w: %v", ErrValidation, err)
+}
+
+// maxRows bounds one upload; at one row per set this is years of history.
+const maxRows = 100_000
+
+type Service struct {
+	workouts Workouts
+}
+
+func NewService(workouts Workouts) *Service {
+	return &Service{workouts: workouts}
+}
+
+// ImportCommand is one uploaded file. Format may be empty to detect it from
+// the header; TimeZone (IANA, default UTC) is how zone-less timestamps in
+// the file are read.
+type ImportCommand struct {
+	UserID   user.UserID
+	Format   string
+	TimeZone string
+	File     io.Reader
+}
+
+// Report is the outcome of an import.
+type Report struct {
+	Format     string          `json:"format"`
+	Rows       int             `json:"rows"`
+	Created    []WorkoutReport `json:"created"`
+	Duplicates []WorkoutReport `json:"duplicates"`
+	Errors     []RowError      `json:"errors"`
+}
+
+type WorkoutReport struct {
+	ID      workout.WorkoutID `json:"id,omitempty"`
+	Title   string            `json:"title"`
+	Date    time.Time         `json:"date"`
+	Entries int               `json:"entries"`
+}
+
+// RowError points at a CSV line (1-based, header is line 1). Validation
+// messages are WorkoutEntry.Validate's, indexed within the row's workout.
+type RowError struct {
+	Row   int    `json:"row"`
+	Error string `json:"error"`
+}
+
+func (s *Service) Import(ctx context.Context, cmd ImportCommand) (*Report, error) {
+	loc := time.UTC
+	if cmd.TimeZone != "" {
+		var err error
+		if loc, err = time.LoadLocation(cmd.TimeZone); err != nil {
+			return nil, wrapValidation(fmt.Errorf("tz: unknown time zone %q", cmd.TimeZone))
+		}
+	}
+
+	br := bufio.NewReader(cmd.File)
+	r := csv.NewReader(br)
+	r.Comma = sniffDelimiter(br)
+	r.FieldsPerRecord = -1
+	r.ReuseRecord = true
+
+	header, err := r.Read()
+	if err != nil {
+		return nil, wrapValidation(fmt.Errorf("reading CSV header: %v", err))
+	}
+	header = append([]string(nil), header...)
+	format, err := lookupFormat(cmd.Format, header)
+	if err != nil {
+		return nil, wrapValidation(err)
+	}
+
+	report := &Report{Format: format.Name(), Created: []WorkoutReport{}, Duplicates: []WorkoutReport{}, Errors: []RowError{}}
+	columns := newColumns(header)
+	g := newGrouper()
+	for {
+		fields, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				report.Errors = append(report.Errors, RowError{Row: parseErr.Line, Error: parseErr.Err.Error()})
+				continue
+			}
+			return nil, wrapValidation(err)
+		}
+		// Only a record that parsed has field positions.
+		line, _ := r.FieldPos(0)
+		if report.Rows++; report.Rows > maxRows {
+			return nil, wrapValidation(fmt.Errorf("an import may contain at most %d rows", maxRows))
+		}
+
+		row, skip, err := format.Parse(Record{columns: columns, fields: fields}, loc)
+		if err != nil {
+			report.Errors = append(report.Errors, RowError{Row: line, Error: err.Error()})
+			continue
+		}
+		if skip {
+			continue
+		}
+		row.Line = line
+		g.add(row)
+	}
+
+	var valid []*workout.Workout
+	for _, gw := range g.workouts {
+		if w := gw.validate(&report.Errors); w != nil {
+			valid = append(valid, w)
+		}
+	}
+	if len(valid) == 0 {
+		return report, nil
+	}
+
+	result, err := s.workouts.Import(ctx, cmd.UserID, valid)
+	if err != nil {
+		return nil, err
+	}
+	for _, w := range result.Created {
+		report.Created = append(report.Created, WorkoutReport{ID: w.ID, Title: w.Title, Date: w.CreatedAt, Entries: len(w.Entries)})
+	}
+	for _, w := range result.Duplicates {
+		report.Duplicates = append(report.Duplicates, WorkoutReport{Title: w.Title, Date: w.CreatedAt, Entries: len(w.Entries)})
+	}
+	return report, nil
+}
+
+// sniffDelimiter picks ';' over ',' when the header line has more of them:
+// Strong writes semicolons in locales that use a decimal comma.
+func sniffDelimiter(br *bufio.Reader) rune {
+	peek, _ := br.Peek(4096)
+	if i := bytes.IndexByte(peek, '\n'); i >= 0 {
+		peek = peek[:i]
+	}
+	if bytes.Count(peek, []byte{';'}) > bytes.Count(peek, []byte{','}) {
+		return ';'
+	}
+	return ','
+}
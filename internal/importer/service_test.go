@@ -0,0 +1,127 @@
+package importer
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tsatsarisg/go-fit/internal/user"
+	"github.com/tsatsarisg/go-fit/internal/workout"
+)
+
+// fakeWorkouts accepts every workout, so these tests cover parsing,
+// grouping and row-level validation without a database.
+type fakeWorkouts struct {
+	got []*workout.Workout
+}
+
+func (f *fakeWorkouts) Import(_ context.Context, _ user.UserID, ws []*workout.Workout) (*workout.ImportResult, error) {
+	f.got = ws
+	return &workout.ImportResult{Created: ws, Duplicates: []*workout.Workout{}}, nil
+}
+
+func TestImport(t *testing.T) {
+	tests := []struct {
+		name       string
+		format     string
+		csv        string
+		wantFormat string
+		wantSets   [][]int // per workout, logged sets per entry
+		wantErrors []RowError
+	}{
+		{
+			name: "strong",
+			csv: "Date;Workout Name;Duration;Exercise Name;Set Order;Weight;Reps;Distance;Seconds;Notes;Workout Notes;RPE\n" +
+				"2024-04-26 18:30:00;Push;1h 5m;Bench Press (Barbell);W;40;10;0;0;;;\n" +
+				"2024-04-26 18:30:00;Push;1h 5m;Bench Press (Barbell);1;80;5;0;0;;;8\n" +
+				"2024-04-26 18:30:00;Push;1h 5m;Bench Press (Barbell);Rest Timer;0;0;0;90;;;\n" +
+				"2024-04-26 18:30:00;Push;1h 5m;Bench Press (Barbell);2;80;5;0;0;;;15\n" +
+				"2024-04-26 18:30:00;Push;1h 5m;Plank;1;0;0;0;60;;;\n" +
+				"2024-04-28 09:00:00;Legs;45m;Squat (Barbell);1;100;five;0;0;;;\n",
+			wantFormat: "strong",
+			wantSets:   [][]int{{2, 1}},
+			wantErrors: []RowError{
+				{Row: 7, Error: `Reps: "five" is not a whole number`},
+				{Row: 5, Error: "entries[0].logged_sets[2]: rpe must be between 1 and 10"},
+			},
+		},
+		{
+			name: "hevy",
+			csv: "title,start_time,end_time,description,exercise_title,superset_id,exercise_notes,set_index,set_type,weight_kg,reps,distance_km,duration_seconds,rpe\n" +
+				"Upper,\"26 Apr 2024, 18:30\",\"26 Apr 2024, 19:30\",,Pull Up,,,0,warmup,,5,,,\n" +
+				"Upper,\"26 Apr 2024, 18:30\",\"26 Apr 2024, 19:30\",,Pull Up,,,1,normal,10,8,,,\n" +
+				"Upper,\"26 Apr 2024, 18:30\",\"26 Apr 2024, 19:30\",,Row,,,0,normal,60,10,,,\n",
+			wantFormat: "hevy",
+			wantSets:   [][]int{{2, 1}},
+		},
+		{
+			name:   "generic with explicit format",
+			format: "generic",
+			csv: "date,title,exercise,set_type,reps,duration_seconds,weight\n" +
+				"2024-04-26,Run,,working,,1200,\n" +
+				"2024-04-27T07:00:00Z,Lift,Deadlift,working,5,,140\n" +
+				"2024-04-27T07:00:00Z,Lift,Deadlift,bogus,5,,140\n",
+			wantFormat: "generic",
+			wantSets:   [][]int{{1}},
+			wantErrors: []RowError{
+				{Row: 2, Error: "entries[0]: exercise_name or exercise_id is required"},
+				{Row: 4, Error: `entries[0].logged_sets[1]: type must be one of "warmup", "working", "drop" or "failure"`},
+			},
+		},
+		{
+			name: "generic with a bare quote",
+			csv: "date,title,exercise,set_type,reps,duration_seconds,weight\n" +
+				"2024-04-27T07:00:00Z,Lift,Deadlift,working,5,,140\n" +
+				"x\"y,Lift,Deadlift,working,5,,140\n",
+			wantFormat: "generic",
+			wantSets:   [][]int{{1}},
+			wantErrors: []RowError{
+				{Row: 3, Error: `bare " in non-quoted-field`},
+			},
+		},
+		{
+			name: "generic sub-second start times",
+			csv: "date,title,exercise,set_type,reps,duration_seconds,weight\n" +
+				"2024-04-27T07:00:00.25Z,Lift,Deadlift,working,5,,140\n" +
+				"2024-04-27T07:00:00.25Z,Lift,Deadlift,working,5,,140\n" +
+				"2024-04-27T07:00:00.75Z,Lift,Deadlift,working,5,,140\n",
+			wantFormat: "generic",
+			wantSets:   [][]int{{2}, {1}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeWorkouts{}
+			report, err := NewService(fake).Import(context.Background(), ImportCommand{
+				UserID: 1,
+				Format: tt.format,
+				File:   strings.NewReader(tt.csv),
+			})
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantFormat, report.Format)
+
+			var sets [][]int
+			for _, w := range fake.got {
+				var perEntry []int
+				for _, e := range w.Entries {
+					perEntry = append(perEntry, len(e.LoggedSets))
+				}
+				sets = append(sets, perEntry)
+			}
+			assert.Equal(t, tt.wantSets, sets)
+			assert.ElementsMatch(t, tt.wantErrors, report.Errors)
+		})
+	}
+}
+
+func TestImportRejectsUnknownHeader(t *testing.T) {
+	_, err := NewService(&fakeWorkouts{}).Import(context.Background(), ImportCommand{
+		UserID: 1,
+		File:   strings.NewReader("foo,bar\n1,2\n"),
+	})
+	assert.ErrorIs(t, err, ErrValidation)
+}
@@ -0,0 +1,86 @@
+package importer
+
+import (
+	"strings"
+	"time"
+
+	"github.com/tsatsarisg/go-fit/internal/workout"
+)
+
+// strongFormat reads the Strong app's "Export Data" CSV: one row per set,
+// with "Set Order" holding a number, or W / D / F for warmup, drop and
+// failure sets. Weights are taken as kilograms.
+type strongFormat struct{}
+
+func (strongFormat) Name() string { return "strong" }
+
+func (strongFormat) Detect(header []string) bool {
+	return hasColumns(header, "date", "workout name", "exercise name", "set order")
+}
+
+func (strongFormat) Parse(rec Record, loc *time.Location) (Row, bool, error) {
+	order := rec.Get("set order")
+	if strings.EqualFold(order, "rest timer") {
+		return Row{}, true, nil
+	}
+
+	start, err := parseTime("Date", rec.Get("date"), loc, time.DateTime, "2006-01-02 15:04")
+	if err != nil {
+		return Row{}, false, err
+	}
+	duration, err := parseClockDuration(rec.Get("duration"))
+	if err != nil {
+		return Row{}, false, err
+	}
+	reps, err := parseOptionalInt("Reps", rec.Get("reps"))
+	if err != nil {
+		return Row{}, false, err
+	}
+	seconds, err := parseOptionalInt("Seconds", rec.Get("seconds"))
+	if err != nil {
+		return Row{}, false, err
+	}
+	weight, err := parseOptionalFloat("Weight", rec.Get("weight"))
+	if err != nil {
+		return Row{}, false, err
+	}
+	rpe, err := parseOptionalFloat("RPE", rec.Get("rpe"))
+	if err != nil {
+		return Row{}, false, err
+	}
+
+	set := workout.WorkoutSet{
+		Type:      strongSetType(order),
+		Weight:    positiveFloat(weight),
+		RPE:       positiveFloat(rpe),
+		Completed: true,
+	}
+	// Strong writes 0 into whichever of Reps / Seconds doesn't apply.
+	if positiveInt(seconds) != nil && positiveInt(reps) == nil {
+		set.DurationSeconds = seconds
+	} else {
+		set.Reps = reps
+	}
+
+	return Row{
+		Start:           start,
+		Title:           rec.Get("workout name"),
+		Description:     rec.Get("workout notes"),
+		DurationMinutes: duration,
+		Exercise:        rec.Get("exercise name"),
+		Notes:           rec.Get("notes"),
+		Set:             set,
+	}, false, nil
+}
+
+func strongSetType(order string) workout.SetType {
+	switch strings.ToUpper(order) {
+	case "W":
+		return workout.SetTypeWarmup
+	case "D":
+		return workout.SetTypeDrop
+	case "F":
+		return workout.SetTypeFailure
+	}
+	return workout.SetTypeWorking
+}
@@ -0,0 +1,220 @@
+// Package breached answers "has this password appeared in a breach?" from
+// a local corpus, without sending anything anywhere. The corpus is a bloom
+// filter over the SHA-1 of each password, which is how Have I Been Pwned
+// publishes its Pwned Passwords list, so the filter is built from the hashes
+// and no plaintext is ever needed.
+//
+// A bloom filter never misses a breached password, and wrongly flags a
+// clean one with the probability it was built for. For a password policy
+// that error is harmless: the user picks another password.
+package breached
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// magic starts a filter file written by WriteTo.
+const magic = "GOFITBF1"
+
+// textFalsePositiveRate is used for filters built in memory from a text
+// file at Load time; such files are small enough to afford it.
+const textFalsePositiveRate = 1e-6
+
+// ErrMalformed is a corpus file that is neither a filter nor a hash list.
+var ErrMalformed = errors.New("malformed breached-password file")
+
+// Filter is a bloom filter of SHA-1 digests.
+type Filter struct {
+	bits []uint64
+	m    uint64
+	k    uint32
+}
+
+// New sizes a filter for n hashes at the given false-positive rate.
+func New(n uint64, falsePositiveRate float64) *Filter {
+	if n == 0 {
+		n = 1
+	}
+	m := uint64(math.Ceil(-float64(n) * math.Log(falsePositiveRate) / (math.Ln2 * math.Ln2)))
+	m = max(64, (m+63)/64*64)
+	k := uint32(max(1, math.Round(float64(m)/float64(n)*math.Ln2)))
+	return &Filter{bits: make([]uint64, m/64), m: m, k: k}
+}
+
+// indexes derives the filter's k bit positions from a digest by double
+// hashing: SHA-1 output is already uniform, so two slices of it will do.
+func (f *Filter) indexes(sum [sha1.Size]byte, yield func(uint64) bool) {
+	h1 := binary.BigEndian.Uint64(sum[0:8])
+	h2 := binary.BigEndian.Uint64(sum[8:16]) | 1
+	for i := range uint64(f.k) {
+		if !yield((h1 + i*h2) % f.m) {
+			return
+		}
+	}
+}
+
+// AddHash adds a SHA-1 digest.
+func (f *Filter) AddHash(sum [sha1.Size]byte) {
+	f.indexes(sum, func(i uint64) bool {
+		f.bits[i/64] |= 1 << (i % 64)
+		return true
+	})
+}
+
+// ContainsHash reports whether the digest was probably added.
+func (f *Filter) ContainsHash(sum [sha1.Size]byte) bool {
+	found := true
+	f.indexes(sum, func(i uint64) bool {
+		found = f.bits[i/64]&(1<<(i%64)) != 0
+		return found
+	})
+	return found
+}
+
+// Contains reports whether plaintext is probably a breached password.
+func (f *Filter) Contains(plaintext string) bool {
+	return f.ContainsHash(sha1.Sum([]byte(plaintext)))
+}
+
+// AddHashes adds every hash of a Pwned Passwords style list: one
+// "<40 hex digits>[:<count>]" per line, blank lines ignored. Hashes seen
+// fewer than minCount times are skipped, which is how a filter over the
+// full list is kept to a manageable size. It returns how many were added.
+func (f *Filter) AddHashes(r io.Reader, minCount int) (uint64, error) {
+	var added uint64
+	err := scanHashes(r, func(sum [sha1.Size]byte, count int) {
+		if count >= minCount {
+			f.AddHash(sum)
+			added++
+		}
+	})
+	return added, err
+}
+
+// CountHashes counts the hashes AddHashes would add, to size a filter.
+func CountHashes(r io.Reader, minCount int) (uint64, error) {
+	var n uint64
+	err := scanHashes(r, func(_ [sha1.Size]byte, count int) {
+		if count >= minCount {
+			n++
+		}
+	})
+	return n, err
+}
+
+func scanHashes(r io.Reader, fn func(sum [sha1.Size]byte, count int)) error {
+	sc := bufio.NewScanner(r)
+	for line := 1; sc.Scan(); line++ {
+		text := strings.TrimSpace(sc.Text())
+		if text == "" {
+			continue
+		}
+		digest, rawCount, hasCount := strings.Cut(text, ":")
+		var sum [sha1.Size]byte
+		if n, err := hex.Decode(sum[:], []byte(digest)); err != nil || n != sha1.Size || len(digest) != 2*sha1.Size {
+			return fmt.Errorf("%w: line %d is not a SHA-1 hash", ErrMalformed, line)
+		}
+		count := 1
+		if hasCount {
+			c, err := strconv.Atoi(rawCount)
+			if err != nil {
+				return fmt.Errorf("%w: line %d has a bad count", ErrMalformed, line)
+			}
+			count = c
+		}
+		fn(sum, count)
+	}
+	return sc.Err()
+}
+
+// WriteTo writes the filter in the format ReadFilter reads: the magic, m
+// and k, then the bits, all big-endian.
+func (f *Filter) WriteTo(w io.Writer) (int64, error) {
+	bw := bufio.NewWriter(w)
+	header := make([]byte, 0, len(magic)+12)
+	header = append(header, magic...)
+	header = binary.BigEndian.AppendUint64(header, f.m)
+	header = binary.BigEndian.AppendUint32(header, f.k)
+	written, err := bw.Write(header)
+	if err != nil {
+		return int64(written), err
+	}
+	var word [8]byte
+	for _, b := range f.bits {
+		binary.BigEndian.PutUint64(word[:], b)
+		n, err := bw.Write(word[:])
+		written += n
+		if err != nil {
+			return int64(written), err
+		}
+	}
+	return int64(written), bw.Flush()
+}
+
+// ReadFilter reads a filter written by WriteTo.
+func ReadFilter(r io.Reader) (*Filter, error) {
+	br := bufio.NewReader(r)
+	header := make([]byte, len(magic)+12)
+	if _, err := io.ReadFull(br, header); err != nil || string(header[:len(magic)]) != magic {
+		return nil, ErrMalformed
+	}
+	m := binary.BigEndian.Uint64(header[len(magic):])
+	k := binary.BigEndian.Uint32(header[len(magic)+8:])
+	if m == 0 || m%64 != 0 || k == 0 {
+		return nil, ErrMalformed
+	}
+	f := &Filter{bits: make([]uint64, m/64), m: m, k: k}
+	var word [8]byte
+	for i := range f.bits {
+		if _, err := io.ReadFull(br, word[:]); err != nil {
+			return nil, ErrMalformed
+		}
+		f.bits[i] = binary.BigEndian.Uint64(word[:])
+	}
+	return f, nil
+}
+
+// Load opens a corpus file: a filter built by cmd/breached, or, for small
+// lists, the Pwned Passwords text format itself, which is loaded into a
+// filter in memory.
+func Load(path string) (*Filter, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	head := make([]byte, len(magic))
+	if _, err := io.ReadFull(file, head); err == nil && string(head) == magic {
+		if _, err := file.Seek(0, io.SeekStart); err != nil {
+			return nil, err
+		}
+		return ReadFilter(file)
+	}
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	n, err := CountHashes(file, 1)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	f := New(n, textFalsePositiveRate)
+	if _, err := f.AddHashes(file, 1); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
@@ -0,0 +1,65 @@
+package breached
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func hashLine(plaintext string, count int) string {
+	return fmt.Sprintf("%X:%d\n", sha1.Sum([]byte(plaintext)), count)
+}
+
+func TestFilter(t *testing.T) {
+	list := hashLine("password123", 100) + "\n" + hashLine("letmein", 3)
+	f := New(2, 1e-6)
+	added, err := f.AddHashes(strings.NewReader(list), 10)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, added)
+	assert.True(t, f.Contains("password123"))
+	assert.False(t, f.Contains("letmein"), "below min count")
+	assert.False(t, f.Contains("correct horse battery"))
+
+	var buf bytes.Buffer
+	_, err = f.WriteTo(&buf)
+	require.NoError(t, err)
+	read, err := ReadFilter(&buf)
+	require.NoError(t, err)
+	assert.True(t, read.Contains("password123"))
+	assert.False(t, read.Contains("correct horse battery"))
+
+	_, err = ReadFilter(strings.NewReader("not a filter"))
+	assert.ErrorIs(t, err, ErrMalformed)
+	_, err = f.AddHashes(strings.NewReader("abc:1\n"), 1)
+	assert.ErrorIs(t, err, ErrMalformed)
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	text := filepath.Join(dir, "pwned.txt")
+	require.NoError(t, os.WriteFile(text, []byte(hashLine("password123", 1)+strings.ToLower(hashLine("hunter2", 5))), 0o600))
+
+	f, err := Load(text)
+	require.NoError(t, err)
+	assert.True(t, f.Contains("password123"))
+	assert.True(t, f.Contains("hunter2"), "lowercase hex")
+	assert.False(t, f.Contains("correct horse battery"))
+
+	bin := filepath.Join(dir, "breached.bf")
+	out, err := os.Create(bin)
+	require.NoError(t, err)
+	_, err = f.WriteTo(out)
+	require.NoError(t, err)
+	require.NoError(t, out.Close())
+
+	f, err = Load(bin)
+	require.NoError(t, err)
+	assert.True(t, f.Contains("hunter2"))
+}
@@ -0,0 +1,93 @@
+// Package jobs runs work after the request that asked for it has been
+// answered, on a fixed pool of workers behind a bounded queue. A full queue
+// drops the job rather than growing: everything submitted here comes from
+// request handlers, some of them unauthenticated, and must not be able to
+// pile up goroutines.
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// jobTimeout bounds one job.
+const jobTimeout = 30 * time.Second
+
+type job struct {
+	ctx  context.Context
+	name string
+	fn   func(context.Context) error
+}
+
+// Queue is a worker pool. The zero value is not usable; call New.
+type Queue struct {
+	jobs   chan job
+	logger *slog.Logger
+	wg     sync.WaitGroup
+
+	mu     sync.RWMutex
+	closed bool
+}
+
+// New starts workers goroutines draining a queue of size jobs.
+func New(logger *slog.Logger, workers, size int) *Queue {
+	q := &Queue{jobs: make(chan job, size), logger: logger}
+	q.wg.Add(workers)
+	for range workers {
+		go q.work()
+	}
+	return q
+}
+
+func (q *Queue) work() {
+	defer q.wg.Done()
+	for j := range q.jobs {
+		q.run(j)
+	}
+}
+
+func (q *Queue) run(j job) {
+	ctx, cancel := context.WithTimeout(j.ctx, jobTimeout)
+	defer cancel()
+	defer func() {
+		if r := recover(); r != nil {
+			q.logger.ErrorContext(ctx, "background job panicked", slog.String("job", j.name), slog.Any("err", fmt.Errorf("%v", r)))
+		}
+	}()
+	if err := j.fn(ctx); err != nil {
+		q.logger.ErrorContext(ctx, "background job failed", slog.String("job", j.name), slog.Any("err", err))
+	}
+}
+
+// Submit queues fn and returns at once. fn keeps ctx's values (the request
+// id for its log lines) but not its cancellation, since the response is
+// about to be written. Errors fn returns are logged. When the queue is full
+// or closed the job is dropped, logged, and Submit reports false.
+func (q *Queue) Submit(ctx context.Context, name string, fn func(context.Context) error) bool {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	if !q.closed {
+		select {
+		case q.jobs <- job{ctx: context.WithoutCancel(ctx), name: name, fn: fn}:
+			return true
+		default:
+		}
+	}
+	q.logger.WarnContext(ctx, "background job dropped", slog.String("job", name))
+	return false
+}
+
+// Close stops accepting jobs and waits for the queued ones to finish. Jobs
+// submitted by a running job after that are dropped. Call it on shutdown.
+func (q *Queue) Close() {
+	q.mu.Lock()
+	if !q.closed {
+		q.closed = true
+		close(q.jobs)
+	}
+	q.mu.Unlock()
+	q.wg.Wait()
+}
@@ -0,0 +1,55 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueue(t *testing.T) {
+	ctx := context.Background()
+	q := New(slog.New(slog.NewTextHandler(io.Discard, nil)), 1, 1)
+
+	// The one worker is held by the first job and the queue by the second,
+	// so the third is dropped.
+	release := make(chan struct{})
+	started := make(chan struct{})
+	var ran atomic.Int32
+	assert.True(t, q.Submit(ctx, "block", func(context.Context) error {
+		close(started)
+		<-release
+		ran.Add(1)
+		return nil
+	}))
+	<-started
+	assert.True(t, q.Submit(ctx, "queued", func(context.Context) error {
+		ran.Add(1)
+		return errors.New("logged, not fatal")
+	}))
+	assert.False(t, q.Submit(ctx, "dropped", func(context.Context) error {
+		ran.Add(1)
+		return nil
+	}))
+
+	close(release)
+	q.Close()
+	assert.Equal(t, int32(2), ran.Load())
+	assert.False(t, q.Submit(ctx, "after close", func(context.Context) error { return nil }))
+}
+
+func TestQueueSurvivesPanic(t *testing.T) {
+	q := New(slog.New(slog.NewTextHandler(io.Discard, nil)), 1, 2)
+	var ran atomic.Bool
+	q.Submit(context.Background(), "panics", func(context.Context) error { panic("boom") })
+	q.Submit(context.Background(), "after", func(context.Context) error {
+		ran.Store(true)
+		return nil
+	})
+	q.Close()
+	assert.True(t, ran.Load())
+}
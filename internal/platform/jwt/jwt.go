@@ -0,0 +1,234 @@
+// Package jwt signs and verifies JSON Web Tokens in compact JWS form with
+// EdDSA (Ed25519) or HS256 keys, and publishes the public keys as a JWKS.
+// It implements what go-fit issues and nothing more; it is not a general
+// JOSE library.
+package jwt
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Supported signing algorithms, as they appear in the "alg" header.
+const (
+	AlgEdDSA = "EdDSA"
+	AlgHS256 = "HS256"
+)
+
+// ErrInvalidToken covers every way a token can fail verification:
+// malformed, unknown key, bad signature, expired. Callers treat them alike.
+var ErrInvalidToken = errors.New("invalid token")
+
+// Key is one signing key, identified by the "kid" header of the tokens it
+// signs. An EdDSA key holds a private key and publishes its public half in
+// the JWKS; an HS256 key is a shared secret and is never published.
+type Key struct {
+	ID        string
+	Algorithm string
+
+	private ed25519.PrivateKey
+	secret  []byte
+}
+
+// minHS256SecretLen follows RFC 7518 §3.2: the secret must be at least as
+// long as the hash output.
+const minHS256SecretLen = 32
+
+// ParseKey builds a key from its id, algorithm and base64 material: the
+// 32-byte seed of an Ed25519 key, or an HS256 secret of at least 32 bytes.
+// Standard and URL-safe base64 are both accepted, padded or not.
+func ParseKey(id, alg, material string) (Key, error) {
+	if id == "" {
+		return Key{}, errors.New("key id must not be empty")
+	}
+	raw, err := decodeBase64(material)
+	if err != nil {
+		return Key{}, fmt.Errorf("key %q: material is not base64: %w", id, err)
+	}
+	switch alg {
+	case AlgEdDSA:
+		if len(raw) != ed25519.SeedSize {
+			return Key{}, fmt.Errorf("key %q: an EdDSA seed must be %d bytes, got %d", id, ed25519.SeedSize, len(raw))
+		}
+		return Key{ID: id, Algorithm: alg, private: ed25519.NewKeyFromSeed(raw)}, nil
+	case AlgHS256:
+		if len(raw) < minHS256SecretLen {
+			return Key{}, fmt.Errorf("key %q: an HS256 secret must be at least %d bytes, got %d", id, minHS256SecretLen, len(raw))
+		}
+		return Key{ID: id, Algorithm: alg, secret: raw}, nil
+	}
+	return Key{}, fmt.Errorf("key %q: unsupported algorithm %q: must be %s or %s", id, alg, AlgEdDSA, AlgHS256)
+}
+
+func decodeBase64(s string) ([]byte, error) {
+	s = strings.TrimRight(s, "=")
+	if strings.ContainsAny(s, "+/") {
+		return base64.RawStdEncoding.DecodeString(s)
+	}
+	return base64.RawURLEncoding.DecodeString(s)
+}
+
+func (k Key) sign(input []byte) []byte {
+	if k.Algorithm == AlgEdDSA {
+		return ed25519.Sign(k.private, input)
+	}
+	mac := hmac.New(sha256.New, k.secret)
+	mac.Write(input)
+	return mac.Sum(nil)
+}
+
+func (k Key) verify(input, sig []byte) bool {
+	if k.Algorithm == AlgEdDSA {
+		return ed25519.Verify(k.private.Public().(ed25519.PublicKey), input, sig)
+	}
+	return hmac.Equal(k.sign(input), sig)
+}
+
+// KeySet signs with its first key and verifies with any of them. Rotation
+// is: prepend the new key, and drop the old one once every token it signed
+// has expired.
+type KeySet struct {
+	keys []Key
+}
+
+func NewKeySet(keys ...Key) (*KeySet, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("at least one key is required")
+	}
+	seen := make(map[string]bool, len(keys))
+	for _, k := range keys {
+		if seen[k.ID] {
+			return nil, fmt.Errorf("duplicate key id %q", k.ID)
+		}
+		seen[k.ID] = true
+	}
+	return &KeySet{keys: keys}, nil
+}
+
+func (ks *KeySet) lookup(id string) (Key, bool) {
+	for _, k := range ks.keys {
+		if k.ID == id {
+			return k, true
+		}
+	}
+	return Key{}, false
+}
+
+type header struct {
+	Alg string `json:"alg"`
+	Typ string `json:"typ,omitempty"`
+	Kid string `json:"kid"`
+}
+
+// Claims are the registered claims go-fit uses. Embed it in a token's
+// claims struct to get Validate.
+type Claims struct {
+	Issuer    string `json:"iss,omitempty"`
+	Subject   string `json:"sub"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// Validate checks the claims that don't depend on the caller: expiry.
+func (c Claims) Validate(now time.Time) error {
+	if now.Unix() >= c.ExpiresAt {
+		return fmt.Errorf("%w: expired", ErrInvalidToken)
+	}
+	return nil
+}
+
+// Validator is implemented by every claims struct that embeds Claims.
+type Validator interface {
+	Validate(now time.Time) error
+}
+
+// Sign encodes claims and signs them with the set's first key.
+func (ks *KeySet) Sign(claims any) (string, error) {
+	k := ks.keys[0]
+	h, err := json.Marshal(header{Alg: k.Algorithm, Typ: "JWT", Kid: k.ID})
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	input := base64.RawURLEncoding.EncodeToString(h) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return input + "." + base64.RawURLEncoding.EncodeToString(k.sign([]byte(input))), nil
+}
+
+// Verify checks token's signature against the key its "kid" names, decodes
+// its payload into claims and validates them at now. The header's "alg"
+// must match the key's algorithm, so an HS256 token can't be forged with an
+// EdDSA public key as the secret.
+func (ks *KeySet) Verify(token string, claims Validator, now time.Time) error {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return fmt.Errorf("%w: not a compact JWS", ErrInvalidToken)
+	}
+	rawHeader, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return fmt.Errorf("%w: header: %v", ErrInvalidToken, err)
+	}
+	var h header
+	if err := json.Unmarshal(rawHeader, &h); err != nil {
+		return fmt.Errorf("%w: header: %v", ErrInvalidToken, err)
+	}
+	k, ok := ks.lookup(h.Kid)
+	if !ok || h.Alg != k.Algorithm {
+		return fmt.Errorf("%w: unknown key %q for %s", ErrInvalidToken, h.Kid, h.Alg)
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return fmt.Errorf("%w: signature: %v", ErrInvalidToken, err)
+	}
+	if !k.verify([]byte(parts[0]+"."+parts[1]), sig) {
+		return fmt.Errorf("%w: bad signature", ErrInvalidToken)
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return fmt.Errorf("%w: payload: %v", ErrInvalidToken, err)
+	}
+	if err := json.Unmarshal(payload, claims); err != nil {
+		return fmt.Errorf("%w: payload: %v", ErrInvalidToken, err)
+	}
+	return claims.Validate(now)
+}
+
+// JWK is the RFC 8037 form of an Ed25519 public key.
+type JWK struct {
+	KeyType   string `json:"kty"`
+	Curve     string `json:"crv"`
+	X         string `json:"x"`
+	KeyID     string `json:"kid"`
+	Algorithm string `json:"alg"`
+	Use       string `json:"use"`
+}
+
+// JWKS returns the public keys of the set's EdDSA keys. HS256 secrets are
+// left out: whoever can verify them can also forge tokens.
+func (ks *KeySet) JWKS() []JWK {
+	jwks := []JWK{}
+	for _, k := range ks.keys {
+		if k.Algorithm != AlgEdDSA {
+			continue
+		}
+		jwks = append(jwks, JWK{
+			KeyType:   "OKP",
+			Curve:     "Ed25519",
+			X:         base64.RawURLEncoding.EncodeToString(k.private.Public().(ed25519.PublicKey)),
+			KeyID:     k.ID,
+			Algorithm: AlgEdDSA,
+			Use:       "sig",
+		})
+	}
+	return jwks
+}
@@ -0,0 +1,95 @@
+package jwt
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testClaims struct {
+	Claims
+	Username string `json:"username"`
+}
+
+func mustKey(t *testing.T, id, alg string, size int) Key {
+	t.Helper()
+	k, err := ParseKey(id, alg, base64.StdEncoding.EncodeToString([]byte(strings.Repeat(id[:1], size))))
+	require.NoError(t, err)
+	return k
+}
+
+func TestSignVerify(t *testing.T) {
+	now := time.Unix(1_800_000_000, 0)
+	claims := testClaims{Claims: Claims{Subject: "7", IssuedAt: now.Unix(), ExpiresAt: now.Add(time.Minute).Unix()}, Username: "alice"}
+
+	for _, alg := range []string{AlgEdDSA, AlgHS256} {
+		t.Run(alg, func(t *testing.T) {
+			old := mustKey(t, "old", alg, 32)
+			current := mustKey(t, "new", alg, 32)
+
+			oldSet, err := NewKeySet(old)
+			require.NoError(t, err)
+			token, err := oldSet.Sign(claims)
+			require.NoError(t, err)
+
+			// After rotation the old key still verifies what it signed.
+			rotated, err := NewKeySet(current, old)
+			require.NoError(t, err)
+			var got testClaims
+			require.NoError(t, rotated.Verify(token, &got, now))
+			assert.Equal(t, claims, got)
+
+			// Once it is dropped, its tokens are rejected.
+			dropped, err := NewKeySet(current)
+			require.NoError(t, err)
+			assert.ErrorIs(t, dropped.Verify(token, &got, now), ErrInvalidToken)
+
+			assert.ErrorIs(t, rotated.Verify(token, &got, now.Add(time.Minute)), ErrInvalidToken, "expired")
+
+			parts := strings.Split(token, ".")
+			tampered := parts[0] + "." + base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"1","exp":9999999999}`)) + "." + parts[2]
+			assert.ErrorIs(t, rotated.Verify(tampered, &got, now), ErrInvalidToken)
+		})
+	}
+}
+
+// An HS256 token keyed with the published EdDSA public key must not verify:
+// the header's alg has to match the key's.
+func TestVerifyRejectsAlgorithmConfusion(t *testing.T) {
+	ed := mustKey(t, "ed", AlgEdDSA, 32)
+	set, err := NewKeySet(ed)
+	require.NoError(t, err)
+
+	pub := ed.private.Public().(ed25519.PublicKey)
+	forger, err := NewKeySet(Key{ID: "ed", Algorithm: AlgHS256, secret: pub})
+	require.NoError(t, err)
+	token, err := forger.Sign(Claims{Subject: "1", ExpiresAt: time.Now().Add(time.Hour).Unix()})
+	require.NoError(t, err)
+
+	var got Claims
+	assert.ErrorIs(t, set.Verify(token, &got, time.Now()), ErrInvalidToken)
+}
+
+func TestJWKSPublishesOnlyEdDSA(t *testing.T) {
+	set, err := NewKeySet(mustKey(t, "ed", AlgEdDSA, 32), mustKey(t, "hs", AlgHS256, 32))
+	require.NoError(t, err)
+
+	jwks := set.JWKS()
+	require.Len(t, jwks, 1)
+	assert.Equal(t, "ed", jwks[0].KeyID)
+	assert.Equal(t, "OKP", jwks[0].KeyType)
+}
+
+func TestParseKeyRejectsWeakMaterial(t *testing.T) {
+	_, err := ParseKey("hs", AlgHS256, base64.StdEncoding.EncodeToString(make([]byte, 16)))
+	assert.Error(t, err)
+	_, err = ParseKey("ed", AlgEdDSA, base64.StdEncoding.EncodeToString(make([]byte, 31)))
+	assert.Error(t, err)
+	_, err = ParseKey("rs", "RS256", base64.StdEncoding.EncodeToString(make([]byte, 32)))
+	assert.Error(t, err)
+}
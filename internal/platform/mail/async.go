@@ -0,0 +1,27 @@
+package mail
+
+import (
+	"context"
+
+	"github.com/tsatsarisg/go-fit/internal/platform/jobs"
+)
+
+// Async sends through a background job queue and returns at once, so a
+// slow relay stays off the request path. The queue is bounded: when it is
+// full the message is dropped and logged rather than piling up goroutines,
+// and delivery failures are logged too.
+type Async struct {
+	next Sender
+	jobs *jobs.Queue
+}
+
+func NewAsync(next Sender, queue *jobs.Queue) *Async {
+	return &Async{next: next, jobs: queue}
+}
+
+func (a *Async) Send(ctx context.Context, msg Message) error {
+	a.jobs.Submit(ctx, "send mail: "+msg.Subject, func(ctx context.Context) error {
+		return a.next.Send(ctx, msg)
+	})
+	return nil
+}
@@ -0,0 +1,33 @@
+package mail
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileMailer(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mail.log")
+	m := NewFileMailer(path, "go-fit <no-reply@example.com>")
+
+	require.NoError(t, m.Send(context.Background(), Message{To: "alice@example.com", Subject: "Hi", Body: "line one\nline two"}))
+	require.NoError(t, m.Send(context.Background(), Message{To: "bob@example.com", Subject: "Héllo", Body: "x"}))
+
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	out := string(b)
+	assert.Contains(t, out, "To: alice@example.com\r\n")
+	assert.Contains(t, out, "\r\n\r\nline one\r\nline two\r\n")
+	assert.Contains(t, out, "Subject: =?utf-8?q?H=C3=A9llo?=\r\n")
+	assert.Equal(t, 2, strings.Count(out, "From: go-fit <no-reply@example.com>\r\n"))
+}
+
+func TestEnvelopeAddress(t *testing.T) {
+	assert.Equal(t, "no-reply@example.com", envelopeAddress("go-fit <no-reply@example.com>"))
+	assert.Equal(t, "no-reply@example.com", envelopeAddress("no-reply@example.com"))
+}
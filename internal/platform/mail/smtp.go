@@ -0,0 +1,51 @@
+package mail
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SMTPMailer delivers through an SMTP relay with net/smtp. The connection
+// upgrades to TLS with STARTTLS when the server offers it, and PLAIN auth is
+// only sent over TLS (or to localhost), per net/smtp.PlainAuth.
+type SMTPMailer struct {
+	addr string
+	from string
+	auth smtp.Auth
+}
+
+// NewSMTPMailer returns a mailer for host:port. Leave username empty for an
+// unauthenticated relay.
+func NewSMTPMailer(host string, port int, username, password, from string) *SMTPMailer {
+	m := &SMTPMailer{addr: net.JoinHostPort(host, strconv.Itoa(port)), from: from}
+	if username != "" {
+		m.auth = smtp.PlainAuth("", username, password, host)
+	}
+	return m
+}
+
+// Send ignores ctx beyond an early cancellation check: net/smtp has no
+// context support. Callers that must not block use Async.
+func (m *SMTPMailer) Send(ctx context.Context, msg Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if strings.ContainsAny(msg.To, "\r\n") {
+		return errors.New("mail: invalid recipient")
+	}
+	return smtp.SendMail(m.addr, m.auth, envelopeAddress(m.from), []string{msg.To}, format(m.from, msg, time.Now()))
+}
+
+// envelopeAddress extracts the bare address from a From header value like
+// "go-fit <no-reply@example.com>".
+func envelopeAddress(from string) string {
+	if i := strings.LastIndexByte(from, '<'); i >= 0 {
+		return strings.TrimSuffix(from[i+1:], ">")
+	}
+	return from
+}
@@ -12,8 +12,9 @@ import (
 // they're infrastructure-level classifications — the pg SQLSTATE codes are the
 // same whether a workout insert or a user insert violated a unique constraint.
 var (
-	ErrDuplicate           = errors.New("duplicate resource")   // 23505 unique violation
-	ErrConstraintViolation = errors.New("constraint violation") // 23514 CHECK, etc.
+	ErrDuplicate           = errors.New("duplicate resource")    // 23505 unique violation
+	ErrConstraintViolation = errors.New("constraint violation")  // 23514 CHECK, etc.
+	ErrForeignKeyViolation = errors.New("foreign key violation") // 23503 referenced row missing
 )
 
 // ClassifyError inspects err for a *pgconn.PgError and returns the matching
@@ -30,9 +31,23 @@ func ClassifyError(err error) error {
 	switch pgErr.Code {
 	case "23505":
 		return fmt.Errorf("%w: %s", ErrDuplicate, pgErr.ConstraintName)
+	case "23503":
+		return fmt.Errorf("%w: %s", ErrForeignKeyViolation, pgErr.ConstraintName)
 	case "23514":
 		return fmt.Errorf("%w: %s", ErrConstraintViolation, pgErr.ConstraintName)
 	default:
 		return err
 	}
 }
+
+// ConstraintName returns the name of the constraint a pg error violated, or
+// "" when err isn't one. Stores use it to tell apart the unique constraints
+// of one table (users.username vs users.email) when both map to
+// ErrDuplicate.
+func ConstraintName(err error) string {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.ConstraintName
+	}
+	return ""
+}
@@ -0,0 +1,86 @@
+// Package pgtest sets up the Postgres database of a package's integration
+// tests. go test runs packages in parallel, so each package gets a database
+// of its own on the test server: one package's TRUNCATE or migration can't
+// land in the middle of another's tests.
+package pgtest
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/stdlib"
+
+	"github.com/tsatsarisg/go-fit/internal/platform/postgres"
+	"github.com/tsatsarisg/go-fit/migrations"
+)
+
+// defaultDSN is the test_db service of docker-compose.yml. TEST_DATABASE_URL
+// overrides it. Either way it only needs to reach a database the test user
+// can CREATE DATABASE from.
+const defaultDSN = "host=localhost port=5433 user=postgres password=postgres dbname=postgres sslmode=disable"
+
+// duplicateDatabase is the SQLSTATE of CREATE DATABASE on an existing name.
+const duplicateDatabase = "42P04"
+
+// Open returns a connection to the go_fit_test_<pkg> database, creating it
+// on first use, migrated to the latest version and with tables emptied
+// (RESTART IDENTITY CASCADE). The caller closes it.
+func Open(t testing.TB, pkg string, tables ...string) *sql.DB {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		dsn = defaultDSN
+	}
+	cfg, err := pgx.ParseConfig(dsn)
+	if err != nil {
+		t.Fatalf("failed to parse test database DSN: %v", err)
+	}
+
+	ctx := context.Background()
+	name := "go_fit_test_" + pkg
+	if err := createDatabase(ctx, cfg, name); err != nil {
+		t.Fatalf("failed to create test database %s: %v", name, err)
+	}
+
+	cfg.Database = name
+	db := stdlib.OpenDB(*cfg)
+	if err := postgres.MigrateFS(db, migrations.FS, "."); err != nil {
+		db.Close()
+		t.Fatalf("failed to run migrations: %v", err)
+	}
+	if len(tables) > 0 {
+		query := `TRUNCATE TABLE ` + strings.Join(tables, ", ") + ` RESTART IDENTITY CASCADE`
+		if _, err := db.ExecContext(ctx, query); err != nil {
+			db.Close()
+			t.Fatalf("failed to truncate tables: %v", err)
+		}
+	}
+	return db
+}
+
+// createDatabase creates name unless it exists, connecting through the
+// database cfg names.
+func createDatabase(ctx context.Context, cfg *pgx.ConnConfig, name string) error {
+	admin := stdlib.OpenDB(*cfg)
+	defer admin.Close()
+
+	var exists bool
+	err := admin.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM pg_database WHERE datname = $1)`, name).Scan(&exists)
+	if err != nil || exists {
+		return err
+	}
+	// An identifier can't be a bind parameter, so it is quoted instead.
+	_, err = admin.ExecContext(ctx, `CREATE DATABASE `+pgx.Identifier{name}.Sanitize())
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == duplicateDatabase {
+		// Another run created it between the check and here.
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,62 @@
+// Package sealer encrypts small secrets at rest with AES-256-GCM. It is for
+// values the application must read back, such as TOTP secrets; passwords
+// and tokens are hashed instead.
+package sealer
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+)
+
+// KeySize is the length of the key New takes.
+const KeySize = 32
+
+// ErrOpen is a ciphertext that doesn't decrypt: corrupt, sealed with a
+// different key, or bound to different associated data.
+var ErrOpen = errors.New("sealed value cannot be opened")
+
+type Sealer struct {
+	aead cipher.AEAD
+}
+
+func New(key []byte) (*Sealer, error) {
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("key must be %d bytes, got %d", KeySize, len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &Sealer{aead: aead}, nil
+}
+
+// Seal encrypts plaintext with a random nonce, which it prepends to the
+// result. ad is authenticated but not stored: pass something that ties the
+// value to its row, like the owner's id, so it can't be moved to another.
+func (s *Sealer) Seal(plaintext, ad []byte) ([]byte, error) {
+	nonce := make([]byte, s.aead.NonceSize(), s.aead.NonceSize()+len(plaintext)+s.aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return s.aead.Seal(nonce, nonce, plaintext, ad), nil
+}
+
+// Open reverses Seal, given the same ad.
+func (s *Sealer) Open(sealed, ad []byte) ([]byte, error) {
+	if len(sealed) < s.aead.NonceSize() {
+		return nil, ErrOpen
+	}
+	nonce, ciphertext := sealed[:s.aead.NonceSize()], sealed[s.aead.NonceSize():]
+	plaintext, err := s.aead.Open(nil, nonce, ciphertext, ad)
+	if err != nil {
+		return nil, ErrOpen
+	}
+	return plaintext, nil
+}
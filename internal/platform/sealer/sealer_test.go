@@ -0,0 +1,33 @@
+package sealer
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSealOpen(t *testing.T) {
+	s, err := New(bytes.Repeat([]byte{1}, KeySize))
+	require.NoError(t, err)
+
+	sealed, err := s.Seal([]byte("secret"), []byte("user:1"))
+	require.NoError(t, err)
+	assert.NotContains(t, string(sealed), "secret")
+
+	plain, err := s.Open(sealed, []byte("user:1"))
+	require.NoError(t, err)
+	assert.Equal(t, "secret", string(plain))
+
+	_, err = s.Open(sealed, []byte("user:2"))
+	assert.ErrorIs(t, err, ErrOpen, "bound to its associated data")
+
+	other, err := New(bytes.Repeat([]byte{2}, KeySize))
+	require.NoError(t, err)
+	_, err = other.Open(sealed, []byte("user:1"))
+	assert.ErrorIs(t, err, ErrOpen)
+
+	_, err = New(make([]byte, 16))
+	assert.Error(t, err)
+}
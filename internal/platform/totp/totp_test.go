@@ -0,0 +1,51 @@
+package totp
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// The SHA1 vectors of RFC 6238 appendix B, truncated to six digits.
+func TestCodeRFC6238(t *testing.T) {
+	secret := []byte("12345678901234567890")
+	vectors := map[int64]string{
+		59:          "287082",
+		1111111109:  "081804",
+		1111111111:  "050471",
+		1234567890:  "005924",
+		2000000000:  "279037",
+		20000000000: "353130",
+	}
+	for unix, want := range vectors {
+		assert.Equal(t, want, Code(secret, Step(time.Unix(unix, 0))), "t=%d", unix)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	secret, err := GenerateSecret()
+	require.NoError(t, err)
+	now := time.Unix(1_800_000_000, 0)
+
+	step, ok := Verify(secret, Code(secret, Step(now)-1), now)
+	assert.True(t, ok, "the previous code is still accepted")
+	assert.Equal(t, Step(now)-1, step)
+
+	_, ok = Verify(secret, Code(secret, Step(now)-2), now)
+	assert.False(t, ok)
+	_, ok = Verify(secret, "12345", now)
+	assert.False(t, ok)
+}
+
+func TestProvisioningURI(t *testing.T) {
+	u, err := url.Parse(ProvisioningURI("go-fit", "alice", []byte("12345678901234567890")))
+	require.NoError(t, err)
+	assert.Equal(t, "otpauth", u.Scheme)
+	assert.Equal(t, "totp", u.Host)
+	assert.Equal(t, "/go-fit:alice", u.Path)
+	assert.Equal(t, "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ", u.Query().Get("secret"))
+	assert.Equal(t, "go-fit", u.Query().Get("issuer"))
+}
@@ -0,0 +1,293 @@
+package program
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/tsatsarisg/go-fit/internal/auth"
+	"github.com/tsatsarisg/go-fit/internal/httpx"
+	"github.com/tsatsarisg/go-fit/internal/workout"
+)
+
+type Handler struct {
+	service *Service
+	logger  *slog.Logger
+}
+
+func NewHandler(service *Service, logger *slog.Logger) *Handler {
+	return &Handler{service: service, logger: logger}
+}
+
+var errorMapping = httpx.StoreErrorMapping{
+	ResourceName: "Program",
+	NotFoundErr:  ErrNotFound,
+	ForbiddenErr: ErrForbidden,
+}
+
+var enrollmentErrorMapping = httpx.StoreErrorMapping{
+	ResourceName: "Enrollment",
+	NotFoundErr:  ErrNotFound,
+}
+
+func writeValidationError(w http.ResponseWriter, err error) {
+	httpx.WriteJson(w, http.StatusBadRequest, httpx.Envelope{"error": err.Error()})
+}
+
+type createProgramRequest struct {
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	Progression Progression `json:"progression"`
+	Weeks       []Week      `json:"weeks"`
+}
+
+func (h *Handler) HandleCreateProgram(w http.ResponseWriter, r *http.Request) {
+	principal := auth.GetPrincipal(r)
+	if principal.IsAnonymous() {
+		httpx.WriteJson(w, http.StatusUnauthorized, httpx.Envelope{"error": "Unauthenticated"})
+		return
+	}
+
+	var req createProgramRequest
+	if derr := httpx.DecodeJSONBody(w, r, &req); derr != nil {
+		h.logger.WarnContext(r.Context(), "decode create program", slog.Any("err", derr))
+		httpx.WriteDecodeError(w, derr)
+		return
+	}
+
+	created, err := h.service.Create(r.Context(), CreateProgramCommand{
+		UserID:      principal.ID,
+		Name:        req.Name,
+		Description: req.Description,
+		Progression: req.Progression,
+		Weeks:       req.Weeks,
+	})
+	if err != nil {
+		if errors.Is(err, ErrValidation) {
+			writeValidationError(w, err)
+			return
+		}
+		httpx.WriteStoreError(r.Context(), w, h.logger, err, errorMapping, "Failed to create program")
+		return
+	}
+	httpx.WriteJson(w, http.StatusCreated, httpx.Envelope{"program": created})
+}
+
+func (h *Handler) HandleListPrograms(w http.ResponseWriter, r *http.Request) {
+	programs, err := h.service.List(r.Context())
+	if err != nil {
+		httpx.WriteStoreError(r.Context(), w, h.logger, err, errorMapping, "Failed to list programs")
+		return
+	}
+	httpx.WriteJson(w, http.StatusOK, httpx.Envelope{"programs": programs})
+}
+
+func (h *Handler) HandleGetProgram(w http.ResponseWriter, r *http.Request) {
+	programID, err := httpx.ReadIdParam(r, "id")
+	if err != nil {
+		h.logger.WarnContext(r.Context(), "read id param", slog.Any("err", err))
+		httpx.WriteJson(w, http.StatusBadRequest, httpx.Envelope{"error": err.Error()})
+		return
+	}
+
+	program, err := h.service.Get(r.Context(), ProgramID(programID))
+	if err != nil {
+		httpx.WriteStoreError(r.Context(), w, h.logger, err, errorMapping, "Failed to retrieve program")
+		return
+	}
+	httpx.WriteJson(w, http.StatusOK, httpx.Envelope{"program": program})
+}
+
+func (h *Handler) HandleDeleteProgram(w http.ResponseWriter, r *http.Request) {
+	programID, err := httpx.ReadIdParam(r, "id")
+	if err != nil {
+		h.logger.WarnContext(r.Context(), "read id param", slog.Any("err", err))
+		httpx.WriteJson(w, http.StatusBadRequest, httpx.Envelope{"error": err.Error()})
+		return
+	}
+
+	principal := auth.GetPrincipal(r)
+	if principal.IsAnonymous() {
+		httpx.WriteJson(w, http.StatusUnauthorized, httpx.Envelope{"error": "Unauthenticated"})
+		return
+	}
+
+	if err := h.service.Delete(r.Context(), ProgramID(programID), principal.ID); err != nil {
+		httpx.WriteStoreError(r.Context(), w, h.logger, err, errorMapping, "Failed to delete program")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type enrollRequest struct {
+	StartDate Date `json:"start_date"`
+}
+
+func (h *Handler) HandleEnroll(w http.ResponseWriter, r *http.Request) {
+	programID, err := httpx.ReadIdParam(r, "id")
+	if err != nil {
+		h.logger.WarnContext(r.Context(), "read id param", slog.Any("err", err))
+		httpx.WriteJson(w, http.StatusBadRequest, httpx.Envelope{"error": err.Error()})
+		return
+	}
+
+	principal := auth.GetPrincipal(r)
+	if principal.IsAnonymous() {
+		httpx.WriteJson(w, http.StatusUnauthorized, httpx.Envelope{"error": "Unauthenticated"})
+		return
+	}
+
+	var req enrollRequest
+	if derr := httpx.DecodeJSONBody(w, r, &req); derr != nil {
+		h.logger.WarnContext(r.Context(), "decode enroll", slog.Any("err", derr))
+		httpx.WriteDecodeError(w, derr)
+		return
+	}
+
+	enrollment, err := h.service.Enroll(r.Context(), EnrollCommand{
+		UserID:    principal.ID,
+		ProgramID: ProgramID(programID),
+		StartDate: req.StartDate,
+	})
+	if err != nil {
+		if errors.Is(err, ErrValidation) {
+			writeValidationError(w, err)
+			return
+		}
+		httpx.WriteStoreError(r.Context(), w, h.logger, err, errorMapping, "Failed to enroll")
+		return
+	}
+	httpx.WriteJson(w, http.StatusCreated, httpx.Envelope{"enrollment": enrollment})
+}
+
+func (h *Handler) HandleListEnrollments(w http.ResponseWriter, r *http.Request) {
+	principal := auth.GetPrincipal(r)
+	if principal.IsAnonymous() {
+		httpx.WriteJson(w, http.StatusUnauthorized, httpx.Envelope{"error": "Unauthenticated"})
+		return
+	}
+
+	enrollments, err := h.service.ListEnrollments(r.Context(), principal.ID)
+	if err != nil {
+		httpx.WriteStoreError(r.Context(), w, h.logger, err, enrollmentErrorMapping, "Failed to list enrollments")
+		return
+	}
+	httpx.WriteJson(w, http.StatusOK, httpx.Envelope{"enrollments": enrollments})
+}
+
+func (h *Handler) HandleUnenroll(w http.ResponseWriter, r *http.Request) {
+	enrollmentID, err := httpx.ReadIdParam(r, "id")
+	if err != nil {
+		h.logger.WarnContext(r.Context(), "read id param", slog.Any("err", err))
+		httpx.WriteJson(w, http.StatusBadRequest, httpx.Envelope{"error": err.Error()})
+		return
+	}
+
+	principal := auth.GetPrincipal(r)
+	if principal.IsAnonymous() {
+		httpx.WriteJson(w, http.StatusUnauthorized, httpx.Envelope{"error": "Unauthenticated"})
+		return
+	}
+
+	if err := h.service.Unenroll(r.Context(), EnrollmentID(enrollmentID), principal.ID); err != nil {
+		httpx.WriteStoreError(r.Context(), w, h.logger, err, enrollmentErrorMapping, "Failed to delete enrollment")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// readLocation reads the optional ?tz= IANA zone that decides which
+// calendar day it is for the caller. Absent means UTC.
+func readLocation(r *http.Request) (*time.Location, error) {
+	tz := r.URL.Query().Get("tz")
+	if tz == "" {
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return nil, fmt.Errorf("tz: unknown time zone %q", tz)
+	}
+	return loc, nil
+}
+
+// HandleStartSession serves POST /me/enrollments/{id}/start?tz= and returns
+// the new workout as POST /workouts would.
+func (h *Handler) HandleStartSession(w http.ResponseWriter, r *http.Request) {
+	enrollmentID, err := httpx.ReadIdParam(r, "id")
+	if err != nil {
+		h.logger.WarnContext(r.Context(), "read id param", slog.Any("err", err))
+		httpx.WriteJson(w, http.StatusBadRequest, httpx.Envelope{"error": err.Error()})
+		return
+	}
+	loc, err := readLocation(r)
+	if err != nil {
+		writeValidationError(w, err)
+		return
+	}
+
+	principal := auth.GetPrincipal(r)
+	if principal.IsAnonymous() {
+		httpx.WriteJson(w, http.StatusUnauthorized, httpx.Envelope{"error": "Unauthenticated"})
+		return
+	}
+
+	created, err := h.service.StartSession(r.Context(), StartSessionCommand{
+		UserID:       principal.ID,
+		EnrollmentID: EnrollmentID(enrollmentID),
+		Location:     loc,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrNoSession):
+			httpx.WriteJson(w, http.StatusConflict, httpx.Envelope{"error": err.Error()})
+		case errors.Is(err, workout.ErrValidation):
+			writeValidationError(w, err)
+		default:
+			httpx.WriteStoreError(r.Context(), w, h.logger, err, enrollmentErrorMapping, "Failed to start session")
+		}
+		return
+	}
+	httpx.WriteJson(w, http.StatusCreated, httpx.Envelope{"workout": created})
+}
+
+// HandleSchedule serves GET /me/schedule?from=YYYY-MM-DD&to=YYYY-MM-DD&tz=
+// (both dates inclusive).
+func (h *Handler) HandleSchedule(w http.ResponseWriter, r *http.Request) {
+	principal := auth.GetPrincipal(r)
+	if principal.IsAnonymous() {
+		httpx.WriteJson(w, http.StatusUnauthorized, httpx.Envelope{"error": "Unauthenticated"})
+		return
+	}
+
+	loc, err := readLocation(r)
+	if err != nil {
+		writeValidationError(w, err)
+		return
+	}
+	q := ScheduleQuery{UserID: principal.ID, Location: loc}
+	if from := r.URL.Query().Get("from"); from != "" {
+		if q.From, err = ParseDate(from); err != nil {
+			writeValidationError(w, err)
+			return
+		}
+	}
+	if to := r.URL.Query().Get("to"); to != "" {
+		if q.To, err = ParseDate(to); err != nil {
+			writeValidationError(w, err)
+			return
+		}
+	}
+
+	sessions, err := h.service.Schedule(r.Context(), q)
+	if err != nil {
+		if errors.Is(err, ErrValidation) {
+			writeValidationError(w, err)
+			return
+		}
+		httpx.WriteStoreError(r.Context(), w, h.logger, err, errorMapping, "Failed to build schedule")
+		return
+	}
+	httpx.WriteJson(w, http.StatusOK, httpx.Envelope{"schedule": sessions})
+}
@@ -0,0 +1,284 @@
+package program
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/tsatsarisg/go-fit/internal/user"
+	"github.com/tsatsarisg/go-fit/internal/workout"
+)
+
+// ProgramID and EnrollmentID wrap their BIGSERIAL columns, typed for the
+// same id-mixup reasons as user.UserID and workout.WorkoutID.
+type (
+	ProgramID    int64
+	EnrollmentID int64
+)
+
+// maxWeeks bounds a program's length. Generous for 8–12 week blocks while
+// keeping a schedule computation over a program trivially small.
+const maxWeeks = 52
+
+// Date is a calendar day with no time-of-day or zone, serialized as
+// YYYY-MM-DD. Program schedules are day-granular; carrying a full timestamp
+// would invite off-by-one-day bugs at zone boundaries.
+type Date struct {
+	time.Time
+}
+
+// ParseDate parses YYYY-MM-DD into a Date at UTC midnight.
+func ParseDate(s string) (Date, error) {
+	t, err := time.Parse(time.DateOnly, s)
+	if err != nil {
+		return Date{}, fmt.Errorf("invalid date %q: expected YYYY-MM-DD", s)
+	}
+	return Date{t}, nil
+}
+
+// NewDate truncates t to its UTC calendar day.
+func NewDate(t time.Time) Date {
+	return DateIn(t, time.UTC)
+}
+
+// DateIn returns the calendar day t falls on in loc. A workout logged at
+// 8pm in New York is on that day there, though it is the next one in UTC.
+func DateIn(t time.Time, loc *time.Location) Date {
+	y, m, d := t.In(loc).Date()
+	return Date{time.Date(y, m, d, 0, 0, 0, 0, time.UTC)}
+}
+
+// StartIn returns the instant d begins in loc: its local midnight.
+func (d Date) StartIn(loc *time.Location) time.Time {
+	y, m, day := d.Date()
+	return time.Date(y, m, day, 0, 0, 0, 0, loc)
+}
+
+// orUTC is loc, or UTC when the caller gave no zone.
+func orUTC(loc *time.Location) *time.Location {
+	if loc == nil {
+		return time.UTC
+	}
+	return loc
+}
+
+func (d Date) String() string { return d.Format(time.DateOnly) }
+
+// DaysUntil returns the number of whole days from d to other (negative when
+// other is earlier).
+func (d Date) DaysUntil(other Date) int {
+	return int(other.Sub(d.Time).Hours() / 24)
+}
+
+func (d Date) AddDays(n int) Date { return Date{d.AddDate(0, 0, n)} }
+
+func (d Date) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+func (d *Date) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	parsed, err := ParseDate(s)
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+// Progression is the step from one week to the next, applied
+// cumulatively: week N prescribes the template's targets plus the steps
+// into weeks 2..N. A week's step is its own Progression when set, the
+// program's otherwise. Weight applies only to entries that have a weight;
+// reps only to rep-based entries.
+type Progression struct {
+	WeightIncrement float64 `json:"weight_increment"`
+	RepsIncrement   int     `json:"reps_increment"`
+}
+
+// Day maps a day of the week (1–7, relative to the enrollment start date)
+// to the template prescribed for it.
+type Day struct {
+	Day        int                `json:"day"`
+	TemplateID workout.TemplateID `json:"template_id"`
+}
+
+// Week is one week of a program. Number is 1-based and weeks are kept in
+// order; days not listed are rest days. Progression overrides the
+// program's step into this week, e.g. a zero step to hold a week or a
+// bigger one after a deload; week 1 has no step.
+type Week struct {
+	Number      int          `json:"number"`
+	Days        []Day        `json:"days"`
+	Progression *Progression `json:"progression,omitempty"`
+}
+
+type Program struct {
+	ID          ProgramID   `json:"id"`
+	UserID      user.UserID `json:"user_id"`
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	Progression Progression `json:"progression"`
+	Weeks       []Week      `json:"weeks"`
+	CreatedAt   time.Time   `json:"created_at"`
+	UpdatedAt   time.Time   `json:"updated_at"`
+}
+
+// Validate enforces the program's shape: weeks numbered 1..N in order, each
+// day in range and scheduled at most once per week.
+func (p *Program) Validate() error {
+	if p.Name == "" {
+		return errors.New("name must not be empty")
+	}
+	if len(p.Weeks) == 0 || len(p.Weeks) > maxWeeks {
+		return fmt.Errorf("a program must have between 1 and %d weeks", maxWeeks)
+	}
+	if err := p.Progression.validate(); err != nil {
+		return fmt.Errorf("progression.%v", err)
+	}
+	for i, w := range p.Weeks {
+		if w.Number != i+1 {
+			return fmt.Errorf("weeks[%d]: number must be %d", i, i+1)
+		}
+		if w.Progression != nil {
+			if i == 0 {
+				return errors.New("weeks[0]: week 1 prescribes the templates as written and takes no progression")
+			}
+			if err := w.Progression.validate(); err != nil {
+				return fmt.Errorf("weeks[%d].progression.%v", i, err)
+			}
+		}
+		seen := make(map[int]bool, len(w.Days))
+		for j, d := range w.Days {
+			if d.Day < 1 || d.Day > 7 {
+				return fmt.Errorf("weeks[%d].days[%d]: day must be between 1 and 7", i, j)
+			}
+			if seen[d.Day] {
+				return fmt.Errorf("weeks[%d].days[%d]: day %d is scheduled twice", i, j, d.Day)
+			}
+			if d.TemplateID <= 0 {
+				return fmt.Errorf("weeks[%d].days[%d]: template_id is required", i, j)
+			}
+			seen[d.Day] = true
+		}
+	}
+	return nil
+}
+
+func (pr Progression) validate() error {
+	if pr.WeightIncrement < 0 {
+		return errors.New("weight_increment must be non-negative")
+	}
+	if pr.RepsIncrement < 0 {
+		return errors.New("reps_increment must be non-negative")
+	}
+	return nil
+}
+
+// TemplateIDs returns every template the program references, deduplicated.
+func (p *Program) TemplateIDs() []workout.TemplateID {
+	seen := make(map[workout.TemplateID]bool)
+	var ids []workout.TemplateID
+	for _, w := range p.Weeks {
+		for _, d := range w.Days {
+			if !seen[d.TemplateID] {
+				seen[d.TemplateID] = true
+				ids = append(ids, d.TemplateID)
+			}
+		}
+	}
+	return ids
+}
+
+// dayAt returns the session scheduled offset days after the start of the
+// program, or ok=false for a rest day or a day past the final week.
+func (p *Program) dayAt(offset int) (week int, day Day, ok bool) {
+	if offset < 0 {
+		return 0, Day{}, false
+	}
+	weekIdx, dayOfWeek := offset/7, offset%7+1
+	if weekIdx >= len(p.Weeks) {
+		return 0, Day{}, false
+	}
+	for _, d := range p.Weeks[weekIdx].Days {
+		if d.Day == dayOfWeek {
+			return weekIdx + 1, d, true
+		}
+	}
+	return 0, Day{}, false
+}
+
+// progressionTo sums the steps into weeks 2..week.
+func (p *Program) progressionTo(week int) Progression {
+	var total Progression
+	if week < 2 || len(p.Weeks) < 2 {
+		return total
+	}
+	for _, w := range p.Weeks[1:min(week, len(p.Weeks))] {
+		step := p.Progression
+		if w.Progression != nil {
+			step = *w.Progression
+		}
+		total.WeightIncrement += step.WeightIncrement
+		total.RepsIncrement += step.RepsIncrement
+	}
+	return total
+}
+
+// progress returns a copy of entries with the week's cumulative progression
+// applied. The template's own entries are never mutated.
+func (p *Program) progress(entries []workout.WorkoutEntry, week int) []workout.WorkoutEntry {
+	total := p.progressionTo(week)
+	out := make([]workout.WorkoutEntry, len(entries))
+	for i, e := range entries {
+		e.ID = 0
+		if e.Weight != nil && total.WeightIncrement != 0 {
+			w := *e.Weight + total.WeightIncrement
+			e.Weight = &w
+		}
+		if e.Reps != nil && total.RepsIncrement != 0 {
+			r := *e.Reps + total.RepsIncrement
+			e.Reps = &r
+		}
+		out[i] = e
+	}
+	return out
+}
+
+// Enrollment puts a user on a program starting on StartDate, which is day 1
+// of week 1.
+type Enrollment struct {
+	ID        EnrollmentID `json:"id"`
+	UserID    user.UserID  `json:"user_id"`
+	ProgramID ProgramID    `json:"program_id"`
+	StartDate Date         `json:"start_date"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+// Session is one day's prescription in a user's schedule. WorkoutID is set
+// when a workout started from the session's template was logged that day.
+type Session struct {
+	Date         Date                   `json:"date"`
+	EnrollmentID EnrollmentID           `json:"enrollment_id"`
+	ProgramID    ProgramID              `json:"program_id"`
+	ProgramName  string                 `json:"program_name"`
+	Week         int                    `json:"week"`
+	Day          int                    `json:"day"`
+	TemplateID   workout.TemplateID     `json:"template_id"`
+	TemplateName string                 `json:"template_name"`
+	Entries      []workout.WorkoutEntry `json:"entries"`
+	Fulfilled    bool                   `json:"fulfilled"`
+	WorkoutID    *workout.WorkoutID     `json:"workout_id"`
+}
+
+// Fulfillment is a logged workout that can satisfy a scheduled session: same
+// template, same calendar day.
+type Fulfillment struct {
+	WorkoutID  workout.WorkoutID
+	TemplateID workout.TemplateID
+	Date       Date
+}
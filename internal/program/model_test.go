@@ -0,0 +1,63 @@
+package program
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/tsatsarisg/go-fit/internal/workout"
+)
+
+func TestProgress(t *testing.T) {
+	weight, reps := 100.0, 5
+	entries := []workout.WorkoutEntry{
+		{ID: 9, ExerciseName: "Squat", Sets: 3, Reps: &reps, Weight: &weight},
+		{ExerciseName: "Plank", Sets: 3, DurationSeconds: &reps},
+	}
+	p := &Program{
+		Progression: Progression{WeightIncrement: 2.5},
+		Weeks: []Week{
+			{Number: 1},
+			{Number: 2},
+			{Number: 3, Progression: &Progression{}},
+			{Number: 4, Progression: &Progression{WeightIncrement: 5, RepsIncrement: 1}},
+			{Number: 5},
+		},
+	}
+
+	for _, tc := range []struct {
+		week   int
+		weight float64
+		reps   int
+	}{
+		{1, 100, 5},
+		{2, 102.5, 5},
+		{3, 102.5, 5},
+		{4, 107.5, 6},
+		{5, 110, 6},
+	} {
+		got := p.progress(entries, tc.week)
+		assert.Equal(t, tc.weight, *got[0].Weight, "week %d", tc.week)
+		assert.Equal(t, tc.reps, *got[0].Reps, "week %d", tc.week)
+		assert.Zero(t, got[0].ID)
+		assert.Nil(t, got[1].Weight, "only weighted entries progress")
+	}
+	assert.Equal(t, 100.0, weight, "the template's entries are untouched")
+	assert.Equal(t, 5, reps)
+}
+
+func TestValidateWeekProgression(t *testing.T) {
+	week := func(n int, pr *Progression) Week {
+		return Week{Number: n, Days: []Day{{Day: 1, TemplateID: 1}}, Progression: pr}
+	}
+
+	p := &Program{Name: "Block", Weeks: []Week{week(1, nil), week(2, &Progression{RepsIncrement: 1})}}
+	assert.NoError(t, p.Validate())
+
+	p.Weeks[0].Progression = &Progression{}
+	assert.ErrorContains(t, p.Validate(), "week 1")
+
+	p.Weeks[0].Progression = nil
+	p.Weeks[1].Progression.WeightIncrement = -2.5
+	assert.ErrorContains(t, p.Validate(), "weeks[1].progression.weight_increment")
+}
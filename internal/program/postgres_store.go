@@ -0,0 +1,340 @@
+package program
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/tsatsarisg/go-fit/internal/platform/postgres"
+	"github.com/tsatsarisg/go-fit/internal/user"
+	"github.com/tsatsarisg/go-fit/internal/workout"
+)
+
+// PostgresStore is the concrete adapter satisfying Store.
+type PostgresStore struct {
+	db *sql.DB
+}
+
+func NewPostgresStore(db *sql.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+const programColumns = `p.id, p.user_id, p.name, p.description, p.weeks, p.weight_increment, p.reps_increment, p.created_at, p.updated_at`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanProgram reads a programs row and sizes Weeks from the weeks column;
+// loadDays fills in the sessions and per-week progressions.
+func scanProgram(row rowScanner) (*Program, error) {
+	p := &Program{}
+	var weeks int
+	err := row.Scan(&p.ID, &p.UserID, &p.Name, &p.Description, &weeks, &p.Progression.WeightIncrement, &p.Progression.RepsIncrement, &p.CreatedAt, &p.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	p.Weeks = make([]Week, weeks)
+	for i := range p.Weeks {
+		p.Weeks[i] = Week{Number: i + 1, Days: []Day{}}
+	}
+	return p, nil
+}
+
+// loadDays attaches program_days and program_weeks to every program, with
+// one query each.
+func (pg *PostgresStore) loadDays(ctx context.Context, programs ...*Program) error {
+	if len(programs) == 0 {
+		return nil
+	}
+	byID := make(map[ProgramID]*Program, len(programs))
+	ids := make([]int64, 0, len(programs))
+	for _, p := range programs {
+		byID[p.ID] = p
+		ids = append(ids, int64(p.ID))
+	}
+
+	rows, err := pg.db.QueryContext(ctx, `SELECT program_id, week, day, template_id
+			  FROM program_days
+			  WHERE program_id = ANY($1)
+			  ORDER BY program_id, week, day`, ids)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			programID ProgramID
+			week      int
+			d         Day
+		)
+		if err := rows.Scan(&programID, &week, &d.Day, &d.TemplateID); err != nil {
+			return err
+		}
+		p, ok := byID[programID]
+		if !ok || week < 1 || week > len(p.Weeks) {
+			continue
+		}
+		p.Weeks[week-1].Days = append(p.Weeks[week-1].Days, d)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	weekRows, err := pg.db.QueryContext(ctx, `SELECT program_id, week, weight_increment, reps_increment
+			  FROM program_weeks
+			  WHERE program_id = ANY($1)`, ids)
+	if err != nil {
+		return err
+	}
+	defer weekRows.Close()
+
+	for weekRows.Next() {
+		var (
+			programID ProgramID
+			week      int
+			pr        Progression
+		)
+		if err := weekRows.Scan(&programID, &week, &pr.WeightIncrement, &pr.RepsIncrement); err != nil {
+			return err
+		}
+		p, ok := byID[programID]
+		if !ok || week < 1 || week > len(p.Weeks) {
+			continue
+		}
+		p.Weeks[week-1].Progression = &pr
+	}
+	return weekRows.Err()
+}
+
+func (pg *PostgresStore) CreateProgram(ctx context.Context, program *Program) (*Program, error) {
+	tx, err := pg.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	query := `INSERT INTO programs (user_id, name, description, weeks, weight_increment, reps_increment)
+			  VALUES ($1, $2, $3, $4, $5, $6)
+			  RETURNING id, created_at, updated_at`
+
+	err = tx.QueryRowContext(ctx, query, program.UserID, program.Name, program.Description, len(program.Weeks), program.Progression.WeightIncrement, program.Progression.RepsIncrement).Scan(&program.ID, &program.CreatedAt, &program.UpdatedAt)
+	if err != nil {
+		return nil, postgres.ClassifyError(err)
+	}
+
+	for _, w := range program.Weeks {
+		if w.Progression != nil {
+			_, err := tx.ExecContext(ctx, `INSERT INTO program_weeks (program_id, week, weight_increment, reps_increment) VALUES ($1, $2, $3, $4)`,
+				program.ID, w.Number, w.Progression.WeightIncrement, w.Progression.RepsIncrement)
+			if err != nil {
+				return nil, postgres.ClassifyError(err)
+			}
+		}
+		for _, d := range w.Days {
+			_, err := tx.ExecContext(ctx, `INSERT INTO program_days (program_id, week, day, template_id) VALUES ($1, $2, $3, $4)`,
+				program.ID, w.Number, d.Day, d.TemplateID)
+			if err != nil {
+				return nil, postgres.ClassifyError(err)
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return program, nil
+}
+
+func (pg *PostgresStore) GetProgram(ctx context.Context, id ProgramID) (*Program, error) {
+	query := `SELECT ` + programColumns + ` FROM programs p WHERE p.id = $1`
+
+	program, err := scanProgram(pg.db.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := pg.loadDays(ctx, program); err != nil {
+		return nil, err
+	}
+	return program, nil
+}
+
+func (pg *PostgresStore) queryPrograms(ctx context.Context, query string, args ...any) ([]*Program, error) {
+	rows, err := pg.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	programs := []*Program{}
+	for rows.Next() {
+		p, err := scanProgram(rows)
+		if err != nil {
+			return nil, err
+		}
+		programs = append(programs, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := pg.loadDays(ctx, programs...); err != nil {
+		return nil, err
+	}
+	return programs, nil
+}
+
+func (pg *PostgresStore) GetProgramsByID(ctx context.Context, ids []ProgramID) ([]*Program, error) {
+	raw := make([]int64, len(ids))
+	for i, id := range ids {
+		raw[i] = int64(id)
+	}
+	return pg.queryPrograms(ctx, `SELECT `+programColumns+` FROM programs p WHERE p.id = ANY($1)`, raw)
+}
+
+// ListPrograms returns the catalog newest first. Programs are readable by
+// every authenticated user; only enrollment is per-user.
+func (pg *PostgresStore) ListPrograms(ctx context.Context) ([]*Program, error) {
+	return pg.queryPrograms(ctx, `SELECT `+programColumns+` FROM programs p ORDER BY p.created_at DESC, p.id DESC`)
+}
+
+func (pg *PostgresStore) DeleteProgram(ctx context.Context, id ProgramID, userID user.UserID) error {
+	tx, err := pg.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var deletedID ProgramID
+	err = tx.QueryRowContext(ctx, `DELETE FROM programs WHERE id = $1 AND user_id = $2 RETURNING id`, id, userID).Scan(&deletedID)
+	if errors.Is(err, sql.ErrNoRows) {
+		var ownerID user.UserID
+		probeErr := tx.QueryRowContext(ctx, `SELECT user_id FROM programs WHERE id = $1`, id).Scan(&ownerID)
+		if errors.Is(probeErr, sql.ErrNoRows) {
+			return ErrNotFound
+		}
+		if probeErr != nil {
+			return probeErr
+		}
+		return ErrForbidden
+	}
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// CreateEnrollment maps a missing program (FK violation) to ErrNotFound so
+// the handler answers 404 rather than a generic 400.
+func (pg *PostgresStore) CreateEnrollment(ctx context.Context, enrollment *Enrollment) error {
+	query := `INSERT INTO program_enrollments (user_id, program_id, start_date)
+			  VALUES ($1, $2, $3)
+			  RETURNING id, created_at`
+
+	err := pg.db.QueryRowContext(ctx, query, enrollment.UserID, enrollment.ProgramID, enrollment.StartDate.Time).Scan(&enrollment.ID, &enrollment.CreatedAt)
+	err = postgres.ClassifyError(err)
+	if errors.Is(err, postgres.ErrForeignKeyViolation) {
+		return ErrNotFound
+	}
+	return err
+}
+
+func (pg *PostgresStore) GetEnrollment(ctx context.Context, id EnrollmentID, userID user.UserID) (*Enrollment, error) {
+	e := &Enrollment{}
+	var start time.Time
+	err := pg.db.QueryRowContext(ctx, `SELECT id, user_id, program_id, start_date, created_at
+			  FROM program_enrollments
+			  WHERE id = $1 AND user_id = $2`, id, userID).Scan(&e.ID, &e.UserID, &e.ProgramID, &start, &e.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	e.StartDate = NewDate(start)
+	return e, nil
+}
+
+func (pg *PostgresStore) ListEnrollments(ctx context.Context, userID user.UserID) ([]*Enrollment, error) {
+	rows, err := pg.db.QueryContext(ctx, `SELECT id, user_id, program_id, start_date, created_at
+			  FROM program_enrollments
+			  WHERE user_id = $1
+			  ORDER BY start_date, id`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	enrollments := []*Enrollment{}
+	for rows.Next() {
+		e := &Enrollment{}
+		var start time.Time
+		if err := rows.Scan(&e.ID, &e.UserID, &e.ProgramID, &start, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		e.StartDate = NewDate(start)
+		enrollments = append(enrollments, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return enrollments, nil
+}
+
+// DeleteEnrollment scopes by user in SQL; someone else's enrollment is
+// indistinguishable from a missing one.
+func (pg *PostgresStore) DeleteEnrollment(ctx context.Context, id EnrollmentID, userID user.UserID) error {
+	res, err := pg.db.ExecContext(ctx, `DELETE FROM program_enrollments WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Fulfillments reads the workouts table directly (as auth.PostgresStore
+// reads users): a narrow, read-only projection keyed on template_id. Days
+// are calendar days in loc, the zone the schedule is computed in.
+func (pg *PostgresStore) Fulfillments(ctx context.Context, userID user.UserID, templateIDs []workout.TemplateID, from, to Date, loc *time.Location) ([]Fulfillment, error) {
+	raw := make([]int64, len(templateIDs))
+	for i, id := range templateIDs {
+		raw[i] = int64(id)
+	}
+
+	rows, err := pg.db.QueryContext(ctx, `SELECT id, template_id, created_at
+			  FROM workouts
+			  WHERE user_id = $1 AND template_id = ANY($2)
+			    AND created_at >= $3 AND created_at < $4
+			  ORDER BY created_at, id`, userID, raw, from.StartIn(loc), to.AddDays(1).StartIn(loc))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Fulfillment
+	for rows.Next() {
+		var (
+			f         Fulfillment
+			createdAt time.Time
+		)
+		if err := rows.Scan(&f.WorkoutID, &f.TemplateID, &createdAt); err != nil {
+			return nil, err
+		}
+		f.Date = DateIn(createdAt, loc)
+		out = append(out, f)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,336 @@
+package program
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/tsatsarisg/go-fit/internal/user"
+	"github.com/tsatsarisg/go-fit/internal/workout"
+)
+
+// Store is the program bounded context's persistence port (consumer-side,
+// like every other Store in this codebase).
+type Store interface {
+	CreateProgram(ctx context.Context, program *Program) (*Program, error)
+	GetProgram(ctx context.Context, id ProgramID) (*Program, error)
+	GetProgramsByID(ctx context.Context, ids []ProgramID) ([]*Program, error)
+	ListPrograms(ctx context.Context) ([]*Program, error)
+	// DeleteProgram enforces authorship in SQL: ErrNotFound when the row is
+	// missing, ErrForbidden when it belongs to another user.
+	DeleteProgram(ctx context.Context, id ProgramID, userID user.UserID) error
+
+	CreateEnrollment(ctx context.Context, enrollment *Enrollment) error
+	// GetEnrollment scopes by user: someone else's enrollment is
+	// ErrNotFound.
+	GetEnrollment(ctx context.Context, id EnrollmentID, userID user.UserID) (*Enrollment, error)
+	ListEnrollments(ctx context.Context, userID user.UserID) ([]*Enrollment, error)
+	DeleteEnrollment(ctx context.Context, id EnrollmentID, userID user.UserID) error
+	// Fulfillments returns the user's workouts started from any of
+	// templateIDs and logged on a day in [from, to], days being calendar
+	// days in loc.
+	Fulfillments(ctx context.Context, userID user.UserID, templateIDs []workout.TemplateID, from, to Date, loc *time.Location) ([]Fulfillment, error)
+}
+
+// Templates is the narrow slice of workout.Service this context needs to
+// resolve the templates a program references. Unscoped by owner: programs
+// authorize template use at creation time (author must own them).
+type Templates interface {
+	TemplatesByID(ctx context.Context, ids []workout.TemplateID) (map[workout.TemplateID]*workout.Template, error)
+}
+
+// Workouts is the narrow slice of workout.Service used to log a session.
+type Workouts interface {
+	Create(ctx context.Context, cmd workout.CreateWorkoutCommand) (*workout.Workout, error)
+}
+
+// Domain-level sentinels:
+//   - ErrNotFound:   program / enrollment doesn't exist (for you) → 404
+//   - ErrForbidden:  program exists but you didn't author it      → 403
+//   - ErrValidation: invariant violated                           → 400
+//   - ErrNoSession:  the enrollment has a rest day today          → 409
+var (
+	ErrNotFound   = errors.New("program not found")
+	ErrForbidden  = errors.New("forbidden")
+	ErrValidation = errors.New("validation failed")
+	ErrNoSession  = errors.New("no session is scheduled today")
+)
+
+func wrapValidation(err error) error {
+	if err == nil {
+		return nil
+	}
+	return fmt.Errorf("%w: %v", ErrValidation, err)
+}
+
+// maxScheduleDays caps GET /me/schedule so one request can't ask for years
+// of sessions.
+const maxScheduleDays = 92
+
+type Service struct {
+	store     Store
+	templates Templates
+	workouts  Workouts
+}
+
+func NewService(store Store, templates Templates, workouts Workouts) *Service {
+	return &Service{store: store, templates: templates, workouts: workouts}
+}
+
+// CreateProgramCommand is the input to Service.Create. UserID is the author
+// and comes from the principal.
+type CreateProgramCommand struct {
+	UserID      user.UserID
+	Name        string
+	Description string
+	Progression Progression
+	Weeks       []Week
+}
+
+// Create validates the program's shape and checks that every referenced
+// template exists and belongs to the author, so a program can't be used to
+// read someone else's private templates through the schedule.
+func (s *Service) Create(ctx context.Context, cmd CreateProgramCommand) (*Program, error) {
+	p := &Program{
+		UserID:      cmd.UserID,
+		Name:        cmd.Name,
+		Description: cmd.Description,
+		Progression: cmd.Progression,
+		Weeks:       cmd.Weeks,
+	}
+	if err := p.Validate(); err != nil {
+		return nil, wrapValidation(err)
+	}
+
+	templates, err := s.templates.TemplatesByID(ctx, p.TemplateIDs())
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range p.TemplateIDs() {
+		t, ok := templates[id]
+		if !ok || t.UserID != cmd.UserID {
+			return nil, wrapValidation(fmt.Errorf("template %d not found", id))
+		}
+	}
+
+	return s.store.CreateProgram(ctx, p)
+}
+
+func (s *Service) Get(ctx context.Context, id ProgramID) (*Program, error) {
+	return s.store.GetProgram(ctx, id)
+}
+
+func (s *Service) List(ctx context.Context) ([]*Program, error) {
+	return s.store.ListPrograms(ctx)
+}
+
+func (s *Service) Delete(ctx context.Context, id ProgramID, userID user.UserID) error {
+	return s.store.DeleteProgram(ctx, id, userID)
+}
+
+// EnrollCommand starts UserID on ProgramID; StartDate is day 1 of week 1.
+type EnrollCommand struct {
+	UserID    user.UserID
+	ProgramID ProgramID
+	StartDate Date
+}
+
+func (s *Service) Enroll(ctx context.Context, cmd EnrollCommand) (*Enrollment, error) {
+	if cmd.StartDate.IsZero() {
+		return nil, wrapValidation(errors.New("start_date is required"))
+	}
+	e := &Enrollment{UserID: cmd.UserID, ProgramID: cmd.ProgramID, StartDate: cmd.StartDate}
+	if err := s.store.CreateEnrollment(ctx, e); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+func (s *Service) ListEnrollments(ctx context.Context, userID user.UserID) ([]*Enrollment, error) {
+	return s.store.ListEnrollments(ctx, userID)
+}
+
+func (s *Service) Unenroll(ctx context.Context, id EnrollmentID, userID user.UserID) error {
+	return s.store.DeleteEnrollment(ctx, id, userID)
+}
+
+// StartSessionCommand logs today's session of one of UserID's enrollments.
+// Today is the calendar day in Location, UTC when nil.
+type StartSessionCommand struct {
+	UserID       user.UserID
+	EnrollmentID EnrollmentID
+	Location     *time.Location
+}
+
+// StartSession creates a workout from the session due today, with the
+// week's progression applied. Enrollment is what authorizes reading the
+// template, which is usually another user's: POST /templates/{id}/start
+// only starts your own. The workout records the template, so it fulfils
+// the session in the schedule. Exercise ids are dropped from another
+// author's entries and relinked by name, since the author's custom
+// exercises aren't visible to the enrollee.
+func (s *Service) StartSession(ctx context.Context, cmd StartSessionCommand) (*workout.Workout, error) {
+	e, err := s.store.GetEnrollment(ctx, cmd.EnrollmentID, cmd.UserID)
+	if err != nil {
+		return nil, err
+	}
+	p, err := s.store.GetProgram(ctx, e.ProgramID)
+	if err != nil {
+		return nil, err
+	}
+	today := DateIn(time.Now(), orUTC(cmd.Location))
+	week, day, ok := p.dayAt(e.StartDate.DaysUntil(today))
+	if !ok {
+		return nil, ErrNoSession
+	}
+	templates, err := s.templates.TemplatesByID(ctx, []workout.TemplateID{day.TemplateID})
+	if err != nil {
+		return nil, err
+	}
+	t, ok := templates[day.TemplateID]
+	if !ok {
+		return nil, ErrNoSession
+	}
+
+	entries := p.progress(t.Entries, week)
+	if t.UserID != cmd.UserID {
+		for i := range entries {
+			entries[i].ExerciseID = nil
+		}
+	}
+	return s.workouts.Create(ctx, workout.CreateWorkoutCommand{
+		UserID:      cmd.UserID,
+		Title:       t.Name,
+		Description: t.Description,
+		TemplateID:  &t.ID,
+		Entries:     entries,
+	})
+}
+
+// ScheduleQuery asks for the caller's sessions on every day in [From, To].
+// Days are calendar days in Location, UTC when nil, so a workout counts
+// for the day it was logged on where the user is.
+type ScheduleQuery struct {
+	UserID   user.UserID
+	From     Date
+	To       Date
+	Location *time.Location
+}
+
+func (q *ScheduleQuery) Validate() error {
+	if q.From.IsZero() || q.To.IsZero() {
+		return errors.New("from and to are required")
+	}
+	if q.To.Before(q.From.Time) {
+		return errors.New("from must not be after to")
+	}
+	if q.From.DaysUntil(q.To) >= maxScheduleDays {
+		return fmt.Errorf("schedule range must not exceed %d days", maxScheduleDays)
+	}
+	return nil
+}
+
+// Schedule expands every enrollment over the requested range into dated
+// sessions with progression applied, then marks each one fulfilled if a
+// workout started from that session's template was logged the same day.
+// A logged workout fulfils at most one session.
+func (s *Service) Schedule(ctx context.Context, q ScheduleQuery) ([]Session, error) {
+	if err := q.Validate(); err != nil {
+		return nil, wrapValidation(err)
+	}
+
+	enrollments, err := s.store.ListEnrollments(ctx, q.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if len(enrollments) == 0 {
+		return []Session{}, nil
+	}
+
+	programIDs := make([]ProgramID, 0, len(enrollments))
+	for _, e := range enrollments {
+		programIDs = append(programIDs, e.ProgramID)
+	}
+	programs, err := s.store.GetProgramsByID(ctx, programIDs)
+	if err != nil {
+		return nil, err
+	}
+	programByID := make(map[ProgramID]*Program, len(programs))
+	var templateIDs []workout.TemplateID
+	for _, p := range programs {
+		programByID[p.ID] = p
+		templateIDs = append(templateIDs, p.TemplateIDs()...)
+	}
+
+	templates, err := s.templates.TemplatesByID(ctx, templateIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := []Session{}
+	for _, e := range enrollments {
+		p, ok := programByID[e.ProgramID]
+		if !ok {
+			continue
+		}
+		for d := q.From; !d.After(q.To.Time); d = d.AddDays(1) {
+			week, day, ok := p.dayAt(e.StartDate.DaysUntil(d))
+			if !ok {
+				continue
+			}
+			t, ok := templates[day.TemplateID]
+			if !ok {
+				continue
+			}
+			sessions = append(sessions, Session{
+				Date:         d,
+				EnrollmentID: e.ID,
+				ProgramID:    p.ID,
+				ProgramName:  p.Name,
+				Week:         week,
+				Day:          day.Day,
+				TemplateID:   t.ID,
+				TemplateName: t.Name,
+				Entries:      p.progress(t.Entries, week),
+			})
+		}
+	}
+	sort.SliceStable(sessions, func(i, j int) bool {
+		return sessions[i].Date.Before(sessions[j].Date.Time)
+	})
+
+	if len(sessions) == 0 {
+		return sessions, nil
+	}
+	fulfillments, err := s.store.Fulfillments(ctx, q.UserID, templateIDs, q.From, q.To, orUTC(q.Location))
+	if err != nil {
+		return nil, err
+	}
+	markFulfilled(sessions, fulfillments)
+	return sessions, nil
+}
+
+// markFulfilled pairs sessions with same-day, same-template workouts,
+// consuming each workout at most once.
+func markFulfilled(sessions []Session, fulfillments []Fulfillment) {
+	type key struct {
+		date       string
+		templateID workout.TemplateID
+	}
+	pending := make(map[key][]workout.WorkoutID)
+	for _, f := range fulfillments {
+		k := key{f.Date.String(), f.TemplateID}
+		pending[k] = append(pending[k], f.WorkoutID)
+	}
+	for i := range sessions {
+		k := key{sessions[i].Date.String(), sessions[i].TemplateID}
+		if ids := pending[k]; len(ids) > 0 {
+			id := ids[0]
+			pending[k] = ids[1:]
+			sessions[i].Fulfilled = true
+			sessions[i].WorkoutID = &id
+		}
+	}
+}
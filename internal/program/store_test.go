@@ -0,0 +1,188 @@
+package program
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tsatsarisg/go-fit/internal/exercise"
+	"github.com/tsatsarisg/go-fit/internal/platform/postgres/pgtest"
+	"github.com/tsatsarisg/go-fit/internal/user"
+	"github.com/tsatsarisg/go-fit/internal/workout"
+)
+
+func setupTestDB(t *testing.T) *sql.DB {
+	return pgtest.Open(t, "program", "users")
+}
+
+func seedUser(t *testing.T, db *sql.DB, name string) user.UserID {
+	var id user.UserID
+	err := db.QueryRowContext(context.Background(), `
+		INSERT INTO users (username, email, password_hash, bio)
+		VALUES ($1, $2, 'unused-hash', '')
+		RETURNING id`, name, name+"@example.com").Scan(&id)
+	require.NoError(t, err)
+	return id
+}
+
+// TestEnrollment follows a coach's program through another user's
+// enrollment: the schedule applies per-week progression, a started session
+// uses the coach's template and fulfils the day, and the template can't be
+// deleted from under the program.
+func TestEnrollment(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	ctx := context.Background()
+	workouts := workout.NewService(workout.NewPostgresStore(db), exercise.NewService(exercise.NewPostgresStore(db)))
+	svc := NewService(NewPostgresStore(db), workouts, workouts)
+	coach, alice := seedUser(t, db, "coach"), seedUser(t, db, "alice")
+
+	reps, weight := 5, 100.0
+	tmpl, err := workouts.CreateTemplate(ctx, workout.CreateTemplateCommand{
+		UserID: coach,
+		Name:   "Squat day",
+		Entries: []workout.WorkoutEntry{
+			{ExerciseName: "Back Squat", Sets: 3, Reps: &reps, Weight: &weight},
+		},
+	})
+	require.NoError(t, err)
+
+	day := []Day{{Day: 1, TemplateID: tmpl.ID}}
+	p, err := svc.Create(ctx, CreateProgramCommand{
+		UserID:      coach,
+		Name:        "Squat block",
+		Progression: Progression{WeightIncrement: 2.5},
+		Weeks: []Week{
+			{Number: 1, Days: day},
+			{Number: 2, Days: day},
+			{Number: 3, Days: day, Progression: &Progression{WeightIncrement: 5, RepsIncrement: 1}},
+		},
+	})
+	require.NoError(t, err)
+
+	_, err = svc.Create(ctx, CreateProgramCommand{UserID: alice, Name: "Borrowed", Weeks: []Week{{Number: 1, Days: day}}})
+	assert.ErrorIs(t, err, ErrValidation, "a program can only use its author's templates")
+
+	got, err := svc.Get(ctx, p.ID)
+	require.NoError(t, err)
+	assert.Nil(t, got.Weeks[1].Progression)
+	assert.Equal(t, &Progression{WeightIncrement: 5, RepsIncrement: 1}, got.Weeks[2].Progression)
+
+	// Started a week ago, so today is day 1 of week 2.
+	today := NewDate(time.Now())
+	e, err := svc.Enroll(ctx, EnrollCommand{UserID: alice, ProgramID: p.ID, StartDate: today.AddDays(-7)})
+	require.NoError(t, err)
+
+	schedule := func() []Session {
+		sessions, err := svc.Schedule(ctx, ScheduleQuery{UserID: alice, From: e.StartDate, To: e.StartDate.AddDays(20)})
+		require.NoError(t, err)
+		require.Len(t, sessions, 3)
+		return sessions
+	}
+	sessions := schedule()
+	for i, want := range []struct {
+		weight float64
+		reps   int
+	}{{100, 5}, {102.5, 5}, {107.5, 6}} {
+		assert.Equal(t, want.weight, *sessions[i].Entries[0].Weight, "week %d", i+1)
+		assert.Equal(t, want.reps, *sessions[i].Entries[0].Reps, "week %d", i+1)
+		assert.False(t, sessions[i].Fulfilled)
+	}
+
+	_, err = svc.StartSession(ctx, StartSessionCommand{UserID: coach, EnrollmentID: e.ID})
+	assert.ErrorIs(t, err, ErrNotFound, "someone else's enrollment")
+
+	w, err := svc.StartSession(ctx, StartSessionCommand{UserID: alice, EnrollmentID: e.ID})
+	require.NoError(t, err, "enrollment grants the coach's template")
+	assert.Equal(t, alice, w.UserID)
+	assert.Equal(t, &tmpl.ID, w.TemplateID)
+	assert.Equal(t, 102.5, *w.Entries[0].Weight)
+
+	sessions = schedule()
+	assert.False(t, sessions[0].Fulfilled)
+	assert.True(t, sessions[1].Fulfilled)
+	assert.Equal(t, &w.ID, sessions[1].WorkoutID)
+
+	later, err := svc.Enroll(ctx, EnrollCommand{UserID: alice, ProgramID: p.ID, StartDate: today.AddDays(1)})
+	require.NoError(t, err)
+	_, err = svc.StartSession(ctx, StartSessionCommand{UserID: alice, EnrollmentID: later.ID})
+	assert.ErrorIs(t, err, ErrNoSession)
+
+	err = workouts.DeleteTemplate(ctx, tmpl.ID, coach)
+	assert.ErrorIs(t, err, workout.ErrTemplateInUse)
+	require.NoError(t, svc.Delete(ctx, p.ID, coach))
+	assert.NoError(t, workouts.DeleteTemplate(ctx, tmpl.ID, coach))
+}
+
+// TestScheduleTimeZone checks that a workout counts for the day it was
+// logged on in the caller's zone, not in UTC.
+func TestScheduleTimeZone(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	ctx := context.Background()
+	workouts := workout.NewService(workout.NewPostgresStore(db), exercise.NewService(exercise.NewPostgresStore(db)))
+	svc := NewService(NewPostgresStore(db), workouts, workouts)
+	alice := seedUser(t, db, "alice")
+
+	tmpl, err := workouts.CreateTemplate(ctx, workout.CreateTemplateCommand{UserID: alice, Name: "Run"})
+	require.NoError(t, err)
+	p, err := svc.Create(ctx, CreateProgramCommand{
+		UserID: alice,
+		Name:   "Daily run",
+		Weeks:  []Week{{Number: 1, Days: []Day{{Day: 1, TemplateID: tmpl.ID}}}},
+	})
+	require.NoError(t, err)
+	monday, err := ParseDate("2024-04-29")
+	require.NoError(t, err)
+	_, err = svc.Enroll(ctx, EnrollCommand{UserID: alice, ProgramID: p.ID, StartDate: monday})
+	require.NoError(t, err)
+
+	// 8pm on Monday in New York is already Tuesday in UTC.
+	_, err = db.ExecContext(ctx, `
+		INSERT INTO workouts (user_id, title, duration_minutes, calories_burned, template_id, created_at)
+		VALUES ($1, 'Run', 30, 0, $2, '2024-04-30T00:00:00Z')`, alice, tmpl.ID)
+	require.NoError(t, err)
+
+	newYork, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+	sessions, err := svc.Schedule(ctx, ScheduleQuery{UserID: alice, From: monday, To: monday, Location: newYork})
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	assert.True(t, sessions[0].Fulfilled)
+
+	sessions, err = svc.Schedule(ctx, ScheduleQuery{UserID: alice, From: monday, To: monday})
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	assert.False(t, sessions[0].Fulfilled, "in UTC the workout is on Tuesday")
+}
+
+// TestPurgeProgramAuthor checks that the template foreign key, which blocks
+// deleting a template in use, doesn't block deleting its author.
+func TestPurgeProgramAuthor(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	ctx := context.Background()
+	workouts := workout.NewService(workout.NewPostgresStore(db), exercise.NewService(exercise.NewPostgresStore(db)))
+	svc := NewService(NewPostgresStore(db), workouts, workouts)
+	coach := seedUser(t, db, "coach")
+
+	reps := 10
+	tmpl, err := workouts.CreateTemplate(ctx, workout.CreateTemplateCommand{
+		UserID:  coach,
+		Name:    "Push-ups",
+		Entries: []workout.WorkoutEntry{{ExerciseName: "Push Up", Sets: 3, Reps: &reps}},
+	})
+	require.NoError(t, err)
+	_, err = svc.Create(ctx, CreateProgramCommand{UserID: coach, Name: "Daily", Weeks: []Week{{Number: 1, Days: []Day{{Day: 1, TemplateID: tmpl.ID}}}}})
+	require.NoError(t, err)
+
+	_, err = db.ExecContext(ctx, `DELETE FROM users WHERE id = $1`, coach)
+	assert.NoError(t, err)
+}
@@ -1,6 +1,7 @@
 package user
 
 import (
+	"context"
 	"errors"
 	"log/slog"
 	"net/http"
@@ -15,13 +16,66 @@ type registerUserRequest struct {
 	Bio      string `json:"bio,omitempty"`
 }
 
+// CurrentUserFunc reports the authenticated caller's id, or false for an
+// anonymous request. Injected from the composition root: user can't import
+// auth (auth depends on user), so the handler can't read the principal
+// itself.
+type CurrentUserFunc func(r *http.Request) (UserID, bool)
+
+// SendActivationFunc mails u an activation token. Injected for the same
+// reason as CurrentUserFunc: tokens belong to auth.
+type SendActivationFunc func(ctx context.Context, u *User) error
+
 type Handler struct {
-	service *Service
-	logger  *slog.Logger
+	service        *Service
+	logger         *slog.Logger
+	currentUser    CurrentUserFunc
+	sendActivation SendActivationFunc
 }
 
-func NewHandler(service *Service, logger *slog.Logger) *Handler {
-	return &Handler{service: service, logger: logger}
+func NewHandler(service *Service, logger *slog.Logger, currentUser CurrentUserFunc, sendActivation SendActivationFunc) *Handler {
+	return &Handler{service: service, logger: logger, currentUser: currentUser, sendActivation: sendActivation}
+}
+
+// requestActivation sends the activation mail without failing the request
+// that triggered it: the account change already happened, and a lost mail
+// is recoverable by changing the email again.
+func (h *Handler) requestActivation(ctx context.Context, u *User) {
+	if err := h.sendActivation(ctx, u); err != nil {
+		h.logger.ErrorContext(ctx, "send activation failed", slog.Any("err", err))
+	}
+}
+
+// HandleResendActivation serves POST /users/me/activation: mails the caller
+// a new activation token for their current address, revoking earlier ones.
+func (h *Handler) HandleResendActivation(w http.ResponseWriter, r *http.Request) {
+	callerID, ok := h.currentUser(r)
+	if !ok {
+		httpx.WriteJson(w, http.StatusUnauthorized, httpx.Envelope{"error": "Unauthenticated"})
+		return
+	}
+	u, err := h.service.Get(r.Context(), callerID)
+	if err != nil {
+		httpx.WriteStoreError(r.Context(), w, h.logger, err, errorMapping, "Failed to retrieve user")
+		return
+	}
+	if u.Activated {
+		httpx.WriteJson(w, http.StatusConflict, httpx.Envelope{"error": "account is already activated"})
+		return
+	}
+	if err := h.sendActivation(r.Context(), u); err != nil {
+		h.logger.ErrorContext(r.Context(), "send activation failed", slog.Any("err", err))
+		httpx.WriteJson(w, http.StatusInternalServerError, httpx.Envelope{"error": "internal server error"})
+		return
+	}
+	httpx.WriteJson(w, http.StatusAccepted, httpx.Envelope{"message": "An activation token has been sent to " + u.Email.String() + "."})
+}
+
+// errorMapping centralizes user-specific sentinel → HTTP mapping for the
+// httpx.WriteStoreError helper.
+var errorMapping = httpx.StoreErrorMapping{
+	ResourceName: "User",
+	NotFoundErr:  ErrNotFound,
 }
 
 func (h *Handler) HandleRegisterUser(w http.ResponseWriter, r *http.Request) {
@@ -47,5 +101,104 @@ func (h *Handler) HandleRegisterUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	h.requestActivation(r.Context(), u)
 	httpx.WriteJson(w, http.StatusCreated, httpx.Envelope{"user": u})
 }
+
+// HandleFollow makes the caller follow {id}. Idempotent: following someone
+// twice is still 204.
+func (h *Handler) HandleFollow(w http.ResponseWriter, r *http.Request) {
+	h.handleFollowChange(w, r, h.service.Follow)
+}
+
+// HandleUnfollow is the inverse of HandleFollow, equally idempotent.
+func (h *Handler) HandleUnfollow(w http.ResponseWriter, r *http.Request) {
+	h.handleFollowChange(w, r, h.service.Unfollow)
+}
+
+func (h *Handler) handleFollowChange(w http.ResponseWriter, r *http.Request, change func(ctx context.Context, followerID, followeeID UserID) error) {
+	followeeID, err := httpx.ReadIdParam(r, "id")
+	if err != nil {
+		h.logger.WarnContext(r.Context(), "read id param", slog.Any("err", err))
+		httpx.WriteJson(w, http.StatusBadRequest, httpx.Envelope{"error": err.Error()})
+		return
+	}
+
+	callerID, ok := h.currentUser(r)
+	if !ok {
+		httpx.WriteJson(w, http.StatusUnauthorized, httpx.Envelope{"error": "Unauthenticated"})
+		return
+	}
+
+	if err := change(r.Context(), callerID, UserID(followeeID)); err != nil {
+		if errors.Is(err, ErrValidation) {
+			httpx.WriteJson(w, http.StatusBadRequest, httpx.Envelope{"error": err.Error()})
+			return
+		}
+		httpx.WriteStoreError(r.Context(), w, h.logger, err, errorMapping, "internal server error")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandleGetMe returns the caller's own profile, email included.
+func (h *Handler) HandleGetMe(w http.ResponseWriter, r *http.Request) {
+	callerID, ok := h.currentUser(r)
+	if !ok {
+		httpx.WriteJson(w, http.StatusUnauthorized, httpx.Envelope{"error": "Unauthenticated"})
+		return
+	}
+
+	u, err := h.service.Get(r.Context(), callerID)
+	if err != nil {
+		httpx.WriteStoreError(r.Context(), w, h.logger, err, errorMapping, "internal server error")
+		return
+	}
+
+	httpx.WriteJson(w, http.StatusOK, httpx.Envelope{"user": u})
+}
+
+// HandleUpdateMe partially updates the caller's username, email and bio.
+func (h *Handler) HandleUpdateMe(w http.ResponseWriter, r *http.Request) {
+	callerID, ok := h.currentUser(r)
+	if !ok {
+		httpx.WriteJson(w, http.StatusUnauthorized, httpx.Envelope{"error": "Unauthenticated"})
+		return
+	}
+
+	var body struct {
+		Username *string `json:"username"`
+		Email    *string `json:"email"`
+		Bio      *string `json:"bio"`
+	}
+	if derr := httpx.DecodeJSONBody(w, r, &body); derr != nil {
+		h.logger.WarnContext(r.Context(), "decode update user", slog.Any("err", derr))
+		httpx.WriteDecodeError(w, derr)
+		return
+	}
+
+	u, err := h.service.UpdateProfile(r.Context(), callerID, UserPatch{
+		Username: body.Username,
+		Email:    body.Email,
+		Bio:      body.Bio,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrValidation):
+			httpx.WriteJson(w, http.StatusBadRequest, httpx.Envelope{"error": err.Error()})
+		case errors.Is(err, ErrUsernameTaken):
+			httpx.WriteJson(w, http.StatusConflict, httpx.Envelope{"error": "username is already taken"})
+		case errors.Is(err, ErrEmailTaken):
+			httpx.WriteJson(w, http.StatusConflict, httpx.Envelope{"error": "email is already in use"})
+		default:
+			httpx.WriteStoreError(r.Context(), w, h.logger, err, errorMapping, "internal server error")
+		}
+		return
+	}
+
+	if body.Email != nil && !u.Activated {
+		h.requestActivation(r.Context(), u)
+	}
+	httpx.WriteJson(w, http.StatusOK, httpx.Envelope{"user": u})
+}
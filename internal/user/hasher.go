@@ -1,18 +1,34 @@
 package user
 
-import "golang.org/x/crypto/bcrypt"
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
 
 // Hasher is the strategy used by Service to hash new passwords. Decoupled
 // from the bcrypt impl so tests can inject a cheap-cost variant (integration
 // tests that churn through Register hit Service.Register dozens of times;
-// DefaultCost at ~100ms/hash makes suites minute-slow) and so a future
-// migration to argon2 is a drop-in at the app wiring site, not a surgery
-// on every call site.
+// DefaultCost at ~100ms/hash makes suites minute-slow) and so the algorithm
+// is chosen at the app wiring site, not on every call site.
+//
+// Stored hashes say which algorithm made them (password.Matches reads
+// either), so changing the Hasher doesn't lock anyone out. NeedsRehash
+// reports whether a stored hash falls short of what Hash would produce
+// now; Service.VerifyPassword replaces those on the next login.
 type Hasher interface {
 	Hash(plaintext string) (password, error)
+	NeedsRehash(p password) bool
 }
 
-// BcryptHasher is the production Hasher. Cost is bcrypt work factor; leave at
+// BcryptHasher hashes with bcrypt. Cost is bcrypt work factor; leave at
 // bcrypt.DefaultCost (currently 10) for prod, bcrypt.MinCost (4) for tests.
 type BcryptHasher struct {
 	Cost int
@@ -35,3 +51,117 @@ func (h *BcryptHasher) Hash(plaintext string) (password, error) {
 	}
 	return password{hash: hash}, nil
 }
+
+// NeedsRehash is true for anything but a bcrypt hash of at least h.Cost.
+func (h *BcryptHasher) NeedsRehash(p password) bool {
+	if p.isArgon2id() {
+		return true
+	}
+	cost, err := bcrypt.Cost(p.hash)
+	return err != nil || cost < h.Cost
+}
+
+// Argon2id parameters, as the PHC string format names them: Memory in KiB
+// (m), Iterations (t) and Parallelism (p). The defaults are OWASP's
+// minimum recommendation for argon2id, which costs about what bcrypt at
+// DefaultCost does while needing 19 MiB per hash in flight.
+const (
+	DefaultArgon2Memory      = 19 * 1024
+	DefaultArgon2Iterations  = 2
+	DefaultArgon2Parallelism = 1
+
+	argon2SaltLen = 16
+	argon2KeyLen  = 32
+)
+
+var argon2idPrefix = []byte("$argon2id$")
+
+// Argon2idHasher hashes with argon2id and stores the result as a PHC
+// string: $argon2id$v=19$m=<memory>,t=<iterations>,p=<parallelism>$<salt>$<key>.
+type Argon2idHasher struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+}
+
+// NewArgon2idHasher returns a hasher with the given parameters, replacing
+// any zero with its default for the same reason NewBcryptHasher does.
+func NewArgon2idHasher(memory, iterations uint32, parallelism uint8) *Argon2idHasher {
+	if memory == 0 {
+		memory = DefaultArgon2Memory
+	}
+	if iterations == 0 {
+		iterations = DefaultArgon2Iterations
+	}
+	if parallelism == 0 {
+		parallelism = DefaultArgon2Parallelism
+	}
+	return &Argon2idHasher{Memory: memory, Iterations: iterations, Parallelism: parallelism}
+}
+
+func (h *Argon2idHasher) Hash(plaintext string) (password, error) {
+	salt := make([]byte, argon2SaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return password{}, err
+	}
+	key := argon2.IDKey([]byte(plaintext), salt, h.Iterations, h.Memory, h.Parallelism, argon2KeyLen)
+	hash := fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, h.Memory, h.Iterations, h.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key))
+	return password{hash: []byte(hash)}, nil
+}
+
+// NeedsRehash is true for anything but an argon2id hash whose parameters
+// are all at least h's.
+func (h *Argon2idHasher) NeedsRehash(p password) bool {
+	if !p.isArgon2id() {
+		return true
+	}
+	params, _, _, err := parseArgon2id(p.hash)
+	return err != nil || params.Memory < h.Memory || params.Iterations < h.Iterations || params.Parallelism < h.Parallelism
+}
+
+var errMalformedArgon2id = errors.New("malformed argon2id hash")
+
+// parseArgon2id splits a PHC string made by Argon2idHasher.Hash.
+func parseArgon2id(hash []byte) (params Argon2idHasher, salt, key []byte, err error) {
+	// "", "argon2id", "v=19", "m=..,t=..,p=..", salt, key
+	fields := strings.Split(string(hash), "$")
+	if len(fields) != 6 {
+		return params, nil, nil, errMalformedArgon2id
+	}
+	var version int
+	if _, err := fmt.Sscanf(fields[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return params, nil, nil, errMalformedArgon2id
+	}
+	if _, err := fmt.Sscanf(fields[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil {
+		return params, nil, nil, errMalformedArgon2id
+	}
+	if params.Memory == 0 || params.Iterations == 0 || params.Parallelism == 0 {
+		return params, nil, nil, errMalformedArgon2id
+	}
+	salt, err = base64.RawStdEncoding.DecodeString(fields[4])
+	if err != nil {
+		return params, nil, nil, errMalformedArgon2id
+	}
+	key, err = base64.RawStdEncoding.DecodeString(fields[5])
+	if err != nil || len(key) == 0 {
+		return params, nil, nil, errMalformedArgon2id
+	}
+	return params, salt, key, nil
+}
+
+func (p password) isArgon2id() bool {
+	return bytes.HasPrefix(p.hash, argon2idPrefix)
+}
+
+// matchesArgon2id recomputes the key with the stored parameters and salt,
+// and compares in constant time.
+func (p password) matchesArgon2id(plaintext string) (bool, error) {
+	params, salt, key, err := parseArgon2id(p.hash)
+	if err != nil {
+		return false, err
+	}
+	got := argon2.IDKey([]byte(plaintext), salt, params.Iterations, params.Memory, params.Parallelism, uint32(len(key)))
+	return subtle.ConstantTimeCompare(got, key) == 1, nil
+}
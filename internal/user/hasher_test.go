@@ -0,0 +1,47 @@
+package user
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestArgon2idHasher(t *testing.T) {
+	h := NewArgon2idHasher(8*1024, 1, 1)
+	p, err := h.Hash("correct horse battery")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(p.hash), "$argon2id$v=19$m=8192,t=1,p=1$"), string(p.hash))
+
+	ok, err := p.Matches("correct horse battery")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = p.Matches("correct horse battery!")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	again, err := h.Hash("correct horse battery")
+	require.NoError(t, err)
+	assert.NotEqual(t, p.hash, again.hash, "salted")
+
+	_, err = password{hash: []byte("$argon2id$v=19$m=8192$x$y")}.Matches("x")
+	assert.Error(t, err)
+}
+
+func TestNeedsRehash(t *testing.T) {
+	bcryptLow, err := NewBcryptHasher(bcrypt.MinCost).Hash("correct horse battery")
+	require.NoError(t, err)
+	argonLow, err := NewArgon2idHasher(8*1024, 1, 1).Hash("correct horse battery")
+	require.NoError(t, err)
+
+	assert.False(t, NewBcryptHasher(bcrypt.MinCost).NeedsRehash(bcryptLow))
+	assert.True(t, NewBcryptHasher(bcrypt.MinCost+1).NeedsRehash(bcryptLow), "below the target cost")
+	assert.True(t, NewBcryptHasher(bcrypt.MinCost).NeedsRehash(argonLow), "other algorithm")
+
+	assert.False(t, NewArgon2idHasher(8*1024, 1, 1).NeedsRehash(argonLow))
+	assert.True(t, NewArgon2idHasher(16*1024, 1, 1).NeedsRehash(argonLow), "less memory than configured")
+	assert.True(t, NewArgon2idHasher(8*1024, 2, 1).NeedsRehash(argonLow), "fewer passes than configured")
+	assert.True(t, NewArgon2idHasher(8*1024, 1, 1).NeedsRehash(bcryptLow), "other algorithm")
+}
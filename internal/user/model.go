@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"net/mail"
+	"slices"
 	"strings"
 	"time"
 
@@ -37,18 +38,23 @@ func NewEmail(s string) (Email, error) {
 
 func (e Email) String() string { return string(e) }
 
-// password wraps a bcrypt hash. The field is unexported and the type has no
-// setter — instances come only from HashPassword or a DB scan — so plaintext
+// password wraps a password hash: bcrypt's own format, or a PHC string for
+// argon2id (see hasher.go). The field is unexported and the type has no
+// setter — instances come only from a Hasher or a DB scan — so plaintext
 // is never retained on the value. Zero value (nil hash) is meaningful only
 // for the timing-equalization path in VerifyPassword.
 type password struct {
 	hash []byte
 }
 
-// Matches reports whether plaintext is the password behind this hash.
-// Returns (false, nil) for a well-formed mismatch so callers don't need to
-// special-case bcrypt.ErrMismatchedHashAndPassword themselves.
+// Matches reports whether plaintext is the password behind this hash,
+// whichever algorithm made it. Returns (false, nil) for a well-formed
+// mismatch so callers don't need to special-case
+// bcrypt.ErrMismatchedHashAndPassword themselves.
 func (p password) Matches(plaintext string) (bool, error) {
+	if p.isArgon2id() {
+		return p.matchesArgon2id(plaintext)
+	}
 	err := bcrypt.CompareHashAndPassword(p.hash, []byte(plaintext))
 	if err == bcrypt.ErrMismatchedHashAndPassword {
 		return false, nil
@@ -59,38 +65,54 @@ func (p password) Matches(plaintext string) (bool, error) {
 	return true, nil
 }
 
+// Role is what an account is allowed to do beyond its own data. Every
+// account starts as RoleUser; roles are granted through the admin API.
+// What each role permits is auth's business (see auth.RolePermissions):
+// user only stores it.
+type Role string
+
+const (
+	RoleUser  Role = "user"
+	RoleCoach Role = "coach"
+	RoleAdmin Role = "admin"
+)
+
+// Roles lists every role, in increasing order of privilege.
+var Roles = []Role{RoleUser, RoleCoach, RoleAdmin}
+
+// ParseRole returns the role named s, or an error naming the valid ones.
+func ParseRole(s string) (Role, error) {
+	r := Role(s)
+	if !slices.Contains(Roles, r) {
+		return "", fmt.Errorf("role must be one of %q, %q or %q", RoleUser, RoleCoach, RoleAdmin)
+	}
+	return r, nil
+}
+
 type User struct {
 	ID           UserID    `json:"id"`
 	Username     string    `json:"username"`
 	Email        Email     `json:"email"`
 	PasswordHash password  `json:"-"`
 	Bio          string    `json:"bio,omitempty"`
+	Activated    bool      `json:"activated"`
+	Role         Role      `json:"role"`
 	CreatedAt    time.Time `json:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at"`
+	// DeletionRequestedAt is set while the account waits out its deletion
+	// grace period; see Service.RequestDeletion.
+	DeletionRequestedAt *time.Time `json:"deletion_requested_at,omitempty"`
+	// DisabledAt is set while an admin has the account disabled; see
+	// Service.Disable.
+	DisabledAt *time.Time `json:"disabled_at,omitempty"`
 }
 
-var dummyPasswordHash = func() []byte {
-	h, err := bcrypt.GenerateFromPassword([]byte("timing-equalization-dummy"), bcrypt.DefaultCost)
-	if err != nil {
-		panic(err)
-	}
-	return h
-}()
+// PendingDeletion reports whether the account is scheduled for deletion.
+func (u *User) PendingDeletion() bool {
+	return u.DeletionRequestedAt != nil
+}
 
-// VerifyPassword runs bcrypt against the user's hash, or against a fixed dummy
-// hash when user is nil, so response timing does not reveal whether the
-// username existed. Returns (true, nil) only on a real match.
-func VerifyPassword(user *User, plainText string) (bool, error) {
-	hash := dummyPasswordHash
-	if user != nil {
-		hash = user.PasswordHash.hash
-	}
-	err := bcrypt.CompareHashAndPassword(hash, []byte(plainText))
-	if err == bcrypt.ErrMismatchedHashAndPassword {
-		return false, nil
-	}
-	if err != nil {
-		return false, err
-	}
-	return user != nil, nil
+// Disabled reports whether an admin has disabled the account.
+func (u *User) Disabled() bool {
+	return u.DisabledAt != nil
 }
@@ -0,0 +1,53 @@
+package user
+
+import (
+	"errors"
+	"strings"
+)
+
+// BreachedPasswords is the corpus PasswordPolicy screens against.
+// Consumer-side so user doesn't import the loader; platform/breached's
+// Filter satisfies it.
+type BreachedPasswords interface {
+	Contains(plaintext string) bool
+}
+
+// PasswordPolicy is what every new password must meet, whether set at
+// registration, by ChangePassword or by a reset: the minimum length, not
+// containing the account's own identifiers, and, when Breached is set, not
+// appearing in a known breach. The zero value checks all but the last.
+type PasswordPolicy struct {
+	Breached BreachedPasswords
+}
+
+// minIdentifierLen is the shortest username or email local part the policy
+// looks for inside a password; shorter ones occur in too many passwords by
+// chance to be worth refusing.
+const minIdentifierLen = 3
+
+// Check returns an error suitable for wrapping in ErrValidation, naming
+// the first rule plaintext breaks. email may be empty when the address
+// isn't known yet.
+func (p PasswordPolicy) Check(plaintext, username string, email Email) error {
+	if err := validatePassword(plaintext); err != nil {
+		return err
+	}
+	lower := strings.ToLower(plaintext)
+	if containsIdentifier(lower, username) {
+		return errors.New("password must not contain your username")
+	}
+	local, _, _ := strings.Cut(string(email), "@")
+	if containsIdentifier(lower, local) {
+		return errors.New("password must not contain your email address")
+	}
+	if p.Breached != nil && p.Breached.Contains(plaintext) {
+		return errors.New("password appears in a known data breach; choose another")
+	}
+	return nil
+}
+
+// containsIdentifier reports whether the lowercased password contains id,
+// ignoring case.
+func containsIdentifier(lowerPassword, id string) bool {
+	return len(id) >= minIdentifierLen && strings.Contains(lowerPassword, strings.ToLower(id))
+}
@@ -0,0 +1,36 @@
+package user
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type breachedSet map[string]bool
+
+func (b breachedSet) Contains(plaintext string) bool { return b[plaintext] }
+
+func TestPasswordPolicy(t *testing.T) {
+	p := PasswordPolicy{Breached: breachedSet{"password1234": true}}
+	email := Email("j.smith@example.com")
+
+	for _, tc := range []struct {
+		password, want string
+	}{
+		{"correct horse battery", ""},
+		{"short", "password must be at least 12 characters long"},
+		{"my-Alice-password", "password must not contain your username"},
+		{"hello J.Smith 2026", "password must not contain your email address"},
+		{"password1234", "password appears in a known data breach; choose another"},
+	} {
+		err := p.Check(tc.password, "alice", email)
+		if tc.want == "" {
+			assert.NoError(t, err, tc.password)
+		} else {
+			assert.EqualError(t, err, tc.want, tc.password)
+		}
+	}
+
+	assert.NoError(t, p.Check("al is my nickname", "al", ""), "too short an identifier to refuse")
+	assert.NoError(t, PasswordPolicy{}.Check("password1234", "alice", email), "no corpus, no screening")
+}
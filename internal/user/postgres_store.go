@@ -4,6 +4,9 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
+	"strings"
+	"time"
 
 	"github.com/tsatsarisg/go-fit/internal/platform/postgres"
 )
@@ -18,8 +21,8 @@ func NewPostgresStore(db *sql.DB) *PostgresStore {
 
 func (store *PostgresStore) CreateUser(ctx context.Context, user *User) error {
 	query := `INSERT INTO users (username, email, password_hash, bio)
-			  VALUES ($1, $2, $3, $4) RETURNING id, created_at, updated_at`
-	err := store.db.QueryRowContext(ctx, query, user.Username, string(user.Email), user.PasswordHash.hash, user.Bio).Scan(&user.ID, &user.CreatedAt, &user.UpdatedAt)
+			  VALUES ($1, $2, $3, $4) RETURNING id, role, created_at, updated_at`
+	err := store.db.QueryRowContext(ctx, query, user.Username, string(user.Email), user.PasswordHash.hash, user.Bio).Scan(&user.ID, &user.Role, &user.CreatedAt, &user.UpdatedAt)
 	if err != nil {
 		return postgres.ClassifyError(err)
 	}
@@ -27,31 +30,227 @@ func (store *PostgresStore) CreateUser(ctx context.Context, user *User) error {
 }
 
 func (store *PostgresStore) GetUserByUsername(ctx context.Context, username string) (*User, error) {
+	return store.getUser(ctx, `username = $1`, username)
+}
+
+func (store *PostgresStore) GetUserByEmail(ctx context.Context, email Email) (*User, error) {
+	return store.getUser(ctx, `email = $1`, string(email))
+}
+
+func (store *PostgresStore) GetUserByID(ctx context.Context, id UserID) (*User, error) {
+	return store.getUser(ctx, `id = $1`, id)
+}
+
+// userColumns is the select list scanUser reads.
+const userColumns = `id, username, email, password_hash, bio, activated, role, created_at, updated_at, deletion_requested_at, disabled_at`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanUser(row rowScanner) (*User, error) {
 	user := &User{
 		PasswordHash: password{},
 	}
-	query := `SELECT id, username, email, password_hash, bio, created_at, updated_at FROM users WHERE username = $1`
-	row := store.db.QueryRowContext(ctx, query, username)
-
 	// Email scans into a *string buffer first then is typed; keeps database/sql
 	// happy without requiring a custom sql.Scanner on the VO.
 	var emailStr string
-	err := row.Scan(&user.ID, &user.Username, &emailStr, &user.PasswordHash.hash, &user.Bio, &user.CreatedAt, &user.UpdatedAt)
+	err := row.Scan(&user.ID, &user.Username, &emailStr, &user.PasswordHash.hash, &user.Bio, &user.Activated, &user.Role, &user.CreatedAt, &user.UpdatedAt, &user.DeletionRequestedAt, &user.DisabledAt)
+	if err != nil {
+		return nil, err
+	}
+	user.Email = Email(emailStr)
+	return user, nil
+}
+
+// getUser loads the single user matching where, a fixed predicate with one
+// placeholder.
+func (store *PostgresStore) getUser(ctx context.Context, where string, arg any) (*User, error) {
+	query := `SELECT ` + userColumns + ` FROM users WHERE ` + where
+	user, err := scanUser(store.db.QueryRowContext(ctx, query, arg))
 	if errors.Is(err, sql.ErrNoRows) {
 		return nil, ErrNotFound
 	}
 	if err != nil {
 		return nil, err
 	}
-	user.Email = Email(emailStr)
 	return user, nil
 }
 
+// likeEscaper neutralizes LIKE metacharacters in a search, as
+// workout.ListWorkouts does for title filters.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// SearchUsers returns up to q.Limit+1 users matching q in id order, the
+// extra row telling Service.Search there is another page. Like
+// workout.ListWorkouts, every predicate is parameterized.
+func (store *PostgresStore) SearchUsers(ctx context.Context, q SearchQuery) ([]*User, error) {
+	var (
+		args  []any
+		conds = []string{"TRUE"}
+	)
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+	if q.Query != "" {
+		pattern := arg("%" + likeEscaper.Replace(q.Query) + "%")
+		conds = append(conds, "(username ILIKE "+pattern+" OR email ILIKE "+pattern+")")
+	}
+	if q.Role != "" {
+		conds = append(conds, "role = "+arg(q.Role))
+	}
+	if q.Disabled != nil {
+		if *q.Disabled {
+			conds = append(conds, "disabled_at IS NOT NULL")
+		} else {
+			conds = append(conds, "disabled_at IS NULL")
+		}
+	}
+	if q.After != 0 {
+		conds = append(conds, "id > "+arg(q.After))
+	}
+	query := `SELECT ` + userColumns + ` FROM users WHERE ` + strings.Join(conds, " AND ") + ` ORDER BY id LIMIT ` + arg(q.Limit+1)
+
+	rows, err := store.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var users []*User
+	for rows.Next() {
+		u, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, u)
+	}
+	return users, rows.Err()
+}
+
+// UpdateUser reports a clash on either unique column as ErrUsernameTaken or
+// ErrEmailTaken rather than the generic postgres.ErrDuplicate.
 func (store *PostgresStore) UpdateUser(ctx context.Context, user *User) error {
-	query := `UPDATE users SET email = $1, username = $2, bio = $3, updated_at = NOW() WHERE id = $4 RETURNING updated_at`
-	err := store.db.QueryRowContext(ctx, query, string(user.Email), user.Username, user.Bio, user.ID).Scan(&user.UpdatedAt)
+	query := `UPDATE users SET email = $1, username = $2, bio = $3, activated = $4, updated_at = NOW() WHERE id = $5 RETURNING updated_at`
+	err := store.db.QueryRowContext(ctx, query, string(user.Email), user.Username, user.Bio, user.Activated, user.ID).Scan(&user.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
 	if err != nil {
+		switch postgres.ConstraintName(err) {
+		case "users_username_key":
+			return ErrUsernameTaken
+		case "users_email_key":
+			return ErrEmailTaken
+		}
 		return postgres.ClassifyError(err)
 	}
 	return nil
 }
+
+func (store *PostgresStore) ActivateUser(ctx context.Context, id UserID) error {
+	query := `UPDATE users SET activated = true, updated_at = NOW() WHERE id = $1`
+	res, err := store.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// SetRole changes the account's role.
+func (store *PostgresStore) SetRole(ctx context.Context, id UserID, role Role) error {
+	return store.updateOne(ctx, `UPDATE users SET role = $1, updated_at = NOW() WHERE id = $2`, role, id)
+}
+
+// SetDisabledAt disables (at non-nil) or enables (nil) the account.
+func (store *PostgresStore) SetDisabledAt(ctx context.Context, id UserID, at *time.Time) error {
+	return store.updateOne(ctx, `UPDATE users SET disabled_at = $1, updated_at = NOW() WHERE id = $2`, at, id)
+}
+
+// updateOne runs an UPDATE of a single user, ErrNotFound when there is no
+// such row.
+func (store *PostgresStore) updateOne(ctx context.Context, query string, args ...any) error {
+	res, err := store.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// SetDeletionRequestedAt schedules (at non-nil) or cancels (nil) the
+// account's deletion.
+func (store *PostgresStore) SetDeletionRequestedAt(ctx context.Context, id UserID, at *time.Time) error {
+	query := `UPDATE users SET deletion_requested_at = $1, updated_at = NOW() WHERE id = $2`
+	res, err := store.db.ExecContext(ctx, query, at, id)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// PurgeUsers hard-deletes every account whose deletion was requested before
+// cutoff. It is one statement, so each user and everything that cascades
+// from them goes in one transaction.
+func (store *PostgresStore) PurgeUsers(ctx context.Context, cutoff time.Time) (int64, error) {
+	query := `DELETE FROM users WHERE deletion_requested_at IS NOT NULL AND deletion_requested_at < $1`
+	res, err := store.db.ExecContext(ctx, query, cutoff)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func (store *PostgresStore) UpdatePassword(ctx context.Context, id UserID, hash password) error {
+	query := `UPDATE users SET password_hash = $1, updated_at = NOW() WHERE id = $2`
+	res, err := store.db.ExecContext(ctx, query, hash.hash, id)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (store *PostgresStore) Follow(ctx context.Context, followerID, followeeID UserID) error {
+	query := `INSERT INTO follows (follower_id, followee_id)
+			  VALUES ($1, $2)
+			  ON CONFLICT DO NOTHING`
+	_, err := store.db.ExecContext(ctx, query, followerID, followeeID)
+	err = postgres.ClassifyError(err)
+	if errors.Is(err, postgres.ErrForeignKeyViolation) {
+		return ErrNotFound
+	}
+	return err
+}
+
+func (store *PostgresStore) Unfollow(ctx context.Context, followerID, followeeID UserID) error {
+	query := `DELETE FROM follows WHERE follower_id = $1 AND followee_id = $2`
+	_, err := store.db.ExecContext(ctx, query, followerID, followeeID)
+	return err
+}
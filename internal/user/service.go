@@ -4,6 +4,10 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/tsatsarisg/go-fit/internal/platform/postgres"
 )
 
 // Store is the user bounded context's persistence port. Defined on the
@@ -13,7 +17,26 @@ import (
 type Store interface {
 	CreateUser(ctx context.Context, user *User) error
 	GetUserByUsername(ctx context.Context, username string) (*User, error)
+	GetUserByID(ctx context.Context, id UserID) (*User, error)
+	GetUserByEmail(ctx context.Context, email Email) (*User, error)
 	UpdateUser(ctx context.Context, user *User) error
+	// UpdatePassword replaces only the password hash; UpdateUser never
+	// touches it.
+	UpdatePassword(ctx context.Context, id UserID, hash password) error
+	ActivateUser(ctx context.Context, id UserID) error
+	SetDeletionRequestedAt(ctx context.Context, id UserID, at *time.Time) error
+	// PurgeUsers hard-deletes accounts whose deletion was requested before
+	// cutoff and reports how many went.
+	PurgeUsers(ctx context.Context, cutoff time.Time) (int64, error)
+	// Follow is idempotent and returns ErrNotFound when followeeID doesn't
+	// exist. Unfollow of a relationship that isn't there is a no-op.
+	Follow(ctx context.Context, followerID, followeeID UserID) error
+	Unfollow(ctx context.Context, followerID, followeeID UserID) error
+	// SearchUsers returns up to q.Limit+1 matches in id order, after
+	// q.After.
+	SearchUsers(ctx context.Context, q SearchQuery) ([]*User, error)
+	SetRole(ctx context.Context, id UserID, role Role) error
+	SetDisabledAt(ctx context.Context, id UserID, at *time.Time) error
 }
 
 // Domain-level sentinels for the user bounded context.
@@ -21,23 +44,91 @@ type Store interface {
 //   - ErrNotFound:   username does not exist in the users table → callers that
 //                    need timing parity with "user exists but wrong password"
 //                    (e.g. auth.Login) convert to a nil user at their boundary
-//                    and still run bcrypt via user.VerifyPassword.
+//                    and still hash via Service.VerifyPassword.
+//   - ErrUsernameTaken / ErrEmailTaken: a profile update collided with
+//                    another user → 409. Both wrap postgres.ErrDuplicate, so
+//                    httpx.WriteStoreError still maps them if a caller
+//                    doesn't check them itself.
+//   - ErrWrongPassword: the current password given to ChangePassword
+//                    didn't match → 403, not 401, so a client doesn't take it
+//                    for an expired session.
 var (
-	ErrValidation = errors.New("user validation failed")
-	ErrNotFound   = errors.New("user not found")
+	ErrValidation    = errors.New("user validation failed")
+	ErrNotFound      = errors.New("user not found")
+	ErrUsernameTaken = fmt.Errorf("%w: username is already taken", postgres.ErrDuplicate)
+	ErrEmailTaken    = fmt.Errorf("%w: email is already in use", postgres.ErrDuplicate)
+	ErrWrongPassword = errors.New("current password is incorrect")
 )
 
 // Service is the user bounded context's application service. Owns register
 // orchestration (validate → hash → persist) so handlers stay thin. Takes a
 // Hasher (D6) rather than calling bcrypt directly so test wiring can swap in
 // a cheap-cost hasher without the service caring.
+//
+// policy is checked on every new password; see PasswordPolicy.
+//
+// dummyHash is a hash made by hasher, for VerifyPassword to check against
+// when there is no user; it must cost what a real one does.
+//
+// deletionGrace is how long a deleted account can still be restored before
+// Purge erases it.
 type Service struct {
-	store  Store
-	hasher Hasher
+	store         Store
+	hasher        Hasher
+	policy        PasswordPolicy
+	dummyHash     password
+	deletionGrace time.Duration
+}
+
+// NewService hashes a dummy password with hasher up front. It panics if
+// that fails, which only a broken random source makes it do.
+func NewService(store Store, hasher Hasher, policy PasswordPolicy, deletionGrace time.Duration) *Service {
+	dummy, err := hasher.Hash("timing-equalization-dummy")
+	if err != nil {
+		panic(fmt.Sprintf("hash dummy password: %v", err))
+	}
+	return &Service{store: store, hasher: hasher, policy: policy, dummyHash: dummy, deletionGrace: deletionGrace}
 }
 
-func NewService(store Store, hasher Hasher) *Service {
-	return &Service{store: store, hasher: hasher}
+// VerifyPassword checks plaintext against u's hash, or against a dummy hash
+// made by the same Hasher when u is nil, so response timing does not reveal
+// whether the username existed. Returns (true, nil) only on a real match.
+//
+// After a match, a hash that Hasher.NeedsRehash says is outdated — another
+// algorithm, or weaker parameters than configured — is replaced with a
+// fresh one. Only a login can do that: it is the one time the plaintext is
+// at hand.
+func (s *Service) VerifyPassword(ctx context.Context, u *User, plaintext string) (bool, error) {
+	ok, err := s.matches(u, plaintext)
+	if err != nil || !ok {
+		return false, err
+	}
+	if !s.hasher.NeedsRehash(u.PasswordHash) {
+		return true, nil
+	}
+	hash, err := s.hasher.Hash(plaintext)
+	if err != nil {
+		return false, fmt.Errorf("rehash password: %w", err)
+	}
+	if err := s.store.UpdatePassword(ctx, u.ID, hash); err != nil {
+		return false, fmt.Errorf("rehash password: %w", err)
+	}
+	u.PasswordHash = hash
+	return true, nil
+}
+
+// matches is VerifyPassword without the rehash, for callers about to
+// replace or discard the hash anyway.
+func (s *Service) matches(u *User, plaintext string) (bool, error) {
+	hash := s.dummyHash
+	if u != nil {
+		hash = u.PasswordHash
+	}
+	ok, err := hash.Matches(plaintext)
+	if err != nil {
+		return false, err
+	}
+	return ok && u != nil, nil
 }
 
 // RegisterCommand captures the minimum fields needed to create a user. The
@@ -54,14 +145,20 @@ type RegisterCommand struct {
 // resistance to offline brute force on stolen hashes).
 const minPasswordLen = 12
 
+// validatePassword is the length rule, the one part of PasswordPolicy that
+// needs nothing but the password.
+func validatePassword(plaintext string) error {
+	if len(plaintext) < minPasswordLen {
+		return fmt.Errorf("password must be at least %d characters long", minPasswordLen)
+	}
+	return nil
+}
+
 func (c *RegisterCommand) Validate() error {
 	if c.Username == "" || c.Password == "" {
 		return errors.New("missing required fields")
 	}
-	if len(c.Password) < minPasswordLen {
-		return fmt.Errorf("password must be at least %d characters long", minPasswordLen)
-	}
-	return nil
+	return validatePassword(c.Password)
 }
 
 func (s *Service) Register(ctx context.Context, cmd RegisterCommand) (*User, error) {
@@ -75,6 +172,9 @@ func (s *Service) Register(ctx context.Context, cmd RegisterCommand) (*User, err
 	if err != nil {
 		return nil, fmt.Errorf("%w: %v", ErrValidation, err)
 	}
+	if err := s.policy.Check(cmd.Password, cmd.Username, email); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrValidation, err)
+	}
 
 	hash, err := s.hasher.Hash(cmd.Password)
 	if err != nil {
@@ -96,8 +196,308 @@ func (s *Service) Register(ctx context.Context, cmd RegisterCommand) (*User, err
 // FindByUsername returns (nil, ErrNotFound) for "no such user" and a non-nil
 // User for the happy path — normal Go convention (L9). Callers in the login
 // flow must be careful to preserve timing: see auth.Service.Login, which
-// folds ErrNotFound into a nil User so VerifyPassword still runs bcrypt
-// against the package-level dummy hash.
+// folds ErrNotFound into a nil User so VerifyPassword still hashes against
+// the dummy hash.
 func (s *Service) FindByUsername(ctx context.Context, username string) (*User, error) {
 	return s.store.GetUserByUsername(ctx, username)
 }
+
+// Get returns the user with id, or ErrNotFound.
+func (s *Service) Get(ctx context.Context, id UserID) (*User, error) {
+	return s.store.GetUserByID(ctx, id)
+}
+
+// UserPatch is a partial profile update: nil fields are left as they are,
+// the same semantics as workout.WorkoutPatch.
+type UserPatch struct {
+	Username *string
+	Email    *string
+	Bio      *string
+}
+
+// maxFieldLen mirrors the VARCHAR(255) on users.username and users.email.
+const maxFieldLen = 255
+
+func (p *UserPatch) Validate() error {
+	if p.Username != nil {
+		if *p.Username == "" {
+			return errors.New("username must not be empty")
+		}
+		if len(*p.Username) > maxFieldLen {
+			return fmt.Errorf("username must be at most %d bytes long", maxFieldLen)
+		}
+	}
+	if p.Email != nil && len(*p.Email) > maxFieldLen {
+		return fmt.Errorf("email must be at most %d bytes long", maxFieldLen)
+	}
+	return nil
+}
+
+// UpdateProfile applies patch to the user's profile. A changed email goes
+// through NewEmail again, so it is validated and lowercased exactly as at
+// registration, and puts the account back to unactivated until the new
+// address is confirmed.
+func (s *Service) UpdateProfile(ctx context.Context, id UserID, patch UserPatch) (*User, error) {
+	if err := patch.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrValidation, err)
+	}
+
+	u, err := s.store.GetUserByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if patch.Username != nil {
+		u.Username = *patch.Username
+	}
+	if patch.Email != nil {
+		email, err := NewEmail(*patch.Email)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrValidation, err)
+		}
+		if email != u.Email {
+			u.Email = email
+			u.Activated = false
+		}
+	}
+	if patch.Bio != nil {
+		u.Bio = *patch.Bio
+	}
+
+	if err := s.store.UpdateUser(ctx, u); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// Activate marks the account's email as confirmed and returns the updated
+// user.
+func (s *Service) Activate(ctx context.Context, id UserID) (*User, error) {
+	if err := s.store.ActivateUser(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.store.GetUserByID(ctx, id)
+}
+
+// FindByEmail normalizes email the way registration stored it and looks the
+// user up. A malformed address is ErrNotFound too: callers in the reset flow
+// must answer the same either way.
+func (s *Service) FindByEmail(ctx context.Context, email string) (*User, error) {
+	e, err := NewEmail(email)
+	if err != nil {
+		return nil, ErrNotFound
+	}
+	return s.store.GetUserByEmail(ctx, e)
+}
+
+// ChangePassword sets a new password after checking the current one.
+// Revoking the user's other sessions is the caller's job (see
+// auth.Service.ChangePassword): user can't reach the token store.
+func (s *Service) ChangePassword(ctx context.Context, id UserID, current, next string) error {
+	u, err := s.store.GetUserByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	ok, err := s.matches(u, current)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrWrongPassword
+	}
+	return s.setPassword(ctx, u, next)
+}
+
+// SetPassword replaces the password without asking for the old one. Only
+// for callers that have proven identity another way, e.g. a redeemed
+// password-reset token.
+func (s *Service) SetPassword(ctx context.Context, id UserID, plaintext string) error {
+	u, err := s.store.GetUserByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	return s.setPassword(ctx, u, plaintext)
+}
+
+// ValidatePassword reports whether plaintext would pass the password policy
+// for the user, without setting it.
+func (s *Service) ValidatePassword(ctx context.Context, id UserID, plaintext string) error {
+	u, err := s.store.GetUserByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.policy.Check(plaintext, u.Username, u.Email); err != nil {
+		return fmt.Errorf("%w: %v", ErrValidation, err)
+	}
+	return nil
+}
+
+// setPassword checks plaintext against the policy for u and stores its hash.
+func (s *Service) setPassword(ctx context.Context, u *User, plaintext string) error {
+	if err := s.policy.Check(plaintext, u.Username, u.Email); err != nil {
+		return fmt.Errorf("%w: %v", ErrValidation, err)
+	}
+	hash, err := s.hasher.Hash(plaintext)
+	if err != nil {
+		return fmt.Errorf("hash password: %w", err)
+	}
+	return s.store.UpdatePassword(ctx, u.ID, hash)
+}
+
+// RequestDeletion schedules the account for deletion after checking the
+// password. Until Purge runs past the grace period the row stays, login is
+// refused, and CancelDeletion can bring the account back. Revoking the
+// user's tokens is the caller's job (see auth.Service.DeleteAccount).
+func (s *Service) RequestDeletion(ctx context.Context, id UserID, plaintext string) (*User, error) {
+	u, err := s.store.GetUserByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	ok, err := s.matches(u, plaintext)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrWrongPassword
+	}
+
+	now := time.Now().UTC()
+	if err := s.store.SetDeletionRequestedAt(ctx, id, &now); err != nil {
+		return nil, err
+	}
+	u.DeletionRequestedAt = &now
+	return u, nil
+}
+
+// CancelDeletion takes the account out of its grace period.
+func (s *Service) CancelDeletion(ctx context.Context, id UserID) error {
+	return s.store.SetDeletionRequestedAt(ctx, id, nil)
+}
+
+// PurgeAfter is when a pending deletion becomes final.
+func (s *Service) PurgeAfter(u *User) time.Time {
+	if u.DeletionRequestedAt == nil {
+		return time.Time{}
+	}
+	return u.DeletionRequestedAt.Add(s.deletionGrace)
+}
+
+// Purge hard-deletes every account whose grace period is over. Meant to run
+// periodically; see app.Application.runPurge.
+func (s *Service) Purge(ctx context.Context) (int64, error) {
+	return s.store.PurgeUsers(ctx, time.Now().Add(-s.deletionGrace))
+}
+
+// Follow records that followerID follows followeeID, which is what lets the
+// follower read followee's followers-only workouts.
+func (s *Service) Follow(ctx context.Context, followerID, followeeID UserID) error {
+	if followerID == followeeID {
+		return fmt.Errorf("%w: you cannot follow yourself", ErrValidation)
+	}
+	return s.store.Follow(ctx, followerID, followeeID)
+}
+
+func (s *Service) Unfollow(ctx context.Context, followerID, followeeID UserID) error {
+	return s.store.Unfollow(ctx, followerID, followeeID)
+}
+
+// Page size bounds for Search, as for workout listings.
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+)
+
+// SearchQuery is the input to Service.Search. Query matches anywhere in the
+// username or email, ignoring case; Role and Disabled narrow the results
+// when set. After is the cursor: the id of the last user of the previous
+// page.
+type SearchQuery struct {
+	Query    string
+	Role     Role
+	Disabled *bool
+	Limit    int
+	After    UserID
+}
+
+func (q *SearchQuery) Validate() error {
+	if len(q.Query) > maxFieldLen {
+		return fmt.Errorf("query must be at most %d bytes long", maxFieldLen)
+	}
+	if q.Role != "" {
+		if _, err := ParseRole(string(q.Role)); err != nil {
+			return err
+		}
+	}
+	if q.Limit == 0 {
+		q.Limit = defaultSearchLimit
+	}
+	if q.Limit < 1 || q.Limit > maxSearchLimit {
+		return fmt.Errorf("limit must be between 1 and %d", maxSearchLimit)
+	}
+	return nil
+}
+
+// UserPage is one page of Search results. NextCursor is empty on the last
+// page.
+type UserPage struct {
+	Users      []*User `json:"users"`
+	NextCursor string  `json:"next_cursor,omitempty"`
+}
+
+// Search finds accounts for the admin API, deleted and disabled ones
+// included.
+func (s *Service) Search(ctx context.Context, q SearchQuery) (*UserPage, error) {
+	if err := q.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrValidation, err)
+	}
+	users, err := s.store.SearchUsers(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	page := &UserPage{Users: users}
+	if len(users) > q.Limit {
+		page.Users = users[:q.Limit]
+		page.NextCursor = strconv.FormatInt(int64(page.Users[q.Limit-1].ID), 10)
+	}
+	if page.Users == nil {
+		page.Users = []*User{}
+	}
+	return page, nil
+}
+
+// SetRole gives the account role and returns it updated.
+func (s *Service) SetRole(ctx context.Context, id UserID, role Role) (*User, error) {
+	if _, err := ParseRole(string(role)); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrValidation, err)
+	}
+	if err := s.store.SetRole(ctx, id, role); err != nil {
+		return nil, err
+	}
+	return s.store.GetUserByID(ctx, id)
+}
+
+// Disable stops the account from signing in until Enable; its data is
+// kept. Disabling a disabled account keeps the original time. Revoking
+// the account's tokens is the caller's job, as for RequestDeletion.
+func (s *Service) Disable(ctx context.Context, id UserID) (*User, error) {
+	u, err := s.store.GetUserByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if u.Disabled() {
+		return u, nil
+	}
+	now := time.Now()
+	if err := s.store.SetDisabledAt(ctx, id, &now); err != nil {
+		return nil, err
+	}
+	u.DisabledAt = &now
+	return u, nil
+}
+
+// Enable lets a disabled account sign in again.
+func (s *Service) Enable(ctx context.Context, id UserID) (*User, error) {
+	if err := s.store.SetDisabledAt(ctx, id, nil); err != nil {
+		return nil, err
+	}
+	return s.store.GetUserByID(ctx, id)
+}
@@ -0,0 +1,181 @@
+package user
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/tsatsarisg/go-fit/internal/platform/postgres/pgtest"
+)
+
+func setupTestDB(t *testing.T) *sql.DB {
+	return pgtest.Open(t, "user", "users")
+}
+
+func TestUpdateProfile(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	svc := NewService(NewPostgresStore(db), NewBcryptHasher(bcrypt.MinCost), PasswordPolicy{}, time.Hour)
+	ctx := context.Background()
+
+	register := func(name string) *User {
+		u, err := svc.Register(ctx, RegisterCommand{Username: name, Email: name + "@example.com", Password: "correct horse battery"})
+		assert.NoError(t, err)
+		return u
+	}
+	alice, bob := register("alice"), register("bob")
+	assert.False(t, alice.Activated)
+
+	activated, err := svc.Activate(ctx, alice.ID)
+	assert.NoError(t, err)
+	assert.True(t, activated.Activated)
+
+	str := func(s string) *string { return &s }
+
+	// Same address in another case is no change: activation is kept.
+	same, err := svc.UpdateProfile(ctx, alice.ID, UserPatch{Email: str("ALICE@example.com")})
+	assert.NoError(t, err)
+	assert.True(t, same.Activated)
+
+	updated, err := svc.UpdateProfile(ctx, alice.ID, UserPatch{Email: str("Alice.New@Example.com"), Bio: str("lifter")})
+	assert.NoError(t, err)
+	assert.Equal(t, Email("alice.new@example.com"), updated.Email)
+	assert.False(t, updated.Activated, "a new email must be confirmed again")
+	assert.Equal(t, "alice", updated.Username)
+
+	got, err := svc.Get(ctx, alice.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "lifter", got.Bio)
+
+	_, err = svc.UpdateProfile(ctx, bob.ID, UserPatch{Username: str("alice")})
+	assert.ErrorIs(t, err, ErrUsernameTaken)
+	_, err = svc.UpdateProfile(ctx, bob.ID, UserPatch{Email: str("ALICE.NEW@example.com")})
+	assert.ErrorIs(t, err, ErrEmailTaken)
+	_, err = svc.UpdateProfile(ctx, bob.ID, UserPatch{Email: str("not an email")})
+	assert.ErrorIs(t, err, ErrValidation)
+
+	_, err = svc.Get(ctx, 9999)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestChangePassword(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	svc := NewService(NewPostgresStore(db), NewBcryptHasher(bcrypt.MinCost), PasswordPolicy{}, time.Hour)
+	ctx := context.Background()
+
+	u, err := svc.Register(ctx, RegisterCommand{Username: "alice", Email: "alice@example.com", Password: "correct horse battery"})
+	assert.NoError(t, err)
+
+	err = svc.ChangePassword(ctx, u.ID, "wrong password!!", "tr0ub4dor & three")
+	assert.ErrorIs(t, err, ErrWrongPassword)
+	err = svc.ChangePassword(ctx, u.ID, "correct horse battery", "short")
+	assert.ErrorIs(t, err, ErrValidation)
+	assert.NoError(t, svc.ChangePassword(ctx, u.ID, "correct horse battery", "tr0ub4dor & three"))
+
+	got, err := svc.FindByUsername(ctx, "alice")
+	assert.NoError(t, err)
+	ok, err := svc.VerifyPassword(ctx, got, "tr0ub4dor & three")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+}
+
+// TestAccountDeletion covers the account side; what others can still read
+// is in workout.TestAccountDeletionHidesWorkouts.
+func TestAccountDeletion(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	store := NewPostgresStore(db)
+	svc := NewService(store, NewBcryptHasher(bcrypt.MinCost), PasswordPolicy{}, time.Hour)
+	ctx := context.Background()
+
+	u, err := svc.Register(ctx, RegisterCommand{Username: "alice", Email: "alice@example.com", Password: "correct horse battery"})
+	assert.NoError(t, err)
+
+	_, err = svc.RequestDeletion(ctx, u.ID, "wrong password here")
+	assert.ErrorIs(t, err, ErrWrongPassword)
+
+	pending, err := svc.RequestDeletion(ctx, u.ID, "correct horse battery")
+	assert.NoError(t, err)
+	assert.True(t, pending.PendingDeletion())
+	assert.Equal(t, pending.DeletionRequestedAt.Add(time.Hour), svc.PurgeAfter(pending))
+
+	// Inside the grace period nothing is purged, and the account can come back.
+	n, err := svc.Purge(ctx)
+	assert.NoError(t, err)
+	assert.Zero(t, n)
+	assert.NoError(t, svc.CancelDeletion(ctx, u.ID))
+	restored, err := svc.Get(ctx, u.ID)
+	assert.NoError(t, err)
+	assert.False(t, restored.PendingDeletion())
+
+	// With no grace left the purge erases the row.
+	_, err = svc.RequestDeletion(ctx, u.ID, "correct horse battery")
+	assert.NoError(t, err)
+	n, err = NewService(store, NewBcryptHasher(bcrypt.MinCost), PasswordPolicy{}, 0).Purge(ctx)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, n)
+	_, err = svc.Get(ctx, u.ID)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+// TestUserReferencesCascade guards the purge: it deletes users rows only,
+// so every foreign key onto users must be ON DELETE CASCADE or a new table
+// would either block the purge or keep the data of an erased account.
+func TestUserReferencesCascade(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	rows, err := db.QueryContext(context.Background(), `
+		SELECT conrelid::regclass::text, conname
+		FROM pg_constraint
+		WHERE contype = 'f' AND confrelid = 'users'::regclass AND confdeltype <> 'c'`)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var table, name string
+		assert.NoError(t, rows.Scan(&table, &name))
+		t.Errorf("%s.%s references users without ON DELETE CASCADE", table, name)
+	}
+	assert.NoError(t, rows.Err())
+}
+
+func TestRehashOnLogin(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	ctx := context.Background()
+	store := NewPostgresStore(db)
+	u, err := NewService(store, NewBcryptHasher(bcrypt.MinCost), PasswordPolicy{}, time.Hour).Register(ctx, RegisterCommand{Username: "alice", Email: "alice@example.com", Password: "correct horse battery"})
+	assert.NoError(t, err)
+
+	// The app switches to argon2id: the bcrypt hash still verifies, and is
+	// replaced on the way.
+	svc := NewService(store, NewArgon2idHasher(8*1024, 1, 1), PasswordPolicy{}, time.Hour)
+	ok, err := svc.VerifyPassword(ctx, u, "wrong password here")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	got, err := svc.FindByUsername(ctx, "alice")
+	assert.NoError(t, err)
+	assert.False(t, got.PasswordHash.isArgon2id(), "a failed login changes nothing")
+
+	ok, err = svc.VerifyPassword(ctx, got, "correct horse battery")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	got, err = svc.FindByUsername(ctx, "alice")
+	assert.NoError(t, err)
+	assert.True(t, got.PasswordHash.isArgon2id())
+
+	ok, err = svc.VerifyPassword(ctx, got, "correct horse battery")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+}
@@ -2,8 +2,14 @@ package workout
 
 import (
 	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
 
 	"github.com/tsatsarisg/go-fit/internal/auth"
 	"github.com/tsatsarisg/go-fit/internal/httpx"
@@ -38,7 +44,13 @@ func (wh *Handler) HandleGetWorkoutByID(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	workout, err := wh.service.Get(r.Context(), WorkoutID(workoutID))
+	principal := auth.GetPrincipal(r)
+	if principal.IsAnonymous() {
+		httpx.WriteJson(w, http.StatusUnauthorized, httpx.Envelope{"error": "Unauthenticated"})
+		return
+	}
+
+	workout, err := wh.service.Get(r.Context(), WorkoutID(workoutID), principal.ID)
 	if err != nil {
 		httpx.WriteStoreError(r.Context(), w, wh.logger, err, errorMapping, "Failed to retrieve workout")
 		return
@@ -47,11 +59,110 @@ func (wh *Handler) HandleGetWorkoutByID(w http.ResponseWriter, r *http.Request)
 	httpx.WriteJson(w, http.StatusOK, httpx.Envelope{"workout": workout})
 }
 
+func (wh *Handler) HandleListWorkouts(w http.ResponseWriter, r *http.Request) {
+	principal := auth.GetPrincipal(r)
+	if principal.IsAnonymous() {
+		httpx.WriteJson(w, http.StatusUnauthorized, httpx.Envelope{"error": "Unauthenticated"})
+		return
+	}
+
+	q, err := parseListQuery(r.URL.Query())
+	if err != nil {
+		writeValidationError(w, err)
+		return
+	}
+	q.UserID = principal.ID
+
+	page, err := wh.service.List(r.Context(), q)
+	if err != nil {
+		if errors.Is(err, ErrValidation) {
+			writeValidationError(w, err)
+			return
+		}
+		httpx.WriteStoreError(r.Context(), w, wh.logger, err, errorMapping, "Failed to list workouts")
+		return
+	}
+
+	httpx.WriteJson(w, http.StatusOK, httpx.Envelope{"workouts": page.Workouts, "next_cursor": page.NextCursor})
+}
+
+// parseListQuery maps ?limit, cursor, sort, from, to, title, min_duration,
+// min_calories and exercise onto a ListWorkoutsQuery. Range checks live in
+// ListWorkoutsQuery.Validate; this only rejects values that don't parse.
+func parseListQuery(v url.Values) (ListWorkoutsQuery, error) {
+	q := ListWorkoutsQuery{
+		Sort: SortOrder(v.Get("sort")),
+		Filter: ListFilter{
+			Title:    v.Get("title"),
+			Exercise: v.Get("exercise"),
+		},
+	}
+
+	var err error
+	if s := v.Get("limit"); s != "" {
+		if q.Limit, err = strconv.Atoi(s); err != nil {
+			return q, errors.New("limit must be an integer")
+		}
+	}
+	if s := v.Get("cursor"); s != "" {
+		if q.After, err = DecodeCursor(s); err != nil {
+			return q, err
+		}
+	}
+	if q.Filter.From, err = parseTimeParam(v, "from", false); err != nil {
+		return q, err
+	}
+	if q.Filter.To, err = parseTimeParam(v, "to", true); err != nil {
+		return q, err
+	}
+	if q.Filter.MinDuration, err = parseIntParam(v, "min_duration"); err != nil {
+		return q, err
+	}
+	if q.Filter.MinCalories, err = parseIntParam(v, "min_calories"); err != nil {
+		return q, err
+	}
+	return q, nil
+}
+
+// parseTimeParam accepts RFC 3339 timestamps or bare YYYY-MM-DD dates (UTC).
+// A bare date used as an exclusive upper bound is bumped to the next day so
+// ?to=2026-05-31 includes workouts logged on the 31st.
+func parseTimeParam(v url.Values, key string, upper bool) (*time.Time, error) {
+	s := v.Get(key)
+	if s == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return &t, nil
+	}
+	t, err := time.Parse(time.DateOnly, s)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be an RFC 3339 timestamp or YYYY-MM-DD date", key)
+	}
+	if upper {
+		t = t.AddDate(0, 0, 1)
+	}
+	return &t, nil
+}
+
+func parseIntParam(v url.Values, key string) (*int, error) {
+	s := v.Get(key)
+	if s == "" {
+		return nil, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be an integer", key)
+	}
+	return &n, nil
+}
+
 type createWorkoutRequest struct {
 	Title           string         `json:"title"`
 	Description     string         `json:"description"`
 	DurationMinutes int            `json:"duration_minutes"`
 	CaloriesBurned  int            `json:"calories_burned"`
+	Visibility      Visibility     `json:"visibility"`
 	Entries         []WorkoutEntry `json:"entries"`
 }
 
@@ -75,6 +186,7 @@ func (wh *Handler) HandleCreateWorkout(w http.ResponseWriter, r *http.Request) {
 		Description:     req.Description,
 		DurationMinutes: req.DurationMinutes,
 		CaloriesBurned:  req.CaloriesBurned,
+		Visibility:      req.Visibility,
 		Entries:         req.Entries,
 	}
 	created, err := wh.service.Create(r.Context(), cmd)
@@ -108,6 +220,7 @@ func (wh *Handler) HandleUpdateWorkout(w http.ResponseWriter, r *http.Request) {
 		Description     *string         `json:"description"`
 		DurationMinutes *int            `json:"duration_minutes"`
 		CaloriesBurned  *int            `json:"calories_burned"`
+		Visibility      *Visibility     `json:"visibility"`
 		Entries         *[]WorkoutEntry `json:"entries"`
 	}
 
@@ -125,6 +238,7 @@ func (wh *Handler) HandleUpdateWorkout(w http.ResponseWriter, r *http.Request) {
 			Description:     body.Description,
 			DurationMinutes: body.DurationMinutes,
 			CaloriesBurned:  body.CaloriesBurned,
+			Visibility:      body.Visibility,
 			Entries:         body.Entries,
 		},
 	}
@@ -163,3 +277,340 @@ func (wh *Handler) HandleDeleteWorkout(w http.ResponseWriter, r *http.Request) {
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// shareErrorMapping reuses the workout sentinels: share endpoints are
+// addressed through /workouts/{id}, so a missing or foreign workout reads
+// the same as it does on the workout routes.
+var shareErrorMapping = httpx.StoreErrorMapping{
+	ResourceName: "Share",
+	NotFoundErr:  ErrNotFound,
+	ForbiddenErr: ErrForbidden,
+}
+
+type createShareRequest struct {
+	ExpiresInHours *int `json:"expires_in_hours"`
+}
+
+func (wh *Handler) HandleCreateShare(w http.ResponseWriter, r *http.Request) {
+	workoutID, err := httpx.ReadIdParam(r, "id")
+	if err != nil {
+		wh.logger.WarnContext(r.Context(), "read id param", slog.Any("err", err))
+		httpx.WriteJson(w, http.StatusBadRequest, httpx.Envelope{"error": err.Error()})
+		return
+	}
+
+	principal := auth.GetPrincipal(r)
+	if principal.IsAnonymous() {
+		httpx.WriteJson(w, http.StatusUnauthorized, httpx.Envelope{"error": "Unauthenticated"})
+		return
+	}
+
+	var req createShareRequest
+	if derr := httpx.DecodeJSONBody(w, r, &req); derr != nil {
+		wh.logger.WarnContext(r.Context(), "decode create share", slog.Any("err", derr))
+		httpx.WriteDecodeError(w, derr)
+		return
+	}
+
+	cmd := CreateShareCommand{WorkoutID: WorkoutID(workoutID), UserID: principal.ID, ExpiresInHours: req.ExpiresInHours}
+	share, err := wh.service.CreateShare(r.Context(), cmd)
+	if err != nil {
+		if errors.Is(err, ErrValidation) {
+			writeValidationError(w, err)
+			return
+		}
+		httpx.WriteStoreError(r.Context(), w, wh.logger, err, errorMapping, "Failed to create share link")
+		return
+	}
+
+	httpx.WriteJson(w, http.StatusCreated, httpx.Envelope{"share": share})
+}
+
+func (wh *Handler) HandleListShares(w http.ResponseWriter, r *http.Request) {
+	workoutID, err := httpx.ReadIdParam(r, "id")
+	if err != nil {
+		wh.logger.WarnContext(r.Context(), "read id param", slog.Any("err", err))
+		httpx.WriteJson(w, http.StatusBadRequest, httpx.Envelope{"error": err.Error()})
+		return
+	}
+
+	principal := auth.GetPrincipal(r)
+	if principal.IsAnonymous() {
+		httpx.WriteJson(w, http.StatusUnauthorized, httpx.Envelope{"error": "Unauthenticated"})
+		return
+	}
+
+	shares, err := wh.service.ListShares(r.Context(), WorkoutID(workoutID), principal.ID)
+	if err != nil {
+		httpx.WriteStoreError(r.Context(), w, wh.logger, err, errorMapping, "Failed to list share links")
+		return
+	}
+
+	httpx.WriteJson(w, http.StatusOK, httpx.Envelope{"shares": shares})
+}
+
+func (wh *Handler) HandleRevokeShare(w http.ResponseWriter, r *http.Request) {
+	workoutID, err := httpx.ReadIdParam(r, "id")
+	if err != nil {
+		wh.logger.WarnContext(r.Context(), "read id param", slog.Any("err", err))
+		httpx.WriteJson(w, http.StatusBadRequest, httpx.Envelope{"error": err.Error()})
+		return
+	}
+	shareID, err := httpx.ReadIdParam(r, "shareID")
+	if err != nil {
+		wh.logger.WarnContext(r.Context(), "read share id param", slog.Any("err", err))
+		httpx.WriteJson(w, http.StatusBadRequest, httpx.Envelope{"error": err.Error()})
+		return
+	}
+
+	principal := auth.GetPrincipal(r)
+	if principal.IsAnonymous() {
+		httpx.WriteJson(w, http.StatusUnauthorized, httpx.Envelope{"error": "Unauthenticated"})
+		return
+	}
+
+	if err := wh.service.RevokeShare(r.Context(), ShareID(shareID), WorkoutID(workoutID), principal.ID); err != nil {
+		httpx.WriteStoreError(r.Context(), w, wh.logger, err, shareErrorMapping, "Failed to revoke share link")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandleGetSharedWorkout is the anonymous read path for share links. Every
+// failure — unknown, revoked, expired — is the same 404.
+func (wh *Handler) HandleGetSharedWorkout(w http.ResponseWriter, r *http.Request) {
+	workout, err := wh.service.GetShared(r.Context(), chi.URLParam(r, "token"))
+	if err != nil {
+		httpx.WriteStoreError(r.Context(), w, wh.logger, err, errorMapping, "Failed to retrieve workout")
+		return
+	}
+
+	httpx.WriteJson(w, http.StatusOK, httpx.Envelope{"workout": workout})
+}
+
+var templateErrorMapping = httpx.StoreErrorMapping{
+	ResourceName: "Template",
+	NotFoundErr:  ErrTemplateNotFound,
+	ForbiddenErr: ErrForbidden,
+}
+
+type createTemplateRequest struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	Entries     []WorkoutEntry `json:"entries"`
+}
+
+func (wh *Handler) HandleCreateTemplate(w http.ResponseWriter, r *http.Request) {
+	principal := auth.GetPrincipal(r)
+	if principal.IsAnonymous() {
+		httpx.WriteJson(w, http.StatusUnauthorized, httpx.Envelope{"error": "Unauthenticated"})
+		return
+	}
+
+	var req createTemplateRequest
+	if derr := httpx.DecodeJSONBody(w, r, &req); derr != nil {
+		wh.logger.WarnContext(r.Context(), "decode create template", slog.Any("err", derr))
+		httpx.WriteDecodeError(w, derr)
+		return
+	}
+
+	created, err := wh.service.CreateTemplate(r.Context(), CreateTemplateCommand{
+		UserID:      principal.ID,
+		Name:        req.Name,
+		Description: req.Description,
+		Entries:     req.Entries,
+	})
+	if err != nil {
+		if errors.Is(err, ErrValidation) {
+			writeValidationError(w, err)
+			return
+		}
+		httpx.WriteStoreError(r.Context(), w, wh.logger, err, templateErrorMapping, "Failed to create template")
+		return
+	}
+	httpx.WriteJson(w, http.StatusCreated, httpx.Envelope{"template": created})
+}
+
+func (wh *Handler) HandleListTemplates(w http.ResponseWriter, r *http.Request) {
+	principal := auth.GetPrincipal(r)
+	if principal.IsAnonymous() {
+		httpx.WriteJson(w, http.StatusUnauthorized, httpx.Envelope{"error": "Unauthenticated"})
+		return
+	}
+
+	templates, err := wh.service.ListTemplates(r.Context(), principal.ID)
+	if err != nil {
+		httpx.WriteStoreError(r.Context(), w, wh.logger, err, templateErrorMapping, "Failed to list templates")
+		return
+	}
+	httpx.WriteJson(w, http.StatusOK, httpx.Envelope{"templates": templates})
+}
+
+func (wh *Handler) HandleGetTemplate(w http.ResponseWriter, r *http.Request) {
+	templateID, err := httpx.ReadIdParam(r, "id")
+	if err != nil {
+		wh.logger.WarnContext(r.Context(), "read id param", slog.Any("err", err))
+		httpx.WriteJson(w, http.StatusBadRequest, httpx.Envelope{"error": err.Error()})
+		return
+	}
+
+	principal := auth.GetPrincipal(r)
+	if principal.IsAnonymous() {
+		httpx.WriteJson(w, http.StatusUnauthorized, httpx.Envelope{"error": "Unauthenticated"})
+		return
+	}
+
+	template, err := wh.service.GetTemplate(r.Context(), TemplateID(templateID), principal.ID)
+	if err != nil {
+		httpx.WriteStoreError(r.Context(), w, wh.logger, err, templateErrorMapping, "Failed to retrieve template")
+		return
+	}
+	httpx.WriteJson(w, http.StatusOK, httpx.Envelope{"template": template})
+}
+
+func (wh *Handler) HandleUpdateTemplate(w http.ResponseWriter, r *http.Request) {
+	templateID, err := httpx.ReadIdParam(r, "id")
+	if err != nil {
+		wh.logger.WarnContext(r.Context(), "read id param", slog.Any("err", err))
+		httpx.WriteJson(w, http.StatusBadRequest, httpx.Envelope{"error": err.Error()})
+		return
+	}
+
+	principal := auth.GetPrincipal(r)
+	if principal.IsAnonymous() {
+		httpx.WriteJson(w, http.StatusUnauthorized, httpx.Envelope{"error": "Unauthenticated"})
+		return
+	}
+
+	var body struct {
+		Name        *string         `json:"name"`
+		Description *string         `json:"description"`
+		Entries     *[]WorkoutEntry `json:"entries"`
+	}
+	if derr := httpx.DecodeJSONBody(w, r, &body); derr != nil {
+		wh.logger.WarnContext(r.Context(), "decode update template", slog.Any("err", derr))
+		httpx.WriteDecodeError(w, derr)
+		return
+	}
+
+	updated, err := wh.service.UpdateTemplate(r.Context(), UpdateTemplateCommand{
+		TemplateID: TemplateID(templateID),
+		UserID:     principal.ID,
+		Patch: TemplatePatch{
+			Name:        body.Name,
+			Description: body.Description,
+			Entries:     body.Entries,
+		},
+	})
+	if err != nil {
+		if errors.Is(err, ErrValidation) {
+			writeValidationError(w, err)
+			return
+		}
+		httpx.WriteStoreError(r.Context(), w, wh.logger, err, templateErrorMapping, "Failed to update template")
+		return
+	}
+	httpx.WriteJson(w, http.StatusOK, httpx.Envelope{"template": updated})
+}
+
+func (wh *Handler) HandleDeleteTemplate(w http.ResponseWriter, r *http.Request) {
+	templateID, err := httpx.ReadIdParam(r, "id")
+	if err != nil {
+		wh.logger.WarnContext(r.Context(), "read id param", slog.Any("err", err))
+		httpx.WriteJson(w, http.StatusBadRequest, httpx.Envelope{"error": err.Error()})
+		return
+	}
+
+	principal := auth.GetPrincipal(r)
+	if principal.IsAnonymous() {
+		httpx.WriteJson(w, http.StatusUnauthorized, httpx.Envelope{"error": "Unauthenticated"})
+		return
+	}
+
+	if err := wh.service.DeleteTemplate(r.Context(), TemplateID(templateID), principal.ID); err != nil {
+		if errors.Is(err, ErrTemplateInUse) {
+			httpx.WriteJson(w, http.StatusConflict, httpx.Envelope{"error": err.Error()})
+			return
+		}
+		httpx.WriteStoreError(r.Context(), w, wh.logger, err, templateErrorMapping, "Failed to delete template")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandleStartTemplate creates a workout pre-filled from the template and
+// returns it exactly as POST /workouts would.
+func (wh *Handler) HandleStartTemplate(w http.ResponseWriter, r *http.Request) {
+	templateID, err := httpx.ReadIdParam(r, "id")
+	if err != nil {
+		wh.logger.WarnContext(r.Context(), "read id param", slog.Any("err", err))
+		httpx.WriteJson(w, http.StatusBadRequest, httpx.Envelope{"error": err.Error()})
+		return
+	}
+
+	principal := auth.GetPrincipal(r)
+	if principal.IsAnonymous() {
+		httpx.WriteJson(w, http.StatusUnauthorized, httpx.Envelope{"error": "Unauthenticated"})
+		return
+	}
+
+	created, err := wh.service.StartTemplate(r.Context(), StartTemplateCommand{
+		TemplateID: TemplateID(templateID),
+		UserID:     principal.ID,
+	})
+	if err != nil {
+		if errors.Is(err, ErrValidation) {
+			writeValidationError(w, err)
+			return
+		}
+		httpx.WriteStoreError(r.Context(), w, wh.logger, err, templateErrorMapping, "Failed to start workout from template")
+		return
+	}
+	httpx.WriteJson(w, http.StatusCreated, httpx.Envelope{"workout": created})
+}
+
+// recordErrorMapping labels ErrNotFound from the records endpoints, which
+// means "no records for that exercise" rather than a missing workout.
+var recordErrorMapping = httpx.StoreErrorMapping{
+	ResourceName: "Records",
+	NotFoundErr:  ErrNotFound,
+}
+
+func (wh *Handler) HandleListRecords(w http.ResponseWriter, r *http.Request) {
+	principal := auth.GetPrincipal(r)
+	if principal.IsAnonymous() {
+		httpx.WriteJson(w, http.StatusUnauthorized, httpx.Envelope{"error": "Unauthenticated"})
+		return
+	}
+
+	records, err := wh.service.Records(r.Context(), principal.ID)
+	if err != nil {
+		httpx.WriteStoreError(r.Context(), w, wh.logger, err, recordErrorMapping, "Failed to list records")
+		return
+	}
+	httpx.WriteJson(w, http.StatusOK, httpx.Envelope{"records": records})
+}
+
+// HandleRecordHistory serves GET /me/records/{exercise}, where {exercise} is
+// a catalog exercise id or a (URL-escaped) exercise name.
+func (wh *Handler) HandleRecordHistory(w http.ResponseWriter, r *http.Request) {
+	principal := auth.GetPrincipal(r)
+	if principal.IsAnonymous() {
+		httpx.WriteJson(w, http.StatusUnauthorized, httpx.Envelope{"error": "Unauthenticated"})
+		return
+	}
+
+	ref, err := url.PathUnescape(chi.URLParam(r, "exercise"))
+	if err != nil {
+		httpx.WriteJson(w, http.StatusBadRequest, httpx.Envelope{"error": "invalid exercise"})
+		return
+	}
+
+	records, err := wh.service.RecordHistory(r.Context(), principal.ID, ref)
+	if err != nil {
+		httpx.WriteStoreError(r.Context(), w, wh.logger, err, recordErrorMapping, "Failed to retrieve record history")
+		return
+	}
+	httpx.WriteJson(w, http.StatusOK, httpx.Envelope{"records": records})
+}
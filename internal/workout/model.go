@@ -1,9 +1,14 @@
 package workout
 
 import (
+	"encoding/base64"
 	"errors"
 	"fmt"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/tsatsarisg/go-fit/internal/exercise"
 	"github.com/tsatsarisg/go-fit/internal/user"
 )
 
@@ -12,6 +17,25 @@ import (
 // kills the id-mixup bug class D2 calls out. int64 matches BIGSERIAL.
 type WorkoutID int64
 
+// Visibility controls who besides the owner may read a workout. Enforced in
+// the read query itself (see PostgresStore.GetWorkoutByID), never in Go after
+// the row has already been fetched.
+type Visibility string
+
+const (
+	VisibilityPrivate   Visibility = "private"
+	VisibilityFollowers Visibility = "followers"
+	VisibilityPublic    Visibility = "public"
+)
+
+func (v Visibility) Valid() bool {
+	switch v {
+	case VisibilityPrivate, VisibilityFollowers, VisibilityPublic:
+		return true
+	}
+	return false
+}
+
 type Workout struct {
 	ID              WorkoutID      `json:"id"`
 	UserID          user.UserID    `json:"user_id"`
@@ -19,18 +43,67 @@ type Workout struct {
 	Description     string         `json:"description"`
 	DurationMinutes int            `json:"duration_minutes"`
 	CaloriesBurned  int            `json:"calories_burned"`
+	Visibility      Visibility     `json:"visibility"`
+	TemplateID      *TemplateID    `json:"template_id"`
 	Entries         []WorkoutEntry `json:"entries"`
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+	// NewRecords is only populated on the responses to create and update:
+	// the personal records this save set.
+	NewRecords []PersonalRecord `json:"new_records,omitempty"`
 }
 
+// WorkoutEntry is one exercise within a workout. ExerciseID links it to the
+// exercise catalog; ExerciseName stays as free text so unlinked entries keep
+// working (see Service.linkExercises).
+//
+// LoggedSets is the authoritative per-set record; the flat
+// Sets/Reps/DurationSeconds/Weight fields are kept for backward
+// compatibility. When LoggedSets is supplied the flat fields are derived from
+// it (see reconcile); when only the flat fields are supplied, they are
+// expanded into that many identical working sets.
 type WorkoutEntry struct {
+	ID              int                  `json:"id"`
+	ExerciseID      *exercise.ExerciseID `json:"exercise_id"`
+	ExerciseName    string               `json:"exercise_name"`
+	Sets            int                  `json:"sets"`
+	Reps            *int                 `json:"reps"`
+	DurationSeconds *int                 `json:"duration_seconds"`
+	Weight          *float64             `json:"weight"`
+	Notes           string               `json:"notes"`
+	OrderIndex      int                  `json:"order_index"`
+	LoggedSets      []WorkoutSet         `json:"logged_sets"`
+}
+
+// SetType classifies a set. Warmups are logged but don't count towards the
+// entry's derived set count or top set.
+type SetType string
+
+const (
+	SetTypeWarmup  SetType = "warmup"
+	SetTypeWorking SetType = "working"
+	SetTypeDrop    SetType = "drop"
+	SetTypeFailure SetType = "failure"
+)
+
+func (t SetType) Valid() bool {
+	switch t {
+	case SetTypeWarmup, SetTypeWorking, SetTypeDrop, SetTypeFailure:
+		return true
+	}
+	return false
+}
+
+// WorkoutSet is a single performed set. Like an entry, it is measured in
+// reps or in seconds, never both. An empty Type means working.
+type WorkoutSet struct {
 	ID              int      `json:"id"`
-	ExerciseName    string   `json:"exercise_name"`
-	Sets            int      `json:"sets"`
+	Type            SetType  `json:"type"`
 	Reps            *int     `json:"reps"`
 	DurationSeconds *int     `json:"duration_seconds"`
 	Weight          *float64 `json:"weight"`
-	Notes           string   `json:"notes"`
-	OrderIndex      int      `json:"order_index"`
+	RPE             *float64 `json:"rpe"`
+	Completed       bool     `json:"completed"`
 }
 
 // WorkoutPatch is the partial-update input. A nil pointer means "leave the
@@ -41,9 +114,12 @@ type WorkoutPatch struct {
 	Description     *string
 	DurationMinutes *int
 	CaloriesBurned  *int
+	Visibility      *Visibility
 	Entries         *[]WorkoutEntry
 }
 
+var errInvalidVisibility = fmt.Errorf("visibility must be one of %q, %q or %q", VisibilityPrivate, VisibilityFollowers, VisibilityPublic)
+
 // Validate enforces the workout aggregate's invariants so the service layer
 // catches bad inputs before they reach the DB. Mirrors the workout_entries
 // CHECK constraint (reps XOR duration) plus non-negative numerics.
@@ -57,6 +133,9 @@ func (w *Workout) Validate() error {
 	if w.CaloriesBurned < 0 {
 		return errors.New("calories_burned must be non-negative")
 	}
+	if !w.Visibility.Valid() {
+		return errInvalidVisibility
+	}
 	for i := range w.Entries {
 		if err := w.Entries[i].Validate(i); err != nil {
 			return err
@@ -65,32 +144,184 @@ func (w *Workout) Validate() error {
 	return nil
 }
 
+// EntryError is a validation failure on one entry (Set < 0) or on one of
+// its logged sets. Its message is the familiar "entries[i]: ..." form;
+// the indices let callers such as the importer point back at their input.
+type EntryError struct {
+	Entry int
+	Set   int
+	Msg   string
+}
+
+func (e *EntryError) Error() string {
+	if e.Set < 0 {
+		return fmt.Sprintf("entries[%d]: %s", e.Entry, e.Msg)
+	}
+	return fmt.Sprintf("entries[%d].logged_sets[%d]: %s", e.Entry, e.Set, e.Msg)
+}
+
+func entryError(idx int, msg string) error {
+	return &EntryError{Entry: idx, Set: -1, Msg: msg}
+}
+
+func setError(idx, set int, format string, args ...any) error {
+	return &EntryError{Entry: idx, Set: set, Msg: fmt.Sprintf(format, args...)}
+}
+
+// maxSetsPerEntry caps the sets of one entry, logged or flat. Flat sets are
+// expanded into that many rows, so without it a single number could make
+// one request insert millions.
+const maxSetsPerEntry = 100
+
 // Validate checks a single entry. idx is threaded through so error messages
-// point at the offending element.
+// point at the offending element. With LoggedSets present the flat fields
+// are ignored, since reconcile overwrites them.
 func (e *WorkoutEntry) Validate(idx int) error {
-	if e.ExerciseName == "" {
-		return fmt.Errorf("entries[%d]: exercise_name must not be empty", idx)
+	if e.ExerciseName == "" && e.ExerciseID == nil {
+		return entryError(idx, "exercise_name or exercise_id is required")
+	}
+	if len(e.LoggedSets) > maxSetsPerEntry {
+		return entryError(idx, fmt.Sprintf("logged_sets must have at most %d sets", maxSetsPerEntry))
+	}
+	if len(e.LoggedSets) > 0 {
+		return e.validateSets(idx)
 	}
-	if e.Sets < 0 {
-		return fmt.Errorf("entries[%d]: sets must be non-negative", idx)
+	if e.Sets < 0 || e.Sets > maxSetsPerEntry {
+		return entryError(idx, fmt.Sprintf("sets must be between 0 and %d", maxSetsPerEntry))
 	}
 	hasReps := e.Reps != nil
 	hasDuration := e.DurationSeconds != nil
 	if hasReps == hasDuration {
-		return fmt.Errorf("entries[%d]: exactly one of reps or duration_seconds is required", idx)
+		return entryError(idx, "exactly one of reps or duration_seconds is required")
 	}
 	if hasReps && *e.Reps < 0 {
-		return fmt.Errorf("entries[%d]: reps must be non-negative", idx)
+		return entryError(idx, "reps must be non-negative")
 	}
 	if hasDuration && *e.DurationSeconds < 0 {
-		return fmt.Errorf("entries[%d]: duration_seconds must be non-negative", idx)
+		return entryError(idx, "duration_seconds must be non-negative")
 	}
 	if e.Weight != nil && *e.Weight < 0 {
-		return fmt.Errorf("entries[%d]: weight must be non-negative", idx)
+		return entryError(idx, "weight must be non-negative")
 	}
 	return nil
 }
 
+// validateSets applies the entry invariants to each set and requires every
+// set of an entry to use the same measure, so the derived flat fields stay
+// meaningful.
+func (e *WorkoutEntry) validateSets(idx int) error {
+	byReps := e.LoggedSets[0].Reps != nil
+	for j, set := range e.LoggedSets {
+		if set.Type != "" && !set.Type.Valid() {
+			return setError(idx, j, "type must be one of %q, %q, %q or %q", SetTypeWarmup, SetTypeWorking, SetTypeDrop, SetTypeFailure)
+		}
+		hasReps := set.Reps != nil
+		hasDuration := set.DurationSeconds != nil
+		if hasReps == hasDuration {
+			return setError(idx, j, "exactly one of reps or duration_seconds is required")
+		}
+		if hasReps != byReps {
+			return setError(idx, j, "all sets of an entry must use the same measure")
+		}
+		if hasReps && *set.Reps < 0 {
+			return setError(idx, j, "reps must be non-negative")
+		}
+		if hasDuration && *set.DurationSeconds < 0 {
+			return setError(idx, j, "duration_seconds must be non-negative")
+		}
+		if set.Weight != nil && *set.Weight < 0 {
+			return setError(idx, j, "weight must be non-negative")
+		}
+		if set.RPE != nil && (*set.RPE < 1 || *set.RPE > 10) {
+			return setError(idx, j, "rpe must be between 1 and 10")
+		}
+	}
+	return nil
+}
+
+// reconcile makes the flat fields and LoggedSets agree. Must run after
+// Validate. With sets logged, Sets counts the non-warmup sets and
+// Reps/DurationSeconds/Weight come from the top set (heaviest, then most
+// reps or longest); with only flat fields, they are expanded into Sets
+// completed working sets.
+func (e *WorkoutEntry) reconcile() {
+	if len(e.LoggedSets) == 0 {
+		if e.Sets == 0 {
+			e.LoggedSets = []WorkoutSet{}
+			return
+		}
+		e.LoggedSets = make([]WorkoutSet, e.Sets)
+		for i := range e.LoggedSets {
+			e.LoggedSets[i] = WorkoutSet{
+				Type:            SetTypeWorking,
+				Reps:            e.Reps,
+				DurationSeconds: e.DurationSeconds,
+				Weight:          e.Weight,
+				Completed:       true,
+			}
+		}
+		return
+	}
+
+	working := 0
+	for i := range e.LoggedSets {
+		if e.LoggedSets[i].Type == "" {
+			e.LoggedSets[i].Type = SetTypeWorking
+		}
+		if e.LoggedSets[i].Type != SetTypeWarmup {
+			working++
+		}
+	}
+	var top *WorkoutSet
+	for i := range e.LoggedSets {
+		set := &e.LoggedSets[i]
+		if working > 0 && set.Type == SetTypeWarmup {
+			continue
+		}
+		if top == nil || set.heavierThan(top) {
+			top = set
+		}
+	}
+	if working == 0 {
+		working = len(e.LoggedSets)
+	}
+	e.Sets = working
+	e.Reps = top.Reps
+	e.DurationSeconds = top.DurationSeconds
+	e.Weight = top.Weight
+}
+
+// heavierThan orders sets by weight, then by reps or duration.
+func (s *WorkoutSet) heavierThan(o *WorkoutSet) bool {
+	sw, ow := valueOrZero(s.Weight), valueOrZero(o.Weight)
+	if sw != ow {
+		return sw > ow
+	}
+	return valueOrZero(s.Reps)+valueOrZero(s.DurationSeconds) > valueOrZero(o.Reps)+valueOrZero(o.DurationSeconds)
+}
+
+func valueOrZero[T int | float64](p *T) T {
+	if p == nil {
+		return 0
+	}
+	return *p
+}
+
+// measurement reports whether the entry counts reps or time, in the
+// catalog's vocabulary.
+func (e *WorkoutEntry) measurement() exercise.Measurement {
+	if e.DurationSeconds != nil {
+		return exercise.MeasurementTime
+	}
+	return exercise.MeasurementReps
+}
+
+func reconcileEntries(entries []WorkoutEntry) {
+	for i := range entries {
+		entries[i].reconcile()
+	}
+}
+
 // Validate enforces the same invariants Workout.Validate does, but only for
 // fields the patch actually touches.
 func (p *WorkoutPatch) Validate() error {
@@ -103,6 +334,9 @@ func (p *WorkoutPatch) Validate() error {
 	if p.CaloriesBurned != nil && *p.CaloriesBurned < 0 {
 		return fmt.Errorf("calories_burned must be non-negative")
 	}
+	if p.Visibility != nil && !p.Visibility.Valid() {
+		return errInvalidVisibility
+	}
 	if p.Entries != nil {
 		entries := *p.Entries
 		for i := range entries {
@@ -113,3 +347,154 @@ func (p *WorkoutPatch) Validate() error {
 	}
 	return nil
 }
+
+// TemplateID wraps workout_templates.id, typed for the same reason
+// WorkoutID is.
+type TemplateID int64
+
+// Template is a reusable workout prescription. Its entries use the
+// WorkoutEntry shape as targets (sets, reps or duration, weight) so starting
+// a template is a straight copy, and so the same entry invariants apply.
+type Template struct {
+	ID          TemplateID     `json:"id"`
+	UserID      user.UserID    `json:"user_id"`
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	Entries     []WorkoutEntry `json:"entries"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+}
+
+// TemplatePatch follows WorkoutPatch semantics: nil leaves the field alone,
+// a non-nil Entries replaces the whole prescription.
+type TemplatePatch struct {
+	Name        *string
+	Description *string
+	Entries     *[]WorkoutEntry
+}
+
+// Validate applies WorkoutEntry.Validate to every prescription, so a
+// template can never hold an entry a workout would reject.
+func (t *Template) Validate() error {
+	if t.Name == "" {
+		return errors.New("name must not be empty")
+	}
+	return validateTemplateEntries(t.Entries)
+}
+
+// validateTemplateEntries rejects logged_sets: a template prescribes targets
+// through the flat fields, and sets are only materialised once a workout is
+// started from it.
+func validateTemplateEntries(entries []WorkoutEntry) error {
+	for i := range entries {
+		if len(entries[i].LoggedSets) > 0 {
+			return entryError(i, "logged_sets are not supported on templates")
+		}
+		if err := entries[i].Validate(i); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *TemplatePatch) Validate() error {
+	if p.Name != nil && *p.Name == "" {
+		return errors.New("name must not be empty")
+	}
+	if p.Entries != nil {
+		return validateTemplateEntries(*p.Entries)
+	}
+	return nil
+}
+
+// ShareID wraps workout_shares.id. Share links are addressed by id for
+// listing and revocation; the token itself is only ever shown once.
+type ShareID int64
+
+// Share is a revocable, unguessable link granting anonymous read access to a
+// single workout. Token is populated only on the creation response — the
+// store keeps a SHA-256 hash, so it cannot be shown again.
+type Share struct {
+	ID        ShareID    `json:"id"`
+	WorkoutID WorkoutID  `json:"workout_id"`
+	Token     string     `json:"token,omitempty"`
+	Expiry    *time.Time `json:"expiry"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// SortOrder selects the direction of the (created_at, id) keyset used by
+// ListWorkouts. The string values double as the accepted ?sort= inputs.
+type SortOrder string
+
+const (
+	SortNewestFirst SortOrder = "-created_at"
+	SortOldestFirst SortOrder = "created_at"
+)
+
+// Cursor is the keyset position of the last row on a page. Pagination is on
+// (created_at, id) rather than OFFSET so deep pages stay index-backed and
+// rows inserted mid-scroll don't shift later pages.
+type Cursor struct {
+	CreatedAt time.Time
+	ID        WorkoutID
+}
+
+// Encode renders the cursor as an opaque, URL-safe token. Microsecond
+// precision matches timestamptz, so the round trip is lossless.
+func (c Cursor) Encode() string {
+	raw := strconv.FormatInt(c.CreatedAt.UnixMicro(), 10) + ":" + strconv.FormatInt(int64(c.ID), 10)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodeCursor parses a token produced by Cursor.Encode. Any malformed input
+// is reported as a single generic error — clients must treat cursors as
+// opaque, so there is nothing more useful to tell them.
+func DecodeCursor(s string) (*Cursor, error) {
+	errInvalid := errors.New("invalid cursor")
+
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, errInvalid
+	}
+	micros, id, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return nil, errInvalid
+	}
+	us, err := strconv.ParseInt(micros, 10, 64)
+	if err != nil {
+		return nil, errInvalid
+	}
+	n, err := strconv.ParseInt(id, 10, 64)
+	if err != nil || n <= 0 {
+		return nil, errInvalid
+	}
+	return &Cursor{CreatedAt: time.UnixMicro(us).UTC(), ID: WorkoutID(n)}, nil
+}
+
+// ListFilter narrows a ListWorkouts query. Zero values mean "no filter":
+// nil bounds, empty strings. From is inclusive, To exclusive. ExerciseID is
+// not read from the request: Service.List sets it when Exercise resolves to
+// the catalog.
+type ListFilter struct {
+	From        *time.Time
+	To          *time.Time
+	Title       string
+	MinDuration *int
+	MinCalories *int
+	Exercise    string
+	ExerciseID  *exercise.ExerciseID
+}
+
+// Validate rejects filters that can never match or that would be ambiguous.
+func (f *ListFilter) Validate() error {
+	if f.From != nil && f.To != nil && !f.From.Before(*f.To) {
+		return errors.New("from must be before to")
+	}
+	if f.MinDuration != nil && *f.MinDuration < 0 {
+		return errors.New("min_duration must be non-negative")
+	}
+	if f.MinCalories != nil && *f.MinCalories < 0 {
+		return errors.New("min_calories must be non-negative")
+	}
+	return nil
+}
@@ -0,0 +1,24 @@
+package workout
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateSetCap(t *testing.T) {
+	flat := func(sets int) *WorkoutEntry {
+		return &WorkoutEntry{ExerciseName: "Squat", Sets: sets, Reps: ptrInt(5)}
+	}
+	assert.NoError(t, flat(maxSetsPerEntry).Validate(0))
+	assert.ErrorContains(t, flat(maxSetsPerEntry+1).Validate(0), "sets must be between 0 and 100")
+	assert.ErrorContains(t, flat(-1).Validate(0), "sets must be between 0 and 100")
+
+	logged := &WorkoutEntry{ExerciseName: "Squat", LoggedSets: make([]WorkoutSet, maxSetsPerEntry+1)}
+	for i := range logged.LoggedSets {
+		logged.LoggedSets[i].Reps = ptrInt(5)
+	}
+	assert.ErrorContains(t, logged.Validate(0), "logged_sets must have at most 100 sets")
+	logged.LoggedSets = logged.LoggedSets[:maxSetsPerEntry]
+	assert.NoError(t, logged.Validate(0))
+}
@@ -4,6 +4,10 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/tsatsarisg/go-fit/internal/platform/postgres"
 	"github.com/tsatsarisg/go-fit/internal/user"
@@ -20,50 +24,322 @@ func NewPostgresStore(db *sql.DB) *PostgresStore {
 	return &PostgresStore{db: db}
 }
 
-func (pg *PostgresStore) CreateWorkout(ctx context.Context, workout *Workout) (*Workout, error) {
+// workoutColumns is the canonical projection of a workouts row (aliased w),
+// paired with scanWorkout so every read path stays in column order.
+const workoutColumns = `w.id, w.user_id, w.title, w.description, w.duration_minutes, w.calories_burned, w.visibility, w.template_id, w.created_at, w.updated_at`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanWorkout(row rowScanner) (*Workout, error) {
+	w := &Workout{}
+	err := row.Scan(&w.ID, &w.UserID, &w.Title, &w.Description, &w.DurationMinutes, &w.CaloriesBurned, &w.Visibility, &w.TemplateID, &w.CreatedAt, &w.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// queryer is the read surface shared by *sql.DB and *sql.Tx, so the entry
+// loader below serves both the plain read path and the in-tx re-read after
+// an update.
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+const insertEntryQuery = `
+	INSERT INTO workout_entries (workout_id, exercise_id, exercise_name, sets, reps, duration_seconds, weight, notes, order_index)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	RETURNING id`
+
+const insertSetQuery = `
+	INSERT INTO workout_sets (workout_entry_id, set_index, set_type, reps, duration_seconds, weight, rpe, completed)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	RETURNING id`
+
+// insertEntries writes entries and their sets for workoutID inside tx,
+// filling in each entry's and set's generated id.
+func insertEntries(ctx context.Context, tx *sql.Tx, workoutID WorkoutID, entries []WorkoutEntry) error {
+	for i := range entries {
+		entry := &entries[i]
+		err := tx.QueryRowContext(ctx, insertEntryQuery, workoutID, entry.ExerciseID, entry.ExerciseName, entry.Sets, entry.Reps, entry.DurationSeconds, entry.Weight, entry.Notes, entry.OrderIndex).Scan(&entry.ID)
+		if err != nil {
+			return postgres.ClassifyError(err)
+		}
+		for j := range entry.LoggedSets {
+			set := &entry.LoggedSets[j]
+			err := tx.QueryRowContext(ctx, insertSetQuery, entry.ID, j, string(set.Type), set.Reps, set.DurationSeconds, set.Weight, set.RPE, set.Completed).Scan(&set.ID)
+			if err != nil {
+				return postgres.ClassifyError(err)
+			}
+		}
+	}
+	return nil
+}
+
+// loadEntries fetches the entries of every workout in ids with one query and
+// attaches them in order_index order. Batching keeps list pages at two round
+// trips regardless of page size.
+func loadEntries(ctx context.Context, q queryer, workouts ...*Workout) error {
+	if len(workouts) == 0 {
+		return nil
+	}
+	byID := make(map[WorkoutID]*Workout, len(workouts))
+	ids := make([]int64, 0, len(workouts))
+	for _, w := range workouts {
+		byID[w.ID] = w
+		ids = append(ids, int64(w.ID))
+	}
+
+	query := `SELECT workout_id, id, exercise_id, exercise_name, sets, reps, duration_seconds, weight, notes, order_index
+			  FROM workout_entries
+			  WHERE workout_id = ANY($1)
+			  ORDER BY workout_id, order_index`
+
+	rows, err := q.QueryContext(ctx, query, ids)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var workoutID WorkoutID
+		entry := WorkoutEntry{LoggedSets: []WorkoutSet{}}
+		if err := rows.Scan(&workoutID, &entry.ID, &entry.ExerciseID, &entry.ExerciseName, &entry.Sets, &entry.Reps, &entry.DurationSeconds, &entry.Weight, &entry.Notes, &entry.OrderIndex); err != nil {
+			return err
+		}
+		if w, ok := byID[workoutID]; ok {
+			w.Entries = append(w.Entries, entry)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	return loadSets(ctx, q, workouts)
+}
+
+// loadSets attaches workout_sets to the already-loaded entries of workouts,
+// again with a single query.
+func loadSets(ctx context.Context, q queryer, workouts []*Workout) error {
+	byEntryID := make(map[int]*WorkoutEntry)
+	entryIDs := make([]int64, 0)
+	for _, w := range workouts {
+		for i := range w.Entries {
+			byEntryID[w.Entries[i].ID] = &w.Entries[i]
+			entryIDs = append(entryIDs, int64(w.Entries[i].ID))
+		}
+	}
+	if len(entryIDs) == 0 {
+		return nil
+	}
+
+	query := `SELECT workout_entry_id, id, set_type, reps, duration_seconds, weight, rpe, completed
+			  FROM workout_sets
+			  WHERE workout_entry_id = ANY($1)
+			  ORDER BY workout_entry_id, set_index`
+
+	rows, err := q.QueryContext(ctx, query, entryIDs)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var entryID int
+		var set WorkoutSet
+		if err := rows.Scan(&entryID, &set.ID, &set.Type, &set.Reps, &set.DurationSeconds, &set.Weight, &set.RPE, &set.Completed); err != nil {
+			return err
+		}
+		if e, ok := byEntryID[entryID]; ok {
+			e.LoggedSets = append(e.LoggedSets, set)
+		}
+	}
+	return rows.Err()
+}
+
+func (pg *PostgresStore) CreateWorkout(ctx context.Context, workout *Workout, candidates []PersonalRecord) (*Workout, error) {
 	tx, err := pg.db.BeginTx(ctx, nil)
 	if err != nil {
 		return nil, err
 	}
 	defer tx.Rollback()
 
-	query := `INSERT INTO workouts (user_id, title, description, duration_minutes, calories_burned)
-			  VALUES ($1, $2, $3, $4, $5)
-			  RETURNING id`
+	if err := insertWorkout(ctx, tx, workout); err != nil {
+		return nil, err
+	}
+	workout.NewRecords, err = recordPersonalRecords(ctx, tx, workout, candidates)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return workout, nil
+}
+
+// insertWorkout writes a workout with its entries and sets inside tx; the
+// caller records its personal records. A zero CreatedAt means "now";
+// imports set it to when the workout actually happened.
+func insertWorkout(ctx context.Context, tx *sql.Tx, workout *Workout) error {
+	query := `INSERT INTO workouts (user_id, title, description, duration_minutes, calories_burned, visibility, template_id, created_at)
+			  VALUES ($1, $2, $3, $4, $5, $6, $7, COALESCE($8::timestamptz, CURRENT_TIMESTAMP))
+			  RETURNING id, created_at, updated_at`
+
+	var createdAt *time.Time
+	if !workout.CreatedAt.IsZero() {
+		createdAt = &workout.CreatedAt
+	}
 
-	err = tx.QueryRowContext(ctx, query, workout.UserID, workout.Title, workout.Description, workout.DurationMinutes, workout.CaloriesBurned).Scan(&workout.ID)
+	err := tx.QueryRowContext(ctx, query, workout.UserID, workout.Title, workout.Description, workout.DurationMinutes, workout.CaloriesBurned, string(workout.Visibility), workout.TemplateID, createdAt).Scan(&workout.ID, &workout.CreatedAt, &workout.UpdatedAt)
 	if err != nil {
-		return nil, postgres.ClassifyError(err)
+		return postgres.ClassifyError(err)
 	}
 
-	for i := range workout.Entries {
-		entryQuery := `
-			INSERT INTO workout_entries (workout_id, exercise_name, sets, reps, duration_seconds, weight, notes, order_index)
-			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-			RETURNING id
-		`
+	return insertEntries(ctx, tx, workout.ID, workout.Entries)
+}
+
+// ImportWorkouts inserts workouts in order inside one tx, skipping any that
+// duplicate an existing workout of the same user: same title and the same
+// start time to the microsecond. candidates[i] belongs to workouts[i].
+// Imported workouts are usually older than ones already logged, so the
+// records of every exercise they touch are rebuilt once, after the last
+// insert.
+func (pg *PostgresStore) ImportWorkouts(ctx context.Context, workouts []*Workout, candidates [][]PersonalRecord) (*ImportResult, error) {
+	tx, err := pg.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
 
-		entry := &workout.Entries[i]
-		err = tx.QueryRowContext(ctx, entryQuery, workout.ID, entry.ExerciseName, entry.Sets, entry.Reps, entry.DurationSeconds, entry.Weight, entry.Notes, entry.OrderIndex).Scan(&entry.ID)
+	result := &ImportResult{Created: []*Workout{}, Duplicates: []*Workout{}}
+	var keys []string
+	for i, w := range workouts {
+		var exists bool
+		err := tx.QueryRowContext(ctx,
+			`SELECT EXISTS (SELECT 1 FROM workouts WHERE user_id = $1 AND created_at = $2 AND title = $3)`,
+			w.UserID, w.CreatedAt, w.Title,
+		).Scan(&exists)
 		if err != nil {
-			return nil, postgres.ClassifyError(err)
+			return nil, err
+		}
+		if exists {
+			result.Duplicates = append(result.Duplicates, w)
+			continue
+		}
+		if err := insertWorkout(ctx, tx, w); err != nil {
+			return nil, err
+		}
+		keys = append(keys, candidateKeys(candidates[i])...)
+		result.Created = append(result.Created, w)
+	}
+
+	if len(result.Created) > 0 {
+		records, err := rebuildRecords(ctx, tx, result.Created[0].UserID, keys)
+		if err != nil {
+			return nil, err
+		}
+		for _, w := range result.Created {
+			w.NewRecords = recordsOf(records, w.ID)
 		}
 	}
 
 	if err := tx.Commit(); err != nil {
 		return nil, err
 	}
+	return result, nil
+}
 
-	return workout, nil
+// exportBatchSize is how many workouts one FETCH pulls from the export
+// cursor; their entries and sets are then loaded with two batched queries.
+const exportBatchSize = 200
+
+// ExportWorkouts walks the user's workouts through a DECLAREd cursor inside
+// a read-only, repeatable-read tx, so the export is one consistent snapshot
+// even if the user keeps logging while it streams.
+func (pg *PostgresStore) ExportWorkouts(ctx context.Context, userID user.UserID, fn func(*Workout) error) error {
+	tx, err := pg.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `DECLARE workout_export NO SCROLL CURSOR FOR
+		SELECT `+workoutColumns+`
+		FROM workouts w
+		WHERE w.user_id = $1
+		ORDER BY w.created_at, w.id`, userID)
+	if err != nil {
+		return err
+	}
+
+	fetch := fmt.Sprintf(`FETCH FORWARD %d FROM workout_export`, exportBatchSize)
+	for {
+		batch, err := fetchWorkouts(ctx, tx, fetch)
+		if err != nil {
+			return err
+		}
+		if len(batch) == 0 {
+			break
+		}
+		if err := loadEntries(ctx, tx, batch...); err != nil {
+			return err
+		}
+		for _, w := range batch {
+			if w.Entries == nil {
+				w.Entries = []WorkoutEntry{}
+			}
+			if err := fn(w); err != nil {
+				return err
+			}
+		}
+	}
+	return tx.Commit()
+}
+
+func fetchWorkouts(ctx context.Context, tx *sql.Tx, fetch string) ([]*Workout, error) {
+	rows, err := tx.QueryContext(ctx, fetch)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var workouts []*Workout
+	for rows.Next() {
+		w, err := scanWorkout(rows)
+		if err != nil {
+			return nil, err
+		}
+		workouts = append(workouts, w)
+	}
+	return workouts, rows.Err()
+}
+
+// GetWorkoutByID folds the visibility check into the lookup so a workout the
+// viewer may not see is indistinguishable from a missing one. So is one
+// whose owner asked for their account to be deleted.
+func (pg *PostgresStore) GetWorkoutByID(ctx context.Context, id WorkoutID, viewerID user.UserID) (*Workout, error) {
+	query := `SELECT ` + workoutColumns + `
+			  FROM workouts w
+			  INNER JOIN users u ON u.id = w.user_id
+			  WHERE w.id = $1 AND u.deletion_requested_at IS NULL
+			    AND (w.user_id = $2
+			         OR w.visibility = 'public'
+			         OR (w.visibility = 'followers' AND EXISTS (
+			             SELECT 1 FROM follows f WHERE f.follower_id = $2 AND f.followee_id = w.user_id)))`
+
+	return pg.getWorkout(ctx, query, id, viewerID)
 }
 
-func (pg *PostgresStore) GetWorkoutByID(ctx context.Context, id WorkoutID) (*Workout, error) {
-	query := `SELECT id, user_id, title, description, duration_minutes, calories_burned
-			  FROM workouts
-			  WHERE id = $1`
+func (pg *PostgresStore) GetAnyWorkoutByID(ctx context.Context, id WorkoutID) (*Workout, error) {
+	return pg.getWorkout(ctx, `SELECT `+workoutColumns+` FROM workouts w WHERE w.id = $1`, id)
+}
 
-	workout := &Workout{}
-	err := pg.db.QueryRowContext(ctx, query, id).Scan(&workout.ID, &workout.UserID, &workout.Title, &workout.Description, &workout.DurationMinutes, &workout.CaloriesBurned)
+// getWorkout loads the workout query selects, with its entries.
+func (pg *PostgresStore) getWorkout(ctx context.Context, query string, args ...any) (*Workout, error) {
+	workout, err := scanWorkout(pg.db.QueryRowContext(ctx, query, args...))
 	if errors.Is(err, sql.ErrNoRows) {
 		return nil, ErrNotFound
 	}
@@ -71,33 +347,93 @@ func (pg *PostgresStore) GetWorkoutByID(ctx context.Context, id WorkoutID) (*Wor
 		return nil, err
 	}
 
-	entriesQuery := `SELECT id, exercise_name, sets, reps, duration_seconds, weight, notes, order_index
-					 FROM workout_entries
-					 WHERE workout_id = $1
-					 ORDER BY order_index`
+	if err := loadEntries(ctx, pg.db, workout); err != nil {
+		return nil, err
+	}
+
+	return workout, nil
+}
+
+// likeEscaper neutralizes LIKE metacharacters in user input so a title
+// filter of "100%" matches literally instead of acting as a wildcard.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// ListWorkouts builds the filtered keyset query. Every predicate is
+// parameterized; only the fixed ORDER BY direction and comparison operator
+// are spliced in, and those come from a closed set. The (user_id,
+// created_at, id) index serves both the scope and the keyset in either
+// direction.
+func (pg *PostgresStore) ListWorkouts(ctx context.Context, q ListWorkoutsQuery) ([]*Workout, error) {
+	args := []any{q.UserID}
+	conds := []string{"w.user_id = $1"}
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	f := q.Filter
+	if f.From != nil {
+		conds = append(conds, "w.created_at >= "+arg(*f.From))
+	}
+	if f.To != nil {
+		conds = append(conds, "w.created_at < "+arg(*f.To))
+	}
+	if f.Title != "" {
+		conds = append(conds, "w.title ILIKE '%' || "+arg(likeEscaper.Replace(f.Title))+" || '%'")
+	}
+	if f.MinDuration != nil {
+		conds = append(conds, "w.duration_minutes >= "+arg(*f.MinDuration))
+	}
+	if f.MinCalories != nil {
+		conds = append(conds, "w.calories_burned >= "+arg(*f.MinCalories))
+	}
+	if f.Exercise != "" {
+		conds = append(conds, `EXISTS (SELECT 1 FROM workout_entries e
+			WHERE e.workout_id = w.id
+			  AND (e.exercise_id = `+arg(f.ExerciseID)+`::bigint
+			       OR (e.exercise_id IS NULL
+			           AND regexp_replace(lower(btrim(e.exercise_name)), '\s+', ' ', 'g') = `+arg(f.Exercise)+`)))`)
+	}
 
-	rows, err := pg.db.QueryContext(ctx, entriesQuery, workout.ID)
+	dir, cmp := "DESC", "<"
+	if q.Sort == SortOldestFirst {
+		dir, cmp = "ASC", ">"
+	}
+	if q.After != nil {
+		conds = append(conds, fmt.Sprintf("(w.created_at, w.id) %s (%s, %s)", cmp, arg(q.After.CreatedAt), arg(q.After.ID)))
+	}
+
+	query := `SELECT ` + workoutColumns + `
+			  FROM workouts w
+			  WHERE ` + strings.Join(conds, " AND ") + `
+			  ORDER BY w.created_at ` + dir + `, w.id ` + dir + `
+			  LIMIT ` + arg(q.Limit+1)
+
+	rows, err := pg.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
+	var workouts []*Workout
 	for rows.Next() {
-		entry := WorkoutEntry{}
-		if err := rows.Scan(&entry.ID, &entry.ExerciseName, &entry.Sets, &entry.Reps, &entry.DurationSeconds, &entry.Weight, &entry.Notes, &entry.OrderIndex); err != nil {
+		w, err := scanWorkout(rows)
+		if err != nil {
 			return nil, err
 		}
-		workout.Entries = append(workout.Entries, entry)
+		workouts = append(workouts, w)
 	}
-
 	if err := rows.Err(); err != nil {
 		return nil, err
 	}
 
-	return workout, nil
+	if err := loadEntries(ctx, pg.db, workouts...); err != nil {
+		return nil, err
+	}
+	return workouts, nil
 }
 
-func (pg *PostgresStore) UpdateWorkout(ctx context.Context, id WorkoutID, userID user.UserID, patch WorkoutPatch) (*Workout, error) {
+func (pg *PostgresStore) UpdateWorkout(ctx context.Context, id WorkoutID, userID user.UserID, patch WorkoutPatch, candidates []PersonalRecord) (*Workout, error) {
 	tx, err := pg.db.BeginTx(ctx, nil)
 	if err != nil {
 		return nil, err
@@ -108,46 +444,35 @@ func (pg *PostgresStore) UpdateWorkout(ctx context.Context, id WorkoutID, userID
 	// fields via COALESCE. Explicit casts keep pgx happy about parameter types
 	// when the typed pointer is nil. updated_at always bumps on a successful
 	// update so the row's audit timestamp stays accurate.
-	updateQuery := `UPDATE workouts
+	updateQuery := `UPDATE workouts AS w
 					SET title = COALESCE($1::text, title),
 					    description = COALESCE($2::text, description),
 					    duration_minutes = COALESCE($3::int, duration_minutes),
 					    calories_burned = COALESCE($4::int, calories_burned),
+					    visibility = COALESCE($5::text, visibility),
 					    updated_at = NOW()
-					WHERE id = $5 AND user_id = $6
-					RETURNING id, user_id, title, description, duration_minutes, calories_burned`
+					WHERE id = $6 AND user_id = $7
+					RETURNING ` + workoutColumns
 
-	workout := &Workout{}
-	err = tx.QueryRowContext(
+	var visibility *string
+	if patch.Visibility != nil {
+		v := string(*patch.Visibility)
+		visibility = &v
+	}
+
+	workout, err := scanWorkout(tx.QueryRowContext(
 		ctx,
 		updateQuery,
 		patch.Title,
 		patch.Description,
 		patch.DurationMinutes,
 		patch.CaloriesBurned,
+		visibility,
 		id,
 		userID,
-	).Scan(
-		&workout.ID,
-		&workout.UserID,
-		&workout.Title,
-		&workout.Description,
-		&workout.DurationMinutes,
-		&workout.CaloriesBurned,
-	)
+	))
 	if errors.Is(err, sql.ErrNoRows) {
-		var ownerID user.UserID
-		probeErr := tx.QueryRowContext(ctx, `SELECT user_id FROM workouts WHERE id = $1`, id).Scan(&ownerID)
-		if errors.Is(probeErr, sql.ErrNoRows) {
-			return nil, ErrNotFound
-		}
-		if probeErr != nil {
-			return nil, probeErr
-		}
-		if ownerID != userID {
-			return nil, ErrForbidden
-		}
-		return nil, ErrNotFound
+		return nil, probeOwnership(ctx, tx, id, userID)
 	}
 	if err != nil {
 		return nil, postgres.ClassifyError(err)
@@ -158,40 +483,26 @@ func (pg *PostgresStore) UpdateWorkout(ctx context.Context, id WorkoutID, userID
 			return nil, err
 		}
 
-		newEntries := *patch.Entries
-		for i := range newEntries {
-			insertQuery := `
-				INSERT INTO workout_entries (workout_id, exercise_name, sets, reps, duration_seconds, weight, notes, order_index)
-				VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-				RETURNING id
-			`
-
-			entry := &newEntries[i]
-			if err := tx.QueryRowContext(ctx, insertQuery, workout.ID, entry.ExerciseName, entry.Sets, entry.Reps, entry.DurationSeconds, entry.Weight, entry.Notes, entry.OrderIndex).Scan(&entry.ID); err != nil {
-				return nil, postgres.ClassifyError(err)
-			}
+		if err := insertEntries(ctx, tx, workout.ID, *patch.Entries); err != nil {
+			return nil, err
 		}
-	}
 
-	entriesQuery := `SELECT id, exercise_name, sets, reps, duration_seconds, weight, notes, order_index
-					 FROM workout_entries
-					 WHERE workout_id = $1
-					 ORDER BY order_index`
-
-	rows, err := tx.QueryContext(ctx, entriesQuery, workout.ID)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	for rows.Next() {
-		entry := WorkoutEntry{}
-		if err := rows.Scan(&entry.ID, &entry.ExerciseName, &entry.Sets, &entry.Reps, &entry.DurationSeconds, &entry.Weight, &entry.Notes, &entry.OrderIndex); err != nil {
+		// Rebuild every exercise the old entries held a record on, since a
+		// lower value may have been the only thing beating an earlier
+		// workout's, and every exercise the new entries touch, since a
+		// higher one may unseat later records.
+		keys, err := heldRecordKeys(ctx, tx, workout.ID)
+		if err != nil {
 			return nil, err
 		}
-		workout.Entries = append(workout.Entries, entry)
+		records, err := rebuildRecords(ctx, tx, workout.UserID, append(keys, candidateKeys(candidates)...))
+		if err != nil {
+			return nil, err
+		}
+		workout.NewRecords = recordsOf(records, workout.ID)
 	}
-	if err := rows.Err(); err != nil {
+
+	if err := loadEntries(ctx, tx, workout); err != nil {
 		return nil, err
 	}
 
@@ -205,7 +516,8 @@ func (pg *PostgresStore) UpdateWorkout(ctx context.Context, id WorkoutID, userID
 // DeleteWorkout removes a workout and its entries in a single tx, enforcing
 // ownership in the WHERE clause. Uses DELETE ... RETURNING to learn whether
 // a row was actually removed without a second round trip, and disambiguates
-// 404 vs 403 via a probe inside the same tx.
+// 404 vs 403 via a probe inside the same tx. The workout's records go with
+// it, and the exercises they were on are rebuilt from what is left.
 func (pg *PostgresStore) DeleteWorkout(ctx context.Context, id WorkoutID, userID user.UserID) error {
 	tx, err := pg.db.BeginTx(ctx, nil)
 	if err != nil {
@@ -213,6 +525,11 @@ func (pg *PostgresStore) DeleteWorkout(ctx context.Context, id WorkoutID, userID
 	}
 	defer tx.Rollback()
 
+	keys, err := heldRecordKeys(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
 	if _, err := tx.ExecContext(ctx, `DELETE FROM workout_entries WHERE workout_id = $1`, id); err != nil {
 		return err
 	}
@@ -224,19 +541,622 @@ func (pg *PostgresStore) DeleteWorkout(ctx context.Context, id WorkoutID, userID
 		id, userID,
 	).Scan(&deletedID)
 	if errors.Is(err, sql.ErrNoRows) {
-		var ownerID user.UserID
-		probeErr := tx.QueryRowContext(ctx, `SELECT user_id FROM workouts WHERE id = $1`, id).Scan(&ownerID)
-		if errors.Is(probeErr, sql.ErrNoRows) {
-			return ErrNotFound
+		return probeOwnership(ctx, tx, id, userID)
+	}
+	if err != nil {
+		return err
+	}
+	if _, err := rebuildRecords(ctx, tx, userID, keys); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// probeOwnership disambiguates a zero-row write against workoutID: the row
+// doesn't exist (ErrNotFound) or belongs to someone else (ErrForbidden).
+// Runs inside the caller's tx so the answer is consistent with the write.
+func probeOwnership(ctx context.Context, tx *sql.Tx, workoutID WorkoutID, userID user.UserID) error {
+	var ownerID user.UserID
+	err := tx.QueryRowContext(ctx, `SELECT user_id FROM workouts WHERE id = $1`, workoutID).Scan(&ownerID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if ownerID != userID {
+		return ErrForbidden
+	}
+	return ErrNotFound
+}
+
+// CreateShare inserts the share only if userID owns the workout, using
+// INSERT ... SELECT so the ownership check and the write are one statement.
+func (pg *PostgresStore) CreateShare(ctx context.Context, share *Share, hash []byte, userID user.UserID) error {
+	tx, err := pg.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `INSERT INTO workout_shares (hash, workout_id, expiry)
+			  SELECT $1, id, $2 FROM workouts WHERE id = $3 AND user_id = $4
+			  RETURNING id, created_at`
+
+	err = tx.QueryRowContext(ctx, query, hash, share.Expiry, share.WorkoutID, userID).Scan(&share.ID, &share.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return probeOwnership(ctx, tx, share.WorkoutID, userID)
+	}
+	if err != nil {
+		return postgres.ClassifyError(err)
+	}
+
+	return tx.Commit()
+}
+
+func (pg *PostgresStore) ListShares(ctx context.Context, workoutID WorkoutID, userID user.UserID) ([]*Share, error) {
+	tx, err := pg.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var ownerID user.UserID
+	err = tx.QueryRowContext(ctx, `SELECT user_id FROM workouts WHERE id = $1`, workoutID).Scan(&ownerID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if ownerID != userID {
+		return nil, ErrForbidden
+	}
+
+	rows, err := tx.QueryContext(ctx, `SELECT id, workout_id, expiry, created_at
+			  FROM workout_shares
+			  WHERE workout_id = $1
+			  ORDER BY id`, workoutID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	shares := []*Share{}
+	for rows.Next() {
+		sh := &Share{}
+		if err := rows.Scan(&sh.ID, &sh.WorkoutID, &sh.Expiry, &sh.CreatedAt); err != nil {
+			return nil, err
+		}
+		shares = append(shares, sh)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return shares, nil
+}
+
+// DeleteShare revokes a share link. The join through workouts enforces
+// ownership in the same statement as the delete.
+func (pg *PostgresStore) DeleteShare(ctx context.Context, shareID ShareID, workoutID WorkoutID, userID user.UserID) error {
+	tx, err := pg.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var deletedID ShareID
+	err = tx.QueryRowContext(ctx, `DELETE FROM workout_shares s
+			  USING workouts w
+			  WHERE s.id = $1 AND s.workout_id = $2 AND w.id = s.workout_id AND w.user_id = $3
+			  RETURNING s.id`, shareID, workoutID, userID).Scan(&deletedID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return probeOwnership(ctx, tx, workoutID, userID)
+	}
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (pg *PostgresStore) GetWorkoutByShareHash(ctx context.Context, hash []byte) (*Workout, error) {
+	query := `SELECT ` + workoutColumns + `
+			  FROM workouts w
+			  INNER JOIN workout_shares s ON s.workout_id = w.id
+			  INNER JOIN users u ON u.id = w.user_id
+			  WHERE s.hash = $1 AND (s.expiry IS NULL OR s.expiry > $2)
+			    AND u.deletion_requested_at IS NULL`
+
+	workout, err := scanWorkout(pg.db.QueryRowContext(ctx, query, hash, time.Now()))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := loadEntries(ctx, pg.db, workout); err != nil {
+		return nil, err
+	}
+	return workout, nil
+}
+
+const insertTemplateEntryQuery = `
+	INSERT INTO template_entries (template_id, exercise_id, exercise_name, sets, reps, duration_seconds, weight, notes, order_index)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	RETURNING id`
+
+func insertTemplateEntries(ctx context.Context, tx *sql.Tx, templateID TemplateID, entries []WorkoutEntry) error {
+	for i := range entries {
+		entry := &entries[i]
+		err := tx.QueryRowContext(ctx, insertTemplateEntryQuery, templateID, entry.ExerciseID, entry.ExerciseName, entry.Sets, entry.Reps, entry.DurationSeconds, entry.Weight, entry.Notes, entry.OrderIndex).Scan(&entry.ID)
+		if err != nil {
+			return postgres.ClassifyError(err)
 		}
-		if probeErr != nil {
-			return probeErr
+	}
+	return nil
+}
+
+// loadTemplateEntries is loadEntries for template_entries: one query per
+// batch of templates, attached in order_index order.
+func loadTemplateEntries(ctx context.Context, q queryer, templates ...*Template) error {
+	if len(templates) == 0 {
+		return nil
+	}
+	byID := make(map[TemplateID]*Template, len(templates))
+	ids := make([]int64, 0, len(templates))
+	for _, t := range templates {
+		byID[t.ID] = t
+		ids = append(ids, int64(t.ID))
+	}
+
+	query := `SELECT template_id, id, exercise_id, exercise_name, sets, reps, duration_seconds, weight, notes, order_index
+			  FROM template_entries
+			  WHERE template_id = ANY($1)
+			  ORDER BY template_id, order_index`
+
+	rows, err := q.QueryContext(ctx, query, ids)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var templateID TemplateID
+		entry := WorkoutEntry{}
+		if err := rows.Scan(&templateID, &entry.ID, &entry.ExerciseID, &entry.ExerciseName, &entry.Sets, &entry.Reps, &entry.DurationSeconds, &entry.Weight, &entry.Notes, &entry.OrderIndex); err != nil {
+			return err
+		}
+		if t, ok := byID[templateID]; ok {
+			t.Entries = append(t.Entries, entry)
 		}
+	}
+	return rows.Err()
+}
+
+const templateColumns = `t.id, t.user_id, t.name, t.description, t.created_at, t.updated_at`
+
+func scanTemplate(row rowScanner) (*Template, error) {
+	t := &Template{}
+	if err := row.Scan(&t.ID, &t.UserID, &t.Name, &t.Description, &t.CreatedAt, &t.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// probeTemplateOwnership is probeOwnership for templates.
+func probeTemplateOwnership(ctx context.Context, tx *sql.Tx, id TemplateID, userID user.UserID) error {
+	var ownerID user.UserID
+	err := tx.QueryRowContext(ctx, `SELECT user_id FROM workout_templates WHERE id = $1`, id).Scan(&ownerID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrTemplateNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if ownerID != userID {
 		return ErrForbidden
 	}
+	return ErrTemplateNotFound
+}
+
+func (pg *PostgresStore) CreateTemplate(ctx context.Context, template *Template) (*Template, error) {
+	tx, err := pg.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	query := `INSERT INTO workout_templates (user_id, name, description)
+			  VALUES ($1, $2, $3)
+			  RETURNING id, created_at, updated_at`
+
+	err = tx.QueryRowContext(ctx, query, template.UserID, template.Name, template.Description).Scan(&template.ID, &template.CreatedAt, &template.UpdatedAt)
+	if err != nil {
+		return nil, postgres.ClassifyError(err)
+	}
+
+	if err := insertTemplateEntries(ctx, tx, template.ID, template.Entries); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return template, nil
+}
+
+func (pg *PostgresStore) GetTemplate(ctx context.Context, id TemplateID, userID user.UserID) (*Template, error) {
+	query := `SELECT ` + templateColumns + `
+			  FROM workout_templates t
+			  WHERE t.id = $1 AND t.user_id = $2`
+
+	template, err := scanTemplate(pg.db.QueryRowContext(ctx, query, id, userID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrTemplateNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := loadTemplateEntries(ctx, pg.db, template); err != nil {
+		return nil, err
+	}
+	return template, nil
+}
+
+func (pg *PostgresStore) ListTemplates(ctx context.Context, userID user.UserID) ([]*Template, error) {
+	query := `SELECT ` + templateColumns + `
+			  FROM workout_templates t
+			  WHERE t.user_id = $1
+			  ORDER BY t.name, t.id`
+
+	rows, err := pg.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	templates := []*Template{}
+	for rows.Next() {
+		t, err := scanTemplate(rows)
+		if err != nil {
+			return nil, err
+		}
+		templates = append(templates, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := loadTemplateEntries(ctx, pg.db, templates...); err != nil {
+		return nil, err
+	}
+	return templates, nil
+}
+
+func (pg *PostgresStore) GetTemplatesByID(ctx context.Context, ids []TemplateID) ([]*Template, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	raw := make([]int64, len(ids))
+	for i, id := range ids {
+		raw[i] = int64(id)
+	}
+
+	query := `SELECT ` + templateColumns + `
+			  FROM workout_templates t
+			  WHERE t.id = ANY($1)`
+
+	rows, err := pg.db.QueryContext(ctx, query, raw)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var templates []*Template
+	for rows.Next() {
+		t, err := scanTemplate(rows)
+		if err != nil {
+			return nil, err
+		}
+		templates = append(templates, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := loadTemplateEntries(ctx, pg.db, templates...); err != nil {
+		return nil, err
+	}
+	return templates, nil
+}
+
+// UpdateTemplate mirrors UpdateWorkout: COALESCE partial update with
+// ownership in the WHERE clause, then a full entry replace when requested.
+func (pg *PostgresStore) UpdateTemplate(ctx context.Context, id TemplateID, userID user.UserID, patch TemplatePatch) (*Template, error) {
+	tx, err := pg.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	updateQuery := `UPDATE workout_templates AS t
+					SET name = COALESCE($1::text, name),
+					    description = COALESCE($2::text, description),
+					    updated_at = NOW()
+					WHERE id = $3 AND user_id = $4
+					RETURNING ` + templateColumns
+
+	template, err := scanTemplate(tx.QueryRowContext(ctx, updateQuery, patch.Name, patch.Description, id, userID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, probeTemplateOwnership(ctx, tx, id, userID)
+	}
+	if err != nil {
+		return nil, postgres.ClassifyError(err)
+	}
+
+	if patch.Entries != nil {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM template_entries WHERE template_id = $1`, template.ID); err != nil {
+			return nil, err
+		}
+		if err := insertTemplateEntries(ctx, tx, template.ID, *patch.Entries); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := loadTemplateEntries(ctx, tx, template); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return template, nil
+}
+
+// DeleteTemplate removes a template and (via ON DELETE CASCADE) its entries.
+// Workouts started from it keep their history; their template_id is nulled.
+// A template a program schedules is ErrTemplateInUse: deleting it would
+// drop days from every enrollee's program.
+func (pg *PostgresStore) DeleteTemplate(ctx context.Context, id TemplateID, userID user.UserID) error {
+	tx, err := pg.db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
+	defer tx.Rollback()
+
+	var deletedID TemplateID
+	err = tx.QueryRowContext(ctx, `DELETE FROM workout_templates WHERE id = $1 AND user_id = $2 RETURNING id`, id, userID).Scan(&deletedID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return probeTemplateOwnership(ctx, tx, id, userID)
+	}
+	if err != nil {
+		if errors.Is(postgres.ClassifyError(err), postgres.ErrForeignKeyViolation) {
+			return ErrTemplateInUse
+		}
+		return err
+	}
 
 	return tx.Commit()
 }
+
+// recordPersonalRecords stores every candidate that beats the user's current
+// record of the same kind (for most_reps, at the same weight) and returns
+// the ones that did. Runs inside the workout's tx so a workout and its
+// records are saved together. That comparison is only right for the user's
+// latest workout; a backdated one rebuilds the exercises it touches instead.
+func recordPersonalRecords(ctx context.Context, tx *sql.Tx, workout *Workout, candidates []PersonalRecord) ([]PersonalRecord, error) {
+	if len(candidates) == 0 {
+		return []PersonalRecord{}, nil
+	}
+	var backdated bool
+	err := tx.QueryRowContext(ctx,
+		`SELECT EXISTS (SELECT 1 FROM workouts WHERE user_id = $1 AND id <> $2 AND created_at > $3)`,
+		workout.UserID, workout.ID, workout.CreatedAt,
+	).Scan(&backdated)
+	if err != nil {
+		return nil, err
+	}
+	if backdated {
+		records, err := rebuildRecords(ctx, tx, workout.UserID, candidateKeys(candidates))
+		if err != nil {
+			return nil, err
+		}
+		return recordsOf(records, workout.ID), nil
+	}
+
+	bestQuery := `SELECT MAX(value)
+				  FROM personal_records
+				  WHERE user_id = $1 AND exercise_key = $2 AND record_type = $3
+				    AND ($3 <> 'most_reps' OR weight = $4)`
+
+	records := []PersonalRecord{}
+	for _, c := range candidates {
+		var best sql.NullFloat64
+		if err := tx.QueryRowContext(ctx, bestQuery, workout.UserID, c.exerciseKey, string(c.Type), c.Weight).Scan(&best); err != nil {
+			return nil, err
+		}
+		if best.Valid && c.Value <= best.Float64 {
+			continue
+		}
+		if err := insertRecord(ctx, tx, workout, &c); err != nil {
+			return nil, err
+		}
+		records = append(records, c)
+	}
+	return records, nil
+}
+
+// rebuildRecords recomputes the user's records on the exercises identified
+// by keys from their workouts, oldest first: a set is a record if it beats
+// every earlier one of its kind. Storing only the records, not every set,
+// means a removed or lowered record can't be undone incrementally: the
+// value it beat may never have been stored. Returns the records written.
+func rebuildRecords(ctx context.Context, tx *sql.Tx, userID user.UserID, keys []string) ([]PersonalRecord, error) {
+	if len(keys) == 0 {
+		return nil, nil
+	}
+	wanted := make(map[string]bool, len(keys))
+	var exerciseIDs []int64
+	byName := false
+	for _, k := range keys {
+		if wanted[k] {
+			continue
+		}
+		wanted[k] = true
+		if id, ok := strings.CutPrefix(k, "exercise:"); ok {
+			n, err := strconv.ParseInt(id, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid exercise key %q", k)
+			}
+			exerciseIDs = append(exerciseIDs, n)
+		} else {
+			byName = true
+		}
+	}
+	unique := make([]string, 0, len(wanted))
+	for k := range wanted {
+		unique = append(unique, k)
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM personal_records WHERE user_id = $1 AND exercise_key = ANY($2)`, userID, unique); err != nil {
+		return nil, err
+	}
+
+	// Free-text keys are normalized names, which SQL can't reproduce, so
+	// every unlinked entry is a candidate and Go filters by key.
+	rows, err := tx.QueryContext(ctx, `SELECT w.id, w.created_at
+			  FROM workouts w
+			  WHERE w.user_id = $1 AND EXISTS (
+			    SELECT 1 FROM workout_entries e
+			    WHERE e.workout_id = w.id AND (e.exercise_id = ANY($2) OR ($3 AND e.exercise_id IS NULL))
+			  )
+			  ORDER BY w.created_at, w.id`, userID, exerciseIDs, byName)
+	if err != nil {
+		return nil, err
+	}
+	var workouts []*Workout
+	for rows.Next() {
+		w := &Workout{UserID: userID}
+		if err := rows.Scan(&w.ID, &w.CreatedAt); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		workouts = append(workouts, w)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if err := loadEntries(ctx, tx, workouts...); err != nil {
+		return nil, err
+	}
+
+	type bucket struct {
+		key    string
+		kind   RecordType
+		weight float64
+	}
+	best := make(map[bucket]float64)
+	var records []PersonalRecord
+	for _, w := range workouts {
+		var entries []WorkoutEntry
+		for _, e := range w.Entries {
+			if wanted[exerciseKey(e.ExerciseID, e.ExerciseName)] {
+				entries = append(entries, e)
+			}
+		}
+		for _, c := range recordCandidates(userID, entries) {
+			b := bucket{key: c.exerciseKey, kind: c.Type}
+			if c.Type == RecordMostReps {
+				b.weight = valueOrZero(c.Weight)
+			}
+			if v, ok := best[b]; ok && c.Value <= v {
+				continue
+			}
+			best[b] = c.Value
+			if err := insertRecord(ctx, tx, w, &c); err != nil {
+				return nil, err
+			}
+			records = append(records, c)
+		}
+	}
+	return records, nil
+}
+
+// insertRecord stores c as set by workout, filling in its id.
+func insertRecord(ctx context.Context, tx *sql.Tx, workout *Workout, c *PersonalRecord) error {
+	c.WorkoutID = workout.ID
+	c.AchievedAt = workout.CreatedAt
+	err := tx.QueryRowContext(ctx, `INSERT INTO personal_records (user_id, exercise_key, exercise_id, exercise_name, record_type, value, weight, reps, workout_id, achieved_at)
+			  VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+			  RETURNING id`,
+		workout.UserID, c.exerciseKey, c.ExerciseID, c.ExerciseName, string(c.Type), c.Value, c.Weight, c.Reps, c.WorkoutID, c.AchievedAt).Scan(&c.ID)
+	return postgres.ClassifyError(err)
+}
+
+// heldRecordKeys returns the exercises on which workoutID holds a record.
+func heldRecordKeys(ctx context.Context, tx *sql.Tx, workoutID WorkoutID) ([]string, error) {
+	rows, err := tx.QueryContext(ctx, `SELECT DISTINCT exercise_key FROM personal_records WHERE workout_id = $1`, workoutID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []string
+	for rows.Next() {
+		var k string
+		if err := rows.Scan(&k); err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+	return keys, rows.Err()
+}
+
+const recordColumns = `id, exercise_key, exercise_id, exercise_name, record_type, value, weight, reps, workout_id, achieved_at`
+
+func (pg *PostgresStore) queryRecords(ctx context.Context, query string, args ...any) ([]*PersonalRecord, error) {
+	rows, err := pg.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	records := []*PersonalRecord{}
+	for rows.Next() {
+		r := &PersonalRecord{}
+		if err := rows.Scan(&r.ID, &r.exerciseKey, &r.ExerciseID, &r.ExerciseName, &r.Type, &r.Value, &r.Weight, &r.Reps, &r.WorkoutID, &r.AchievedAt); err != nil {
+			return nil, err
+		}
+		records = append(records, r)
+	}
+	return records, rows.Err()
+}
+
+// ListCurrentRecords returns the standing record of each kind for each of
+// the user's exercises (per weight for most_reps).
+func (pg *PostgresStore) ListCurrentRecords(ctx context.Context, userID user.UserID) ([]*PersonalRecord, error) {
+	query := `SELECT ` + recordColumns + `
+			  FROM (
+			    SELECT DISTINCT ON (exercise_key, record_type, CASE WHEN record_type = 'most_reps' THEN weight END) *
+			    FROM personal_records
+			    WHERE user_id = $1
+			    ORDER BY exercise_key, record_type, CASE WHEN record_type = 'most_reps' THEN weight END, value DESC, achieved_at
+			  ) current
+			  ORDER BY lower(exercise_name), record_type, weight`
+	return pg.queryRecords(ctx, query, userID)
+}
+
+// ListRecordHistory returns every record the user set on the exercises
+// identified by keys, oldest first.
+func (pg *PostgresStore) ListRecordHistory(ctx context.Context, userID user.UserID, keys []string) ([]*PersonalRecord, error) {
+	query := `SELECT ` + recordColumns + `
+			  FROM personal_records
+			  WHERE user_id = $1 AND exercise_key = ANY($2)
+			  ORDER BY achieved_at, id`
+	return pg.queryRecords(ctx, query, userID, keys)
+}
@@ -0,0 +1,158 @@
+package workout
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/tsatsarisg/go-fit/internal/exercise"
+	"github.com/tsatsarisg/go-fit/internal/user"
+)
+
+// RecordType is the kind of personal record. Value is measured per type:
+// kilograms for heaviest_weight and estimated_1rm, reps for most_reps,
+// seconds for longest_duration.
+type RecordType string
+
+const (
+	RecordHeaviestWeight  RecordType = "heaviest_weight"
+	RecordMostReps        RecordType = "most_reps"
+	RecordEstimated1RM    RecordType = "estimated_1rm"
+	RecordLongestDuration RecordType = "longest_duration"
+)
+
+// maxEstimateReps bounds the sets an estimated 1RM is computed from; past
+// this, rep-max formulas say more about endurance than strength.
+const maxEstimateReps = 12
+
+// PersonalRecord is a best performance on one exercise. Each row in the
+// history is a record that beat every earlier one of the same kind; the
+// current record is the latest. most_reps is tracked per Weight ("most reps
+// at 100 kg"), the other kinds per exercise.
+type PersonalRecord struct {
+	ID           int64                `json:"id"`
+	UserID       user.UserID          `json:"-"`
+	ExerciseID   *exercise.ExerciseID `json:"exercise_id"`
+	ExerciseName string               `json:"exercise_name"`
+	Type         RecordType           `json:"type"`
+	Value        float64              `json:"value"`
+	Weight       *float64             `json:"weight"`
+	Reps         *int                 `json:"reps"`
+	WorkoutID    WorkoutID            `json:"workout_id"`
+	AchievedAt   time.Time            `json:"achieved_at"`
+	exerciseKey  string
+}
+
+// exerciseKey is what records are grouped by: the catalog id when the entry
+// is linked, otherwise the normalized free-text name.
+func exerciseKey(id *exercise.ExerciseID, name string) string {
+	if id != nil {
+		return fmt.Sprintf("exercise:%d", *id)
+	}
+	return "name:" + exercise.NormalizeName(name)
+}
+
+// estimateOneRepMax uses Brzycki up to 10 reps, where it tracks tested maxes
+// closely, and Epley above that, where Brzycki's denominator makes it blow
+// up. A single is its own 1RM.
+func estimateOneRepMax(weight float64, reps int) float64 {
+	var e float64
+	switch {
+	case reps == 1:
+		e = weight
+	case reps <= 10:
+		e = weight * 36 / float64(37-reps)
+	default:
+		e = weight * (1 + float64(reps)/30)
+	}
+	return math.Round(e*100) / 100
+}
+
+// recordCandidates returns the best performance of each record kind in
+// entries. Only completed, non-warmup sets count. The store decides which
+// candidates are actually new records.
+func recordCandidates(userID user.UserID, entries []WorkoutEntry) []PersonalRecord {
+	type bucket struct {
+		key    string
+		kind   RecordType
+		weight float64
+	}
+	best := make(map[bucket]*PersonalRecord)
+	var order []bucket
+	offer := func(b bucket, r PersonalRecord) {
+		cur, ok := best[b]
+		if !ok {
+			order = append(order, b)
+		}
+		if !ok || r.Value > cur.Value {
+			best[b] = &r
+		}
+	}
+
+	for _, e := range entries {
+		key := exerciseKey(e.ExerciseID, e.ExerciseName)
+		base := PersonalRecord{UserID: userID, ExerciseID: e.ExerciseID, ExerciseName: e.ExerciseName, exerciseKey: key}
+		for _, s := range e.LoggedSets {
+			if !s.Completed || s.Type == SetTypeWarmup {
+				continue
+			}
+			if s.DurationSeconds != nil {
+				if *s.DurationSeconds > 0 {
+					r := base
+					r.Type, r.Value = RecordLongestDuration, float64(*s.DurationSeconds)
+					offer(bucket{key, RecordLongestDuration, 0}, r)
+				}
+				continue
+			}
+			if s.Reps == nil || *s.Reps <= 0 {
+				continue
+			}
+			weight := valueOrZero(s.Weight)
+
+			r := base
+			r.Type, r.Value, r.Weight, r.Reps = RecordMostReps, float64(*s.Reps), &weight, s.Reps
+			offer(bucket{key, RecordMostReps, weight}, r)
+
+			if weight <= 0 {
+				continue
+			}
+			r = base
+			r.Type, r.Value, r.Weight, r.Reps = RecordHeaviestWeight, weight, &weight, s.Reps
+			offer(bucket{key, RecordHeaviestWeight, 0}, r)
+
+			if *s.Reps <= maxEstimateReps {
+				r = base
+				r.Type, r.Value, r.Weight, r.Reps = RecordEstimated1RM, estimateOneRepMax(weight, *s.Reps), &weight, s.Reps
+				offer(bucket{key, RecordEstimated1RM, 0}, r)
+			}
+		}
+	}
+
+	out := make([]PersonalRecord, 0, len(order))
+	for _, b := range order {
+		out = append(out, *best[b])
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].exerciseKey < out[j].exerciseKey })
+	return out
+}
+
+// candidateKeys returns the exercise keys of candidates.
+func candidateKeys(candidates []PersonalRecord) []string {
+	keys := make([]string, len(candidates))
+	for i, c := range candidates {
+		keys[i] = c.exerciseKey
+	}
+	return keys
+}
+
+// recordsOf returns the records in records set by workoutID.
+func recordsOf(records []PersonalRecord, workoutID WorkoutID) []PersonalRecord {
+	out := []PersonalRecord{}
+	for _, r := range records {
+		if r.WorkoutID == workoutID {
+			out = append(out, r)
+		}
+	}
+	return out
+}
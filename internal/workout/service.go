@@ -4,7 +4,12 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
+	"strconv"
+	"time"
 
+	"github.com/tsatsarisg/go-fit/internal/auth"
+	"github.com/tsatsarisg/go-fit/internal/exercise"
 	"github.com/tsatsarisg/go-fit/internal/user"
 )
 
@@ -13,13 +18,61 @@ import (
 // implementation satisfies it. Swapping adapters (e.g. an in-memory fake for
 // tests) requires no change to this file.
 type Store interface {
-	CreateWorkout(ctx context.Context, workout *Workout) (*Workout, error)
-	GetWorkoutByID(ctx context.Context, id WorkoutID) (*Workout, error)
-	UpdateWorkout(ctx context.Context, id WorkoutID, userID user.UserID, patch WorkoutPatch) (*Workout, error)
+	// CreateWorkout and UpdateWorkout persist, in the same tx, each
+	// candidate record that beats every earlier one, by achieved_at, and
+	// report those in Workout.NewRecords. Records a save unseats or
+	// uncovers, on this or later workouts, are recomputed with it.
+	CreateWorkout(ctx context.Context, workout *Workout, candidates []PersonalRecord) (*Workout, error)
+	// GetWorkoutByID applies the visibility rules in SQL: the row is returned
+	// only if viewerID owns it, it is public, or it is followers-only and
+	// viewerID follows the owner. Anything else is ErrNotFound, so a caller
+	// can't distinguish "private" from "doesn't exist".
+	GetWorkoutByID(ctx context.Context, id WorkoutID, viewerID user.UserID) (*Workout, error)
+	// GetAnyWorkoutByID skips the visibility rules, for the admin API.
+	GetAnyWorkoutByID(ctx context.Context, id WorkoutID) (*Workout, error)
+	// ListWorkouts returns up to q.Limit+1 of the user's workouts in keyset
+	// order; the extra row tells the service whether another page exists.
+	ListWorkouts(ctx context.Context, q ListWorkoutsQuery) ([]*Workout, error)
+	UpdateWorkout(ctx context.Context, id WorkoutID, userID user.UserID, patch WorkoutPatch, candidates []PersonalRecord) (*Workout, error)
+	// ImportWorkouts saves a batch in one tx, skipping duplicates of
+	// existing workouts. candidates[i] are workouts[i]'s record candidates.
+	ImportWorkouts(ctx context.Context, workouts []*Workout, candidates [][]PersonalRecord) (*ImportResult, error)
+	// ExportWorkouts calls fn with each of the user's workouts, entries and
+	// sets loaded, oldest first. Rows come from a server-side cursor a batch
+	// at a time, so memory stays flat however long the history is. An error
+	// from fn stops the walk and is returned as is.
+	ExportWorkouts(ctx context.Context, userID user.UserID, fn func(*Workout) error) error
 	// DeleteWorkout enforces ownership in SQL (id + user_id) and returns
 	// ErrNotFound when the row doesn't exist, ErrForbidden when it does
 	// but belongs to someone else.
 	DeleteWorkout(ctx context.Context, id WorkoutID, userID user.UserID) error
+
+	// Share links. Create/List/Delete enforce ownership of the workout the
+	// same way UpdateWorkout does (ErrNotFound vs ErrForbidden).
+	CreateShare(ctx context.Context, share *Share, hash []byte, userID user.UserID) error
+	ListShares(ctx context.Context, workoutID WorkoutID, userID user.UserID) ([]*Share, error)
+	DeleteShare(ctx context.Context, shareID ShareID, workoutID WorkoutID, userID user.UserID) error
+	// GetWorkoutByShareHash resolves an unexpired share link to its workout,
+	// bypassing visibility (holding the link is the authorization). Like
+	// GetWorkoutByID, it hides the workouts of an account pending deletion.
+	GetWorkoutByShareHash(ctx context.Context, hash []byte) (*Workout, error)
+
+	// Templates are private to their owner. GetTemplate returns
+	// ErrTemplateNotFound for someone else's template; Update/Delete
+	// disambiguate ErrTemplateNotFound vs ErrForbidden like the workout
+	// writes do.
+	CreateTemplate(ctx context.Context, template *Template) (*Template, error)
+	GetTemplate(ctx context.Context, id TemplateID, userID user.UserID) (*Template, error)
+	ListTemplates(ctx context.Context, userID user.UserID) ([]*Template, error)
+	UpdateTemplate(ctx context.Context, id TemplateID, userID user.UserID, patch TemplatePatch) (*Template, error)
+	DeleteTemplate(ctx context.Context, id TemplateID, userID user.UserID) error
+	// GetTemplatesByID is the unscoped batch read behind
+	// Service.TemplatesByID. Missing ids are simply absent from the result.
+	GetTemplatesByID(ctx context.Context, ids []TemplateID) ([]*Template, error)
+
+	// Personal records.
+	ListCurrentRecords(ctx context.Context, userID user.UserID) ([]*PersonalRecord, error)
+	ListRecordHistory(ctx context.Context, userID user.UserID, keys []string) ([]*PersonalRecord, error)
 }
 
 // Domain-level sentinels. Callers use errors.Is to map to the appropriate
@@ -27,10 +80,14 @@ type Store interface {
 //   - ErrNotFound:   "workout id doesn't exist"               → 404
 //   - ErrForbidden:  "row exists but belongs to another user" → 403
 //   - ErrValidation: "aggregate / patch invariants violated"   → 400
+//   - ErrTemplateNotFound: "template id doesn't exist (for you)" → 404
+//   - ErrTemplateInUse: "a program schedules the template"      → 409
 var (
-	ErrNotFound   = errors.New("workout not found")
-	ErrForbidden  = errors.New("forbidden")
-	ErrValidation = errors.New("validation failed")
+	ErrNotFound         = errors.New("workout not found")
+	ErrForbidden        = errors.New("forbidden")
+	ErrValidation       = errors.New("validation failed")
+	ErrTemplateNotFound = errors.New("template not found")
+	ErrTemplateInUse    = errors.New("template is used by a program")
 )
 
 func wrapValidation(err error) error {
@@ -40,15 +97,71 @@ func wrapValidation(err error) error {
 	return fmt.Errorf("%w: %v", ErrValidation, err)
 }
 
+// Exercises is the narrow slice of exercise.Service used to link entries to
+// the catalog. Both lookups are scoped to what userID can see.
+type Exercises interface {
+	ByID(ctx context.Context, userID user.UserID, ids []exercise.ExerciseID) (map[exercise.ExerciseID]*exercise.Exercise, error)
+	Resolve(ctx context.Context, userID user.UserID, names []string) (map[string]*exercise.Exercise, error)
+}
+
 // Service is the workout bounded context's application service. Owns the
 // orchestration previously tangled into handlers: validate command, build /
 // mutate aggregate, persist. Transport depends on Service, not Store.
 type Service struct {
-	store Store
+	store     Store
+	exercises Exercises
 }
 
-func NewService(store Store) *Service {
-	return &Service{store: store}
+func NewService(store Store, exercises Exercises) *Service {
+	return &Service{store: store, exercises: exercises}
+}
+
+// linkExercises connects entries to the catalog. An explicit exercise_id
+// must be visible to userID and measured the same way as the entry, and
+// fills in exercise_name when that was omitted. A free-text name is linked
+// to the matching catalog entry when there is one with the same
+// measurement; otherwise it stays free text. Must run after reconcile, so
+// entries given only logged_sets already have their flat fields.
+func (s *Service) linkExercises(ctx context.Context, userID user.UserID, entries []WorkoutEntry) error {
+	var ids []exercise.ExerciseID
+	var names []string
+	for _, e := range entries {
+		if e.ExerciseID != nil {
+			ids = append(ids, *e.ExerciseID)
+		} else {
+			names = append(names, e.ExerciseName)
+		}
+	}
+
+	byID, err := s.exercises.ByID(ctx, userID, ids)
+	if err != nil {
+		return err
+	}
+	byName, err := s.exercises.Resolve(ctx, userID, names)
+	if err != nil {
+		return err
+	}
+
+	for i := range entries {
+		e := &entries[i]
+		if e.ExerciseID != nil {
+			ex, ok := byID[*e.ExerciseID]
+			if !ok {
+				return wrapValidation(fmt.Errorf("entries[%d]: exercise %d not found", i, *e.ExerciseID))
+			}
+			if ex.Measurement != e.measurement() {
+				return wrapValidation(fmt.Errorf("entries[%d]: %s is measured in %s", i, ex.Name, ex.Measurement))
+			}
+			if e.ExerciseName == "" {
+				e.ExerciseName = ex.Name
+			}
+			continue
+		}
+		if ex, ok := byName[exercise.NormalizeName(e.ExerciseName)]; ok && ex.Measurement == e.measurement() {
+			e.ExerciseID = &ex.ID
+		}
+	}
+	return nil
 }
 
 // CreateWorkoutCommand is the input to Service.Create. UserID is set by the
@@ -60,6 +173,8 @@ type CreateWorkoutCommand struct {
 	Description     string
 	DurationMinutes int
 	CaloriesBurned  int
+	Visibility      Visibility
+	TemplateID      *TemplateID
 	Entries         []WorkoutEntry
 }
 
@@ -70,16 +185,175 @@ func (s *Service) Create(ctx context.Context, cmd CreateWorkoutCommand) (*Workou
 		Description:     cmd.Description,
 		DurationMinutes: cmd.DurationMinutes,
 		CaloriesBurned:  cmd.CaloriesBurned,
+		Visibility:      cmd.Visibility,
+		TemplateID:      cmd.TemplateID,
 		Entries:         cmd.Entries,
 	}
+	if w.Visibility == "" {
+		w.Visibility = VisibilityPrivate
+	}
 	if err := w.Validate(); err != nil {
 		return nil, wrapValidation(err)
 	}
-	return s.store.CreateWorkout(ctx, w)
+	reconcileEntries(w.Entries)
+	if err := s.linkExercises(ctx, w.UserID, w.Entries); err != nil {
+		return nil, err
+	}
+	return s.store.CreateWorkout(ctx, w, recordCandidates(w.UserID, w.Entries))
+}
+
+// maxImportWorkouts bounds a single import so its transaction stays short.
+const maxImportWorkouts = 5000
+
+// ImportResult reports what a batch import did. A duplicate is a workout the
+// user already has with the same title and start time; it is not saved again,
+// which makes re-running an import harmless.
+type ImportResult struct {
+	Created    []*Workout
+	Duplicates []*Workout
+}
+
+// Import saves historical workouts for userID in one transaction. Each
+// workout must carry its CreatedAt and must already pass Validate (the
+// importer reports invalid rows itself); one that doesn't fails the whole
+// batch with ErrValidation. Workouts are saved oldest first so personal
+// records are awarded in the order they were actually set.
+func (s *Service) Import(ctx context.Context, userID user.UserID, workouts []*Workout) (*ImportResult, error) {
+	if len(workouts) > maxImportWorkouts {
+		return nil, wrapValidation(fmt.Errorf("an import may contain at most %d workouts", maxImportWorkouts))
+	}
+
+	var all []WorkoutEntry
+	for i, w := range workouts {
+		w.UserID = userID
+		if w.Visibility == "" {
+			w.Visibility = VisibilityPrivate
+		}
+		if w.CreatedAt.IsZero() {
+			return nil, wrapValidation(fmt.Errorf("workouts[%d]: a start time is required", i))
+		}
+		if err := w.Validate(); err != nil {
+			return nil, wrapValidation(fmt.Errorf("workouts[%d]: %w", i, err))
+		}
+		reconcileEntries(w.Entries)
+		all = append(all, w.Entries...)
+	}
+
+	// Link every entry in one pass instead of two lookups per workout.
+	if err := s.linkExercises(ctx, userID, all); err != nil {
+		return nil, err
+	}
+	for _, w := range workouts {
+		n := copy(w.Entries, all)
+		all = all[n:]
+	}
+
+	sort.SliceStable(workouts, func(i, j int) bool {
+		return workouts[i].CreatedAt.Before(workouts[j].CreatedAt)
+	})
+	candidates := make([][]PersonalRecord, len(workouts))
+	for i, w := range workouts {
+		candidates[i] = recordCandidates(userID, w.Entries)
+	}
+	return s.store.ImportWorkouts(ctx, workouts, candidates)
+}
+
+// Export streams every workout userID owns to fn, oldest first. See
+// Store.ExportWorkouts.
+func (s *Service) Export(ctx context.Context, userID user.UserID, fn func(*Workout) error) error {
+	return s.store.ExportWorkouts(ctx, userID, fn)
+}
+
+// Get returns the workout if viewerID is allowed to see it under the
+// workout's visibility, ErrNotFound otherwise. An account pending deletion
+// has no workouts to show.
+func (s *Service) Get(ctx context.Context, id WorkoutID, viewerID user.UserID) (*Workout, error) {
+	return s.store.GetWorkoutByID(ctx, id, viewerID)
+}
+
+// GetAny returns the workout whoever owns it and whatever its visibility,
+// or ErrNotFound. Only for callers that authorized the read themselves,
+// i.e. the admin API.
+func (s *Service) GetAny(ctx context.Context, id WorkoutID) (*Workout, error) {
+	return s.store.GetAnyWorkoutByID(ctx, id)
+}
+
+// Page size bounds for ListWorkouts. The cap keeps a single request from
+// dragging a user's whole history (and every entry) into memory.
+const (
+	defaultListLimit = 20
+	maxListLimit     = 100
+)
+
+// ListWorkoutsQuery is the input to Service.List. UserID comes from the
+// principal, so the listing is always scoped to the caller's own workouts.
+type ListWorkoutsQuery struct {
+	UserID user.UserID
+	Filter ListFilter
+	Sort   SortOrder
+	Limit  int
+	After  *Cursor
+}
+
+func (q *ListWorkoutsQuery) Validate() error {
+	switch q.Sort {
+	case "":
+		q.Sort = SortNewestFirst
+	case SortNewestFirst, SortOldestFirst:
+	default:
+		return fmt.Errorf("sort must be one of %q or %q", SortNewestFirst, SortOldestFirst)
+	}
+	if q.Limit == 0 {
+		q.Limit = defaultListLimit
+	}
+	if q.Limit < 1 || q.Limit > maxListLimit {
+		return fmt.Errorf("limit must be between 1 and %d", maxListLimit)
+	}
+	return q.Filter.Validate()
 }
 
-func (s *Service) Get(ctx context.Context, id WorkoutID) (*Workout, error) {
-	return s.store.GetWorkoutByID(ctx, id)
+// WorkoutPage is one page of ListWorkouts results. NextCursor is empty on
+// the last page.
+type WorkoutPage struct {
+	Workouts   []*Workout `json:"workouts"`
+	NextCursor string     `json:"next_cursor,omitempty"`
+}
+
+// List returns one page of the caller's workouts. An entry keeps the name
+// it was typed with after it is linked to the catalog, so the exercise
+// filter is resolved like an entry's name is: it matches entries linked to
+// that exercise, and by normalized name only entries that aren't linked.
+func (s *Service) List(ctx context.Context, q ListWorkoutsQuery) (*WorkoutPage, error) {
+	if err := q.Validate(); err != nil {
+		return nil, wrapValidation(err)
+	}
+	q.Filter.ExerciseID = nil
+	if q.Filter.Exercise != "" {
+		q.Filter.Exercise = exercise.NormalizeName(q.Filter.Exercise)
+		resolved, err := s.exercises.Resolve(ctx, q.UserID, []string{q.Filter.Exercise})
+		if err != nil {
+			return nil, err
+		}
+		if ex, ok := resolved[q.Filter.Exercise]; ok {
+			q.Filter.ExerciseID = &ex.ID
+		}
+	}
+
+	workouts, err := s.store.ListWorkouts(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &WorkoutPage{Workouts: workouts}
+	if len(workouts) > q.Limit {
+		page.Workouts = workouts[:q.Limit]
+		last := page.Workouts[q.Limit-1]
+		page.NextCursor = Cursor{CreatedAt: last.CreatedAt, ID: last.ID}.Encode()
+	}
+	if page.Workouts == nil {
+		page.Workouts = []*Workout{}
+	}
+	return page, nil
 }
 
 // UpdateWorkoutCommand carries the patch plus the acting user's id so the
@@ -94,9 +368,217 @@ func (s *Service) Update(ctx context.Context, cmd UpdateWorkoutCommand) (*Workou
 	if err := cmd.Patch.Validate(); err != nil {
 		return nil, wrapValidation(err)
 	}
-	return s.store.UpdateWorkout(ctx, cmd.WorkoutID, cmd.UserID, cmd.Patch)
+	var candidates []PersonalRecord
+	if cmd.Patch.Entries != nil {
+		reconcileEntries(*cmd.Patch.Entries)
+		if err := s.linkExercises(ctx, cmd.UserID, *cmd.Patch.Entries); err != nil {
+			return nil, err
+		}
+		candidates = recordCandidates(cmd.UserID, *cmd.Patch.Entries)
+	}
+	return s.store.UpdateWorkout(ctx, cmd.WorkoutID, cmd.UserID, cmd.Patch, candidates)
 }
 
 func (s *Service) Delete(ctx context.Context, workoutID WorkoutID, userID user.UserID) error {
 	return s.store.DeleteWorkout(ctx, workoutID, userID)
 }
+
+// ScopeWorkoutShare is the token scope used when minting share links. Share
+// tokens live in workout_shares rather than tokens, so the scope never
+// reaches the auth middleware; it only labels the generated value.
+const ScopeWorkoutShare = "workout-share"
+
+// maxShareTTL bounds how far in the future a share link may expire. Links
+// without a TTL never expire and must be revoked explicitly.
+const maxShareTTL = 365 * 24 * time.Hour
+
+// CreateShareCommand asks for a new share link on a workout the caller owns.
+// A nil ExpiresInHours creates a link that lives until revoked.
+type CreateShareCommand struct {
+	WorkoutID      WorkoutID
+	UserID         user.UserID
+	ExpiresInHours *int
+}
+
+// CreateShare mints a share link with the same generator (and hashing) used
+// for bearer tokens. The plaintext is returned on the Share exactly once.
+func (s *Service) CreateShare(ctx context.Context, cmd CreateShareCommand) (*Share, error) {
+	// Bound the hours before converting them, so a huge value can't
+	// overflow into an in-range Duration.
+	var ttl time.Duration
+	if cmd.ExpiresInHours != nil {
+		hours := *cmd.ExpiresInHours
+		if hours < 1 || hours > int(maxShareTTL/time.Hour) {
+			return nil, wrapValidation(fmt.Errorf("expires_in_hours must be between 1 and %d", int(maxShareTTL/time.Hour)))
+		}
+		ttl = time.Duration(hours) * time.Hour
+	}
+
+	token, err := auth.GenerateToken(cmd.UserID, ttl, ScopeWorkoutShare)
+	if err != nil {
+		return nil, fmt.Errorf("generate share token: %w", err)
+	}
+
+	share := &Share{WorkoutID: cmd.WorkoutID, Token: token.Plaintext}
+	if cmd.ExpiresInHours != nil {
+		share.Expiry = &token.Expiry
+	}
+	if err := s.store.CreateShare(ctx, share, token.Hash, cmd.UserID); err != nil {
+		return nil, err
+	}
+	return share, nil
+}
+
+func (s *Service) ListShares(ctx context.Context, workoutID WorkoutID, userID user.UserID) ([]*Share, error) {
+	return s.store.ListShares(ctx, workoutID, userID)
+}
+
+func (s *Service) RevokeShare(ctx context.Context, shareID ShareID, workoutID WorkoutID, userID user.UserID) error {
+	return s.store.DeleteShare(ctx, shareID, workoutID, userID)
+}
+
+// GetShared resolves a share-link plaintext to its workout. Unknown, revoked
+// and expired links are all ErrNotFound.
+func (s *Service) GetShared(ctx context.Context, plaintext string) (*Workout, error) {
+	if plaintext == "" {
+		return nil, ErrNotFound
+	}
+	return s.store.GetWorkoutByShareHash(ctx, auth.HashPlaintext(plaintext))
+}
+
+// CreateTemplateCommand is the input to Service.CreateTemplate. UserID comes
+// from the principal, as with CreateWorkoutCommand.
+type CreateTemplateCommand struct {
+	UserID      user.UserID
+	Name        string
+	Description string
+	Entries     []WorkoutEntry
+}
+
+func (s *Service) CreateTemplate(ctx context.Context, cmd CreateTemplateCommand) (*Template, error) {
+	t := &Template{
+		UserID:      cmd.UserID,
+		Name:        cmd.Name,
+		Description: cmd.Description,
+		Entries:     cmd.Entries,
+	}
+	if err := t.Validate(); err != nil {
+		return nil, wrapValidation(err)
+	}
+	if err := s.linkExercises(ctx, t.UserID, t.Entries); err != nil {
+		return nil, err
+	}
+	return s.store.CreateTemplate(ctx, t)
+}
+
+func (s *Service) GetTemplate(ctx context.Context, id TemplateID, userID user.UserID) (*Template, error) {
+	return s.store.GetTemplate(ctx, id, userID)
+}
+
+func (s *Service) ListTemplates(ctx context.Context, userID user.UserID) ([]*Template, error) {
+	return s.store.ListTemplates(ctx, userID)
+}
+
+type UpdateTemplateCommand struct {
+	TemplateID TemplateID
+	UserID     user.UserID
+	Patch      TemplatePatch
+}
+
+func (s *Service) UpdateTemplate(ctx context.Context, cmd UpdateTemplateCommand) (*Template, error) {
+	if err := cmd.Patch.Validate(); err != nil {
+		return nil, wrapValidation(err)
+	}
+	if cmd.Patch.Entries != nil {
+		if err := s.linkExercises(ctx, cmd.UserID, *cmd.Patch.Entries); err != nil {
+			return nil, err
+		}
+	}
+	return s.store.UpdateTemplate(ctx, cmd.TemplateID, cmd.UserID, cmd.Patch)
+}
+
+func (s *Service) DeleteTemplate(ctx context.Context, id TemplateID, userID user.UserID) error {
+	return s.store.DeleteTemplate(ctx, id, userID)
+}
+
+// TemplatesByID loads templates regardless of owner, keyed by id. It exists
+// for other bounded contexts (programs) that reference templates and apply
+// their own authorization; handlers in this package must not expose it.
+func (s *Service) TemplatesByID(ctx context.Context, ids []TemplateID) (map[TemplateID]*Template, error) {
+	templates, err := s.store.GetTemplatesByID(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[TemplateID]*Template, len(templates))
+	for _, t := range templates {
+		byID[t.ID] = t
+	}
+	return byID, nil
+}
+
+// StartTemplateCommand starts a new workout from a template the caller owns.
+type StartTemplateCommand struct {
+	TemplateID TemplateID
+	UserID     user.UserID
+}
+
+// StartTemplate copies the template's prescription into a fresh workout and
+// persists it through Create, so the new workout gets exactly the same
+// validation and defaults as one POSTed by hand. The copy drops entry ids;
+// the workout records which template it came from.
+func (s *Service) StartTemplate(ctx context.Context, cmd StartTemplateCommand) (*Workout, error) {
+	t, err := s.store.GetTemplate(ctx, cmd.TemplateID, cmd.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]WorkoutEntry, len(t.Entries))
+	for i, e := range t.Entries {
+		e.ID = 0
+		entries[i] = e
+	}
+
+	return s.Create(ctx, CreateWorkoutCommand{
+		UserID:      cmd.UserID,
+		Title:       t.Name,
+		Description: t.Description,
+		TemplateID:  &t.ID,
+		Entries:     entries,
+	})
+}
+
+// Records returns the caller's standing personal records across every
+// exercise.
+func (s *Service) Records(ctx context.Context, userID user.UserID) ([]*PersonalRecord, error) {
+	return s.store.ListCurrentRecords(ctx, userID)
+}
+
+// RecordHistory returns every record the caller has set on one exercise,
+// oldest first. ref is a catalog exercise id or a free-text name; a name that
+// resolves to the catalog also covers the records stored under that
+// exercise's id. An exercise with no records is ErrNotFound.
+func (s *Service) RecordHistory(ctx context.Context, userID user.UserID, ref string) ([]*PersonalRecord, error) {
+	var keys []string
+	if id, err := strconv.ParseInt(ref, 10, 64); err == nil {
+		exID := exercise.ExerciseID(id)
+		keys = append(keys, exerciseKey(&exID, ""))
+	} else {
+		keys = append(keys, exerciseKey(nil, ref))
+		resolved, err := s.exercises.Resolve(ctx, userID, []string{ref})
+		if err != nil {
+			return nil, err
+		}
+		if ex, ok := resolved[exercise.NormalizeName(ref)]; ok {
+			keys = append(keys, exerciseKey(&ex.ID, ""))
+		}
+	}
+
+	records, err := s.store.ListRecordHistory(ctx, userID, keys)
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, ErrNotFound
+	}
+	return records, nil
+}
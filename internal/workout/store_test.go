@@ -3,43 +3,26 @@ package workout
 import (
 	"context"
 	"database/sql"
-	"os"
+	"math"
 	"testing"
+	"time"
 
-	_ "github.com/jackc/pgx/v5/stdlib"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
-	"github.com/tsatsarisg/go-fit/internal/platform/postgres"
+	"github.com/tsatsarisg/go-fit/internal/exercise"
+	"github.com/tsatsarisg/go-fit/internal/platform/postgres/pgtest"
 	"github.com/tsatsarisg/go-fit/internal/user"
 )
 
-const defaultTestDSN = "host=localhost port=5433 user=postgres password=postgres dbname=postgres sslmode=disable"
-
 // setupTestDB wipes the workouts-related tables and seeds a single user so
 // the FK on workouts.user_id can be satisfied. Returns the seeded user's id.
 func setupTestDB(t *testing.T) (*sql.DB, user.UserID) {
-	dsn := os.Getenv("TEST_DATABASE_URL")
-	if dsn == "" {
-		dsn = defaultTestDSN
-	}
-	db, err := sql.Open("pgx", dsn)
-	if err != nil {
-		t.Fatalf("failed to connect to test database: %v", err)
-	}
-
-	err = postgres.Migrate(db, "../../migrations/")
-	if err != nil {
-		t.Fatalf("failed to run migrations: %v", err)
-	}
+	db := pgtest.Open(t, "workout", "workout_entries", "workouts", "users")
 
 	ctx := context.Background()
-	// Wipe workouts + entries + test user so each run starts clean.
-	if _, err := db.ExecContext(ctx, `TRUNCATE TABLE workout_entries, workouts, users RESTART IDENTITY CASCADE;`); err != nil {
-		t.Fatalf("failed to truncate tables: %v", err)
-	}
-
 	var userID user.UserID
-	err = db.QueryRowContext(ctx, `
+	err := db.QueryRowContext(ctx, `
 		INSERT INTO users (username, email, password_hash, bio)
 		VALUES ($1, $2, $3, $4)
 		RETURNING id`,
@@ -52,6 +35,17 @@ func setupTestDB(t *testing.T) (*sql.DB, user.UserID) {
 	return db, userID
 }
 
+// seedUser adds another user next to the one setupTestDB seeds.
+func seedUser(t *testing.T, db *sql.DB, name string) user.UserID {
+	var id user.UserID
+	err := db.QueryRowContext(context.Background(), `
+		INSERT INTO users (username, email, password_hash, bio)
+		VALUES ($1, $2, 'unused-hash', '')
+		RETURNING id`, name, name+"@example.com").Scan(&id)
+	require.NoError(t, err)
+	return id
+}
+
 func TestCreate(t *testing.T) {
 	db, userID := setupTestDB(t)
 	defer db.Close()
@@ -69,6 +63,7 @@ func TestCreate(t *testing.T) {
 				Description:     "A quick morning workout",
 				DurationMinutes: 30,
 				CaloriesBurned:  250,
+				Visibility:      VisibilityPrivate,
 				Entries: []WorkoutEntry{
 					{
 						ExerciseName: "Push Ups",
@@ -96,6 +91,7 @@ func TestCreate(t *testing.T) {
 				Description:     "A quick evening workout",
 				DurationMinutes: 20,
 				CaloriesBurned:  200,
+				Visibility:      VisibilityPrivate,
 				Entries: []WorkoutEntry{
 					{
 						ExerciseName: "Squats",
@@ -115,7 +111,7 @@ func TestCreate(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			ctx := context.Background()
 			tt.workout.UserID = userID
-			createdWorkout, err := store.CreateWorkout(ctx, tt.workout)
+			createdWorkout, err := store.CreateWorkout(ctx, tt.workout, nil)
 			if tt.wantErr {
 				assert.Error(t, err)
 				return
@@ -128,11 +124,18 @@ func TestCreate(t *testing.T) {
 			assert.Equal(t, tt.workout.CaloriesBurned, createdWorkout.CaloriesBurned)
 			assert.Len(t, createdWorkout.Entries, len(tt.workout.Entries))
 
-			retrievedWorkout, err := store.GetWorkoutByID(ctx, createdWorkout.ID)
+			retrievedWorkout, err := store.GetWorkoutByID(ctx, createdWorkout.ID, userID)
 			assert.NoError(t, err)
 			assert.Equal(t, createdWorkout, retrievedWorkout)
 			assert.Equal(t, len(tt.workout.Entries), len(retrievedWorkout.Entries))
 
+			// Private: hidden from anyone else, but not from the admin read.
+			_, err = store.GetWorkoutByID(ctx, createdWorkout.ID, userID+1)
+			assert.ErrorIs(t, err, ErrNotFound)
+			anyWorkout, err := store.GetAnyWorkoutByID(ctx, createdWorkout.ID)
+			assert.NoError(t, err)
+			assert.Equal(t, createdWorkout, anyWorkout)
+
 			for i, entry := range createdWorkout.Entries {
 				expectedEntry := tt.workout.Entries[i]
 				assert.NotZero(t, entry.ID)
@@ -149,9 +152,646 @@ func TestCreate(t *testing.T) {
 	}
 }
 
+func TestListWorkouts(t *testing.T) {
+	db, userID := setupTestDB(t)
+	defer db.Close()
+
+	store := NewPostgresStore(db)
+	svc := NewService(store, exercise.NewService(exercise.NewPostgresStore(db)))
+	ctx := context.Background()
+
+	titles := []string{"Leg Day", "Push Day", "Pull Day", "Leg Day 2"}
+	for i, title := range titles {
+		_, err := store.CreateWorkout(ctx, &Workout{
+			UserID:          userID,
+			Title:           title,
+			DurationMinutes: 30 + i*10,
+			Visibility:      VisibilityPrivate,
+			Entries: []WorkoutEntry{
+				{ExerciseName: "Squat", Sets: 3, Reps: ptrInt(5), OrderIndex: 0},
+			},
+		}, nil)
+		assert.NoError(t, err)
+	}
+
+	t.Run("pages newest first", func(t *testing.T) {
+		first, err := svc.List(ctx, ListWorkoutsQuery{UserID: userID, Limit: 3})
+		assert.NoError(t, err)
+		assert.Len(t, first.Workouts, 3)
+		assert.Equal(t, "Leg Day 2", first.Workouts[0].Title)
+		assert.NotEmpty(t, first.NextCursor)
+		assert.Len(t, first.Workouts[0].Entries, 1)
+
+		cursor, err := DecodeCursor(first.NextCursor)
+		assert.NoError(t, err)
+		second, err := svc.List(ctx, ListWorkoutsQuery{UserID: userID, Limit: 3, After: cursor})
+		assert.NoError(t, err)
+		assert.Len(t, second.Workouts, 1)
+		assert.Equal(t, "Leg Day", second.Workouts[0].Title)
+		assert.Empty(t, second.NextCursor)
+	})
+
+	t.Run("filters by title and duration", func(t *testing.T) {
+		page, err := svc.List(ctx, ListWorkoutsQuery{
+			UserID: userID,
+			Sort:   SortOldestFirst,
+			Filter: ListFilter{Title: "leg", MinDuration: ptrInt(40)},
+		})
+		assert.NoError(t, err)
+		assert.Len(t, page.Workouts, 1)
+		assert.Equal(t, "Leg Day 2", page.Workouts[0].Title)
+	})
+
+	t.Run("filters by exercise", func(t *testing.T) {
+		page, err := svc.List(ctx, ListWorkoutsQuery{UserID: userID, Filter: ListFilter{Exercise: "squat"}})
+		assert.NoError(t, err)
+		assert.Len(t, page.Workouts, len(titles))
+	})
+
+	t.Run("rejects oversized limit", func(t *testing.T) {
+		_, err := svc.List(ctx, ListWorkoutsQuery{UserID: userID, Limit: maxListLimit + 1})
+		assert.ErrorIs(t, err, ErrValidation)
+	})
+}
+
+func TestLoggedSets(t *testing.T) {
+	db, userID := setupTestDB(t)
+	defer db.Close()
+
+	store := NewPostgresStore(db)
+	svc := NewService(store, exercise.NewService(exercise.NewPostgresStore(db)))
+	ctx := context.Background()
+
+	t.Run("derives flat fields from sets", func(t *testing.T) {
+		created, err := svc.Create(ctx, CreateWorkoutCommand{
+			UserID: userID,
+			Title:  "Pyramid",
+			Entries: []WorkoutEntry{{
+				ExerciseName: "Bench Press",
+				LoggedSets: []WorkoutSet{
+					{Type: SetTypeWarmup, Reps: ptrInt(10), Weight: ptrFloat64(40), Completed: true},
+					{Reps: ptrInt(5), Weight: ptrFloat64(80), RPE: ptrFloat64(8), Completed: true},
+					{Type: SetTypeFailure, Reps: ptrInt(3), Weight: ptrFloat64(85), Completed: false},
+				},
+			}},
+		})
+		assert.NoError(t, err)
+
+		got, err := store.GetWorkoutByID(ctx, created.ID, userID)
+		assert.NoError(t, err)
+		entry := got.Entries[0]
+		assert.Equal(t, 2, entry.Sets)
+		assert.Equal(t, 3, *entry.Reps)
+		assert.InDelta(t, 85.0, *entry.Weight, 0.001)
+		assert.Len(t, entry.LoggedSets, 3)
+		assert.Equal(t, SetTypeWorking, entry.LoggedSets[1].Type)
+		assert.InDelta(t, 8.0, *entry.LoggedSets[1].RPE, 0.001)
+		assert.False(t, entry.LoggedSets[2].Completed)
+	})
+
+	t.Run("expands flat fields into sets", func(t *testing.T) {
+		created, err := svc.Create(ctx, CreateWorkoutCommand{
+			UserID:  userID,
+			Title:   "Legacy",
+			Entries: []WorkoutEntry{{ExerciseName: "Squat", Sets: 3, Reps: ptrInt(5), Weight: ptrFloat64(100)}},
+		})
+		assert.NoError(t, err)
+
+		got, err := store.GetWorkoutByID(ctx, created.ID, userID)
+		assert.NoError(t, err)
+		assert.Len(t, got.Entries[0].LoggedSets, 3)
+		assert.Equal(t, SetTypeWorking, got.Entries[0].LoggedSets[0].Type)
+	})
+
+	t.Run("rejects mixed measures", func(t *testing.T) {
+		_, err := svc.Create(ctx, CreateWorkoutCommand{
+			UserID: userID,
+			Title:  "Mixed",
+			Entries: []WorkoutEntry{{
+				ExerciseName: "Plank",
+				LoggedSets: []WorkoutSet{
+					{Reps: ptrInt(5)},
+					{DurationSeconds: ptrInt(60)},
+				},
+			}},
+		})
+		assert.ErrorIs(t, err, ErrValidation)
+	})
+}
+
+func TestExerciseLinking(t *testing.T) {
+	db, userID := setupTestDB(t)
+	defer db.Close()
+
+	exercises := exercise.NewService(exercise.NewPostgresStore(db))
+	svc := NewService(NewPostgresStore(db), exercises)
+	ctx := context.Background()
+
+	press, err := exercises.Create(ctx, exercise.CreateExerciseCommand{
+		UserID:          userID,
+		Name:            "Landmine Press",
+		Aliases:         []string{"LM Press"},
+		PrimaryMuscles:  []string{"front_delts"},
+		Equipment:       "barbell",
+		MovementPattern: "vertical_push",
+		Measurement:     exercise.MeasurementReps,
+	})
+	assert.NoError(t, err)
+
+	t.Run("resolves free-text names and aliases", func(t *testing.T) {
+		created, err := svc.Create(ctx, CreateWorkoutCommand{
+			UserID: userID,
+			Title:  "Shoulders",
+			Entries: []WorkoutEntry{
+				{ExerciseName: "lm  press", Sets: 3, Reps: ptrInt(8)},
+				{ExerciseName: "Something Else", Sets: 1, Reps: ptrInt(8), OrderIndex: 1},
+			},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, press.ID, *created.Entries[0].ExerciseID)
+		assert.Nil(t, created.Entries[1].ExerciseID)
+	})
+
+	t.Run("fills the name from exercise_id", func(t *testing.T) {
+		created, err := svc.Create(ctx, CreateWorkoutCommand{
+			UserID:  userID,
+			Title:   "By id",
+			Entries: []WorkoutEntry{{ExerciseID: &press.ID, Sets: 3, Reps: ptrInt(8)}},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "Landmine Press", created.Entries[0].ExerciseName)
+	})
+
+	t.Run("filters by the linked exercise, whatever its typed name", func(t *testing.T) {
+		for _, name := range []string{"Landmine Press", "LM press"} {
+			page, err := svc.List(ctx, ListWorkoutsQuery{UserID: userID, Sort: SortOldestFirst, Filter: ListFilter{Exercise: name}})
+			assert.NoError(t, err)
+			if assert.Len(t, page.Workouts, 2, name) {
+				assert.Equal(t, "Shoulders", page.Workouts[0].Title)
+				assert.Equal(t, "By id", page.Workouts[1].Title)
+			}
+		}
+	})
+
+	t.Run("rejects a measurement mismatch", func(t *testing.T) {
+		_, err := svc.Create(ctx, CreateWorkoutCommand{
+			UserID:  userID,
+			Title:   "Mismatch",
+			Entries: []WorkoutEntry{{ExerciseID: &press.ID, Sets: 1, DurationSeconds: ptrInt(30)}},
+		})
+		assert.ErrorIs(t, err, ErrValidation)
+	})
+}
+
+func TestPersonalRecords(t *testing.T) {
+	db, userID := setupTestDB(t)
+	defer db.Close()
+
+	svc := NewService(NewPostgresStore(db), exercise.NewService(exercise.NewPostgresStore(db)))
+	ctx := context.Background()
+
+	squat := func(weight float64, reps int) CreateWorkoutCommand {
+		return CreateWorkoutCommand{
+			UserID: userID,
+			Title:  "Squats",
+			Entries: []WorkoutEntry{{
+				ExerciseName: "Box Squat",
+				LoggedSets:   []WorkoutSet{{Reps: ptrInt(reps), Weight: ptrFloat64(weight), Completed: true}},
+			}},
+		}
+	}
+
+	first, err := svc.Create(ctx, squat(100, 5))
+	assert.NoError(t, err)
+	assert.Len(t, first.NewRecords, 3) // heaviest, most reps at 100, e1RM
+
+	second, err := svc.Create(ctx, squat(100, 3))
+	assert.NoError(t, err)
+	assert.Empty(t, second.NewRecords)
+
+	third, err := svc.Create(ctx, squat(105, 3))
+	assert.NoError(t, err)
+	types := make([]RecordType, 0, len(third.NewRecords))
+	for _, r := range third.NewRecords {
+		types = append(types, r.Type)
+	}
+	assert.ElementsMatch(t, []RecordType{RecordHeaviestWeight, RecordMostReps}, types)
+
+	history, err := svc.RecordHistory(ctx, userID, "box squat")
+	assert.NoError(t, err)
+	assert.Len(t, history, 5)
+
+	assert.NoError(t, svc.Delete(ctx, third.ID, userID))
+	current, err := svc.Records(ctx, userID)
+	assert.NoError(t, err)
+	for _, r := range current {
+		if r.Type == RecordHeaviestWeight {
+			assert.Equal(t, first.ID, r.WorkoutID)
+		}
+	}
+}
+
+// TestRecordRecompute covers the records a removed or lowered one was
+// hiding: a set that never beat the record was never stored, and must be
+// recovered from the workouts.
+func TestRecordRecompute(t *testing.T) {
+	db, userID := setupTestDB(t)
+	defer db.Close()
+
+	svc := NewService(NewPostgresStore(db), exercise.NewService(exercise.NewPostgresStore(db)))
+	ctx := context.Background()
+
+	sets := func(weight float64) []WorkoutEntry {
+		return []WorkoutEntry{{
+			ExerciseName: "Box Squat",
+			LoggedSets:   []WorkoutSet{{Reps: ptrInt(1), Weight: ptrFloat64(weight), Completed: true}},
+		}}
+	}
+	heaviest := func() *PersonalRecord {
+		current, err := svc.Records(ctx, userID)
+		require.NoError(t, err)
+		for _, r := range current {
+			if r.Type == RecordHeaviestWeight {
+				return r
+			}
+		}
+		return nil
+	}
+
+	a, err := svc.Create(ctx, CreateWorkoutCommand{UserID: userID, Title: "A", Entries: sets(100)})
+	require.NoError(t, err)
+	b, err := svc.Create(ctx, CreateWorkoutCommand{UserID: userID, Title: "B", Entries: sets(95)})
+	require.NoError(t, err)
+	assert.Empty(t, b.NewRecords, "95 doesn't beat 100")
+
+	// Patching A down uncovers B's 95.
+	entries := sets(80)
+	patched, err := svc.Update(ctx, UpdateWorkoutCommand{WorkoutID: a.ID, UserID: userID, Patch: WorkoutPatch{Entries: &entries}})
+	require.NoError(t, err)
+	assert.NotEmpty(t, patched.NewRecords, "80 is still the first squat")
+	if r := heaviest(); assert.NotNil(t, r) {
+		assert.Equal(t, b.ID, r.WorkoutID)
+		assert.Equal(t, 95.0, r.Value)
+	}
+
+	// And back up: B is no record again.
+	entries = sets(100)
+	_, err = svc.Update(ctx, UpdateWorkoutCommand{WorkoutID: a.ID, UserID: userID, Patch: WorkoutPatch{Entries: &entries}})
+	require.NoError(t, err)
+	history, err := svc.RecordHistory(ctx, userID, "box squat")
+	require.NoError(t, err)
+	for _, r := range history {
+		if r.Type != RecordMostReps { // tracked per weight, so B holds its own
+			assert.Equal(t, a.ID, r.WorkoutID)
+		}
+	}
+
+	// Deleting A leaves B's 95 as the record.
+	require.NoError(t, svc.Delete(ctx, a.ID, userID))
+	if r := heaviest(); assert.NotNil(t, r) {
+		assert.Equal(t, b.ID, r.WorkoutID)
+	}
+
+	// A backdated workout takes the credit from later ones.
+	result, err := svc.Import(ctx, userID, []*Workout{{Title: "Old", CreatedAt: b.CreatedAt.AddDate(0, -1, 0), Entries: sets(120)}})
+	require.NoError(t, err)
+	require.Len(t, result.Created, 1)
+	assert.NotEmpty(t, result.Created[0].NewRecords)
+	history, err = svc.RecordHistory(ctx, userID, "box squat")
+	require.NoError(t, err)
+	for _, r := range history {
+		if r.Type != RecordMostReps {
+			assert.Equal(t, result.Created[0].ID, r.WorkoutID, "95 beats nothing that came before it")
+		}
+	}
+}
+
+func TestImport(t *testing.T) {
+	db, userID := setupTestDB(t)
+	defer db.Close()
+
+	svc := NewService(NewPostgresStore(db), exercise.NewService(exercise.NewPostgresStore(db)))
+	ctx := context.Background()
+
+	batch := func() []*Workout {
+		at := func(day int) time.Time { return time.Date(2024, 4, day, 18, 30, 0, 0, time.UTC) }
+		entry := func(weight float64) []WorkoutEntry {
+			return []WorkoutEntry{{
+				ExerciseName: "Box Squat",
+				LoggedSets:   []WorkoutSet{{Reps: ptrInt(5), Weight: ptrFloat64(weight), Completed: true}},
+			}}
+		}
+		// Out of order on purpose: records must follow the dates.
+		return []*Workout{
+			{Title: "Legs", CreatedAt: at(28), Entries: entry(110)},
+			{Title: "Legs", CreatedAt: at(26), Entries: entry(100)},
+		}
+	}
+
+	result, err := svc.Import(ctx, userID, batch())
+	assert.NoError(t, err)
+	assert.Len(t, result.Created, 2)
+	assert.Empty(t, result.Duplicates)
+	assert.Equal(t, 26, result.Created[0].CreatedAt.Day())
+	assert.Len(t, result.Created[1].NewRecords, 3)
+
+	again, err := svc.Import(ctx, userID, batch())
+	assert.NoError(t, err)
+	assert.Empty(t, again.Created)
+	assert.Len(t, again.Duplicates, 2)
+
+	_, err = svc.Import(ctx, userID, []*Workout{{Title: "No date"}})
+	assert.ErrorIs(t, err, ErrValidation)
+}
+
+func TestExportWorkouts(t *testing.T) {
+	db, userID := setupTestDB(t)
+	defer db.Close()
+
+	store := NewPostgresStore(db)
+	ctx := context.Background()
+
+	// More workouts than one cursor batch, saved newest first.
+	total := exportBatchSize + 5
+	base := time.Date(2024, 1, 1, 7, 0, 0, 0, time.UTC)
+	for i := total - 1; i >= 0; i-- {
+		_, err := store.CreateWorkout(ctx, &Workout{
+			UserID:     userID,
+			Title:      "Run",
+			Visibility: VisibilityPrivate,
+			CreatedAt:  base.AddDate(0, 0, i),
+			Entries: []WorkoutEntry{{
+				ExerciseName:    "Running",
+				Sets:            1,
+				DurationSeconds: ptrInt(1800),
+				LoggedSets:      []WorkoutSet{{Type: SetTypeWorking, DurationSeconds: ptrInt(1800), Completed: true}},
+			}},
+		}, nil)
+		assert.NoError(t, err)
+	}
+
+	var seen []*Workout
+	err := store.ExportWorkouts(ctx, userID, func(w *Workout) error {
+		seen = append(seen, w)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Len(t, seen, total)
+	for i, w := range seen {
+		assert.True(t, base.AddDate(0, 0, i).Equal(w.CreatedAt), "oldest first")
+		if assert.Len(t, w.Entries, 1) {
+			assert.Len(t, w.Entries[0].LoggedSets, 1)
+		}
+	}
+}
+
+func TestEstimateOneRepMax(t *testing.T) {
+	assert.Equal(t, 100.0, estimateOneRepMax(100, 1))
+	assert.InDelta(t, 112.5, estimateOneRepMax(100, 5), 0.01)
+	assert.InDelta(t, 140.0, estimateOneRepMax(100, 12), 0.01)
+}
+
 func ptrInt(i int) *int {
 	return &i
 }
 func ptrFloat64(f float64) *float64 {
 	return &f
 }
+
+// TestVisibility reads one user's workouts as another. A workout the viewer
+// may not see is ErrNotFound, never ErrForbidden, so its id can't be probed.
+func TestVisibility(t *testing.T) {
+	db, ownerID := setupTestDB(t)
+	defer db.Close()
+
+	ctx := context.Background()
+	svc := NewService(NewPostgresStore(db), exercise.NewService(exercise.NewPostgresStore(db)))
+	viewerID := seedUser(t, db, "viewer")
+
+	create := func(v Visibility) *Workout {
+		w, err := svc.Create(ctx, CreateWorkoutCommand{
+			UserID:     ownerID,
+			Title:      string(v),
+			Visibility: v,
+			Entries:    []WorkoutEntry{{ExerciseName: "Push Ups", Sets: 3, Reps: ptrInt(10)}},
+		})
+		require.NoError(t, err)
+		return w
+	}
+	private, followers, public := create(VisibilityPrivate), create(VisibilityFollowers), create(VisibilityPublic)
+
+	for _, w := range []*Workout{private, followers, public} {
+		got, err := svc.Get(ctx, w.ID, ownerID)
+		require.NoError(t, err, "the owner reads %s", w.Visibility)
+		assert.Len(t, got.Entries, 1)
+	}
+
+	_, err := svc.Get(ctx, private.ID, viewerID)
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.NotErrorIs(t, err, ErrForbidden)
+	_, err = svc.Get(ctx, followers.ID, viewerID)
+	assert.ErrorIs(t, err, ErrNotFound, "not a follower yet")
+	got, err := svc.Get(ctx, public.ID, viewerID)
+	require.NoError(t, err)
+	assert.Equal(t, public.ID, got.ID)
+
+	_, err = db.ExecContext(ctx, `INSERT INTO follows (follower_id, followee_id) VALUES ($1, $2)`, viewerID, ownerID)
+	require.NoError(t, err)
+	_, err = svc.Get(ctx, followers.ID, viewerID)
+	assert.NoError(t, err, "a follower reads followers-only workouts")
+	_, err = svc.Get(ctx, private.ID, viewerID)
+	assert.ErrorIs(t, err, ErrNotFound, "but still not private ones")
+
+	_, err = svc.Get(ctx, private.ID+1000, viewerID)
+	assert.ErrorIs(t, err, ErrNotFound, "the same answer as a workout that doesn't exist")
+}
+
+func TestShares(t *testing.T) {
+	db, ownerID := setupTestDB(t)
+	defer db.Close()
+
+	ctx := context.Background()
+	svc := NewService(NewPostgresStore(db), exercise.NewService(exercise.NewPostgresStore(db)))
+	otherID := seedUser(t, db, "other")
+
+	w, err := svc.Create(ctx, CreateWorkoutCommand{
+		UserID:  ownerID,
+		Title:   "Private session",
+		Entries: []WorkoutEntry{{ExerciseName: "Squat", Sets: 5, Reps: ptrInt(5)}},
+	})
+	require.NoError(t, err)
+	require.Equal(t, VisibilityPrivate, w.Visibility)
+
+	for _, hours := range []int{0, -1, 365*24 + 1, math.MaxInt} {
+		_, err := svc.CreateShare(ctx, CreateShareCommand{WorkoutID: w.ID, UserID: ownerID, ExpiresInHours: &hours})
+		assert.ErrorIs(t, err, ErrValidation, "%d hours", hours)
+	}
+	_, err = svc.CreateShare(ctx, CreateShareCommand{WorkoutID: w.ID, UserID: otherID})
+	assert.ErrorIs(t, err, ErrForbidden)
+	_, err = svc.CreateShare(ctx, CreateShareCommand{WorkoutID: w.ID + 1000, UserID: ownerID})
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	forever, err := svc.CreateShare(ctx, CreateShareCommand{WorkoutID: w.ID, UserID: ownerID})
+	require.NoError(t, err)
+	assert.NotEmpty(t, forever.Token)
+	assert.Nil(t, forever.Expiry)
+	hours := 72
+	timed, err := svc.CreateShare(ctx, CreateShareCommand{WorkoutID: w.ID, UserID: ownerID, ExpiresInHours: &hours})
+	require.NoError(t, err)
+	require.NotNil(t, timed.Expiry)
+	assert.WithinDuration(t, time.Now().Add(72*time.Hour), *timed.Expiry, time.Minute)
+
+	shares, err := svc.ListShares(ctx, w.ID, ownerID)
+	require.NoError(t, err)
+	require.Len(t, shares, 2)
+	assert.Empty(t, shares[0].Token, "the plaintext is only shown at creation")
+	_, err = svc.ListShares(ctx, w.ID, otherID)
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	// Anyone with a link reads the private workout.
+	for _, sh := range []*Share{forever, timed} {
+		got, err := svc.GetShared(ctx, sh.Token)
+		require.NoError(t, err)
+		assert.Equal(t, w.ID, got.ID)
+		assert.Len(t, got.Entries, 1)
+	}
+	_, err = svc.GetShared(ctx, "not-a-share-token")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	_, err = db.ExecContext(ctx, `UPDATE workout_shares SET expiry = now() - interval '1 minute' WHERE id = $1`, timed.ID)
+	require.NoError(t, err)
+	_, err = svc.GetShared(ctx, timed.Token)
+	assert.ErrorIs(t, err, ErrNotFound, "expired")
+
+	assert.ErrorIs(t, svc.RevokeShare(ctx, forever.ID, w.ID, otherID), ErrForbidden)
+	require.NoError(t, svc.RevokeShare(ctx, forever.ID, w.ID, ownerID))
+	_, err = svc.GetShared(ctx, forever.Token)
+	assert.ErrorIs(t, err, ErrNotFound, "revoked")
+	assert.ErrorIs(t, svc.RevokeShare(ctx, forever.ID, w.ID, ownerID), ErrNotFound, "already revoked")
+
+	shares, err = svc.ListShares(ctx, w.ID, ownerID)
+	require.NoError(t, err)
+	assert.Len(t, shares, 1, "an expired link is still listed")
+}
+
+func TestTemplates(t *testing.T) {
+	db, ownerID := setupTestDB(t)
+	defer db.Close()
+
+	ctx := context.Background()
+	svc := NewService(NewPostgresStore(db), exercise.NewService(exercise.NewPostgresStore(db)))
+	otherID := seedUser(t, db, "other")
+
+	_, err := svc.CreateTemplate(ctx, CreateTemplateCommand{UserID: ownerID})
+	assert.ErrorIs(t, err, ErrValidation, "a name is required")
+	_, err = svc.CreateTemplate(ctx, CreateTemplateCommand{
+		UserID:  ownerID,
+		Name:    "Logged",
+		Entries: []WorkoutEntry{{ExerciseName: "Squat", Sets: 1, LoggedSets: []WorkoutSet{{Reps: ptrInt(5)}}}},
+	})
+	assert.ErrorIs(t, err, ErrValidation, "templates prescribe, they don't log")
+
+	tmpl, err := svc.CreateTemplate(ctx, CreateTemplateCommand{
+		UserID:      ownerID,
+		Name:        "Push day",
+		Description: "Chest and shoulders",
+		Entries: []WorkoutEntry{
+			{ExerciseName: "Bench Press", Sets: 5, Reps: ptrInt(5), Weight: ptrFloat64(80)},
+			{ExerciseName: "Plank", Sets: 3, DurationSeconds: ptrInt(60)},
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, tmpl.Entries, 2)
+
+	got, err := svc.GetTemplate(ctx, tmpl.ID, ownerID)
+	require.NoError(t, err)
+	assert.Equal(t, "Push day", got.Name)
+	assert.Equal(t, 80.0, *got.Entries[0].Weight)
+	_, err = svc.GetTemplate(ctx, tmpl.ID, otherID)
+	assert.ErrorIs(t, err, ErrTemplateNotFound, "templates are private")
+
+	list, err := svc.ListTemplates(ctx, ownerID)
+	require.NoError(t, err)
+	assert.Len(t, list, 1)
+	list, err = svc.ListTemplates(ctx, otherID)
+	require.NoError(t, err)
+	assert.Empty(t, list)
+
+	name := "Heavy push day"
+	entries := []WorkoutEntry{{ExerciseName: "Bench Press", Sets: 3, Reps: ptrInt(3), Weight: ptrFloat64(90)}}
+	_, err = svc.UpdateTemplate(ctx, UpdateTemplateCommand{TemplateID: tmpl.ID, UserID: otherID, Patch: TemplatePatch{Name: &name}})
+	assert.ErrorIs(t, err, ErrForbidden)
+	empty := ""
+	_, err = svc.UpdateTemplate(ctx, UpdateTemplateCommand{TemplateID: tmpl.ID, UserID: ownerID, Patch: TemplatePatch{Name: &empty}})
+	assert.ErrorIs(t, err, ErrValidation)
+	updated, err := svc.UpdateTemplate(ctx, UpdateTemplateCommand{TemplateID: tmpl.ID, UserID: ownerID, Patch: TemplatePatch{Name: &name, Entries: &entries}})
+	require.NoError(t, err)
+	assert.Equal(t, name, updated.Name)
+	assert.Equal(t, "Chest and shoulders", updated.Description, "untouched by the patch")
+	require.Len(t, updated.Entries, 1)
+
+	_, err = svc.StartTemplate(ctx, StartTemplateCommand{TemplateID: tmpl.ID, UserID: otherID})
+	assert.ErrorIs(t, err, ErrTemplateNotFound, "only the owner starts a template")
+	w, err := svc.StartTemplate(ctx, StartTemplateCommand{TemplateID: tmpl.ID, UserID: ownerID})
+	require.NoError(t, err)
+	assert.Equal(t, ownerID, w.UserID)
+	assert.Equal(t, name, w.Title)
+	assert.Equal(t, "Chest and shoulders", w.Description)
+	assert.Equal(t, &tmpl.ID, w.TemplateID)
+	assert.Equal(t, VisibilityPrivate, w.Visibility)
+	require.Len(t, w.Entries, 1)
+	assert.NotEqual(t, updated.Entries[0].ID, w.Entries[0].ID, "entries are copied, not shared")
+	assert.Equal(t, 90.0, *w.Entries[0].Weight)
+	assert.Equal(t, 3, *w.Entries[0].Reps)
+
+	assert.ErrorIs(t, svc.DeleteTemplate(ctx, tmpl.ID, otherID), ErrForbidden)
+	require.NoError(t, svc.DeleteTemplate(ctx, tmpl.ID, ownerID))
+	_, err = svc.GetTemplate(ctx, tmpl.ID, ownerID)
+	assert.ErrorIs(t, err, ErrTemplateNotFound)
+	assert.ErrorIs(t, svc.DeleteTemplate(ctx, tmpl.ID, ownerID), ErrTemplateNotFound)
+
+	kept, err := svc.Get(ctx, w.ID, ownerID)
+	require.NoError(t, err, "a workout outlives its template")
+	assert.Nil(t, kept.TemplateID)
+}
+
+// TestAccountDeletionHidesWorkouts is TestAccountDeletion's counterpart for
+// the reads that don't need the owner to be logged in: once the owner asks
+// for deletion, public workouts and share links answer like missing ones,
+// and come back if the deletion is cancelled. It lives here because user
+// can't import workout.
+func TestAccountDeletionHidesWorkouts(t *testing.T) {
+	db, ownerID := setupTestDB(t)
+	defer db.Close()
+
+	ctx := context.Background()
+	svc := NewService(NewPostgresStore(db), exercise.NewService(exercise.NewPostgresStore(db)))
+	viewerID := seedUser(t, db, "viewer")
+
+	w, err := svc.Create(ctx, CreateWorkoutCommand{
+		UserID:     ownerID,
+		Title:      "Public run",
+		Visibility: VisibilityPublic,
+		Entries:    []WorkoutEntry{{ExerciseName: "Run", Sets: 1, DurationSeconds: ptrInt(1800)}},
+	})
+	require.NoError(t, err)
+	share, err := svc.CreateShare(ctx, CreateShareCommand{WorkoutID: w.ID, UserID: ownerID})
+	require.NoError(t, err)
+
+	setPending := func(pending bool) {
+		_, err := db.ExecContext(ctx, `UPDATE users SET deletion_requested_at = CASE WHEN $2 THEN now() END WHERE id = $1`, ownerID, pending)
+		require.NoError(t, err)
+	}
+
+	setPending(true)
+	_, err = svc.Get(ctx, w.ID, viewerID)
+	assert.ErrorIs(t, err, ErrNotFound, "public read")
+	_, err = svc.GetShared(ctx, share.Token)
+	assert.ErrorIs(t, err, ErrNotFound, "share link")
+
+	setPending(false)
+	_, err = svc.Get(ctx, w.ID, viewerID)
+	assert.NoError(t, err)
+	_, err = svc.GetShared(ctx, share.Token)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Keyset pagination for GET /workouts orders by (created_at, id) within a
+-- single user. Leading with user_id lets this index replace the scan on
+-- idx_workouts_user_id for listings; both directions use it.
+CREATE INDEX IF NOT EXISTS idx_workouts_user_id_created_at_id
+    ON workouts (user_id, created_at, id);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+-- Backs the ?exercise= filter, which matches case-insensitively.
+CREATE INDEX IF NOT EXISTS idx_workout_entries_lower_exercise_name
+    ON workout_entries (lower(exercise_name), workout_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_workout_entries_lower_exercise_name;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_workouts_user_id_created_at_id;
+-- +goose StatementEnd
@@ -0,0 +1,48 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Existing rows become private: nothing was ever meant to be readable by
+-- other users, the old read path just failed to enforce it.
+ALTER TABLE workouts
+    ADD COLUMN visibility TEXT NOT NULL DEFAULT 'private'
+    CONSTRAINT valid_workout_visibility CHECK (visibility IN ('private', 'followers', 'public'));
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS follows (
+    follower_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    followee_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (follower_id, followee_id),
+    CONSTRAINT no_self_follow CHECK (follower_id <> followee_id)
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+-- Share links store only the SHA-256 of the plaintext, exactly like tokens.
+-- Revoking a link deletes its row; expiry NULL means "until revoked".
+CREATE TABLE IF NOT EXISTS workout_shares (
+    id BIGSERIAL PRIMARY KEY,
+    hash BYTEA NOT NULL UNIQUE,
+    workout_id BIGINT NOT NULL REFERENCES workouts(id) ON DELETE CASCADE,
+    expiry TIMESTAMP(3) WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS idx_workout_shares_workout_id
+    ON workout_shares (workout_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS workout_shares;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE IF EXISTS follows;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE workouts DROP COLUMN visibility;
+-- +goose StatementEnd
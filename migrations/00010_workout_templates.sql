@@ -0,0 +1,62 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS workout_templates (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+-- Mirrors workout_entries column-for-column (including the reps XOR
+-- duration CHECK) so starting a template is a straight copy.
+CREATE TABLE IF NOT EXISTS template_entries (
+    id BIGSERIAL PRIMARY KEY,
+    template_id BIGINT NOT NULL REFERENCES workout_templates(id) ON DELETE CASCADE,
+    exercise_name VARCHAR(255) NOT NULL,
+    sets INTEGER NOT NULL,
+    reps INTEGER,
+    duration_seconds INTEGER,
+    weight DECIMAL(5, 2),
+    notes TEXT,
+    order_index INTEGER NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT valid_template_entry CHECK (
+        (reps IS NOT NULL OR duration_seconds IS NOT NULL) AND
+        (reps IS NULL OR duration_seconds IS NULL)
+    )
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS idx_workout_templates_user_id
+    ON workout_templates (user_id);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS idx_template_entries_template_id_order_index
+    ON template_entries (template_id, order_index);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+-- Provenance for workouts started from a template. SET NULL (not CASCADE):
+-- deleting a template must never delete the history logged from it.
+ALTER TABLE workouts
+    ADD COLUMN template_id BIGINT REFERENCES workout_templates(id) ON DELETE SET NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE workouts DROP COLUMN template_id;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE IF EXISTS template_entries;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE IF EXISTS workout_templates;
+-- +goose StatementEnd
@@ -0,0 +1,68 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Progression is cumulative per week: week N (1-based) prescribes the
+-- template's targets plus (N - 1) × increment.
+CREATE TABLE IF NOT EXISTS programs (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    weeks INTEGER NOT NULL CHECK (weeks > 0),
+    weight_increment DECIMAL(5, 2) NOT NULL DEFAULT 0,
+    reps_increment INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+-- One row per scheduled session. 00026 makes a template in use
+-- undeletable instead of cascading.
+CREATE TABLE IF NOT EXISTS program_days (
+    program_id BIGINT NOT NULL REFERENCES programs(id) ON DELETE CASCADE,
+    week INTEGER NOT NULL CHECK (week > 0),
+    day INTEGER NOT NULL CHECK (day BETWEEN 1 AND 7),
+    template_id BIGINT NOT NULL REFERENCES workout_templates(id) ON DELETE CASCADE,
+    PRIMARY KEY (program_id, week, day)
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS program_enrollments (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    program_id BIGINT NOT NULL REFERENCES programs(id) ON DELETE CASCADE,
+    start_date DATE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT unique_enrollment UNIQUE (user_id, program_id, start_date)
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS idx_programs_user_id
+    ON programs (user_id);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+-- Schedule fulfilment looks up the user's workouts by template and day.
+CREATE INDEX IF NOT EXISTS idx_workouts_user_id_template_id_created_at
+    ON workouts (user_id, template_id, created_at)
+    WHERE template_id IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_workouts_user_id_template_id_created_at;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE IF EXISTS program_enrollments;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE IF EXISTS program_days;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE IF EXISTS programs;
+-- +goose StatementEnd
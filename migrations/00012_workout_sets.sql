@@ -0,0 +1,33 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS workout_sets (
+  id BIGSERIAL PRIMARY KEY,
+  workout_entry_id BIGINT NOT NULL REFERENCES workout_entries(id) ON DELETE CASCADE,
+  set_index INTEGER NOT NULL,
+  set_type TEXT NOT NULL DEFAULT 'working',
+  reps INTEGER,
+  duration_seconds INTEGER,
+  weight DECIMAL(5, 2),
+  rpe DECIMAL(3, 1),
+  completed BOOLEAN NOT NULL DEFAULT TRUE,
+  CONSTRAINT workout_sets_entry_index_unique UNIQUE (workout_entry_id, set_index),
+  CONSTRAINT valid_set_type CHECK (set_type IN ('warmup', 'working', 'drop', 'failure')),
+  CONSTRAINT valid_rpe CHECK (rpe IS NULL OR rpe BETWEEN 1 AND 10),
+  CONSTRAINT valid_workout_set CHECK (
+    (reps IS NOT NULL OR duration_seconds IS NOT NULL) AND
+    (reps IS NULL OR duration_seconds IS NULL)
+  )
+);
+
+-- Backfill: every existing entry becomes `sets` identical completed working
+-- sets, matching what the service does for flat-only input.
+INSERT INTO workout_sets (workout_entry_id, set_index, set_type, reps, duration_seconds, weight, completed)
+SELECT e.id, g.i, 'working', e.reps, e.duration_seconds, e.weight, TRUE
+FROM workout_entries e
+CROSS JOIN LATERAL generate_series(0, e.sets - 1) AS g(i);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS workout_sets;
+-- +goose StatementEnd
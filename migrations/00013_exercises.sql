@@ -0,0 +1,96 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS exercises (
+  id BIGSERIAL PRIMARY KEY,
+  -- NULL for the global catalog, set for a user's custom exercise.
+  user_id BIGINT REFERENCES users(id) ON DELETE CASCADE,
+  name VARCHAR(255) NOT NULL,
+  aliases TEXT[] NOT NULL DEFAULT '{}',
+  primary_muscles TEXT[] NOT NULL,
+  secondary_muscles TEXT[] NOT NULL DEFAULT '{}',
+  equipment TEXT NOT NULL,
+  movement_pattern TEXT NOT NULL,
+  measurement TEXT NOT NULL,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  CONSTRAINT valid_measurement CHECK (measurement IN ('reps', 'time'))
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_exercises_global_name ON exercises (lower(name)) WHERE user_id IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_exercises_user_name ON exercises (user_id, lower(name)) WHERE user_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_exercises_aliases ON exercises USING GIN (aliases);
+
+INSERT INTO exercises (name, aliases, primary_muscles, secondary_muscles, equipment, movement_pattern, measurement) VALUES
+  ('Bench Press', '{"barbell bench press","bb bench","bench","flat bench press"}', '{chest}', '{front_delts,triceps}', 'barbell', 'horizontal_push', 'reps'),
+  ('Incline Bench Press', '{"incline barbell bench press","incline bench"}', '{chest}', '{front_delts,triceps}', 'barbell', 'horizontal_push', 'reps'),
+  ('Dumbbell Bench Press', '{"db bench","db bench press","dumbbell press"}', '{chest}', '{front_delts,triceps}', 'dumbbell', 'horizontal_push', 'reps'),
+  ('Incline Dumbbell Press', '{"incline db press","incline dumbbell bench press"}', '{chest}', '{front_delts,triceps}', 'dumbbell', 'horizontal_push', 'reps'),
+  ('Push-up', '{"push up","pushup","push-ups","push ups"}', '{chest}', '{front_delts,triceps,abs}', 'bodyweight', 'horizontal_push', 'reps'),
+  ('Dip', '{"dips","chest dip","tricep dip"}', '{chest,triceps}', '{front_delts}', 'bodyweight', 'vertical_push', 'reps'),
+  ('Cable Fly', '{"cable crossover","cable flye"}', '{chest}', '{front_delts}', 'cable', 'isolation', 'reps'),
+  ('Overhead Press', '{"ohp","military press","standing press","barbell overhead press"}', '{front_delts}', '{side_delts,triceps,upper_back}', 'barbell', 'vertical_push', 'reps'),
+  ('Dumbbell Shoulder Press', '{"db shoulder press","seated dumbbell press"}', '{front_delts}', '{side_delts,triceps}', 'dumbbell', 'vertical_push', 'reps'),
+  ('Lateral Raise', '{"lateral raises","side raise","db lateral raise"}', '{side_delts}', '{traps}', 'dumbbell', 'isolation', 'reps'),
+  ('Face Pull', '{"face pulls"}', '{rear_delts}', '{upper_back,traps}', 'cable', 'horizontal_pull', 'reps'),
+  ('Barbell Row', '{"bent over row","bb row","pendlay row"}', '{upper_back,lats}', '{rear_delts,biceps,lower_back}', 'barbell', 'horizontal_pull', 'reps'),
+  ('Dumbbell Row', '{"db row","one arm dumbbell row","single arm row"}', '{lats,upper_back}', '{rear_delts,biceps}', 'dumbbell', 'horizontal_pull', 'reps'),
+  ('Seated Cable Row', '{"cable row","seated row"}', '{upper_back,lats}', '{rear_delts,biceps}', 'cable', 'horizontal_pull', 'reps'),
+  ('Pull-up', '{"pull up","pullup","pull-ups","pull ups"}', '{lats}', '{biceps,upper_back}', 'bodyweight', 'vertical_pull', 'reps'),
+  ('Chin-up', '{"chin up","chinup","chin-ups","chin ups"}', '{lats,biceps}', '{upper_back}', 'bodyweight', 'vertical_pull', 'reps'),
+  ('Lat Pulldown', '{"lat pull down","pulldown","cable pulldown"}', '{lats}', '{biceps,upper_back}', 'cable', 'vertical_pull', 'reps'),
+  ('Barbell Curl', '{"bb curl","curl","bicep curl"}', '{biceps}', '{forearms}', 'barbell', 'isolation', 'reps'),
+  ('Dumbbell Curl', '{"db curl","dumbbell bicep curl"}', '{biceps}', '{forearms}', 'dumbbell', 'isolation', 'reps'),
+  ('Hammer Curl', '{"hammer curls"}', '{biceps,forearms}', '{}', 'dumbbell', 'isolation', 'reps'),
+  ('Triceps Pushdown', '{"tricep pushdown","cable pushdown","rope pushdown"}', '{triceps}', '{}', 'cable', 'isolation', 'reps'),
+  ('Skull Crusher', '{"skullcrusher","lying triceps extension"}', '{triceps}', '{}', 'ez_bar', 'isolation', 'reps'),
+  ('Back Squat', '{"squat","barbell squat","bb squat","high bar squat","low bar squat"}', '{quads,glutes}', '{adductors,hamstrings,lower_back}', 'barbell', 'squat', 'reps'),
+  ('Front Squat', '{"barbell front squat"}', '{quads}', '{glutes,abs,upper_back}', 'barbell', 'squat', 'reps'),
+  ('Goblet Squat', '{"db goblet squat","kb goblet squat"}', '{quads,glutes}', '{adductors}', 'dumbbell', 'squat', 'reps'),
+  ('Leg Press', '{"machine leg press"}', '{quads,glutes}', '{adductors}', 'machine', 'squat', 'reps'),
+  ('Bulgarian Split Squat', '{"split squat","rear foot elevated split squat","bss"}', '{quads,glutes}', '{adductors,hamstrings}', 'dumbbell', 'lunge', 'reps'),
+  ('Walking Lunge', '{"lunge","lunges","walking lunges"}', '{quads,glutes}', '{hamstrings,adductors}', 'dumbbell', 'lunge', 'reps'),
+  ('Deadlift', '{"conventional deadlift","barbell deadlift","dl"}', '{hamstrings,glutes,lower_back}', '{quads,traps,forearms,upper_back}', 'barbell', 'hinge', 'reps'),
+  ('Sumo Deadlift', '{"sumo dl"}', '{glutes,hamstrings,adductors}', '{quads,lower_back,traps}', 'barbell', 'hinge', 'reps'),
+  ('Romanian Deadlift', '{"rdl","romanian dl","stiff leg deadlift"}', '{hamstrings,glutes}', '{lower_back,forearms}', 'barbell', 'hinge', 'reps'),
+  ('Hip Thrust', '{"barbell hip thrust","glute bridge"}', '{glutes}', '{hamstrings}', 'barbell', 'hinge', 'reps'),
+  ('Kettlebell Swing', '{"kb swing","swings"}', '{glutes,hamstrings}', '{lower_back,abs}', 'kettlebell', 'hinge', 'reps'),
+  ('Leg Curl', '{"hamstring curl","lying leg curl","seated leg curl"}', '{hamstrings}', '{}', 'machine', 'isolation', 'reps'),
+  ('Leg Extension', '{"leg extensions","quad extension"}', '{quads}', '{}', 'machine', 'isolation', 'reps'),
+  ('Standing Calf Raise', '{"calf raise","calf raises"}', '{calves}', '{}', 'machine', 'isolation', 'reps'),
+  ('Farmer''s Carry', '{"farmers carry","farmer carry","farmers walk","farmer''s walk"}', '{forearms,traps}', '{abs,glutes}', 'dumbbell', 'carry', 'time'),
+  ('Plank', '{"front plank","forearm plank"}', '{abs}', '{obliques,front_delts}', 'bodyweight', 'core', 'time'),
+  ('Side Plank', '{"side planks"}', '{obliques}', '{abs,glutes}', 'bodyweight', 'core', 'time'),
+  ('Hanging Leg Raise', '{"leg raise","hanging knee raise"}', '{abs}', '{obliques,forearms}', 'bodyweight', 'core', 'reps'),
+  ('Crunch', '{"crunches","sit-up","sit up","situp"}', '{abs}', '{obliques}', 'bodyweight', 'core', 'reps'),
+  ('Run', '{"running","jog","jogging","treadmill run"}', '{cardio}', '{quads,hamstrings,calves}', 'bodyweight', 'cardio', 'time'),
+  ('Cycling', '{"bike","stationary bike","cycle","spin"}', '{cardio}', '{quads,glutes}', 'machine', 'cardio', 'time'),
+  ('Rowing Machine', '{"rower","erg","rowing","indoor row"}', '{cardio}', '{upper_back,lats,quads}', 'machine', 'cardio', 'time'),
+  ('Jump Rope', '{"skipping","skipping rope","jumping rope"}', '{cardio}', '{calves}', 'other', 'cardio', 'time');
+
+ALTER TABLE workout_entries ADD COLUMN IF NOT EXISTS exercise_id BIGINT REFERENCES exercises(id) ON DELETE SET NULL;
+ALTER TABLE template_entries ADD COLUMN IF NOT EXISTS exercise_id BIGINT REFERENCES exercises(id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS idx_workout_entries_exercise_id ON workout_entries (exercise_id);
+
+-- Link existing free-text entries to the catalog where the name (or an
+-- alias) matches and the measurement agrees.
+UPDATE workout_entries we
+SET exercise_id = e.id
+FROM exercises e
+WHERE e.user_id IS NULL
+  AND (lower(e.name) = lower(btrim(we.exercise_name)) OR lower(btrim(we.exercise_name)) = ANY(e.aliases))
+  AND (e.measurement = 'reps') = (we.reps IS NOT NULL);
+
+UPDATE template_entries te
+SET exercise_id = e.id
+FROM exercises e
+WHERE e.user_id IS NULL
+  AND (lower(e.name) = lower(btrim(te.exercise_name)) OR lower(btrim(te.exercise_name)) = ANY(e.aliases))
+  AND (e.measurement = 'reps') = (te.reps IS NOT NULL);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_workout_entries_exercise_id;
+ALTER TABLE template_entries DROP COLUMN IF EXISTS exercise_id;
+ALTER TABLE workout_entries DROP COLUMN IF EXISTS exercise_id;
+DROP TABLE IF EXISTS exercises;
+-- +goose StatementEnd
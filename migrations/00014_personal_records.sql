@@ -0,0 +1,31 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS personal_records (
+  id BIGSERIAL PRIMARY KEY,
+  user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  -- 'exercise:<id>' for catalog-linked entries, 'name:<normalized name>'
+  -- otherwise. Records are grouped by this, not by exercise_id.
+  exercise_key TEXT NOT NULL,
+  exercise_id BIGINT REFERENCES exercises(id) ON DELETE SET NULL,
+  exercise_name VARCHAR(255) NOT NULL,
+  record_type TEXT NOT NULL,
+  value DECIMAL(10, 2) NOT NULL,
+  weight DECIMAL(5, 2),
+  reps INTEGER,
+  -- Deleting the workout that set a record removes it. Only records are
+  -- stored, so the store then rebuilds the exercise from workout history:
+  -- a set that never beat the removed record may be one now.
+  workout_id BIGINT NOT NULL REFERENCES workouts(id) ON DELETE CASCADE,
+  achieved_at TIMESTAMP WITH TIME ZONE NOT NULL,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  CONSTRAINT valid_record_type CHECK (record_type IN ('heaviest_weight', 'most_reps', 'estimated_1rm', 'longest_duration'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_personal_records_user_key ON personal_records (user_id, exercise_key, record_type, value DESC);
+CREATE INDEX IF NOT EXISTS idx_personal_records_workout_id ON personal_records (workout_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS personal_records;
+-- +goose StatementEnd
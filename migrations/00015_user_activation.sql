@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+-- New accounts start unactivated until the owner redeems the emailed
+-- activation token. Accounts that exist before this migration are trusted
+-- as activated: there is no way to ask them retroactively.
+ALTER TABLE users ADD COLUMN activated BOOLEAN NOT NULL DEFAULT false;
+UPDATE users SET activated = true;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE users DROP COLUMN IF EXISTS activated;
+-- +goose StatementEnd
@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+-- A non-NULL deletion_requested_at marks an account as scheduled for
+-- deletion: login is refused, and the purge job hard-deletes the row once
+-- the grace period has passed. Every table that references users does so
+-- ON DELETE CASCADE, so that single DELETE erases everything the user owns.
+ALTER TABLE users ADD COLUMN deletion_requested_at TIMESTAMP WITH TIME ZONE;
+CREATE INDEX IF NOT EXISTS idx_users_deletion_requested_at
+    ON users (deletion_requested_at)
+    WHERE deletion_requested_at IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_users_deletion_requested_at;
+ALTER TABLE users DROP COLUMN IF EXISTS deletion_requested_at;
+-- +goose StatementEnd
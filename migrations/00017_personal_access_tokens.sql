@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Personal access tokens share the tokens table with sessions, under the
+-- 'personal-access' scope. They are listed and revoked by id (the hash is
+-- never shown), carry a name and their permissions, and may have no expiry.
+-- permissions is NULL for every other scope.
+ALTER TABLE tokens
+    ADD COLUMN id BIGSERIAL UNIQUE,
+    ADD COLUMN name TEXT,
+    ADD COLUMN permissions TEXT[],
+    ADD COLUMN last_used_at TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    ALTER COLUMN expiry DROP NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM tokens WHERE expiry IS NULL;
+ALTER TABLE tokens
+    ALTER COLUMN expiry SET NOT NULL,
+    DROP COLUMN IF EXISTS created_at,
+    DROP COLUMN IF EXISTS last_used_at,
+    DROP COLUMN IF EXISTS permissions,
+    DROP COLUMN IF EXISTS name,
+    DROP COLUMN IF EXISTS id;
+-- +goose StatementEnd
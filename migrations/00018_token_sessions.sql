@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Session metadata for authentication tokens, so each signed-in device can
+-- be listed and revoked on its own. last_used_at (added for personal
+-- access tokens) doubles as a session's last-seen time.
+ALTER TABLE tokens
+    ADD COLUMN user_agent TEXT,
+    ADD COLUMN ip TEXT;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE tokens
+    DROP COLUMN IF EXISTS ip,
+    DROP COLUMN IF EXISTS user_agent;
+-- +goose StatementEnd
@@ -0,0 +1,56 @@
+-- +goose Up
+-- +goose StatementBegin
+-- A token family is one sign-in: the access and refresh tokens issued at
+-- login and every pair rotated from them. It is what GET /me/sessions
+-- lists, so the client metadata moves here from tokens. Deleting a family
+-- revokes all its tokens, which is how logout and refresh-token reuse
+-- detection work.
+CREATE TABLE IF NOT EXISTS token_families (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    user_agent TEXT,
+    ip TEXT,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    last_seen_at TIMESTAMP WITH TIME ZONE
+);
+CREATE INDEX IF NOT EXISTS idx_token_families_user_id ON token_families (user_id);
+
+-- parent_id links a rotated refresh token to the one it replaced;
+-- rotated_at marks a refresh token as spent.
+ALTER TABLE tokens
+    ADD COLUMN family_id BIGINT REFERENCES token_families(id) ON DELETE CASCADE,
+    ADD COLUMN parent_id BIGINT REFERENCES tokens(id) ON DELETE SET NULL,
+    ADD COLUMN rotated_at TIMESTAMP WITH TIME ZONE;
+CREATE INDEX IF NOT EXISTS idx_tokens_family_id ON tokens (family_id);
+
+-- Every existing session becomes a family of one, reusing the token's id.
+INSERT INTO token_families (id, user_id, user_agent, ip, created_at, last_seen_at)
+SELECT id, user_id, user_agent, ip, created_at, last_used_at
+FROM tokens
+WHERE scope = 'authentication';
+UPDATE tokens SET family_id = id WHERE scope = 'authentication';
+SELECT setval(pg_get_serial_sequence('token_families', 'id'), COALESCE((SELECT MAX(id) FROM token_families), 0) + 1, false);
+
+ALTER TABLE tokens
+    DROP COLUMN user_agent,
+    DROP COLUMN ip;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE tokens
+    ADD COLUMN user_agent TEXT,
+    ADD COLUMN ip TEXT;
+UPDATE tokens t
+SET user_agent = f.user_agent, ip = f.ip
+FROM token_families f
+WHERE t.family_id = f.id AND t.scope = 'authentication';
+DELETE FROM tokens WHERE scope = 'refresh';
+
+DROP INDEX IF EXISTS idx_tokens_family_id;
+ALTER TABLE tokens
+    DROP COLUMN IF EXISTS rotated_at,
+    DROP COLUMN IF EXISTS parent_id,
+    DROP COLUMN IF EXISTS family_id;
+DROP TABLE IF EXISTS token_families;
+-- +goose StatementEnd
@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+-- JWT access tokens aren't stored, so deleting a token family can't revoke
+-- them. Each deleted family is recorded here instead, and every instance
+-- polls the table to reject the tokens it signed. Rows only need to
+-- outlive the access-token TTL and are pruned after that.
+CREATE TABLE IF NOT EXISTS session_revocations (
+    session_id BIGINT PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    revoked_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_session_revocations_revoked_at ON session_revocations (revoked_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS session_revocations;
+-- +goose StatementEnd
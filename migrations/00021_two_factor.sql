@@ -0,0 +1,34 @@
+-- +goose Up
+-- +goose StatementBegin
+-- secret is the TOTP secret sealed with TOTP_ENCRYPTION_KEY. A row with no
+-- confirmed_at is an enrollment whose first code hasn't been entered yet;
+-- 2FA is only enforced once it is confirmed. last_used_step stops a code
+-- from being replayed while it is still current.
+CREATE TABLE IF NOT EXISTS two_factor (
+    user_id BIGINT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    secret BYTEA NOT NULL,
+    confirmed_at TIMESTAMP WITH TIME ZONE,
+    last_used_step BIGINT,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Recovery codes are hashed like tokens and go with the enrollment.
+CREATE TABLE IF NOT EXISTS two_factor_recovery_codes (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES two_factor(user_id) ON DELETE CASCADE,
+    hash BYTEA NOT NULL,
+    used_at TIMESTAMP WITH TIME ZONE
+);
+CREATE INDEX IF NOT EXISTS idx_two_factor_recovery_codes_user_id ON two_factor_recovery_codes (user_id);
+
+-- Wrong codes entered against a 2fa-pending token; the token is revoked
+-- after a few.
+ALTER TABLE tokens ADD COLUMN failed_attempts INT NOT NULL DEFAULT 0;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE tokens DROP COLUMN IF EXISTS failed_attempts;
+DROP TABLE IF EXISTS two_factor_recovery_codes;
+DROP TABLE IF EXISTS two_factor;
+-- +goose StatementEnd
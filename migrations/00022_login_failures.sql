@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Failed logins, counted per submitted username and per client IP. key is
+-- "username:<name>" or "ip:<addr>". failures restarts from one when the
+-- previous failure is old enough; locked_until is when the next attempt
+-- will be looked at.
+CREATE TABLE IF NOT EXISTS login_failures (
+    key TEXT PRIMARY KEY,
+    failures INT NOT NULL,
+    last_failure_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    locked_until TIMESTAMP WITH TIME ZONE
+);
+CREATE INDEX IF NOT EXISTS idx_login_failures_last_failure_at ON login_failures (last_failure_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS login_failures;
+-- +goose StatementEnd
@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Shared token buckets for httpx.PostgresRateLimiter. Each bucket is stored
+-- as its theoretical arrival time (GCRA): the bucket is full once tat has
+-- passed, so rows with a past tat carry no state and can be pruned.
+CREATE TABLE IF NOT EXISTS rate_limits (
+    key TEXT PRIMARY KEY,
+    tat TIMESTAMP WITH TIME ZONE NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_rate_limits_tat ON rate_limits (tat);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS rate_limits;
+-- +goose StatementEnd
@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+-- role decides what an account may do beyond its own data; see
+-- auth.RolePermissions. A non-NULL disabled_at marks an account an admin
+-- has disabled: its tokens stop resolving and login is refused, but
+-- nothing is deleted.
+ALTER TABLE users ADD COLUMN role TEXT NOT NULL DEFAULT 'user'
+    CHECK (role IN ('user', 'coach', 'admin'));
+ALTER TABLE users ADD COLUMN disabled_at TIMESTAMP WITH TIME ZONE;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE users DROP COLUMN IF EXISTS disabled_at;
+ALTER TABLE users DROP COLUMN IF EXISTS role;
+-- +goose StatementEnd
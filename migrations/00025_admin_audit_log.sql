@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+-- One row per admin action. actor_id and target_user_id deliberately have
+-- no foreign key: a trail that cascaded away with the accounts it mentions,
+-- or blocked their purge, would defeat its purpose. details holds the
+-- action's parameters, e.g. the search or the old and new role.
+CREATE TABLE IF NOT EXISTS admin_audit_log (
+    id BIGSERIAL PRIMARY KEY,
+    actor_id BIGINT NOT NULL,
+    action TEXT NOT NULL,
+    target_user_id BIGINT,
+    details JSONB NOT NULL DEFAULT '{}',
+    ip TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_admin_audit_log_actor_id ON admin_audit_log (actor_id, id);
+CREATE INDEX IF NOT EXISTS idx_admin_audit_log_target_user_id ON admin_audit_log (target_user_id, id)
+    WHERE target_user_id IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS admin_audit_log;
+-- +goose StatementEnd
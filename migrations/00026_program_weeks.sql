@@ -0,0 +1,34 @@
+-- +goose Up
+-- +goose StatementBegin
+-- A template in use by a program can no longer be deleted: the cascade
+-- silently dropped days from every enrollee's program. NO ACTION rather
+-- than RESTRICT because it is checked at the end of the statement, so
+-- purging the author, which cascades to both their programs and their
+-- templates, still succeeds.
+ALTER TABLE program_days DROP CONSTRAINT IF EXISTS program_days_template_id_fkey;
+ALTER TABLE program_days ADD CONSTRAINT program_days_template_id_fkey
+    FOREIGN KEY (template_id) REFERENCES workout_templates(id) ON DELETE NO ACTION;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+-- Per-week progression. A row overrides the program's increments for the
+-- step into that week; weeks without one use the program's.
+CREATE TABLE IF NOT EXISTS program_weeks (
+    program_id BIGINT NOT NULL REFERENCES programs(id) ON DELETE CASCADE,
+    week INTEGER NOT NULL CHECK (week > 1),
+    weight_increment DECIMAL(5, 2) NOT NULL DEFAULT 0,
+    reps_increment INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (program_id, week)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS program_weeks;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE program_days DROP CONSTRAINT IF EXISTS program_days_template_id_fkey;
+ALTER TABLE program_days ADD CONSTRAINT program_days_template_id_fkey
+    FOREIGN KEY (template_id) REFERENCES workout_templates(id) ON DELETE CASCADE;
+-- +goose StatementEnd
@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+-- An action that changes an account is recorded as attempted before it
+-- runs and marked succeeded or failed after, so a failed audit write stops
+-- the action instead of leaving it unrecorded. Entries written before this
+-- migration were only written on success.
+ALTER TABLE admin_audit_log ADD COLUMN IF NOT EXISTS outcome TEXT NOT NULL DEFAULT 'succeeded'
+    CHECK (outcome IN ('attempted', 'succeeded', 'failed'));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE admin_audit_log DROP COLUMN IF EXISTS outcome;
+-- +goose StatementEnd
@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+-- The address an activation token was mailed to. Redeeming the token
+-- checks the account still has it, so a token sent before an email change
+-- can't confirm the new address. NULL for every other scope, and for
+-- activation tokens issued before this migration, which therefore stop
+-- working; a new one can be requested.
+ALTER TABLE tokens ADD COLUMN IF NOT EXISTS email TEXT;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE tokens DROP COLUMN IF EXISTS email;
+-- +goose StatementEnd